@@ -0,0 +1,16 @@
+resource "vaultprov_random_secret" "db_password" {
+  path     = "/secret/billing/db"
+  format   = "kubernetes.io/basic-auth"
+  username = "billing"
+
+  external_secret = {
+    refresh_interval = "15m"
+  }
+}
+
+data "vaultprov_external_secret" "db_password" {
+  path         = vaultprov_random_secret.db_password.path
+  name         = "db-credentials"
+  namespace    = "billing"
+  secret_store = "vault"
+}
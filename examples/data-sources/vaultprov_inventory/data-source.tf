@@ -0,0 +1,7 @@
+data "vaultprov_inventory" "billing" {
+  prefix = "/secret/billing"
+}
+
+output "billing_key_inventory" {
+  value = data.vaultprov_inventory.billing.json
+}
@@ -0,0 +1,8 @@
+data "vaultprov_keypair_fingerprint" "release_signing" {
+  path = "/secret/release/signing-key"
+}
+
+# Re-publishes the public key whenever the producing workspace replaces the key
+resource "terraform_data" "publish_release_key" {
+  triggers_replace = [data.vaultprov_keypair_fingerprint.release_signing.fingerprint]
+}
@@ -0,0 +1,10 @@
+data "vaultprov_secret_exists" "legacy_db_password" {
+  path = "/secret/legacy/db-password"
+}
+
+# Generates the password only when the legacy one hasn't been migrated yet
+resource "vaultprov_random_secret" "db_password" {
+  count = data.vaultprov_secret_exists.legacy_db_password.exists ? 0 : 1
+
+  path = "/secret/legacy/db-password"
+}
@@ -0,0 +1,2 @@
+# API tokens can be imported using their Vault path
+terraform import vaultprov_api_token.example /secret/billing/api-token
@@ -0,0 +1,8 @@
+resource "vaultprov_api_token" "example" {
+  path     = "/secret/billing/api-token"
+  prefix   = "sk_live_"
+  length   = 32
+  metadata = {
+    owner = "my_team"
+  }
+}
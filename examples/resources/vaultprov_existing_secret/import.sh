@@ -0,0 +1,2 @@
+# Existing secrets can be imported using their Vault path
+terraform import vaultprov_existing_secret.example /secret/legacy/db-password
@@ -0,0 +1,9 @@
+resource "vaultprov_existing_secret" "example" {
+  path                 = "/secret/legacy/db-password"
+  max_versions         = 10
+  delete_version_after = "2160h"
+  deletion_protection  = true
+  metadata = {
+    owner = "my_team"
+  }
+}
@@ -0,0 +1,3 @@
+# PGP keys can be imported using their Vault path. The secret must hold a `private_key` field (`public_key` is derived
+# from it when missing), with an ed25519 or 4096 bits RSA key: `algorithm` is read from the public key when the secret has no metadata.
+terraform import vaultprov_pgp_key.example /secret/release/signing-key
@@ -0,0 +1,9 @@
+resource "vaultprov_pgp_key" "example" {
+  path      = "/secret/release/signing-key"
+  name      = "Release Bot"
+  email     = "release@example.com"
+  algorithm = "ed25519"
+  metadata  = {
+    owner = "my_team"
+  }
+}
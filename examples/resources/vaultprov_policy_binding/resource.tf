@@ -0,0 +1,9 @@
+resource "vaultprov_api_token" "example" {
+  path   = "/secret/billing/api-token"
+  prefix = "sk_live_"
+}
+
+resource "vaultprov_policy_binding" "example" {
+  path        = vaultprov_api_token.example.path
+  policy_name = "billing-api-token-read"
+}
@@ -0,0 +1,2 @@
+# Random secrets can be imported using their Vault path
+terraform import vaultprov_random_secret.example /secret/foo/bar
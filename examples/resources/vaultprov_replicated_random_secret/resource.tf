@@ -0,0 +1,20 @@
+provider "vaultprov" {
+  address = "https://vault.eu-west-1.example.com:8200"
+
+  endpoints = {
+    eu-west = {
+      address = "https://vault.eu-west-1.example.com:8200"
+    }
+    us-east = {
+      address = "https://vault.us-east-1.example.com:8200"
+    }
+  }
+}
+
+resource "vaultprov_replicated_random_secret" "example" {
+  path    = "/secret/foo/session-key"
+  targets = ["eu-west", "us-east"]
+  metadata = {
+    owner = "my_team"
+  }
+}
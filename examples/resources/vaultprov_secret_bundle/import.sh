@@ -0,0 +1,2 @@
+# Secret bundles can be imported using their Vault path. Rotation triggers are not stored in Vault and are left unset
+terraform import vaultprov_secret_bundle.session_keys /secret/web/session-keys
@@ -0,0 +1,17 @@
+resource "vaultprov_secret_bundle" "session_keys" {
+  path = "/secret/web/session-keys"
+
+  fields = {
+    current_key = {
+      previous_field   = "previous_key"
+      rotation_trigger = "2024-06"
+    }
+    salt = {
+      length = 16
+    }
+  }
+
+  metadata = {
+    owner = "web"
+  }
+}
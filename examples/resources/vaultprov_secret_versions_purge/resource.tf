@@ -0,0 +1,11 @@
+resource "vaultprov_pgp_key" "example" {
+  path = "/secret/billing/signing-key"
+}
+
+resource "vaultprov_secret_versions_purge" "example" {
+  path        = vaultprov_pgp_key.example.path
+  keep_latest = 2
+  triggers = {
+    version = vaultprov_pgp_key.example.version
+  }
+}
@@ -0,0 +1,8 @@
+resource "vaultprov_split_secret" "example" {
+  path      = "/secret/pki/root-key-passphrase"
+  shares    = 5
+  threshold = 3
+  metadata = {
+    owner = "security_team"
+  }
+}
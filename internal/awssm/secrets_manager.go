@@ -0,0 +1,310 @@
+package awssm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+)
+
+var _ vault.SecretStore = &SecretsManager{}
+
+// MaxTagValueLength is the maximum length, in characters, of the value of a tag.
+const MaxTagValueLength = 256
+
+// secretsManagerAPI is the subset of the Secrets Manager client used to manage secrets.
+type secretsManagerAPI interface {
+	CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error)
+	TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error)
+	UntagResource(ctx context.Context, params *secretsmanager.UntagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UntagResourceOutput, error)
+	DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error)
+}
+
+// ScheduledDeletionError is returned when reading a secret scheduled for deletion: until the end of its recovery
+// window, the secret can't be read and no secret can be created with the same name.
+type ScheduledDeletionError struct {
+	Name        string
+	DeletedDate time.Time
+}
+
+func (e *ScheduledDeletionError) Error() string {
+	return fmt.Sprintf("secret %s is scheduled for deletion (deleted on %s)", e.Name, e.DeletedDate.Format(time.RFC3339))
+}
+
+// Hint explains how to manage the secret again.
+func (e *ScheduledDeletionError) Hint() string {
+	return fmt.Sprintf("Restore the secret (`aws secretsmanager restore-secret --secret-id %s`), or wait for Secrets Manager to delete it at the end of its recovery window.", e.Name)
+}
+
+// SecretsManager stores generated secrets in AWS Secrets Manager. Secret paths are the names of the secrets, custom
+// metadata are stored as tags of the secrets and the secret data as a JSON object, their secret string.
+type SecretsManager struct {
+	client secretsManagerAPI
+	// kmsKeyID is the KMS key encrypting new secrets, the AWS managed key when empty
+	kmsKeyID string
+	// replicaRegions are the regions new secrets are replicated to
+	replicaRegions []string
+}
+
+// NewSecretsManager creates a Secrets Manager client for the given region, authenticated with the default credential
+// chain (environment variables, shared configuration, instance role...). The region is taken from the shared
+// configuration when empty.
+func NewSecretsManager(ctx context.Context, region, kmsKeyID string, replicaRegions []string) (*SecretsManager, error) {
+	var options []func(*config.LoadOptions) error
+	if region != "" {
+		options = append(options, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretsManager{
+		client:         secretsmanager.NewFromConfig(cfg),
+		kmsKeyID:       kmsKeyID,
+		replicaRegions: replicaRegions,
+	}, nil
+}
+
+// secretName returns the name of the secret at secretPath.
+func secretName(secretPath string) (string, error) {
+	name := strings.Trim(secretPath, "/")
+	if name == "" {
+		return "", fmt.Errorf("invalid secret name %q", secretPath)
+	}
+	return name, nil
+}
+
+// CreateSecret creates a secret encrypted with the configured KMS key and replicated to the configured regions. It
+// fails if a secret already exists with the same name. Secrets Manager versions aren't numbered, 0 is returned.
+func (m *SecretsManager) CreateSecret(ctx context.Context, secret vault.Secret) (int, error) {
+	name, err := secretName(secret.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = checkTags(name, secret.Metadata); err != nil {
+		return 0, err
+	}
+
+	payload, err := json.Marshal(secret.Data)
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode secret data: %w", err)
+	}
+
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(string(payload)),
+		Tags:         tagsFromMetadata(secret.Metadata),
+	}
+	if m.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(m.kmsKeyID)
+	}
+	for _, region := range m.replicaRegions {
+		input.AddReplicaRegions = append(input.AddReplicaRegions, types.ReplicaRegionType{Region: aws.String(region)})
+	}
+
+	_, err = m.client.CreateSecret(ctx, input)
+	var existsErr *types.ResourceExistsException
+	if errors.As(err, &existsErr) {
+		return 0, &vault.SecretExistsError{Path: secret.Path}
+	}
+	if err != nil {
+		return 0, newError("create secret", name, err)
+	}
+	return 0, nil
+}
+
+// ReadSecret reads the current version of a secret along with its tags. It returns nil if the secret doesn't exist.
+func (m *SecretsManager) ReadSecret(ctx context.Context, secretPath string) (*vault.Secret, error) {
+	secret, err := m.ReadSecretMetadata(ctx, secretPath)
+	if err != nil || secret == nil {
+		return secret, err
+	}
+
+	name := secret.DataPath
+	value, err := m.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newError("read secret's data", name, err)
+	}
+
+	if err = json.Unmarshal([]byte(aws.ToString(value.SecretString)), &secret.Data); err != nil {
+		return nil, fmt.Errorf("unable to decode secret data: %w", err)
+	}
+	return secret, nil
+}
+
+// ReadSecretMetadata reads the tags and versions of a secret without reading its value. It returns nil if the secret
+// doesn't exist, and a ScheduledDeletionError if it is scheduled for deletion.
+func (m *SecretsManager) ReadSecretMetadata(ctx context.Context, secretPath string) (*vault.Secret, error) {
+	name, err := secretName(secretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := m.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: aws.String(name)})
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newError("read secret's metadata", name, err)
+	}
+	if s.DeletedDate != nil {
+		return nil, &ScheduledDeletionError{Name: name, DeletedDate: *s.DeletedDate}
+	}
+
+	return &vault.Secret{
+		Path:         secretPath,
+		Metadata:     metadataFromTags(s.Tags),
+		VersionsKept: len(s.VersionIdsToStages),
+		CreatedTime:  aws.ToTime(s.CreatedDate),
+		DataPath:     name,
+		MetadataPath: name,
+	}, nil
+}
+
+// UpdateSecretMetadata sets the given tags of a secret and removes the ones listed in removed, other tags are kept.
+func (m *SecretsManager) UpdateSecretMetadata(ctx context.Context, secretPath string, metadata map[string]string, removed []string) error {
+	name, err := secretName(secretPath)
+	if err != nil {
+		return err
+	}
+
+	if err = checkTags(name, metadata); err != nil {
+		return err
+	}
+
+	// Keys both set and removed are kept, as with Vault custom metadata
+	untagged := make([]string, 0, len(removed))
+	for _, k := range removed {
+		if _, ok := metadata[k]; !ok {
+			untagged = append(untagged, k)
+		}
+	}
+	if len(untagged) > 0 {
+		_, err = m.client.UntagResource(ctx, &secretsmanager.UntagResourceInput{
+			SecretId: aws.String(name),
+			TagKeys:  untagged,
+		})
+		if err != nil {
+			return newError("remove secret's tags", name, err)
+		}
+	}
+
+	if len(metadata) > 0 {
+		_, err = m.client.TagResource(ctx, &secretsmanager.TagResourceInput{
+			SecretId: aws.String(name),
+			Tags:     tagsFromMetadata(metadata),
+		})
+		if err != nil {
+			return newError("update secret's tags", name, err)
+		}
+	}
+	return nil
+}
+
+// DeleteSecret deletes a secret and all its versions, without recovery window so that the name can be used again
+// right away. Deleting a secret that doesn't exist is not an error.
+func (m *SecretsManager) DeleteSecret(ctx context.Context, secretPath string) error {
+	name, err := secretName(secretPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(name),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	if err != nil && !isNotFound(err) {
+		return newError("delete secret", name, err)
+	}
+	return nil
+}
+
+// SecretAPIPaths returns the name of the secret for both its data and metadata: Secrets Manager addresses a secret and
+// its value the same way. ARNs end with a random suffix and are only known once the secret is created.
+func (m *SecretsManager) SecretAPIPaths(ctx context.Context, secretPath string) (string, string, error) {
+	name, err := secretName(secretPath)
+	if err != nil {
+		return "", "", err
+	}
+	return name, name, nil
+}
+
+// checkTags ensures custom metadata can be stored as tags of the secret: Secrets Manager limits the length of tag
+// values, which Vault allows to be longer.
+func checkTags(name string, metadata map[string]string) error {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if length := utf8.RuneCountInString(metadata[k]); length > MaxTagValueLength {
+			return fmt.Errorf("unable to tag secret %s: the value of tag %s is %d characters long, Secrets Manager allows at most %d", name, k, length, MaxTagValueLength)
+		}
+	}
+	return nil
+}
+
+// tagsFromMetadata converts custom metadata to tags, sorted by key.
+func tagsFromMetadata(metadata map[string]string) []types.Tag {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tags := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, types.Tag{Key: aws.String(k), Value: aws.String(metadata[k])})
+	}
+	return tags
+}
+
+// metadataFromTags converts the tags of a secret back to custom metadata.
+func metadataFromTags(tags []types.Tag) map[string]string {
+	metadata := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		metadata[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return metadata
+}
+
+// isNotFound tells if err is returned by Secrets Manager for a secret that doesn't exist.
+func isNotFound(err error) bool {
+	var notFoundErr *types.ResourceNotFoundException
+	return errors.As(err, &notFoundErr)
+}
+
+// newError wraps an error returned by Secrets Manager with the same details as errors returned by Vault.
+func newError(operation, name string, err error) error {
+	message := err.Error()
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		message = apiErr.ErrorMessage()
+	}
+	return &vault.Error{
+		Operation: operation,
+		Path:      name,
+		Errors:    []string{message},
+		Err:       err,
+	}
+}
@@ -0,0 +1,132 @@
+package awssm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+)
+
+// fakeClient records the secrets created, and fails when they already exist.
+type fakeClient struct {
+	secretsManagerAPI
+	created map[string]*secretsmanager.CreateSecretInput
+	// deleted are the deletion dates of secrets scheduled for deletion
+	deleted map[string]time.Time
+}
+
+func (c *fakeClient) CreateSecret(ctx context.Context, input *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	if _, ok := c.created[*input.Name]; ok {
+		return nil, &types.ResourceExistsException{Message: aws.String("already exists")}
+	}
+	c.created[*input.Name] = input
+	return &secretsmanager.CreateSecretOutput{Name: input.Name}, nil
+}
+
+func (c *fakeClient) DescribeSecret(ctx context.Context, input *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	if deletedDate, ok := c.deleted[*input.SecretId]; ok {
+		return &secretsmanager.DescribeSecretOutput{Name: input.SecretId, DeletedDate: aws.Time(deletedDate)}, nil
+	}
+	created, ok := c.created[*input.SecretId]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	return &secretsmanager.DescribeSecretOutput{Name: input.SecretId, Tags: created.Tags}, nil
+}
+
+func TestCreateSecret(t *testing.T) {
+	client := &fakeClient{created: map[string]*secretsmanager.CreateSecretInput{}}
+	m := &SecretsManager{client: client, kmsKeyID: "alias/secrets", replicaRegions: []string{"eu-west-3"}}
+
+	secret := vault.Secret{
+		Path:     "/billing/api_key",
+		Data:     map[string]interface{}{"secret": "foo"},
+		Metadata: map[string]string{"secret_type": "random_secret", "owner": "team_a"},
+	}
+	if _, err := m.CreateSecret(context.Background(), secret); err != nil {
+		t.Fatal("error:", err)
+	}
+
+	input := client.created["billing/api_key"]
+	if input == nil {
+		t.Fatalf("Secret not created: %v", client.created)
+	}
+	if *input.SecretString != `{"secret":"foo"}` {
+		t.Fatalf("Wrong secret string: %s", *input.SecretString)
+	}
+	if *input.KmsKeyId != "alias/secrets" || len(input.AddReplicaRegions) != 1 || *input.AddReplicaRegions[0].Region != "eu-west-3" {
+		t.Fatalf("Wrong encryption or replication settings: %v", input)
+	}
+
+	_, err := m.CreateSecret(context.Background(), secret)
+	var existsErr *vault.SecretExistsError
+	if !errors.As(err, &existsErr) {
+		t.Fatalf("Expected a SecretExistsError, got: %v", err)
+	}
+}
+
+func TestTagsFromMetadata(t *testing.T) {
+	metadata := map[string]string{"secret_type": "random_secret", "owner": "team_a"}
+
+	tags := tagsFromMetadata(metadata)
+	if len(tags) != 2 || *tags[0].Key != "owner" || *tags[1].Key != "secret_type" {
+		t.Fatalf("Wrong tags: %v", tags)
+	}
+
+	if back := metadataFromTags(tags); !reflect.DeepEqual(back, metadata) {
+		t.Fatalf("Wrong metadata: %v. Expected: %v", back, metadata)
+	}
+}
+
+func TestCreateSecretTagTooLong(t *testing.T) {
+	client := &fakeClient{created: map[string]*secretsmanager.CreateSecretInput{}}
+	m := &SecretsManager{client: client}
+
+	secret := vault.Secret{
+		Path:     "/billing/api_key",
+		Data:     map[string]interface{}{"secret": "foo"},
+		Metadata: map[string]string{"description": strings.Repeat("é", MaxTagValueLength+1)},
+	}
+	_, err := m.CreateSecret(context.Background(), secret)
+	if err == nil || !strings.Contains(err.Error(), "tag description") {
+		t.Fatalf("Expected a tag length error, got: %v", err)
+	}
+	if len(client.created) != 0 {
+		t.Fatalf("Secret created: %v", client.created)
+	}
+
+	secret.Metadata["description"] = strings.Repeat("é", MaxTagValueLength)
+	if _, err = m.CreateSecret(context.Background(), secret); err != nil {
+		t.Fatal("error:", err)
+	}
+}
+
+func TestReadSecretMetadata(t *testing.T) {
+	deletedDate := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := &fakeClient{
+		created: map[string]*secretsmanager.CreateSecretInput{},
+		deleted: map[string]time.Time{"billing/old_key": deletedDate},
+	}
+	m := &SecretsManager{client: client}
+
+	secret, err := m.ReadSecretMetadata(context.Background(), "/billing/api_key")
+	if err != nil || secret != nil {
+		t.Fatalf("Expected no secret, got: %v, %v", secret, err)
+	}
+
+	_, err = m.ReadSecretMetadata(context.Background(), "/billing/old_key")
+	var deletionErr *ScheduledDeletionError
+	if !errors.As(err, &deletionErr) || !deletionErr.DeletedDate.Equal(deletedDate) {
+		t.Fatalf("Expected a ScheduledDeletionError, got: %v", err)
+	}
+	if !strings.Contains(deletionErr.Hint(), "restore-secret --secret-id billing/old_key") {
+		t.Fatalf("Wrong hint: %s", deletionErr.Hint())
+	}
+}
@@ -0,0 +1,250 @@
+package gcpsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var secretIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,255}$`)
+
+var _ vault.SecretStore = &SecretManager{}
+
+// SecretManager stores generated secrets in GCP Secret Manager. Secret paths are the IDs of secrets of a single
+// project, custom metadata are stored as annotations of the secrets and the secret data as a JSON object, the payload of
+// their versions.
+type SecretManager struct {
+	client  *secretmanager.Client
+	project string
+}
+
+// NewSecretManager creates a Secret Manager client for the given project, authenticated with the Application Default
+// Credentials.
+func NewSecretManager(ctx context.Context, project string) (*SecretManager, error) {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretManager{client: client, project: project}, nil
+}
+
+// secretName returns the resource name of the secret with the given ID.
+func (m *SecretManager) secretName(secretPath string) (string, error) {
+	id := strings.Trim(secretPath, "/")
+	if !secretIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid secret ID %q: only letters, digits, underscores and dashes are allowed", secretPath)
+	}
+	return fmt.Sprintf("projects/%s/secrets/%s", m.project, id), nil
+}
+
+// CreateSecret creates a secret with automatic replication and writes its first version. It fails if a secret already
+// exists with the same ID.
+func (m *SecretManager) CreateSecret(ctx context.Context, secret vault.Secret) (int, error) {
+	name, err := m.secretName(secret.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	payload, err := json.Marshal(secret.Data)
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode secret data: %w", err)
+	}
+
+	_, err = m.client.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+		Parent:   "projects/" + m.project,
+		SecretId: strings.Trim(secret.Path, "/"),
+		Secret: &secretmanagerpb.Secret{
+			Replication: &secretmanagerpb.Replication{
+				Replication: &secretmanagerpb.Replication_Automatic_{Automatic: &secretmanagerpb.Replication_Automatic{}},
+			},
+			Annotations: secret.Metadata,
+		},
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return 0, &vault.SecretExistsError{Path: secret.Path}
+	}
+	if err != nil {
+		return 0, newError("create secret", name, err)
+	}
+
+	version, err := m.client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  name,
+		Payload: &secretmanagerpb.SecretPayload{Data: payload},
+	})
+	if err != nil {
+		// Don't leave an empty secret behind, it would prevent retries
+		_ = m.client.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{Name: name})
+		return 0, newError("write secret's data", name, err)
+	}
+
+	return versionNumber(version.Name), nil
+}
+
+// ReadSecret reads the latest version of a secret along with its metadata. It returns nil if the secret doesn't exist.
+func (m *SecretManager) ReadSecret(ctx context.Context, secretPath string) (*vault.Secret, error) {
+	secret, err := m.ReadSecretMetadata(ctx, secretPath)
+	if err != nil || secret == nil {
+		return secret, err
+	}
+
+	dataPath := secret.DataPath
+	version, err := m.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: dataPath})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newError("read secret's data", dataPath, err)
+	}
+
+	if err = json.Unmarshal(version.Payload.Data, &secret.Data); err != nil {
+		return nil, fmt.Errorf("unable to decode secret data: %w", err)
+	}
+	return secret, nil
+}
+
+// ReadSecretMetadata reads the annotations and versions of a secret without accessing its data. It returns nil if the
+// secret doesn't exist.
+func (m *SecretManager) ReadSecretMetadata(ctx context.Context, secretPath string) (*vault.Secret, error) {
+	name, err := m.secretName(secretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := m.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: name})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newError("read secret's metadata", name, err)
+	}
+
+	secret := &vault.Secret{
+		Path:         secretPath,
+		Metadata:     s.Annotations,
+		CreatedTime:  s.CreateTime.AsTime(),
+		DataPath:     name + "/versions/latest",
+		MetadataPath: name,
+	}
+	if secret.Metadata == nil {
+		secret.Metadata = map[string]string{}
+	}
+
+	// Versions are listed newest first
+	it := m.client.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{Parent: name})
+	for {
+		version, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, newError("list secret's versions", name, err)
+		}
+		if version.State == secretmanagerpb.SecretVersion_DESTROYED {
+			continue
+		}
+		if secret.Version == 0 {
+			secret.Version = versionNumber(version.Name)
+		}
+		secret.VersionsKept++
+	}
+
+	// A secret without versions can't be used, it's considered deleted
+	if secret.Version == 0 {
+		return nil, nil
+	}
+	return secret, nil
+}
+
+// UpdateSecretMetadata sets the given annotations of a secret and removes the ones listed in removed, other annotations
+// are kept.
+func (m *SecretManager) UpdateSecretMetadata(ctx context.Context, secretPath string, metadata map[string]string, removed []string) error {
+	name, err := m.secretName(secretPath)
+	if err != nil {
+		return err
+	}
+
+	s, err := m.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: name})
+	if err != nil {
+		return newError("read secret's metadata", name, err)
+	}
+
+	_, err = m.client.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+		Secret: &secretmanagerpb.Secret{
+			Name:        name,
+			Etag:        s.Etag,
+			Annotations: mergeAnnotations(s.Annotations, metadata, removed),
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"annotations"}},
+	})
+	if err != nil {
+		return newError("update secret's metadata", name, err)
+	}
+	return nil
+}
+
+// DeleteSecret deletes a secret and all its versions. Deleting a secret that doesn't exist is not an error.
+func (m *SecretManager) DeleteSecret(ctx context.Context, secretPath string) error {
+	name, err := m.secretName(secretPath)
+	if err != nil {
+		return err
+	}
+
+	err = m.client.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{Name: name})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return newError("delete secret", name, err)
+	}
+	return nil
+}
+
+// SecretAPIPaths returns the resource names of the latest version of a secret and of the secret itself, as used in IAM
+// conditions.
+func (m *SecretManager) SecretAPIPaths(ctx context.Context, secretPath string) (string, string, error) {
+	name, err := m.secretName(secretPath)
+	if err != nil {
+		return "", "", err
+	}
+	return name + "/versions/latest", name, nil
+}
+
+// mergeAnnotations returns current updated with metadata, without the removed keys.
+func mergeAnnotations(current, metadata map[string]string, removed []string) map[string]string {
+	merged := make(map[string]string, len(current)+len(metadata))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for _, k := range removed {
+		delete(merged, k)
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	return merged
+}
+
+// versionNumber extracts the number of a version from its resource name, 0 if it can't be parsed.
+func versionNumber(name string) int {
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return n
+}
+
+// newError wraps an error returned by Secret Manager with the same details as errors returned by Vault.
+func newError(operation, name string, err error) error {
+	return &vault.Error{
+		Operation: operation,
+		Path:      name,
+		Errors:    []string{status.Convert(err).Message()},
+		Err:       err,
+	}
+}
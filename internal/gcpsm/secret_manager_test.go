@@ -0,0 +1,46 @@
+package gcpsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecretName(t *testing.T) {
+	m := &SecretManager{project: "my-project"}
+
+	name, err := m.secretName("/billing-api_key/")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if name != "projects/my-project/secrets/billing-api_key" {
+		t.Fatalf("Wrong secret name: %s", name)
+	}
+
+	for _, invalid := range []string{"", "secret/foo", "foo.bar"} {
+		if _, err = m.secretName(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestVersionNumber(t *testing.T) {
+	if n := versionNumber("projects/my-project/secrets/foo/versions/3"); n != 3 {
+		t.Fatalf("Wrong version number: %d. Expected: 3", n)
+	}
+	if n := versionNumber("projects/my-project/secrets/foo/versions/latest"); n != 0 {
+		t.Fatalf("Wrong version number: %d. Expected: 0", n)
+	}
+}
+
+func TestMergeAnnotations(t *testing.T) {
+	merged := mergeAnnotations(
+		map[string]string{"owner": "team_a", "secret_type": "random_secret", "foo": "bar"},
+		map[string]string{"owner": "team_b"},
+		[]string{"foo"},
+	)
+
+	expected := map[string]string{"owner": "team_b", "secret_type": "random_secret"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Fatalf("Wrong annotations: %v. Expected: %v", merged, expected)
+	}
+}
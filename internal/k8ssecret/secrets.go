@@ -0,0 +1,247 @@
+package k8ssecret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// AnnotationPrefix prefixes the annotations holding the custom metadata of a secret, so that they don't collide
+	// with annotations set by other tools
+	AnnotationPrefix = "vaultprov.blablacar.com/"
+
+	// ManagedByLabel marks the Kubernetes secrets created by the provider
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "terraform-provider-vaultprov"
+)
+
+var _ vault.SecretStore = &Secrets{}
+
+// Secrets stores generated secrets directly in Kubernetes Secrets through the API server. Secret paths are
+// `<namespace>/<name>`, each field of the secret data is a key of the Kubernetes Secret and custom metadata are stored
+// as prefixed annotations. Kubernetes Secrets have no versions.
+type Secrets struct {
+	client kubernetes.Interface
+}
+
+// NewSecrets creates a Kubernetes client from the kubeconfig file at configPath (KUBECONFIG or ~/.kube/config when
+// empty) using the given context (the current one when empty). The in-cluster configuration is used when there's no
+// kubeconfig file.
+func NewSecrets(configPath, configContext string) (*Secrets, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if configPath != "" {
+		rules.ExplicitPath = configPath
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: configContext}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Secrets{client: client}, nil
+}
+
+// secretRef returns the namespace and name of the Kubernetes Secret at secretPath.
+func secretRef(secretPath string) (string, string, error) {
+	namespace, name, ok := strings.Cut(strings.Trim(secretPath, "/"), "/")
+	if !ok {
+		return "", "", fmt.Errorf("invalid secret path %q: expected <namespace>/<name>", secretPath)
+	}
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid secret name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return namespace, name, nil
+}
+
+// apiPath returns the API server path of a Kubernetes Secret.
+func apiPath(namespace, name string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", namespace, name)
+}
+
+// CreateSecret creates an Opaque Kubernetes Secret. It fails if a secret already exists with the same name. Kubernetes
+// Secrets have no versions, 0 is returned.
+func (s *Secrets) CreateSecret(ctx context.Context, secret vault.Secret) (int, error) {
+	namespace, name, err := secretRef(secret.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	data := make(map[string][]byte, len(secret.Data))
+	for k, v := range secret.Data {
+		value, ok := v.(string)
+		if !ok {
+			return 0, fmt.Errorf("unsupported value for key %s: only strings can be stored in Kubernetes secrets", k)
+		}
+		data[k] = []byte(value)
+	}
+
+	_, err = s.client.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{ManagedByLabel: managedByValue},
+			Annotations: mergeAnnotations(nil, secret.Metadata, nil),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return 0, &vault.SecretExistsError{Path: secret.Path}
+	}
+	if err != nil {
+		return 0, newError("create secret", apiPath(namespace, name), err)
+	}
+	return 0, nil
+}
+
+// ReadSecret reads a Kubernetes Secret, data included. It returns nil if the secret doesn't exist.
+func (s *Secrets) ReadSecret(ctx context.Context, secretPath string) (*vault.Secret, error) {
+	k8sSecret, err := s.get(ctx, secretPath)
+	if err != nil || k8sSecret == nil {
+		return nil, err
+	}
+
+	secret := toSecret(secretPath, k8sSecret)
+	secret.Data = make(map[string]interface{}, len(k8sSecret.Data))
+	for k, v := range k8sSecret.Data {
+		secret.Data[k] = string(v)
+	}
+	return secret, nil
+}
+
+// ReadSecretMetadata reads the annotations of a Kubernetes Secret. The API server sends the secret data along, it is
+// dropped right away. It returns nil if the secret doesn't exist.
+func (s *Secrets) ReadSecretMetadata(ctx context.Context, secretPath string) (*vault.Secret, error) {
+	k8sSecret, err := s.get(ctx, secretPath)
+	if err != nil || k8sSecret == nil {
+		return nil, err
+	}
+	return toSecret(secretPath, k8sSecret), nil
+}
+
+// UpdateSecretMetadata sets the given annotations of a Kubernetes Secret and removes the ones listed in removed, other
+// annotations are kept. The update fails if the secret has been modified since it was read.
+func (s *Secrets) UpdateSecretMetadata(ctx context.Context, secretPath string, metadata map[string]string, removed []string) error {
+	k8sSecret, err := s.get(ctx, secretPath)
+	if err != nil {
+		return err
+	}
+	if k8sSecret == nil {
+		return fmt.Errorf("no secret at %s", secretPath)
+	}
+
+	k8sSecret.Annotations = mergeAnnotations(k8sSecret.Annotations, metadata, removed)
+	_, err = s.client.CoreV1().Secrets(k8sSecret.Namespace).Update(ctx, k8sSecret, metav1.UpdateOptions{})
+	if err != nil {
+		return newError("update secret's annotations", apiPath(k8sSecret.Namespace, k8sSecret.Name), err)
+	}
+	return nil
+}
+
+// DeleteSecret deletes a Kubernetes Secret. Deleting a secret that doesn't exist is not an error.
+func (s *Secrets) DeleteSecret(ctx context.Context, secretPath string) error {
+	namespace, name, err := secretRef(secretPath)
+	if err != nil {
+		return err
+	}
+
+	err = s.client.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return newError("delete secret", apiPath(namespace, name), err)
+	}
+	return nil
+}
+
+// SecretAPIPaths returns the API server path of the Kubernetes Secret for both its data and metadata.
+func (s *Secrets) SecretAPIPaths(ctx context.Context, secretPath string) (string, string, error) {
+	namespace, name, err := secretRef(secretPath)
+	if err != nil {
+		return "", "", err
+	}
+	p := apiPath(namespace, name)
+	return p, p, nil
+}
+
+// get reads the Kubernetes Secret at secretPath, nil if it doesn't exist.
+func (s *Secrets) get(ctx context.Context, secretPath string) (*corev1.Secret, error) {
+	namespace, name, err := secretRef(secretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	k8sSecret, err := s.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newError("read secret", apiPath(namespace, name), err)
+	}
+	return k8sSecret, nil
+}
+
+// toSecret converts a Kubernetes Secret, without its data.
+func toSecret(secretPath string, k8sSecret *corev1.Secret) *vault.Secret {
+	metadata := make(map[string]string)
+	for k, v := range k8sSecret.Annotations {
+		if key, ok := strings.CutPrefix(k, AnnotationPrefix); ok {
+			metadata[key] = v
+		}
+	}
+
+	p := apiPath(k8sSecret.Namespace, k8sSecret.Name)
+	return &vault.Secret{
+		Path:         secretPath,
+		Metadata:     metadata,
+		VersionsKept: 1,
+		CreatedTime:  k8sSecret.CreationTimestamp.Time,
+		DataPath:     p,
+		MetadataPath: p,
+	}
+}
+
+// mergeAnnotations returns current updated with the prefixed metadata, without the removed keys.
+func mergeAnnotations(current, metadata map[string]string, removed []string) map[string]string {
+	merged := make(map[string]string, len(current)+len(metadata))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for _, k := range removed {
+		delete(merged, AnnotationPrefix+k)
+	}
+	for k, v := range metadata {
+		merged[AnnotationPrefix+k] = v
+	}
+	return merged
+}
+
+// newError wraps an error returned by the API server with the same details as errors returned by Vault.
+func newError(operation, apiPath string, err error) error {
+	message := err.Error()
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		message = status.Status().Message
+	}
+	return &vault.Error{
+		Operation: operation,
+		Path:      apiPath,
+		Errors:    []string{message},
+		Err:       err,
+	}
+}
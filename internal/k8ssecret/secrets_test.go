@@ -0,0 +1,72 @@
+package k8ssecret
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretRef(t *testing.T) {
+	namespace, name, err := secretRef("/billing/api-key/")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if namespace != "billing" || name != "api-key" {
+		t.Fatalf("Wrong secret reference: %s/%s", namespace, name)
+	}
+
+	for _, invalid := range []string{"", "api-key", "billing/api_key", "Billing/api-key", "billing/foo/bar"} {
+		if _, _, err = secretRef(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	ctx := context.Background()
+	s := &Secrets{client: fake.NewSimpleClientset()}
+
+	secret := vault.Secret{
+		Path:     "billing/api-key",
+		Data:     map[string]interface{}{"secret": "foo"},
+		Metadata: map[string]string{"secret_type": "random_secret", "owner": "team_a"},
+	}
+	if _, err := s.CreateSecret(ctx, secret); err != nil {
+		t.Fatal("error:", err)
+	}
+
+	_, err := s.CreateSecret(ctx, secret)
+	var existsErr *vault.SecretExistsError
+	if !errors.As(err, &existsErr) {
+		t.Fatalf("Expected a SecretExistsError, got: %v", err)
+	}
+
+	if err = s.UpdateSecretMetadata(ctx, secret.Path, map[string]string{"owner": "team_b"}, []string{"secret_type"}); err != nil {
+		t.Fatal("error:", err)
+	}
+
+	read, err := s.ReadSecret(ctx, secret.Path)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if !reflect.DeepEqual(read.Data, secret.Data) {
+		t.Fatalf("Wrong data: %v. Expected: %v", read.Data, secret.Data)
+	}
+	if expected := map[string]string{"owner": "team_b"}; !reflect.DeepEqual(read.Metadata, expected) {
+		t.Fatalf("Wrong metadata: %v. Expected: %v", read.Metadata, expected)
+	}
+	if read.DataPath != "/api/v1/namespaces/billing/secrets/api-key" {
+		t.Fatalf("Wrong data path: %s", read.DataPath)
+	}
+
+	if err = s.DeleteSecret(ctx, secret.Path); err != nil {
+		t.Fatal("error:", err)
+	}
+	if read, err = s.ReadSecretMetadata(ctx, secret.Path); err != nil || read != nil {
+		t.Fatalf("Expected no secret after deletion, got: %v, %v", read, err)
+	}
+}
@@ -0,0 +1,49 @@
+package planmodifiers
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ListRequiresReplaceIfNotEmpty plans an empty list for a computed attribute listing damage only a new resource
+// repairs: the resource is replaced when the prior state holds elements, the prior state is kept otherwise.
+func ListRequiresReplaceIfNotEmpty() planmodifier.List {
+	return &listRequiresReplaceIfNotEmptyAttributePlanModifier{}
+}
+
+type listRequiresReplaceIfNotEmptyAttributePlanModifier struct{}
+
+func (d *listRequiresReplaceIfNotEmptyAttributePlanModifier) Description(ctx context.Context) string {
+	return "If the value in state is not empty, the resource is replaced"
+}
+
+func (d *listRequiresReplaceIfNotEmptyAttributePlanModifier) MarkdownDescription(ctx context.Context) string {
+	return d.Description(ctx)
+}
+
+// PlanModifyList plans an empty list on creation and replacement, and the value in state otherwise. Nothing is done
+// when the resource is destroyed.
+func (d *listRequiresReplaceIfNotEmptyAttributePlanModifier) PlanModifyList(ctx context.Context, req planmodifier.ListRequest, resp *planmodifier.ListResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	empty, diags := types.ListValue(req.PlanValue.ElementType(ctx), nil)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if req.State.Raw.IsNull() {
+		resp.PlanValue = empty
+		return
+	}
+	if len(req.StateValue.Elements()) > 0 {
+		resp.PlanValue = empty
+		resp.RequiresReplace = true
+		return
+	}
+	resp.PlanValue = req.StateValue
+}
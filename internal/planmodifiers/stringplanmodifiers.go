@@ -0,0 +1,39 @@
+package planmodifiers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// StringDefaultValue accepts a types.String value and uses the supplied value to set a default
+// if the config for the attribute is null.
+func StringDefaultValue(val types.String) planmodifier.String {
+	return &stringDefaultValueAttributePlanModifier{val}
+}
+
+type stringDefaultValueAttributePlanModifier struct {
+	val types.String
+}
+
+func (d *stringDefaultValueAttributePlanModifier) Description(ctx context.Context) string {
+	return fmt.Sprintf("If not configured, defaults to %s", d.val.ValueString())
+}
+
+func (d *stringDefaultValueAttributePlanModifier) MarkdownDescription(ctx context.Context) string {
+	return d.Description(ctx)
+}
+
+// PlanModifyString checks that the value of the attribute in the configuration and assigns the default value if
+// the value in the config is null. This is a destructive operation in that it will overwrite any value
+// present in the plan.
+func (d *stringDefaultValueAttributePlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Do not set default if the attribute configuration has been set.
+	if !req.ConfigValue.IsNull() {
+		return
+	}
+
+	resp.PlanValue = d.val
+}
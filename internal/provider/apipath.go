@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func dataPathAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Computed: true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.UseStateForUnknown(),
+		},
+		MarkdownDescription: "API path of the secret's data, e.g. `secret/data/foo/bar`, to be used in `vault_policy` documents.",
+	}
+}
+
+func metadataPathAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Computed: true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.UseStateForUnknown(),
+		},
+		MarkdownDescription: "API path of the secret's metadata, e.g. `secret/metadata/foo/bar`, to be used in `vault_policy` documents.",
+	}
+}
+
+// secretAPIPaths resolves the values of the `data_path` and `metadata_path` attributes of a KV v2 secret.
+func secretAPIPaths(ctx context.Context, store vault.SecretStore, secretPath string, diags *diag.Diagnostics) (types.String, types.String) {
+	dataPath, metadataPath, err := store.SecretAPIPaths(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error resolving secret paths", fmt.Sprintf("Couldn't resolve API paths of secret %s", secretPath), err)
+		return types.StringNull(), types.StringNull()
+	}
+	return types.StringValue(dataPath), types.StringValue(metadataPath)
+}
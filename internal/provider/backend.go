@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// checkVaultBackend reports an error when the provider doesn't store secrets in Vault, for resources and data sources
+// relying on Vault-only features.
+func checkVaultBackend(diags *diag.Diagnostics, data *providerData, typeName string) {
+	if data.backend == VaultBackend {
+		return
+	}
+	diags.AddError("Unsupported backend", fmt.Sprintf("%s_%s requires the %s backend, the provider is configured with the %s backend.", providerName, typeName, VaultBackend, data.backend))
+}
+
+// checkRandomSecretBackend checks that a random secret only relies on features the provider's backend supports.
+// Cubbyhole, policies, restoration, deletion scheduling, deleted versions handling, extra headers and templates are
+// Vault-only features.
+func checkRandomSecretBackend(diags *diag.Diagnostics, backend string, plan randomSecretModel) {
+	if backend == VaultBackend || backend == "" {
+		return
+	}
+
+	unsupported := []struct {
+		attribute string
+		set       bool
+	}{
+		{"mount_type", plan.MountType.ValueString() == CubbyholeMountType},
+		{"destroy_after", !plan.DestroyAfter.IsNull()},
+		{"restore_deleted", plan.RestoreDeleted.ValueBool()},
+		{"on_deleted_version", plan.OnDeletedVersion.ValueString() == OnDeletedVersionRecreate || plan.OnDeletedVersion.ValueString() == OnDeletedVersionRestore},
+		{"policy_template", plan.PolicyTemplate != nil},
+		{"delete_all_versions", !plan.DeleteAllVersions.IsUnknown() && !plan.DeleteAllVersions.ValueBool()},
+		{"extra_headers", !plan.ExtraHeaders.IsNull()},
+		{"cas_version", plan.CasVersion.ValueBool()},
+		{"length_change_behavior", plan.LengthChange.ValueString() == LengthChangeNewVersion},
+		{"template", !plan.Template.IsNull()},
+		{"large_secret", plan.LargeSecret.ValueBool()},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			diags.AddAttributeError(path.Root(u.attribute), "Unsupported attribute", fmt.Sprintf("Attribute %s isn't supported with the %s backend.", u.attribute, backend))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCheckVaultBackend(t *testing.T) {
+	var diags diag.Diagnostics
+	checkVaultBackend(&diags, &providerData{backend: VaultBackend}, "pgp_key")
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkVaultBackend(&diags, &providerData{backend: GCPSecretManagerBackend}, "pgp_key")
+	if diags.ErrorsCount() != 1 {
+		t.Fatalf("Expected an error with the %s backend, got: %v", GCPSecretManagerBackend, diags)
+	}
+}
+
+func TestCheckRandomSecretBackend(t *testing.T) {
+	plan := randomSecretModel{
+		MountType:         types.StringValue(KVv2MountType),
+		DestroyAfter:      types.StringNull(),
+		RestoreDeleted:    types.BoolValue(false),
+		DeleteAllVersions: types.BoolValue(true),
+		OnDeletedVersion:  types.StringValue(OnDeletedVersionError),
+	}
+
+	var diags diag.Diagnostics
+	checkRandomSecretBackend(&diags, GCPSecretManagerBackend, plan)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	plan.DestroyAfter = types.StringValue("72h")
+	plan.PolicyTemplate = &policyTemplateModel{Name: types.StringValue("read-foo")}
+	plan.OnDeletedVersion = types.StringValue(OnDeletedVersionRestore)
+	checkRandomSecretBackend(&diags, GCPSecretManagerBackend, plan)
+	if diags.ErrorsCount() != 3 {
+		t.Fatalf("Expected errors for destroy_after, policy_template and on_deleted_version, got: %v", diags)
+	}
+
+	diags = nil
+	checkRandomSecretBackend(&diags, VaultBackend, plan)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error with the %s backend: %v", VaultBackend, diags)
+	}
+}
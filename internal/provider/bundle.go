@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type secretBundleFieldModel struct {
+	Length          types.Int64  `tfsdk:"length"`
+	PreviousField   types.String `tfsdk:"previous_field"`
+	RotationTrigger types.String `tfsdk:"rotation_trigger"`
+}
+
+// checkBundleFields checks that fields and their previous fields don't use the same data keys.
+func checkBundleFields(diags *diag.Diagnostics, fields map[string]secretBundleFieldModel) {
+	keys := make(map[string]string)
+	for name := range fields {
+		keys[name] = name
+	}
+
+	for _, name := range sortedFieldNames(fields) {
+		previous := fields[name].PreviousField
+		if previous.IsNull() || previous.IsUnknown() {
+			continue
+		}
+		if other, ok := keys[previous.ValueString()]; ok {
+			diags.AddAttributeError(
+				path.Root("fields").AtMapKey(name).AtName("previous_field"),
+				"Invalid previous field",
+				fmt.Sprintf("Previous field %q of field %q is already used by field %q.", previous.ValueString(), name, other),
+			)
+			continue
+		}
+		keys[previous.ValueString()] = name
+	}
+
+	if layout := bundleLayout(fields); len(layout) > validators.MaxCustomMetadataValueLength {
+		diags.AddAttributeError(
+			path.Root("fields"),
+			"Too many fields",
+			fmt.Sprintf("The fields layout stored in the %s custom metadata is %d bytes long, Vault accepts at most %d bytes. Use fewer or shorter fields.", SecretBundleFieldsMetadata, len(layout), validators.MaxCustomMetadataValueLength),
+		)
+	}
+}
+
+// bundleLayout describes the fields as stored in the secret's custom metadata: `name:length:previous_field` entries
+// sorted by name and separated by commas.
+func bundleLayout(fields map[string]secretBundleFieldModel) string {
+	entries := make([]string, 0, len(fields))
+	for _, name := range sortedFieldNames(fields) {
+		f := fields[name]
+		entries = append(entries, fmt.Sprintf("%s:%d:%s", name, f.Length.ValueInt64(), f.PreviousField.ValueString()))
+	}
+	return strings.Join(entries, ",")
+}
+
+// parseBundleLayout reads fields described by bundleLayout. Rotation triggers are not stored and are left null.
+func parseBundleLayout(layout string) (map[string]secretBundleFieldModel, error) {
+	fields := make(map[string]secretBundleFieldModel)
+	if layout == "" {
+		return fields, nil
+	}
+
+	for _, entry := range strings.Split(layout, ",") {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid field %q", entry)
+		}
+		length, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid length of field %s: %w", parts[0], err)
+		}
+
+		f := secretBundleFieldModel{
+			Length:          types.Int64Value(length),
+			PreviousField:   types.StringNull(),
+			RotationTrigger: types.StringNull(),
+		}
+		if parts[2] != "" {
+			f.PreviousField = types.StringValue(parts[2])
+		}
+		fields[parts[0]] = f
+	}
+	return fields, nil
+}
+
+// rotateBundle computes the secret data for the planned fields from the current data. Fields that are new, or whose
+// length or rotation trigger changed, are generated, the replaced value being moved to the previous field when one is
+// set. It returns the new data and the names of the generated fields.
+func rotateBundle(data map[string]interface{}, state, plan map[string]secretBundleFieldModel) (map[string]interface{}, []string, error) {
+	result := make(map[string]interface{})
+	rotated := make([]string, 0)
+
+	for _, name := range sortedFieldNames(plan) {
+		p := plan[name]
+		s, existed := state[name]
+		current, hasCurrent := data[name]
+
+		if existed && hasCurrent && !bundleFieldRotated(s, p) {
+			result[name] = current
+			if !p.PreviousField.IsNull() && !s.PreviousField.IsNull() {
+				if previous, ok := data[s.PreviousField.ValueString()]; ok {
+					result[p.PreviousField.ValueString()] = previous
+				}
+			}
+			continue
+		}
+
+		key, err := secrets.GenerateRandomSecret(int(p.Length.ValueInt64()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't generate field %s: %w", name, err)
+		}
+		result[name] = base64.StdEncoding.EncodeToString(key)
+		secrets.Wipe(key)
+		rotated = append(rotated, name)
+
+		if !p.PreviousField.IsNull() && existed && hasCurrent {
+			result[p.PreviousField.ValueString()] = current
+		}
+	}
+
+	return result, rotated, nil
+}
+
+// bundleFieldRotated tells if a field is generated again: its length or rotation trigger changed. A null prior
+// trigger, e.g. after an import as triggers are not stored in Vault, adopts the planned one without rotating.
+func bundleFieldRotated(state, plan secretBundleFieldModel) bool {
+	if !state.Length.Equal(plan.Length) {
+		return true
+	}
+	return !state.RotationTrigger.IsNull() && !state.RotationTrigger.Equal(plan.RotationTrigger)
+}
+
+// bundleFieldsChanged tells if the planned fields write a new version of the secret: fields are added or removed,
+// rotated or have a different previous field.
+func bundleFieldsChanged(state, plan map[string]secretBundleFieldModel) bool {
+	if len(state) != len(plan) {
+		return true
+	}
+	for name, p := range plan {
+		s, ok := state[name]
+		if !ok || bundleFieldRotated(s, p) || !s.PreviousField.Equal(p.PreviousField) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedFieldNames(fields map[string]secretBundleFieldModel) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
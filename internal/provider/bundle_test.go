@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func bundleField(length int64, previous string, trigger string) secretBundleFieldModel {
+	f := secretBundleFieldModel{
+		Length:          types.Int64Value(length),
+		PreviousField:   types.StringNull(),
+		RotationTrigger: types.StringNull(),
+	}
+	if previous != "" {
+		f.PreviousField = types.StringValue(previous)
+	}
+	if trigger != "" {
+		f.RotationTrigger = types.StringValue(trigger)
+	}
+	return f
+}
+
+func TestBundleLayout(t *testing.T) {
+	fields := map[string]secretBundleFieldModel{
+		"salt":        bundleField(16, "", ""),
+		"current_key": bundleField(32, "previous_key", ""),
+	}
+
+	layout := bundleLayout(fields)
+	if layout != "current_key:32:previous_key,salt:16:" {
+		t.Fatalf("Wrong layout: %s", layout)
+	}
+
+	parsed, err := parseBundleLayout(layout)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if !reflect.DeepEqual(parsed, fields) {
+		t.Fatalf("Wrong parsed fields: %v. Expected: %v", parsed, fields)
+	}
+
+	if _, err = parseBundleLayout("current_key:32"); err == nil {
+		t.Fatalf("Expected an error for an invalid layout")
+	}
+}
+
+func TestCheckBundleFields(t *testing.T) {
+	var diags diag.Diagnostics
+	checkBundleFields(&diags, map[string]secretBundleFieldModel{
+		"current_key": bundleField(32, "previous_key", ""),
+		"salt":        bundleField(16, "", ""),
+	})
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkBundleFields(&diags, map[string]secretBundleFieldModel{
+		"current_key": bundleField(32, "salt", ""),
+		"salt":        bundleField(16, "", ""),
+	})
+	if !diags.HasError() {
+		t.Fatalf("Expected an error for a previous field overwriting another field")
+	}
+
+	diags = nil
+	fields := make(map[string]secretBundleFieldModel)
+	for i := 0; i < 40; i++ {
+		fields[fmt.Sprintf("a_rather_long_field_name_%d", i)] = bundleField(32, "", "")
+	}
+	checkBundleFields(&diags, fields)
+	if !diags.HasError() {
+		t.Fatalf("Expected an error for a layout exceeding Vault limits")
+	}
+}
+
+func TestRotateBundle(t *testing.T) {
+	state := map[string]secretBundleFieldModel{
+		"current_key": bundleField(32, "previous_key", "1"),
+		"salt":        bundleField(16, "", ""),
+	}
+
+	data, rotated, err := rotateBundle(nil, nil, state)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if len(data) != 2 || !reflect.DeepEqual(rotated, []string{"current_key", "salt"}) {
+		t.Fatalf("Wrong generated data: %v (rotated: %v)", data, rotated)
+	}
+
+	// Rotating the key moves its value to the previous key, the salt is kept
+	plan := map[string]secretBundleFieldModel{
+		"current_key": bundleField(32, "previous_key", "2"),
+		"salt":        bundleField(16, "", ""),
+	}
+	rotatedData, rotated, err := rotateBundle(data, state, plan)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if !reflect.DeepEqual(rotated, []string{"current_key"}) {
+		t.Fatalf("Wrong rotated fields: %v", rotated)
+	}
+	if rotatedData["previous_key"] != data["current_key"] || rotatedData["current_key"] == data["current_key"] || rotatedData["salt"] != data["salt"] {
+		t.Fatalf("Wrong rotated data: %v. Initial data: %v", rotatedData, data)
+	}
+
+	// Without rotation, the previous key is kept and fields removed from the plan are dropped
+	plan = map[string]secretBundleFieldModel{
+		"current_key": bundleField(32, "previous_key", "2"),
+	}
+	keptData, rotated, err := rotateBundle(rotatedData, map[string]secretBundleFieldModel{"current_key": plan["current_key"], "salt": state["salt"]}, plan)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if len(rotated) != 0 || len(keptData) != 2 || keptData["previous_key"] != rotatedData["previous_key"] || keptData["current_key"] != rotatedData["current_key"] {
+		t.Fatalf("Wrong data: %v. Initial data: %v", keptData, rotatedData)
+	}
+}
+
+func TestBundleFieldsChangedAfterImport(t *testing.T) {
+	// Imported fields have no rotation trigger
+	imported, err := parseBundleLayout("current_key:32:previous_key,salt:16:")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	plan := map[string]secretBundleFieldModel{
+		"current_key": bundleField(32, "previous_key", "2024-06"),
+		"salt":        bundleField(16, "", ""),
+	}
+	if bundleFieldsChanged(imported, plan) {
+		t.Fatal("Configured rotation triggers should be adopted after an import")
+	}
+
+	data := map[string]interface{}{"current_key": "a2V5", "previous_key": "b2xk", "salt": "c2FsdA=="}
+	kept, rotated, err := rotateBundle(data, imported, plan)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if len(rotated) != 0 || !reflect.DeepEqual(kept, data) {
+		t.Fatalf("Wrong data: %v (rotated: %v). Initial data: %v", kept, rotated, data)
+	}
+
+	// Once adopted, changing the trigger rotates the field
+	rotatedPlan := map[string]secretBundleFieldModel{
+		"current_key": bundleField(32, "previous_key", "2024-07"),
+		"salt":        plan["salt"],
+	}
+	if !bundleFieldsChanged(plan, rotatedPlan) {
+		t.Fatal("Changing a rotation trigger should rotate the field")
+	}
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// updatedTimePrivateStateKey holds when the secret was last written, as seen by the last refresh
+const updatedTimePrivateStateKey = "updated_time"
+
+func casVersionAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		Optional: true,
+		Computed: true,
+		PlanModifiers: []planmodifier.Bool{
+			planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+		},
+		MarkdownDescription: "If set to `true`, metadata updates are declined when the secret has been written by another actor (metadata or data) since the last refresh, instead of overwriting its changes: plan again to review them. Vault has no check-and-set on metadata, the secret's `updated_time` is compared right before the update. Only with the `vault` backend. Default is `false`.",
+	}
+}
+
+// setUpdatedTimePrivateState records when the secret read was last written, so that later updates can check it hasn't
+// been written since. Nothing is recorded for stores not reporting it.
+func setUpdatedTimePrivateState(ctx context.Context, private privateState, secret *vault.Secret) diag.Diagnostics {
+	if secret.UpdatedTime.IsZero() {
+		return nil
+	}
+
+	value, err := json.Marshal(secret.UpdatedTime)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Error storing secret's update time", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, updatedTimePrivateStateKey, value)
+}
+
+// checkSecretUnchanged reports an error, with cas_version, when the secret has been written since the last refresh. The
+// recorded time is then cleared: the update about to be made by the resource changes it, the next refresh records it
+// again.
+func checkSecretUnchanged(ctx context.Context, store vault.SecretStore, secretPath string, casVersion types.Bool, private, updated privateState, diags *diag.Diagnostics) {
+	if !casVersion.ValueBool() {
+		return
+	}
+
+	value, d := private.GetKey(ctx, updatedTimePrivateStateKey)
+	diags.Append(d...)
+	if diags.HasError() || value == nil {
+		return
+	}
+	var refreshed time.Time
+	if err := json.Unmarshal(value, &refreshed); err != nil {
+		diags.AddError("Error reading secret's update time", err.Error())
+		return
+	}
+	// Cleared by a previous update, not refreshed since
+	if refreshed.IsZero() {
+		return
+	}
+
+	secret, err := store.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error updating secret", fmt.Sprintf("Error while reading metadata for secret %s", secretPath), err)
+		return
+	}
+	if secret == nil {
+		diags.AddError("Error updating secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return
+	}
+	if !secret.UpdatedTime.Equal(refreshed) {
+		diags.AddError(
+			"Secret modified concurrently",
+			fmt.Sprintf("Secret %s has been written at %s, after the last refresh (%s): its metadata haven't been updated so that the other changes aren't overwritten. Plan again to review them.", secretPath, secret.UpdatedTime.UTC().Format(time.RFC3339), refreshed.UTC().Format(time.RFC3339)),
+		)
+		return
+	}
+
+	diags.Append(updated.SetKey(ctx, updatedTimePrivateStateKey, []byte("null"))...)
+}
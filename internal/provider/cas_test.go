@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testPrivateState keeps private state keys in memory.
+type testPrivateState map[string][]byte
+
+func (p testPrivateState) GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func (p testPrivateState) SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics {
+	p[key] = value
+	return nil
+}
+
+// updatedTimeStore reports the same update time for every secret.
+type updatedTimeStore struct {
+	vault.SecretStore
+	updatedTime time.Time
+}
+
+func (s *updatedTimeStore) ReadSecretMetadata(ctx context.Context, secretPath string) (*vault.Secret, error) {
+	return &vault.Secret{Path: secretPath, UpdatedTime: s.updatedTime}, nil
+}
+
+func TestCheckSecretUnchanged(t *testing.T) {
+	ctx := context.Background()
+	refreshed := time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	store := &updatedTimeStore{updatedTime: refreshed}
+
+	private := testPrivateState{}
+	if diags := setUpdatedTimePrivateState(ctx, private, &vault.Secret{UpdatedTime: refreshed}); diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	var diags diag.Diagnostics
+	updated := testPrivateState{}
+	checkSecretUnchanged(ctx, store, "secret/foo", types.BoolValue(true), private, updated, &diags)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+	if string(updated[updatedTimePrivateStateKey]) != "null" {
+		t.Fatalf("Expected the update time to be cleared, got: %s", updated[updatedTimePrivateStateKey])
+	}
+
+	// A cleared update time isn't checked until the next refresh
+	store.updatedTime = refreshed.Add(time.Second)
+	checkSecretUnchanged(ctx, store, "secret/foo", types.BoolValue(true), updated, updated, &diags)
+	checkSecretUnchanged(ctx, store, "secret/foo", types.BoolValue(false), private, updated, &diags)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkSecretUnchanged(ctx, store, "secret/foo", types.BoolValue(true), private, updated, &diags)
+	if diags.ErrorsCount() != 1 || diags.Errors()[0].Summary() != "Secret modified concurrently" {
+		t.Fatalf("Expected a concurrent modification error, got: %v", diags)
+	}
+}
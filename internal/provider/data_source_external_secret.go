@@ -0,0 +1,239 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	DefaultExternalSecretRefreshInterval = "1h"
+	DefaultExternalSecretStoreKind       = "ClusterSecretStore"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &ExternalSecretDataSource{}
+
+// ExternalSecretDataSource renders the External Secrets Operator manifest materializing a Vault secret managed by the
+// provider as a Kubernetes Secret.
+type ExternalSecretDataSource struct {
+	vaultApi *vault.VaultApi
+}
+
+type externalSecretDataSourceModel struct {
+	Path            types.String `tfsdk:"path"`
+	Name            types.String `tfsdk:"name"`
+	Namespace       types.String `tfsdk:"namespace"`
+	SecretStore     types.String `tfsdk:"secret_store"`
+	SecretStoreKind types.String `tfsdk:"secret_store_kind"`
+	RemoteKey       types.String `tfsdk:"remote_key"`
+	RefreshInterval types.String `tfsdk:"refresh_interval"`
+	TemplateType    types.String `tfsdk:"template_type"`
+	YAML            types.String `tfsdk:"yaml"`
+}
+
+// externalSecretManifest holds the values of the rendered ExternalSecret manifest.
+type externalSecretManifest struct {
+	Name            string
+	Namespace       string
+	SecretStore     string
+	SecretStoreKind string
+	RemoteKey       string
+	RefreshInterval string
+	TemplateType    string
+}
+
+func NewExternalSecretDataSource() datasource.DataSource {
+	return &ExternalSecretDataSource{}
+}
+
+func (d *ExternalSecretDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "external_secret")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.vaultApi = data.vaultApi
+}
+
+func (d *ExternalSecretDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_external_secret"
+}
+
+func (d *ExternalSecretDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Required: true,
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret, as set in the `path` attribute of the resource managing it.",
+			},
+			"name": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Name of the ExternalSecret and of the Kubernetes Secret it creates.",
+			},
+			"namespace": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Kubernetes namespace of the ExternalSecret.",
+			},
+			"secret_store": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Name of the SecretStore (or ClusterSecretStore) giving access to Vault.",
+			},
+			"secret_store_kind": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("SecretStore", DefaultExternalSecretStoreKind),
+				},
+				MarkdownDescription: "Kind of the secret store, `SecretStore` or `ClusterSecretStore`. Default is `ClusterSecretStore`.",
+			},
+			"remote_key": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "Key of the secret in the secret store. Defaults to `path` without leading slash, which suits stores without `path` (the mount being the first element of the key).",
+			},
+			"refresh_interval": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Refresh interval of the ExternalSecret. Defaults to the `eso_refresh_interval` custom metadata of the secret, or `1h`.",
+			},
+			"template_type": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "Type of the Kubernetes Secret. Defaults to the `eso_template_type` custom metadata of the secret, or to its `secret_format` custom metadata. Left empty for an `Opaque` Kubernetes Secret.",
+			},
+			"yaml": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The rendered ExternalSecret manifest.",
+			},
+		},
+		MarkdownDescription: "Renders the [External Secrets Operator](https://external-secrets.io) `ExternalSecret` manifest materializing a Vault secret managed by this provider as a Kubernetes Secret. Hints set with the `external_secret` attribute of the resources are read from the secret's custom metadata, the secret data is never read.",
+	}
+}
+
+func (d *ExternalSecretDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data externalSecretDataSourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	secret, err := d.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error reading secret", fmt.Sprintf("Secret %s doesn't exist", secretPath))
+		return
+	}
+
+	manifest := externalSecretManifest{
+		Name:            data.Name.ValueString(),
+		Namespace:       data.Namespace.ValueString(),
+		SecretStore:     data.SecretStore.ValueString(),
+		SecretStoreKind: DefaultExternalSecretStoreKind,
+		RemoteKey:       strings.TrimPrefix(secretPath, "/"),
+		RefreshInterval: DefaultExternalSecretRefreshInterval,
+	}
+	if !data.SecretStoreKind.IsNull() {
+		manifest.SecretStoreKind = data.SecretStoreKind.ValueString()
+	}
+	if !data.RemoteKey.IsNull() {
+		manifest.RemoteKey = data.RemoteKey.ValueString()
+	}
+
+	if v, ok := secret.Metadata[ExternalSecretRefreshIntervalMetadata]; ok {
+		manifest.RefreshInterval = v
+	}
+	if !data.RefreshInterval.IsNull() {
+		manifest.RefreshInterval = data.RefreshInterval.ValueString()
+	}
+
+	if v, ok := secret.Metadata[SecretFormatMetadata]; ok && v != RawSecretFormat {
+		manifest.TemplateType = v
+	}
+	if v, ok := secret.Metadata[ExternalSecretTemplateTypeMetadata]; ok {
+		manifest.TemplateType = v
+	}
+	if !data.TemplateType.IsNull() {
+		manifest.TemplateType = data.TemplateType.ValueString()
+	}
+
+	data.RemoteKey = types.StringValue(manifest.RemoteKey)
+	data.RefreshInterval = types.StringValue(manifest.RefreshInterval)
+	data.TemplateType = types.StringValue(manifest.TemplateType)
+	data.YAML = types.StringValue(manifest.render())
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// render renders the ExternalSecret manifest as YAML. Every key of the Vault secret is extracted in the Kubernetes
+// Secret.
+func (m externalSecretManifest) render() string {
+	var b strings.Builder
+
+	b.WriteString("apiVersion: external-secrets.io/v1beta1\n")
+	b.WriteString("kind: ExternalSecret\n")
+	b.WriteString("metadata:\n")
+	b.WriteString("  name: " + yamlString(m.Name) + "\n")
+	if m.Namespace != "" {
+		b.WriteString("  namespace: " + yamlString(m.Namespace) + "\n")
+	}
+	b.WriteString("spec:\n")
+	b.WriteString("  refreshInterval: " + yamlString(m.RefreshInterval) + "\n")
+	b.WriteString("  secretStoreRef:\n")
+	b.WriteString("    name: " + yamlString(m.SecretStore) + "\n")
+	b.WriteString("    kind: " + m.SecretStoreKind + "\n")
+	b.WriteString("  target:\n")
+	b.WriteString("    name: " + yamlString(m.Name) + "\n")
+	if m.TemplateType != "" {
+		b.WriteString("    template:\n")
+		b.WriteString("      type: " + yamlString(m.TemplateType) + "\n")
+	}
+	b.WriteString("  dataFrom:\n")
+	b.WriteString("    - extract:\n")
+	b.WriteString("        key: " + yamlString(m.RemoteKey) + "\n")
+
+	return b.String()
+}
+
+// yamlString quotes s as a YAML double-quoted scalar, which JSON strings are.
+func yamlString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
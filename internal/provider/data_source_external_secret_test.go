@@ -0,0 +1,37 @@
+package provider
+
+import "testing"
+
+func TestExternalSecretManifestRender(t *testing.T) {
+	manifest := externalSecretManifest{
+		Name:            "db-credentials",
+		Namespace:       "billing",
+		SecretStore:     "vault",
+		SecretStoreKind: DefaultExternalSecretStoreKind,
+		RemoteKey:       "secret/billing/db",
+		RefreshInterval: DefaultExternalSecretRefreshInterval,
+		TemplateType:    BasicAuthSecretFormat,
+	}
+
+	expected := `apiVersion: external-secrets.io/v1beta1
+kind: ExternalSecret
+metadata:
+  name: "db-credentials"
+  namespace: "billing"
+spec:
+  refreshInterval: "1h"
+  secretStoreRef:
+    name: "vault"
+    kind: ClusterSecretStore
+  target:
+    name: "db-credentials"
+    template:
+      type: "kubernetes.io/basic-auth"
+  dataFrom:
+    - extract:
+        key: "secret/billing/db"
+`
+	if rendered := manifest.render(); rendered != expected {
+		t.Fatalf("Wrong manifest:\n%s\nExpected:\n%s", rendered, expected)
+	}
+}
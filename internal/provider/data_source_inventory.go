@@ -0,0 +1,227 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const DefaultInventoryOwnerMetadata = "owner"
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &InventoryDataSource{}
+
+// InventoryDataSource lists the secrets managed by the provider under a path, e.g. as key inventory evidence for
+// compliance audits.
+type InventoryDataSource struct {
+	vaultApi *vault.VaultApi
+}
+
+type inventoryDataSourceModel struct {
+	Prefix        types.String           `tfsdk:"prefix"`
+	OwnerMetadata types.String           `tfsdk:"owner_metadata"`
+	SecretType    types.String           `tfsdk:"secret_type"`
+	Secrets       []inventorySecretModel `tfsdk:"secrets"`
+	JSON          types.String           `tfsdk:"json"`
+}
+
+type inventorySecretModel struct {
+	Path            types.String `tfsdk:"path"`
+	Type            types.String `tfsdk:"type"`
+	Length          types.Int64  `tfsdk:"length"`
+	Owner           types.String `tfsdk:"owner"`
+	CreatedTime     types.String `tfsdk:"created_time"`
+	Generator       types.String `tfsdk:"generator"`
+	ProviderVersion types.String `tfsdk:"provider_version"`
+}
+
+// inventoryEntry describes a managed secret in the JSON export. Unknown values are omitted.
+type inventoryEntry struct {
+	Path            string `json:"path"`
+	Type            string `json:"type"`
+	Length          *int64 `json:"length,omitempty"`
+	Owner           string `json:"owner,omitempty"`
+	CreatedTime     string `json:"created_time"`
+	Generator       string `json:"generator,omitempty"`
+	ProviderVersion string `json:"provider_version,omitempty"`
+}
+
+func NewInventoryDataSource() datasource.DataSource {
+	return &InventoryDataSource{}
+}
+
+func (d *InventoryDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "inventory")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.vaultApi = data.vaultApi
+}
+
+func (d *InventoryDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_inventory"
+}
+
+func (d *InventoryDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"prefix": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path under which secrets are listed, recursively. Must be in a KV v2 mount, e.g. `/secret` or `/secret/billing`.",
+			},
+			"owner_metadata": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Custom metadata holding the owner of the secrets. Default is `owner`.",
+			},
+			"secret_type": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list the secrets of this type (`secret_type` custom metadata), e.g. `random_secret`, for instance to import them with `import` blocks using `for_each`.",
+			},
+			"secrets": schema.ListNestedAttribute{
+				Computed: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Path of the secret.",
+						},
+						"type": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Type of the secret (`secret_type` custom metadata), e.g. `random_secret`.",
+						},
+						"length": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Length of the secret (`secret_length` custom metadata), null when not relevant for the type.",
+						},
+						"owner": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Owner of the secret, read from the `owner_metadata` custom metadata.",
+						},
+						"created_time": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Creation date of the secret (RFC 3339).",
+						},
+						"generator": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Generator used to create the secret, null for secrets created by older provider versions.",
+						},
+						"provider_version": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Version of the provider that created the secret, null for secrets created by older provider versions.",
+						},
+					},
+				},
+				MarkdownDescription: "Secrets managed by the provider (i.e. with a `secret_type` custom metadata), sorted by path.",
+			},
+			"json": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The `secrets` list as a JSON document, e.g. to be exported as compliance evidence.",
+			},
+		},
+		MarkdownDescription: "Lists the secrets managed by the provider under a path with their type, length, owner and creation date, e.g. as key inventory evidence for compliance audits. Only metadata are read, never the secrets themselves.",
+	}
+}
+
+func (d *InventoryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data inventoryDataSourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	prefix := data.Prefix.ValueString()
+	ownerMetadata := DefaultInventoryOwnerMetadata
+	if !data.OwnerMetadata.IsNull() {
+		ownerMetadata = data.OwnerMetadata.ValueString()
+	}
+
+	secrets, err := d.vaultApi.ListSecrets(ctx, prefix)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error listing secrets", fmt.Sprintf("Error while listing secrets under %s", prefix), err)
+		return
+	}
+
+	entries := inventoryEntries(secrets, ownerMetadata, data.SecretType.ValueString())
+
+	export, err := json.Marshal(entries)
+	if err != nil {
+		resp.Diagnostics.AddError("Error exporting inventory", err.Error())
+		return
+	}
+
+	data.Secrets = make([]inventorySecretModel, 0, len(entries))
+	for _, e := range entries {
+		data.Secrets = append(data.Secrets, inventorySecretModel{
+			Path:            types.StringValue(e.Path),
+			Type:            types.StringValue(e.Type),
+			Length:          types.Int64PointerValue(e.Length),
+			Owner:           optionalString(e.Owner),
+			CreatedTime:     types.StringValue(e.CreatedTime),
+			Generator:       optionalString(e.Generator),
+			ProviderVersion: optionalString(e.ProviderVersion),
+		})
+	}
+	data.JSON = types.StringValue(string(export))
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// inventoryEntries describes the secrets managed by the provider, other secrets are ignored. Only secrets of type
+// secretType are described, unless it's empty.
+func inventoryEntries(secrets []vault.Secret, ownerMetadata, secretType string) []inventoryEntry {
+	entries := make([]inventoryEntry, 0, len(secrets))
+	for _, secret := range secrets {
+		actualType, ok := secret.Metadata[SecretTypeMetadata]
+		if !ok || (secretType != "" && actualType != secretType) {
+			continue
+		}
+
+		e := inventoryEntry{
+			Path:            secret.Path,
+			Type:            actualType,
+			Owner:           secret.Metadata[ownerMetadata],
+			CreatedTime:     secret.CreatedTime.UTC().Format(time.RFC3339),
+			Generator:       secret.Metadata[GeneratorMetadata],
+			ProviderVersion: secret.Metadata[ProviderVersionMetadata],
+		}
+		if length, err := strconv.ParseInt(secret.Metadata[SecretLengthMetadata], 10, 64); err == nil {
+			e.Length = &length
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// optionalString returns a null value for empty strings.
+func optionalString(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
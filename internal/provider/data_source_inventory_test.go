@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+)
+
+func TestInventoryEntries(t *testing.T) {
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	secrets := []vault.Secret{
+		{
+			Path: "/secret/billing/key",
+			Metadata: map[string]string{
+				SecretTypeMetadata:      RandomSecretType,
+				SecretLengthMetadata:    "32",
+				"team":                  "billing",
+				GeneratorMetadata:       "random_secret/v1",
+				ProviderVersionMetadata: "1.2.0",
+			},
+			CreatedTime: created,
+		},
+		{
+			Path:        "/secret/billing/manual",
+			Metadata:    map[string]string{"team": "billing"},
+			CreatedTime: created,
+		},
+		{
+			Path:        "/secret/billing/signing",
+			Metadata:    map[string]string{SecretTypeMetadata: PGPKeyType},
+			CreatedTime: created,
+		},
+	}
+
+	export, err := json.Marshal(inventoryEntries(secrets, "team", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"path":"/secret/billing/key","type":"random_secret","length":32,"owner":"billing","created_time":"2024-03-01T10:00:00Z","generator":"random_secret/v1","provider_version":"1.2.0"},` +
+		`{"path":"/secret/billing/signing","type":"pgp_key","created_time":"2024-03-01T10:00:00Z"}]`
+	if string(export) != expected {
+		t.Fatalf("Wrong inventory: %s. Expected: %s", export, expected)
+	}
+}
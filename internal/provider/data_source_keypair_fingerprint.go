@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// pgpKeyIDLength is the length of the hex encoded key ID of OpenPGP v4 keys, the low 64 bits of their fingerprint.
+const pgpKeyIDLength = 16
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &KeypairFingerprintDataSource{}
+
+// KeypairFingerprintDataSource exposes the fingerprint of a keypair managed by the provider, read from the secret's
+// custom metadata only, so that workspaces consuming the public key detect when the producing workspace replaced it.
+type KeypairFingerprintDataSource struct {
+	vaultApi *vault.VaultApi
+}
+
+type keypairFingerprintDataSourceModel struct {
+	Path        types.String `tfsdk:"path"`
+	SecretType  types.String `tfsdk:"secret_type"`
+	Fingerprint types.String `tfsdk:"fingerprint"`
+	KID         types.String `tfsdk:"kid"`
+	CreatedTime types.String `tfsdk:"created_time"`
+}
+
+func NewKeypairFingerprintDataSource() datasource.DataSource {
+	return &KeypairFingerprintDataSource{}
+}
+
+func (d *KeypairFingerprintDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "keypair_fingerprint")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.vaultApi = data.vaultApi
+}
+
+func (d *KeypairFingerprintDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_keypair_fingerprint"
+}
+
+func (d *KeypairFingerprintDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Required: true,
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret holding the keypair, as set in the `path` attribute of the resource managing it, or of a secret its public key is published to (`publish_public_key_to`).",
+			},
+			"secret_type": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Type of the keypair, from the `secret_type` custom metadata: `pgp_key`, or `pgp_public_key` for a published public key.",
+			},
+			"fingerprint": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Fingerprint of the key, from the `pgp_fingerprint` custom metadata.",
+			},
+			"kid": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Key ID: the last 16 hex digits of the fingerprint, as displayed by `gpg`.",
+			},
+			"created_time": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Creation date of the secret (RFC 3339). Keys are replaced rather than rotated in place, so it changes whenever the key does. Published public keys are overwritten by the replacement key instead: use `fingerprint` to detect a new key.",
+			},
+		},
+		MarkdownDescription: "Fingerprint of a keypair managed by this provider in another workspace, e.g. to trigger the updates depending on its public key when it is replaced. Only the secret's custom metadata are read: the token needs the `read` capability on its `metadata/` path, not on the key itself.",
+	}
+}
+
+func (d *KeypairFingerprintDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data keypairFingerprintDataSourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	secret, err := d.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error reading secret", fmt.Sprintf("Secret %s doesn't exist", secretPath))
+		return
+	}
+
+	secretType := secret.Metadata[SecretTypeMetadata]
+	fingerprint, ok := secret.Metadata[PGPFingerprintMetadata]
+	if (secretType != PGPKeyType && secretType != PGPPublicKeyType) || !ok || len(fingerprint) < pgpKeyIDLength {
+		resp.Diagnostics.AddError("Error reading keypair", fmt.Sprintf("Secret %s isn't a keypair managed by the provider (secret_type: %q), only %s and %s secrets with a `%s` custom metadata are supported.", secretPath, secretType, PGPKeyType, PGPPublicKeyType, PGPFingerprintMetadata))
+		return
+	}
+
+	data.SecretType = types.StringValue(secretType)
+	data.Fingerprint = types.StringValue(fingerprint)
+	data.KID = types.StringValue(fingerprint[len(fingerprint)-pgpKeyIDLength:])
+	data.CreatedTime = types.StringValue(secret.CreatedTime.UTC().Format(time.RFC3339))
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
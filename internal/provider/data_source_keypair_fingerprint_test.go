@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccKeypairFingerprintDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "vaultprov_pgp_key" "test" {
+  path          = "/secret/pgp/keypair"
+  name          = "Release Bot"
+  email         = "release@example.com"
+  force_destroy = true
+}
+
+data "vaultprov_keypair_fingerprint" "test" {
+  path = vaultprov_pgp_key.test.path
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.vaultprov_keypair_fingerprint.test", "fingerprint", pgpKeyResourceName, "fingerprint"),
+					resource.TestCheckResourceAttr("data.vaultprov_keypair_fingerprint.test", "secret_type", PGPKeyType),
+					resource.TestMatchResourceAttr("data.vaultprov_keypair_fingerprint.test", "kid", regexp.MustCompile(`^[0-9A-F]{16}$`)),
+					resource.TestCheckResourceAttrSet("data.vaultprov_keypair_fingerprint.test", "created_time"),
+				),
+			},
+		},
+	})
+}
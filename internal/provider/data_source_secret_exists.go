@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &SecretExistsDataSource{}
+
+// SecretExistsDataSource tells if a secret exists, for module logic such as conditional creation or migration gating.
+// Unlike the other reads of the provider, a missing secret isn't an error. Only the secret's metadata are read.
+type SecretExistsDataSource struct {
+	vaultApi *vault.VaultApi
+}
+
+type secretExistsDataSourceModel struct {
+	Path        types.String `tfsdk:"path"`
+	Exists      types.Bool   `tfsdk:"exists"`
+	Deleted     types.Bool   `tfsdk:"deleted"`
+	SecretType  types.String `tfsdk:"secret_type"`
+	Version     types.Int64  `tfsdk:"version"`
+	CreatedTime types.String `tfsdk:"created_time"`
+	UpdatedTime types.String `tfsdk:"updated_time"`
+}
+
+func NewSecretExistsDataSource() datasource.DataSource {
+	return &SecretExistsDataSource{}
+}
+
+func (d *SecretExistsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "secret_exists")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.vaultApi = data.vaultApi
+}
+
+func (d *SecretExistsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret_exists"
+}
+
+func (d *SecretExistsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Required: true,
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret, as the `path` of the secret resources (e.g. `secret/foo/bar`). It must be under a KV v2 mount.",
+			},
+			"exists": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether a secret exists at `path`, with a latest version neither deleted nor destroyed.",
+			},
+			"deleted": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the latest version of the secret at `path` has been deleted or destroyed, its metadata being left in Vault. `exists` is then `false`: creating a secret at `path` requires `on_deleted_version` or `restore_deleted`.",
+			},
+			"secret_type": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Type of the secret, from the `secret_type` custom metadata, e.g. `random_secret`. Null when the secret doesn't exist or isn't managed by the provider.",
+			},
+			"version": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Latest version of the secret, including a deleted one. Null when there's no secret at `path`.",
+			},
+			"created_time": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Creation date of the secret (RFC 3339). Null when the secret doesn't exist.",
+			},
+			"updated_time": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Date of the last write to the secret, data or metadata (RFC 3339). Null when the secret doesn't exist.",
+			},
+		},
+		MarkdownDescription: "Tells if a secret exists, without failing when it doesn't, e.g. to create a secret only when it's missing or to gate a migration. Only the secret's metadata are read: no secret data ends up in the state, and the token needs the `read` capability on the secret's `metadata/` path only. A path outside any KV v2 mount, or denied to the token, is still an error.",
+	}
+}
+
+func (d *SecretExistsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data secretExistsDataSourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	data.Exists = types.BoolValue(false)
+	data.Deleted = types.BoolValue(false)
+	data.SecretType = types.StringNull()
+	data.Version = types.Int64Null()
+	data.CreatedTime = types.StringNull()
+	data.UpdatedTime = types.StringNull()
+
+	secret, err := d.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	var deletedErr *vault.SecretDeletedError
+	switch {
+	case errors.As(err, &deletedErr):
+		data.Deleted = types.BoolValue(true)
+		data.Version = types.Int64Value(int64(deletedErr.Version))
+	case err != nil:
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	case secret != nil:
+		data.Exists = types.BoolValue(true)
+		if secretType, ok := secret.Metadata[SecretTypeMetadata]; ok {
+			data.SecretType = types.StringValue(secretType)
+		}
+		data.Version = types.Int64Value(int64(secret.Version))
+		data.CreatedTime = types.StringValue(secret.CreatedTime.UTC().Format(time.RFC3339))
+		data.UpdatedTime = types.StringValue(secret.UpdatedTime.UTC().Format(time.RFC3339))
+	}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccSecretExistsDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "vaultprov_random_secret" "test" {
+  path          = "/secret/exists/present"
+  force_destroy = true
+}
+
+data "vaultprov_secret_exists" "present" {
+  path = vaultprov_random_secret.test.path
+}
+
+data "vaultprov_secret_exists" "missing" {
+  path = "/secret/exists/missing"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.vaultprov_secret_exists.present", "exists", "true"),
+					resource.TestCheckResourceAttr("data.vaultprov_secret_exists.present", "deleted", "false"),
+					resource.TestCheckResourceAttr("data.vaultprov_secret_exists.present", "secret_type", RandomSecretType),
+					resource.TestCheckResourceAttr("data.vaultprov_secret_exists.present", "version", "1"),
+					resource.TestCheckResourceAttrSet("data.vaultprov_secret_exists.present", "created_time"),
+					resource.TestCheckResourceAttr("data.vaultprov_secret_exists.missing", "exists", "false"),
+					resource.TestCheckResourceAttr("data.vaultprov_secret_exists.missing", "deleted", "false"),
+					resource.TestCheckNoResourceAttr("data.vaultprov_secret_exists.missing", "version"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// deletedVersionPrivateStateKey holds the latest version of the secret found deleted by the last refresh, to be rolled
+// back on update with on_deleted_version = restore
+const deletedVersionPrivateStateKey = "deleted_version"
+
+const (
+	OnDeletedVersionError    = "error"
+	OnDeletedVersionRecreate = "recreate"
+	OnDeletedVersionRestore  = "restore"
+)
+
+func onDeletedVersionAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Optional: true,
+		Computed: true,
+		PlanModifiers: []planmodifier.String{
+			planmodifiers.StringDefaultValue(types.StringValue(OnDeletedVersionError)),
+		},
+		Validators: []validator.String{
+			stringvalidator.OneOf(OnDeletedVersionError, OnDeletedVersionRecreate, OnDeletedVersionRestore),
+		},
+		MarkdownDescription: "What to do when the latest version of the secret has been deleted outside Terraform (e.g. with `vault kv delete`): `error` fails the refresh, `recreate` treats it as drift and generates a new secret, written as a new version, and `restore` plans an update rolling back to the latest version that can still be read, by writing its data as a new version on apply. Default is `error`.",
+	}
+}
+
+// readDeletedVersion handles the error returned when reading a secret whose latest version is deleted, according to
+// onDeletedVersion. It returns nil without error when the resource must be removed from state to be generated again,
+// and err unchanged otherwise. With restore, nothing is written during the refresh: the latest live version is read and
+// returned, and the deleted version is recorded in the private state for the next update to roll it back (see
+// planDeletedVersion and restoreDeletedVersion).
+func readDeletedVersion(ctx context.Context, store vault.SecretStore, onDeletedVersion types.String, private privateState, err error, diags *diag.Diagnostics) (*vault.Secret, error) {
+	var deletedErr *vault.SecretDeletedError
+	vaultApi, ok := store.(*vault.VaultApi)
+	if !ok || !errors.As(err, &deletedErr) {
+		return nil, err
+	}
+
+	switch onDeletedVersion.ValueString() {
+	case OnDeletedVersionRecreate:
+		diags.AddWarning("Secret version deleted", fmt.Sprintf("The latest version %d of secret %s has been deleted outside Terraform, the secret will be generated again (on_deleted_version = %q).", deletedErr.Version, deletedErr.Path, OnDeletedVersionRecreate))
+		return nil, nil
+	case OnDeletedVersionRestore:
+		if deletedErr.LiveVersion == 0 {
+			return nil, err
+		}
+		secret, err := vaultApi.ReadLiveVersion(ctx, deletedErr.Path)
+		if err != nil {
+			return nil, err
+		}
+		diags.Append(private.SetKey(ctx, deletedVersionPrivateStateKey, []byte(strconv.Itoa(deletedErr.Version)))...)
+		diags.AddWarning("Secret version deleted", fmt.Sprintf("The latest version %d of secret %s has been deleted outside Terraform, version %d will be restored as a new version on the next apply (on_deleted_version = %q).", deletedErr.Version, deletedErr.Path, deletedErr.LiveVersion, OnDeletedVersionRestore))
+		return secret, nil
+	}
+	return nil, err
+}
+
+// clearDeletedVersion forgets the deleted version recorded by a previous refresh, the secret having been restored
+// outside Terraform since.
+func clearDeletedVersion(ctx context.Context, private privateState) diag.Diagnostics {
+	recorded, diags := isDeletedVersionRecorded(ctx, private)
+	if diags.HasError() || !recorded {
+		return diags
+	}
+	return private.SetKey(ctx, deletedVersionPrivateStateKey, []byte("null"))
+}
+
+// isDeletedVersionRecorded tells if the last refresh found the latest version of the secret deleted, see
+// readDeletedVersion.
+func isDeletedVersionRecorded(ctx context.Context, private privateState) (bool, diag.Diagnostics) {
+	value, diags := private.GetKey(ctx, deletedVersionPrivateStateKey)
+	return len(value) > 0 && string(value) != "null", diags
+}
+
+// planDeletedVersion plans an update of the secret when the refresh found its latest version deleted, with
+// on_deleted_version = restore: `version` is marked unknown, the live version being written again on update.
+func planDeletedVersion(ctx context.Context, private privateState, onDeletedVersion types.String, resp *resource.ModifyPlanResponse) {
+	if onDeletedVersion.ValueString() != OnDeletedVersionRestore {
+		return
+	}
+	recorded, diags := isDeletedVersionRecorded(ctx, private)
+	resp.Diagnostics.Append(diags...)
+	if !recorded {
+		return
+	}
+	resp.Plan.SetAttribute(ctx, path.Root("version"), types.Int64Unknown())
+}
+
+// restoreDeletedVersion rolls back a secret whose latest version was found deleted by the last refresh, see
+// readDeletedVersion. Nothing is written when the version has been restored outside Terraform since. The update time
+// recorded in updated is moved to the rollback's, so that cas_version checks made afterwards against updated don't take
+// it for a concurrent write.
+func restoreDeletedVersion(ctx context.Context, store vault.SecretStore, secretPath string, onDeletedVersion types.String, private, updated privateState, diags *diag.Diagnostics) {
+	recorded, d := isDeletedVersionRecorded(ctx, private)
+	diags.Append(d...)
+	if diags.HasError() || !recorded {
+		return
+	}
+	vaultApi, ok := store.(*vault.VaultApi)
+	if !ok || onDeletedVersion.ValueString() != OnDeletedVersionRestore {
+		diags.Append(updated.SetKey(ctx, deletedVersionPrivateStateKey, []byte("null"))...)
+		return
+	}
+
+	_, err := vaultApi.ReadSecretMetadata(ctx, secretPath)
+	var deletedErr *vault.SecretDeletedError
+	if errors.As(err, &deletedErr) {
+		var secret *vault.Secret
+		secret, err = vaultApi.RollbackSecret(ctx, secretPath)
+		if err == nil {
+			diags.Append(setUpdatedTimePrivateState(ctx, updated, secret)...)
+			diags.AddWarning("Secret version restored", fmt.Sprintf("The latest version %d of secret %s had been deleted outside Terraform, version %d has been restored as version %d (on_deleted_version = %q).", deletedErr.Version, secretPath, deletedErr.LiveVersion, secret.Version, OnDeletedVersionRestore))
+		}
+	}
+	if err != nil {
+		addVaultError(diags, "Error restoring secret", fmt.Sprintf("Error while restoring the deleted version of secret %s", secretPath), err)
+		return
+	}
+
+	diags.Append(updated.SetKey(ctx, deletedVersionPrivateStateKey, []byte("null"))...)
+}
+
+// createSecret creates the secret. When onDeletedVersion is recreate and the latest version of the secret at the same
+// path has been deleted, the secret is written as a new version instead of failing because the path is taken.
+func createSecret(ctx context.Context, store vault.SecretStore, secret vault.Secret, onDeletedVersion types.String) (int, error) {
+	version, err := store.CreateSecret(ctx, secret)
+
+	var existsErr *vault.SecretExistsError
+	vaultApi, ok := store.(*vault.VaultApi)
+	if ok && errors.As(err, &existsErr) && existsErr.Deleted && onDeletedVersion.ValueString() == OnDeletedVersionRecreate {
+		return vaultApi.ReplaceDeletedSecret(ctx, secret)
+	}
+	return version, err
+}
@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestReadDeletedVersion(t *testing.T) {
+	ctx := context.Background()
+	store := &vault.VaultApi{}
+	deletedErr := fmt.Errorf("wrapped: %w", &vault.SecretDeletedError{Path: "/secret/foo", Version: 3, LiveVersion: 2})
+
+	var diags diag.Diagnostics
+	secret, err := readDeletedVersion(ctx, store, types.StringValue(OnDeletedVersionError), testPrivateState{}, deletedErr, &diags)
+	if secret != nil || !errors.Is(err, deletedErr) {
+		t.Fatalf("Expected the error to be kept, got: %v, %v", secret, err)
+	}
+
+	secret, err = readDeletedVersion(ctx, store, types.StringValue(OnDeletedVersionRecreate), testPrivateState{}, deletedErr, &diags)
+	if secret != nil || err != nil {
+		t.Fatalf("Expected the resource to be removed, got: %v, %v", secret, err)
+	}
+	if diags.WarningsCount() != 1 {
+		t.Fatalf("Expected a warning, got: %v", diags)
+	}
+
+	otherErr := errors.New("permission denied")
+	if _, err = readDeletedVersion(ctx, store, types.StringValue(OnDeletedVersionRecreate), testPrivateState{}, otherErr, &diags); err != otherErr {
+		t.Fatalf("Expected other errors to be kept, got: %v", err)
+	}
+
+	noLiveVersionErr := &vault.SecretDeletedError{Path: "/secret/foo", Version: 3}
+	if _, err = readDeletedVersion(ctx, store, types.StringValue(OnDeletedVersionRestore), testPrivateState{}, noLiveVersionErr, &diags); err != noLiveVersionErr {
+		t.Fatalf("Expected an error without version to restore, got: %v", err)
+	}
+}
+
+// deletedVersionServer serves a secret whose version 3 is deleted, version 2 being live, until a new version is
+// written. Writes are counted.
+func deletedVersionServer(t *testing.T, writes *int) *httptest.Server {
+	current := 3
+	versions := `{"1":{},"2":{},"3":{"deletion_time":"2024-01-03T10:00:00Z"}}`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/sys/internal/ui/mounts/secret/foo":
+			_, _ = w.Write([]byte(`{"data":{"path":"secret/","options":{"version":"2"}}}`))
+		case r.URL.Path == "/v1/sys/capabilities-self":
+			_, _ = w.Write([]byte(`{"data":{"capabilities":["root"]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/metadata/foo":
+			_, _ = fmt.Fprintf(w, `{"data":{"current_version":%d,"custom_metadata":{"secret_type":"random_secret"},"versions":%s}}`, current, versions)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/foo" && r.URL.Query().Get("version") == "2":
+			_, _ = w.Write([]byte(`{"data":{"data":{"key":"live"},"metadata":{"version":2}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/foo":
+			_, _ = fmt.Fprintf(w, `{"data":{"data":{"key":"live"},"metadata":{"version":%d}}}`, current)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/data/foo":
+			*writes++
+			var body struct {
+				Options map[string]int `json:"options"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Options["cas"] != 3 {
+				t.Errorf("Wrong check-and-set version: %d. Expected: 3", body.Options["cas"])
+			}
+			current = 4
+			versions = `{"1":{},"2":{},"3":{"deletion_time":"2024-01-03T10:00:00Z"},"4":{}}`
+			_, _ = w.Write([]byte(`{"data":{"version":4}}`))
+		case r.URL.Path == "/v1/secret/metadata/foo":
+			*writes++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestRestoreDeletedVersion(t *testing.T) {
+	ctx := context.Background()
+	var writes int
+	server := deletedVersionServer(t, &writes)
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	store := vault.NewVaultApi(client)
+	onDeletedVersion := types.StringValue(OnDeletedVersionRestore)
+
+	// The refresh only reads the live version, and records the deleted one
+	_, err = store.ReadSecretMetadata(ctx, "secret/foo")
+	private := testPrivateState{}
+	var diags diag.Diagnostics
+	secret, err := readDeletedVersion(ctx, store, onDeletedVersion, private, err, &diags)
+	if err != nil || secret == nil || secret.Data["key"] != "live" {
+		t.Fatalf("Expected the live version, got: %v, %v", secret, err)
+	}
+	if writes != 0 {
+		t.Fatalf("Expected no write during the refresh, got %d", writes)
+	}
+	if string(private[deletedVersionPrivateStateKey]) != "3" {
+		t.Fatalf("Wrong deleted version recorded: %s. Expected: 3", private[deletedVersionPrivateStateKey])
+	}
+
+	// The update rolls it back
+	updated := testPrivateState{}
+	restoreDeletedVersion(ctx, store, "secret/foo", onDeletedVersion, private, updated, &diags)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+	if writes == 0 {
+		t.Fatalf("Expected the live version to be written again")
+	}
+	if string(updated[deletedVersionPrivateStateKey]) != "null" {
+		t.Fatalf("Expected the deleted version to be cleared, got: %s", updated[deletedVersionPrivateStateKey])
+	}
+
+	// Nothing left to restore
+	writes = 0
+	restoreDeletedVersion(ctx, store, "secret/foo", onDeletedVersion, updated, updated, &diags)
+	if diags.HasError() || writes != 0 {
+		t.Fatalf("Expected nothing to be restored, got %d writes: %v", writes, diags)
+	}
+}
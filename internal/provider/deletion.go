@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const DeletionProtectionMetadata = "deletion_protection"
+
+// deletionProtectionMetadata sets the deletion protection flag in metadata when enabled, or adds it to the removed
+// metadata keys otherwise.
+func deletionProtectionMetadata(metadata map[string]string, removed []string, enabled types.Bool) []string {
+	if enabled.ValueBool() {
+		metadata[DeletionProtectionMetadata] = "true"
+		return removed
+	}
+	return append(removed, DeletionProtectionMetadata)
+}
+
+// checkDeletionProtection reports an error when deletion protection is enabled, either in state or directly in Vault.
+// The flag must be removed (and applied) before the secret can be deleted, whatever the value of force_destroy.
+func checkDeletionProtection(ctx context.Context, store vault.SecretStore, secretPath string, enabled types.Bool, diags *diag.Diagnostics) {
+	protected := enabled.ValueBool()
+
+	if !protected {
+		secret, err := store.ReadSecretMetadata(ctx, secretPath)
+		if err != nil {
+			addVaultError(diags, "Error deleting secret", fmt.Sprintf("Error while checking deletion protection of secret %s", secretPath), err)
+			return
+		}
+		protected = secret != nil && secret.Metadata[DeletionProtectionMetadata] == "true"
+	}
+
+	if protected {
+		diags.AddError("Error deleting secret", "Can't delete resource for Vault secret '"+secretPath+"': deletion protection is enabled. Set 'deletion_protection' to 'false' and apply before deleting it")
+	}
+}
+
+// scheduleSecretDeletion asks Vault to delete the secret once the destroy_after grace period is over, instead of
+// deleting it right away.
+func scheduleSecretDeletion(ctx context.Context, vaultApi *vault.VaultApi, secretPath string, destroyAfter types.String, diags *diag.Diagnostics) {
+	after, err := time.ParseDuration(destroyAfter.ValueString())
+	if err != nil {
+		diags.AddError("Error deleting secret", fmt.Sprintf("Invalid destroy_after %q: %s", destroyAfter.ValueString(), err.Error()))
+		return
+	}
+
+	scheduledAt, err := vaultApi.ScheduleSecretDeletion(ctx, secretPath, after)
+	if err != nil {
+		addVaultError(diags, "Error deleting secret", fmt.Sprintf("Error while scheduling deletion of secret %s", secretPath), err)
+		return
+	}
+
+	diags.AddWarning(
+		"Secret deletion scheduled",
+		fmt.Sprintf("Vault secret %s will be deleted at %s (custom metadata `%s`). Until then, it can be recovered by resetting its `delete_version_after` metadata and importing it again.", secretPath, scheduledAt.UTC().Format(time.RFC3339), vault.ScheduledDestroyMetadata),
+	)
+}
+
+// deleteManagedVersions only deletes the versions of the secret written by the provider, leaving the versions written
+// by other systems (e.g. before the secret was imported) and the secret's metadata intact. With delete_metadata, the
+// metadata are deleted as well once no version of the secret is left alive.
+func deleteManagedVersions(ctx context.Context, vaultApi *vault.VaultApi, secretPath string, deleteMetadata types.Bool, diags *diag.Diagnostics) {
+	deleted, err := vaultApi.DeleteManagedVersions(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error deleting secret", fmt.Sprintf("Error while deleting versions of secret %s", secretPath), err)
+		return
+	}
+
+	if deleteMetadata.ValueBool() {
+		deleteTombstone(ctx, vaultApi, secretPath, diags)
+		return
+	}
+
+	if len(deleted) == 0 {
+		diags.AddWarning(
+			"Secret left intact",
+			fmt.Sprintf("No active version of Vault secret %s has been written by the provider (custom metadata `%s`), nothing has been deleted.", secretPath, vault.ManagedVersionsMetadata),
+		)
+	}
+}
+
+// deleteTombstone deletes the metadata of a secret whose versions are all deleted, so that no tombstoned path is left in
+// the KV tree.
+func deleteTombstone(ctx context.Context, vaultApi *vault.VaultApi, secretPath string, diags *diag.Diagnostics) {
+	deleted, err := vaultApi.DeleteTombstonedSecret(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error deleting secret", fmt.Sprintf("Error while deleting metadata of secret %s", secretPath), err)
+		return
+	}
+
+	if !deleted {
+		diags.AddWarning(
+			"Secret metadata kept",
+			fmt.Sprintf("Vault secret %s still has versions written by other systems, its metadata have not been deleted.", secretPath),
+		)
+	}
+}
+
+// checkDeleteMetadata rejects delete_metadata along with destroy_after: Vault only deletes the versions of the secret
+// once the grace period is over, its metadata are left behind.
+func checkDeleteMetadata(diags *diag.Diagnostics, deleteMetadata, deleteAllVersions types.Bool, destroyAfter types.String) {
+	if !deleteMetadata.ValueBool() || destroyAfter.IsNull() || !deleteAllVersions.ValueBool() {
+		return
+	}
+	diags.AddAttributeError(
+		path.Root("delete_metadata"),
+		"Invalid attribute combination",
+		"delete_metadata can't be used with destroy_after: Vault deletes the versions of the secret once the grace period is over, but never its metadata.",
+	)
+}
+
+// secretDestroy describes what destroying a resource does to its Vault secrets, from the resource's state.
+type secretDestroy struct {
+	paths              []string
+	forceDestroy       types.Bool
+	deletionProtection types.Bool
+	deleteAllVersions  types.Bool
+	deleteMetadata     types.Bool
+	destroyAfter       types.String
+	versionsKept       types.Int64
+}
+
+// warnDestroy reports, at plan time, a warning summarizing which Vault paths and versions destroying the resource
+// deletes, so that destructive changes stand out when reviewing plans.
+func warnDestroy(diags *diag.Diagnostics, destroy secretDestroy) {
+	diags.AddWarning("Vault secret deletion planned", destroySummary(destroy))
+}
+
+// destroySummary describes the effect of destroying a resource, following the logic of the resources' Delete.
+func destroySummary(destroy secretDestroy) string {
+	secrets := "Vault secret " + strings.Join(destroy.paths, ", ")
+	if len(destroy.paths) > 1 {
+		secrets = "Vault secrets " + strings.Join(destroy.paths, ", ")
+	}
+
+	if !destroy.forceDestroy.ValueBool() {
+		return fmt.Sprintf("Destroying the resource of %s will fail: force_destroy must be set to true and applied first.", secrets)
+	}
+	if destroy.deletionProtection.ValueBool() {
+		return fmt.Sprintf("Destroying the resource of %s will fail: deletion protection is enabled, deletion_protection must be set to false and applied first.", secrets)
+	}
+
+	var count, outOf string
+	if !destroy.versionsKept.IsNull() && !destroy.versionsKept.IsUnknown() {
+		count = fmt.Sprintf(" (%d)", destroy.versionsKept.ValueInt64())
+		outOf = fmt.Sprintf(", out of the %d versions kept by Vault", destroy.versionsKept.ValueInt64())
+	}
+	if !destroy.deleteAllVersions.ValueBool() {
+		summary := fmt.Sprintf("The versions of %s written by the provider will be deleted%s. They can be undeleted, and the versions written by other systems are left intact.", secrets, outOf)
+		if destroy.deleteMetadata.ValueBool() {
+			summary += " With delete_metadata, the metadata and every version are permanently destroyed if no version is left alive."
+		}
+		return summary
+	}
+	if !destroy.destroyAfter.IsNull() {
+		return fmt.Sprintf("All the versions%s of %s will be deleted (recoverable with undelete) after %s, the destroy_after grace period.", count, secrets, destroy.destroyAfter.ValueString())
+	}
+	return fmt.Sprintf("All the versions%s of %s will be permanently destroyed, along with the metadata.", count, secrets)
+}
+
+func deleteMetadataAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		Optional: true,
+		Computed: true,
+		PlanModifiers: []planmodifier.Bool{
+			planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+		},
+		MarkdownDescription: "If set to `true` with `delete_all_versions = false`, the secret's metadata (and every version, deleted ones included) are also deleted on destroy once no version of the secret is left alive, so that no tombstoned path remains in the KV tree. The metadata are kept, with a warning, when versions written by other systems are still alive. With `delete_all_versions = true`, the metadata are always deleted. Can't be used with `destroy_after`. Default is `false`.",
+	}
+}
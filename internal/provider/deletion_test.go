@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDeletionProtectionMetadata(t *testing.T) {
+	metadata := map[string]string{}
+	removed := deletionProtectionMetadata(metadata, nil, types.BoolValue(true))
+	if metadata[DeletionProtectionMetadata] != "true" || len(removed) != 0 {
+		t.Fatalf("Expected deletion protection to be set, got metadata %v and removed keys %v", metadata, removed)
+	}
+
+	metadata = map[string]string{}
+	removed = deletionProtectionMetadata(metadata, []string{"owner"}, types.BoolValue(false))
+	if _, ok := metadata[DeletionProtectionMetadata]; ok || len(removed) != 2 || removed[1] != DeletionProtectionMetadata {
+		t.Fatalf("Expected deletion protection to be removed, got metadata %v and removed keys %v", metadata, removed)
+	}
+}
+
+func TestCheckDeleteMetadata(t *testing.T) {
+	var diags diag.Diagnostics
+	checkDeleteMetadata(&diags, types.BoolValue(true), types.BoolValue(false), types.StringValue("72h"))
+	checkDeleteMetadata(&diags, types.BoolValue(true), types.BoolValue(true), types.StringNull())
+	checkDeleteMetadata(&diags, types.BoolValue(false), types.BoolValue(true), types.StringValue("72h"))
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkDeleteMetadata(&diags, types.BoolValue(true), types.BoolValue(true), types.StringValue("72h"))
+	if diags.ErrorsCount() != 1 {
+		t.Fatalf("Expected an error for delete_metadata with destroy_after, got: %v", diags)
+	}
+}
+
+func TestDestroySummary(t *testing.T) {
+	tests := []struct {
+		destroy  secretDestroy
+		expected string
+	}{
+		{
+			secretDestroy{paths: []string{"secret/foo"}, forceDestroy: types.BoolValue(false)},
+			"Destroying the resource of Vault secret secret/foo will fail: force_destroy must be set to true and applied first.",
+		},
+		{
+			secretDestroy{paths: []string{"secret/foo"}, forceDestroy: types.BoolValue(true), deletionProtection: types.BoolValue(true)},
+			"Destroying the resource of Vault secret secret/foo will fail: deletion protection is enabled, deletion_protection must be set to false and applied first.",
+		},
+		{
+			secretDestroy{paths: []string{"secret/foo"}, forceDestroy: types.BoolValue(true), deleteAllVersions: types.BoolValue(true), destroyAfter: types.StringNull(), versionsKept: types.Int64Value(3)},
+			"All the versions (3) of Vault secret secret/foo will be permanently destroyed, along with the metadata.",
+		},
+		{
+			secretDestroy{paths: []string{"secret/foo"}, forceDestroy: types.BoolValue(true), deleteAllVersions: types.BoolValue(true), destroyAfter: types.StringValue("72h"), versionsKept: types.Int64Null()},
+			"All the versions of Vault secret secret/foo will be deleted (recoverable with undelete) after 72h, the destroy_after grace period.",
+		},
+		{
+			secretDestroy{paths: []string{"secret/foo"}, forceDestroy: types.BoolValue(true), deleteAllVersions: types.BoolValue(true), destroyAfter: types.StringValue("24h"), versionsKept: types.Int64Value(2)},
+			"All the versions (2) of Vault secret secret/foo will be deleted (recoverable with undelete) after 24h, the destroy_after grace period.",
+		},
+		{
+			secretDestroy{paths: []string{"secret/foo"}, forceDestroy: types.BoolValue(true), deleteAllVersions: types.BoolValue(false), versionsKept: types.Int64Value(5)},
+			"The versions of Vault secret secret/foo written by the provider will be deleted, out of the 5 versions kept by Vault. They can be undeleted, and the versions written by other systems are left intact.",
+		},
+		{
+			secretDestroy{paths: []string{"secret/foo"}, forceDestroy: types.BoolValue(true), deleteAllVersions: types.BoolValue(false), deleteMetadata: types.BoolValue(true), versionsKept: types.Int64Null()},
+			"The versions of Vault secret secret/foo written by the provider will be deleted. They can be undeleted, and the versions written by other systems are left intact. With delete_metadata, the metadata and every version are permanently destroyed if no version is left alive.",
+		},
+		{
+			secretDestroy{paths: []string{"secret/foo/share-1", "secret/foo/share-2"}, forceDestroy: types.BoolValue(true), deleteAllVersions: types.BoolValue(true), destroyAfter: types.StringNull(), versionsKept: types.Int64Null()},
+			"All the versions of Vault secrets secret/foo/share-1, secret/foo/share-2 will be permanently destroyed, along with the metadata.",
+		},
+	}
+	for _, test := range tests {
+		if summary := destroySummary(test.destroy); summary != test.expected {
+			t.Fatalf("Wrong summary: %s. Expected: %s", summary, test.expected)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// addVaultError adds an error diagnostic for an error returned by VaultApi. When the error comes with a hint (errors
+// returned by Vault itself, existing secrets), the detail is completed with it.
+func addVaultError(diags *diag.Diagnostics, summary, detail string, err error) {
+	detail = fmt.Sprintf("%s: %s", detail, err.Error())
+
+	var hinted interface{ Hint() string }
+	if errors.As(err, &hinted) {
+		if hint := hinted.Hint(); hint != "" {
+			detail += "\n\n" + hint
+		}
+	}
+
+	diags.AddError(summary, detail)
+}
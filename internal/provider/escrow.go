@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func escrowPublicKeyAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Optional: true,
+		Validators: []validator.String{
+			validators.EscrowPublicKey(),
+		},
+		MarkdownDescription: "Offline public key the secret is escrowed to, for break-glass recovery: an age recipient (`age1...`), an SSH public key (`ssh-rsa ...`, `ssh-ed25519 ...`) or a PEM encoded RSA public key. The encrypted secret is exposed in `escrow_ciphertext`.",
+	}
+}
+
+func escrowCiphertextAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Computed: true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.UseStateForUnknown(),
+		},
+		MarkdownDescription: "ASCII armored age file holding the secret data, as JSON, encrypted to `escrow_public_key`. It can be written to a file (e.g. with a `local_file` resource) and decrypted offline with `age --decrypt`.",
+	}
+}
+
+// planEscrow marks `escrow_ciphertext` unknown when the escrow public key changes, so that the secret is escrowed again
+// on update.
+func planEscrow(ctx context.Context, state, plan types.String, resp *resource.ModifyPlanResponse) {
+	if state.Equal(plan) {
+		return
+	}
+	if plan.IsNull() {
+		resp.Plan.SetAttribute(ctx, path.Root("escrow_ciphertext"), types.StringNull())
+		return
+	}
+	resp.Plan.SetAttribute(ctx, path.Root("escrow_ciphertext"), types.StringUnknown())
+}
+
+// escrowSecret returns the value of the `escrow_ciphertext` attribute: the secret data encrypted to publicKey, or null
+// when no escrow public key is set.
+func escrowSecret(publicKey types.String, data map[string]interface{}, diags *diag.Diagnostics) types.String {
+	if publicKey.IsNull() {
+		return types.StringNull()
+	}
+
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		diags.AddError("Error escrowing secret", fmt.Sprintf("Couldn't encode secret data: %s", err.Error()))
+		return types.StringNull()
+	}
+	defer secrets.Wipe(plaintext)
+
+	ciphertext, err := secrets.Escrow(publicKey.ValueString(), plaintext)
+	if err != nil {
+		diags.AddError("Error escrowing secret", fmt.Sprintf("Couldn't encrypt secret to the escrow public key: %s", err.Error()))
+		return types.StringNull()
+	}
+	return types.StringValue(ciphertext)
+}
+
+// refreshEscrow reads the secret again from Vault and escrows its data.
+func refreshEscrow(ctx context.Context, read func(context.Context, string) (*vault.Secret, error), secretPath string, publicKey types.String, diags *diag.Diagnostics) types.String {
+	if publicKey.IsNull() {
+		return types.StringNull()
+	}
+
+	secret, err := read(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error escrowing secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return types.StringUnknown()
+	}
+	if secret == nil {
+		diags.AddError("Error escrowing secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return types.StringUnknown()
+	}
+	return escrowSecret(publicKey, secret.Data, diags)
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEscrowSecret(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]interface{}{"secret": "c2VjcmV0"}
+
+	var diags diag.Diagnostics
+	if v := escrowSecret(types.StringNull(), data, &diags); !v.IsNull() || diags.HasError() {
+		t.Fatalf("Expected no escrow without public key, got %s: %v", v, diags)
+	}
+
+	v := escrowSecret(types.StringValue(identity.Recipient().String()), data, &diags)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(v.ValueString())), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decrypted map[string]interface{}
+	if err = json.Unmarshal(plaintext, &decrypted); err != nil {
+		t.Fatal(err)
+	}
+	if decrypted["secret"] != "c2VjcmV0" {
+		t.Fatalf("Wrong escrowed data: %s", plaintext)
+	}
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	ExternalSecretRefreshIntervalMetadata = "eso_refresh_interval"
+	ExternalSecretTemplateTypeMetadata    = "eso_template_type"
+)
+
+// externalSecretModel holds hints for the External Secrets Operator. They are stored as custom metadata of the secret
+// and picked up by the vaultprov_external_secret data source when rendering the ExternalSecret manifest.
+type externalSecretModel struct {
+	RefreshInterval types.String `tfsdk:"refresh_interval"`
+	TemplateType    types.String `tfsdk:"template_type"`
+}
+
+func externalSecretAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional: true,
+		Attributes: map[string]schema.Attribute{
+			"refresh_interval": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Interval (e.g. `1h`) at which the External Secrets Operator should refresh the Kubernetes Secret. Stored as a custom metadata under the key `eso_refresh_interval`.",
+			},
+			"template_type": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				MarkdownDescription: "Type of the Kubernetes Secret rendered by the External Secrets Operator, e.g. `kubernetes.io/basic-auth`. Stored as a custom metadata under the key `eso_template_type`.",
+			},
+		},
+		MarkdownDescription: "Hints for the [External Secrets Operator](https://external-secrets.io), stored as custom metadata of the secret and used by the `vaultprov_external_secret` data source.",
+	}
+}
+
+func isExternalSecretMetadata(key string) bool {
+	return key == ExternalSecretRefreshIntervalMetadata || key == ExternalSecretTemplateTypeMetadata
+}
+
+// externalSecretMetadata sets the configured hints in metadata and adds the hints that are not configured to removed.
+func externalSecretMetadata(metadata map[string]string, removed []string, e *externalSecretModel) []string {
+	refreshInterval, templateType := types.StringNull(), types.StringNull()
+	if e != nil {
+		refreshInterval, templateType = e.RefreshInterval, e.TemplateType
+	}
+
+	for _, hint := range []struct {
+		key   string
+		value types.String
+	}{
+		{ExternalSecretRefreshIntervalMetadata, refreshInterval},
+		{ExternalSecretTemplateTypeMetadata, templateType},
+	} {
+		if hint.value.IsNull() {
+			removed = append(removed, hint.key)
+		} else {
+			metadata[hint.key] = hint.value.ValueString()
+		}
+	}
+	return removed
+}
+
+// externalSecretValue builds the `external_secret` attribute from the custom metadata read in Vault. It is kept null
+// when no hint is found and it was null in state.
+func externalSecretValue(prior *externalSecretModel, metadata map[string]string) *externalSecretModel {
+	refreshInterval, hasRefreshInterval := metadata[ExternalSecretRefreshIntervalMetadata]
+	templateType, hasTemplateType := metadata[ExternalSecretTemplateTypeMetadata]
+	if prior == nil && !hasRefreshInterval && !hasTemplateType {
+		return nil
+	}
+
+	e := &externalSecretModel{
+		RefreshInterval: types.StringNull(),
+		TemplateType:    types.StringNull(),
+	}
+	if hasRefreshInterval {
+		e.RefreshInterval = types.StringValue(refreshInterval)
+	}
+	if hasTemplateType {
+		e.TemplateType = types.StringValue(templateType)
+	}
+	return e
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestExternalSecretMetadata(t *testing.T) {
+	metadata := map[string]string{"owner": "my_team"}
+	removed := externalSecretMetadata(metadata, []string{"foo"}, &externalSecretModel{
+		RefreshInterval: types.StringValue("15m"),
+		TemplateType:    types.StringNull(),
+	})
+
+	if metadata[ExternalSecretRefreshIntervalMetadata] != "15m" {
+		t.Fatalf("Wrong metadata: %v", metadata)
+	}
+	if _, ok := metadata[ExternalSecretTemplateTypeMetadata]; ok {
+		t.Fatalf("Unexpected template type in metadata: %v", metadata)
+	}
+	if !reflect.DeepEqual(removed, []string{"foo", ExternalSecretTemplateTypeMetadata}) {
+		t.Fatalf("Wrong removed keys: %v", removed)
+	}
+
+	removed = externalSecretMetadata(map[string]string{}, nil, nil)
+	if !reflect.DeepEqual(removed, []string{ExternalSecretRefreshIntervalMetadata, ExternalSecretTemplateTypeMetadata}) {
+		t.Fatalf("Wrong removed keys without hints: %v", removed)
+	}
+}
+
+func TestExternalSecretValue(t *testing.T) {
+	if e := externalSecretValue(nil, map[string]string{"owner": "my_team"}); e != nil {
+		t.Fatalf("Expected null value, got %v", e)
+	}
+
+	e := externalSecretValue(nil, map[string]string{ExternalSecretTemplateTypeMetadata: "kubernetes.io/basic-auth"})
+	if e == nil || !e.RefreshInterval.IsNull() || e.TemplateType.ValueString() != "kubernetes.io/basic-auth" {
+		t.Fatalf("Wrong value read from metadata: %v", e)
+	}
+
+	prior := &externalSecretModel{RefreshInterval: types.StringNull(), TemplateType: types.StringNull()}
+	if e := externalSecretValue(prior, map[string]string{}); e == nil {
+		t.Fatalf("Expected empty value to be kept")
+	}
+}
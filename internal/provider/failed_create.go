@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+// failedCreatePrivateStateKey records the create id stamped on the secret written by a creation that failed, in the
+// partial state of the resource, see keepFailedCreate.
+const failedCreatePrivateStateKey = "failed_create_id"
+
+// newCreateID generates the unique id stamped on the secret written by the creation of a resource, see
+// vault.Secret.CreateID.
+func newCreateID(diags *diag.Diagnostics) string {
+	createID, err := uuid.GenerateUUID()
+	if err != nil {
+		diags.AddError("Error creating secret", fmt.Sprintf("Couldn't generate create id: %s", err.Error()))
+	}
+	return createID
+}
+
+// isCreatedBy tells if secret has been written by the creation stamped with createID, and not since.
+func isCreatedBy(secret *vault.Secret, createID string) bool {
+	return secret != nil && createID != "" && secret.Metadata[vault.CreateIDMetadata] == createID && secret.Version == 1
+}
+
+// keepFailedCreate saves a partial state when the creation of a resource failed after writing its secret, stamped with
+// createID. Terraform marks the resource as tainted and replaces it on the next apply: the secret written by the
+// failed creation is deleted first (see deleteFailedCreate), instead of the new creation failing because the secret
+// already exists. Nothing is saved when the secret found isn't ours, e.g. it existed before.
+func keepFailedCreate(ctx context.Context, store vault.SecretStore, secretPath secretPathValue, createID string, resp *resource.CreateResponse) {
+	if !resp.Diagnostics.HasError() || !resp.State.Raw.IsNull() {
+		return
+	}
+
+	secret, err := store.ReadSecretMetadata(ctx, secretPath.ValueString())
+	if err != nil || !isCreatedBy(secret, createID) {
+		return
+	}
+
+	value, _ := json.Marshal(createID)
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, failedCreatePrivateStateKey, value)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), secretPath)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), secretPathID(secretPath))...)
+}
+
+// failedCreateID returns the create id recorded by keepFailedCreate, empty when the state isn't the partial state of a
+// failed creation.
+func failedCreateID(ctx context.Context, private privateState, diags *diag.Diagnostics) string {
+	value, d := private.GetKey(ctx, failedCreatePrivateStateKey)
+	diags.Append(d...)
+	var createID string
+	if len(value) > 0 {
+		_ = json.Unmarshal(value, &createID)
+	}
+	return createID
+}
+
+// deleteFailedCreate deletes the secret written by a failed creation, whatever force_destroy, since it has never been
+// handed to anyone. It returns false when the state isn't the partial state of a failed creation, to be deleted as
+// usual. The secret is kept when it has been written since the creation.
+func deleteFailedCreate(ctx context.Context, store vault.SecretStore, secretPath string, private privateState, diags *diag.Diagnostics) bool {
+	createID := failedCreateID(ctx, private, diags)
+	if createID == "" || diags.HasError() {
+		return createID != ""
+	}
+
+	secret, err := store.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error deleting secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return true
+	}
+	if secret == nil {
+		return true
+	}
+	if !isCreatedBy(secret, createID) {
+		diags.AddError("Error deleting secret", fmt.Sprintf("Secret %s, written by a creation that failed, has been modified since: it isn't deleted. Remove the resource from the state (`terraform state rm`), then import or delete the secret.", secretPath))
+		return true
+	}
+
+	if err = store.DeleteSecret(ctx, secretPath); err != nil {
+		addVaultError(diags, "Error deleting secret", fmt.Sprintf("Couldn't delete secret %s written by a creation that failed", secretPath), err)
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestCreateExistingSecret(t *testing.T) {
+	kv := newFakeKV(t)
+	r := newTestResource(t, map[string]*fakeKV{"default": kv}, "vaultprov_random_secret")
+	config := r.config(map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "secret/foo"),
+	})
+	r.apply(config)
+	value := kv.value("foo")
+
+	// Another resource with the same configuration, e.g. in another workspace
+	other := newTestResource(t, map[string]*fakeKV{"default": kv}, "vaultprov_random_secret")
+	diags := other.tryApply(config)
+	if !hasDiagnosticDetail(diags, "already exists") {
+		t.Fatalf("Expected the creation to fail because the secret already exists, got %v", diags)
+	}
+	if !other.state.IsNull() {
+		t.Fatalf("Resource created despite the failure: %v", other.state)
+	}
+	if v := kv.value("foo"); v != value {
+		t.Fatalf("Secret overwritten: %q. Expected: %q", v, value)
+	}
+}
+
+func TestFailedCreate(t *testing.T) {
+	kv := newFakeKV(t)
+	r := newTestResource(t, map[string]*fakeKV{"default": kv}, "vaultprov_random_secret")
+	config := r.config(map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "secret/foo"),
+	})
+
+	// The secret is written, but not its metadata
+	kv.failMetadataWrites = true
+	if diags := r.tryApply(config); !hasErrorDiagnostic(diags) {
+		t.Fatal("Expected an error writing the metadata")
+	}
+	kv.failMetadataWrites = false
+	if r.state.IsNull() || r.attribute("path").Equal(tftypes.NewValue(tftypes.String, nil)) {
+		t.Fatalf("No partial state kept for the secret written: %v", r.state)
+	}
+	value := kv.value("foo")
+	if value == "" {
+		t.Fatal("Secret not written")
+	}
+	r.read()
+
+	// Terraform replaces the tainted resource: the secret written by the failed creation is deleted first
+	r.apply(tftypes.NewValue(r.typ, nil))
+	if kv.exists("foo") {
+		t.Fatal("Secret written by the failed creation not deleted")
+	}
+	r.apply(config)
+	if v := kv.value("foo"); v == "" || v == value {
+		t.Fatalf("Secret not created again: %q", v)
+	}
+}
+
+func TestFailedCreateModified(t *testing.T) {
+	kv := newFakeKV(t)
+	r := newTestResource(t, map[string]*fakeKV{"default": kv}, "vaultprov_random_secret")
+
+	kv.failMetadataWrites = true
+	if diags := r.tryApply(r.config(map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "secret/foo"),
+	})); !hasErrorDiagnostic(diags) {
+		t.Fatal("Expected an error writing the metadata")
+	}
+	kv.failMetadataWrites = false
+
+	// Written since by someone else
+	kv.mu.Lock()
+	kv.secrets["foo"].versions = append(kv.secrets["foo"].versions, map[string]interface{}{SecretDataKey: "other"})
+	kv.mu.Unlock()
+
+	if diags := r.tryApply(tftypes.NewValue(r.typ, nil)); !hasDiagnosticDetail(diags, "has been modified since") {
+		t.Fatalf("Expected the deletion to fail, got %v", diags)
+	}
+	if v := kv.value("foo"); v != "other" {
+		t.Fatalf("Secret deleted: %q", v)
+	}
+}
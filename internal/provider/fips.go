@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Reasons why attributes are restricted in FIPS mode, see fips_mode.
+const (
+	fipsEscrowReason    = "escrowed secrets are encrypted with age, i.e. X25519 and ChaCha20-Poly1305, which aren't FIPS approved"
+	fipsHashReason      = "bcrypt and argon2id aren't FIPS approved password hashing algorithms"
+	fipsAlgorithmReason = "ed25519 keys (Curve25519) aren't allowed, use RSA keys"
+)
+
+// checkFIPSMode reports an error, in FIPS mode, when attribute is set to another value than the allowed ones (none when
+// the attribute must not be set at all). Unknown values are checked once known, at apply time.
+func checkFIPSMode(diags *diag.Diagnostics, fipsMode bool, attribute string, value types.String, reason string, allowed ...string) {
+	if !fipsMode || value.IsNull() || value.IsUnknown() {
+		return
+	}
+	for _, v := range allowed {
+		if value.ValueString() == v {
+			return
+		}
+	}
+	diags.AddAttributeError(path.Root(attribute), "Not allowed in FIPS mode", fmt.Sprintf("The provider is in FIPS mode (fips_mode): %s.", reason))
+}
+
+// checkFIPSCrypto checks, in FIPS mode, that secrets are generated by a FIPS validated module.
+func checkFIPSCrypto(diags *diag.Diagnostics) {
+	if secrets.RNG() != secrets.RNGSource {
+		diags.AddAttributeError(path.Root("fips_mode"), "Not allowed in FIPS mode", fmt.Sprintf("Secrets are generated by the %s random generator (%s is set), not crypto/rand.", secrets.RNG(), TestRNGSeedEnvVar))
+		return
+	}
+	if !secrets.FIPSCrypto() {
+		diags.AddAttributeWarning(path.Root("fips_mode"), "Provider not built with a FIPS validated module", "Only FIPS approved algorithms are allowed, but the provider's cryptography isn't provided by a FIPS validated module. Build the provider with GOEXPERIMENT=boringcrypto (make release-fips) to use BoringCrypto.")
+	}
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCheckFIPSMode(t *testing.T) {
+	tests := []struct {
+		fipsMode bool
+		value    types.String
+		allowed  []string
+		valid    bool
+	}{
+		{false, types.StringValue(secrets.PGPAlgorithmEd25519), []string{secrets.PGPAlgorithmRSA}, true},
+		{true, types.StringValue(secrets.PGPAlgorithmEd25519), []string{secrets.PGPAlgorithmRSA}, false},
+		{true, types.StringValue(secrets.PGPAlgorithmRSA), []string{secrets.PGPAlgorithmRSA}, true},
+		{true, types.StringValue("age1..."), nil, false},
+		{true, types.StringNull(), nil, true},
+		{true, types.StringUnknown(), nil, true},
+	}
+	for _, test := range tests {
+		var diags diag.Diagnostics
+		checkFIPSMode(&diags, test.fipsMode, "algorithm", test.value, fipsAlgorithmReason, test.allowed...)
+		if diags.HasError() == test.valid {
+			t.Fatalf("Wrong validation of %s in FIPS mode %t: %v. Expected valid: %t", test.value, test.fipsMode, diags, test.valid)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	SecretFormatMetadata  = "secret_format"
+	RawSecretFormat       = "raw"
+	BasicAuthSecretFormat = "kubernetes.io/basic-auth"
+	BasicAuthUsernameKey  = "username"
+	BasicAuthPasswordKey  = "password"
+	// TemplateDataKey is the key of the document rendered from the template of a random secret
+	TemplateDataKey = "rendered"
+)
+
+// Types of the values of random secrets, i.e. how the random key is generated and encoded
+const (
+	SecretValueTypeMetadata = "secret_value_type"
+	BytesValueType          = "bytes"
+	HexValueType            = "hex"
+	UUIDValueType           = "uuid"
+	AlphanumericValueType   = "alphanumeric"
+	uuidLength              = 16
+)
+
+// checkSecretFormat checks that a username is provided with (and only with) the basic-auth format.
+func checkSecretFormat(diags *diag.Diagnostics, format types.String, username types.String) {
+	if format.IsUnknown() || username.IsUnknown() {
+		return
+	}
+	if format.ValueString() == BasicAuthSecretFormat && username.IsNull() {
+		diags.AddAttributeError(path.Root("username"), "Missing username", fmt.Sprintf("Attribute username is required with the %s format.", BasicAuthSecretFormat))
+	}
+	if format.ValueString() != BasicAuthSecretFormat && !username.IsNull() {
+		diags.AddAttributeError(path.Root("username"), "Unexpected username", fmt.Sprintf("Attribute username is only allowed with the %s format.", BasicAuthSecretFormat))
+	}
+}
+
+// checkSecretTemplate checks that a template is a JSON document whose placeholders are fields of the secret data laid
+// out by format.
+func checkSecretTemplate(diags *diag.Diagnostics, template types.String, format types.String) {
+	if template.IsNull() || template.IsUnknown() || format.IsUnknown() {
+		return
+	}
+
+	placeholders, err := secrets.TemplatePlaceholders(template.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("template"), "Invalid template", err.Error())
+		return
+	}
+
+	fields := secretDataFields(format.ValueString())
+	for _, placeholder := range placeholders {
+		if !slices.Contains(fields, placeholder) {
+			diags.AddAttributeError(path.Root("template"), "Invalid template", fmt.Sprintf("Unknown placeholder {{ %s }}, secrets of the %s format hold: %s.", placeholder, format.ValueString(), strings.Join(fields, ", ")))
+		}
+	}
+}
+
+// secretDataFields returns the fields of the data of random secrets laid out by format, which templates can refer to.
+func secretDataFields(format string) []string {
+	if format == BasicAuthSecretFormat {
+		return []string{BasicAuthUsernameKey, BasicAuthPasswordKey}
+	}
+	return []string{SecretDataKey}
+}
+
+// renderSecretTemplate adds the document rendered from template with the other fields of data to data, if a template
+// is set.
+func renderSecretTemplate(template types.String, data map[string]interface{}) error {
+	if template.IsNull() {
+		delete(data, TemplateDataKey)
+		return nil
+	}
+
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok && k != TemplateDataKey {
+			values[k] = s
+		}
+	}
+	rendered, err := secrets.RenderTemplate(template.ValueString(), values)
+	if err != nil {
+		return err
+	}
+	data[TemplateDataKey] = rendered
+	return nil
+}
+
+// checkSecretValueType checks that length is not configured for UUIDs, which have a fixed length.
+func checkSecretValueType(diags *diag.Diagnostics, valueType types.String, configLength types.Int64) {
+	if valueType.ValueString() == UUIDValueType && !configLength.IsNull() {
+		diags.AddAttributeError(path.Root("length"), "Unexpected length", fmt.Sprintf("Attribute length isn't allowed with the %s type: UUIDs are always made of %d bytes.", UUIDValueType, uuidLength))
+	}
+}
+
+// randomSecretMetadata sets the custom metadata describing how a random secret is generated and laid out.
+func randomSecretMetadata(metadata map[string]string, format, valueType string, length types.Int64) {
+	// The length of UUIDs is fixed, the length attribute is ignored
+	if valueType != UUIDValueType {
+		metadata[SecretLengthMetadata] = length.String()
+	}
+	if format != RawSecretFormat {
+		metadata[SecretFormatMetadata] = format
+	}
+	if valueType != BytesValueType {
+		metadata[SecretValueTypeMetadata] = valueType
+	}
+}
+
+// generateRandomValue generates the random key of a secret of the given type. Bytes and hex secrets are made of length
+// random bytes, alphanumeric ones of length base62 characters and UUIDs of 16 bytes.
+func generateRandomValue(valueType string, length int) ([]byte, error) {
+	switch valueType {
+	case UUIDValueType:
+		return secrets.GenerateUUID()
+	case AlphanumericValueType:
+		return secrets.GenerateAlphanumeric(length)
+	}
+	return secrets.GenerateRandomSecret(length)
+}
+
+// encodeRandomValue returns the value of a random key as stored in Vault.
+func encodeRandomValue(format, valueType string, key []byte) string {
+	switch valueType {
+	case HexValueType:
+		return hex.EncodeToString(key)
+	case UUIDValueType:
+		return secrets.FormatUUID(key)
+	case AlphanumericValueType:
+		return string(key)
+	}
+	if format == BasicAuthSecretFormat {
+		return base64.RawURLEncoding.EncodeToString(key)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// decodeRandomValue returns the random key of a value stored in Vault, see encodeRandomValue.
+func decodeRandomValue(format, valueType string, value string) ([]byte, error) {
+	switch valueType {
+	case HexValueType:
+		return hex.DecodeString(value)
+	case UUIDValueType:
+		key, err := hex.DecodeString(strings.ReplaceAll(value, "-", ""))
+		if err == nil && len(key) != uuidLength {
+			err = fmt.Errorf("invalid UUID length %d", len(key))
+		}
+		return key, err
+	case AlphanumericValueType:
+		return []byte(value), nil
+	}
+	if format == BasicAuthSecretFormat {
+		return base64.RawURLEncoding.DecodeString(value)
+	}
+	return base64.StdEncoding.DecodeString(value)
+}
+
+// randomSecretData lays out the random secret key in the secret data according to format.
+func randomSecretData(format, valueType, username string, key []byte) map[string]interface{} {
+	if format == BasicAuthSecretFormat {
+		return map[string]interface{}{
+			BasicAuthUsernameKey: username,
+			BasicAuthPasswordKey: encodeRandomValue(format, valueType, key),
+		}
+	}
+	return map[string]interface{}{
+		SecretDataKey: encodeRandomValue(format, valueType, key),
+	}
+}
+
+// randomSecretPassword returns the random secret held by data as stored in Vault, i.e. encoded, which is the value
+// consumers use as password.
+func randomSecretPassword(format string, data map[string]interface{}) (string, error) {
+	field := SecretDataKey
+	if format == BasicAuthSecretFormat {
+		field = BasicAuthPasswordKey
+	}
+
+	password, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("no %s field", field)
+	}
+	return password, nil
+}
+
+// randomSecretFingerprint computes the fingerprint of the random secret held by data.
+func randomSecretFingerprint(format, valueType string, data map[string]interface{}) (string, error) {
+	encoded, err := randomSecretPassword(format, data)
+	if err != nil {
+		return "", err
+	}
+	key, err := decodeRandomValue(format, valueType, encoded)
+	if err != nil {
+		return "", err
+	}
+	defer secrets.Wipe(key)
+	return secrets.Fingerprint(key), nil
+}
+
+// randomSecretHash computes the salted hash of the random secret held by data, or null when no algorithm is set.
+func randomSecretHash(algorithm types.String, format string, data map[string]interface{}) (types.String, error) {
+	if algorithm.IsNull() {
+		return types.StringNull(), nil
+	}
+
+	password, err := randomSecretPassword(format, data)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	hash, err := secrets.HashPassword(algorithm.ValueString(), password)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	return types.StringValue(hash), nil
+}
@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRandomSecretData(t *testing.T) {
+	key := []byte{0xfb, 0xff, 0x00, 0x42}
+
+	for _, format := range []string{RawSecretFormat, BasicAuthSecretFormat} {
+		data := randomSecretData(format, BytesValueType, "app", key)
+
+		fingerprint, err := randomSecretFingerprint(format, BytesValueType, data)
+		if err != nil {
+			t.Fatalf("Error while computing fingerprint for %s format: %s", format, err)
+		}
+		if fingerprint != secrets.Fingerprint(key) {
+			t.Fatalf("Wrong fingerprint for %s format: %s. Expected: %s", format, fingerprint, secrets.Fingerprint(key))
+		}
+	}
+
+	data := randomSecretData(BasicAuthSecretFormat, BytesValueType, "app", key)
+	if data[BasicAuthUsernameKey] != "app" || data[BasicAuthPasswordKey] != "-_8AQg" {
+		t.Fatalf("Wrong basic-auth data: %v", data)
+	}
+}
+
+func TestRandomValueTypes(t *testing.T) {
+	tests := []struct {
+		valueType string
+		pattern   string
+	}{
+		{BytesValueType, `^[A-Za-z0-9+/]{43}=$`},
+		{HexValueType, `^[0-9a-f]{64}$`},
+		{UUIDValueType, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{AlphanumericValueType, `^[A-Za-z0-9]{32}$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.valueType, func(t *testing.T) {
+			key, err := generateRandomValue(tt.valueType, 32)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := randomSecretData(RawSecretFormat, tt.valueType, "", key)
+			if !regexp.MustCompile(tt.pattern).MatchString(data[SecretDataKey].(string)) {
+				t.Fatalf("Wrong %s value: %s", tt.valueType, data[SecretDataKey])
+			}
+
+			fingerprint, err := randomSecretFingerprint(RawSecretFormat, tt.valueType, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fingerprint != secrets.Fingerprint(key) {
+				t.Fatalf("Wrong fingerprint: %s. Expected: %s", fingerprint, secrets.Fingerprint(key))
+			}
+		})
+	}
+}
+
+func TestRandomSecretMetadata(t *testing.T) {
+	metadata := make(map[string]string)
+	randomSecretMetadata(metadata, RawSecretFormat, BytesValueType, types.Int64Value(32))
+	if len(metadata) != 1 || metadata[SecretLengthMetadata] != "32" {
+		t.Fatalf("Wrong bytes metadata: %v", metadata)
+	}
+
+	metadata = make(map[string]string)
+	randomSecretMetadata(metadata, BasicAuthSecretFormat, UUIDValueType, types.Int64Value(32))
+	if _, ok := metadata[SecretLengthMetadata]; ok || metadata[SecretValueTypeMetadata] != UUIDValueType || metadata[SecretFormatMetadata] != BasicAuthSecretFormat {
+		t.Fatalf("Wrong uuid metadata: %v", metadata)
+	}
+}
+
+func TestCheckSecretFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    types.String
+		username  types.String
+		wantError bool
+	}{
+		{"raw", types.StringValue(RawSecretFormat), types.StringNull(), false},
+		{"raw with username", types.StringValue(RawSecretFormat), types.StringValue("app"), true},
+		{"basic-auth", types.StringValue(BasicAuthSecretFormat), types.StringValue("app"), false},
+		{"basic-auth without username", types.StringValue(BasicAuthSecretFormat), types.StringNull(), true},
+		{"unknown username", types.StringValue(BasicAuthSecretFormat), types.StringUnknown(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			checkSecretFormat(&diags, tt.format, tt.username)
+			if diags.HasError() != tt.wantError {
+				t.Fatalf("Wrong result for format %s and username %s: %v", tt.format, tt.username, diags)
+			}
+		})
+	}
+}
+
+func TestRandomSecretHash(t *testing.T) {
+	data := randomSecretData(BasicAuthSecretFormat, BytesValueType, "app", []byte{0xfb, 0xff, 0x00, 0x42})
+
+	hash, err := randomSecretHash(types.StringNull(), BasicAuthSecretFormat, data)
+	if err != nil || !hash.IsNull() {
+		t.Fatalf("Expected no hash without algorithm, got %s (%v)", hash, err)
+	}
+
+	hash, err = randomSecretHash(types.StringValue(secrets.HashBcrypt), BasicAuthSecretFormat, data)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(hash.ValueString()), []byte("-_8AQg")); err != nil {
+		t.Fatalf("Hash %s doesn't match password: %s", hash, err)
+	}
+}
+
+func TestCheckSecretValueType(t *testing.T) {
+	var diags diag.Diagnostics
+	checkSecretValueType(&diags, types.StringValue(UUIDValueType), types.Int64Null())
+	checkSecretValueType(&diags, types.StringValue(HexValueType), types.Int64Value(16))
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkSecretValueType(&diags, types.StringValue(UUIDValueType), types.Int64Value(16))
+	if !diags.HasError() {
+		t.Fatal("Expected an error for a UUID with a length")
+	}
+}
+
+func TestCheckSecretTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  types.String
+		format    types.String
+		wantError bool
+	}{
+		{"no template", types.StringNull(), types.StringValue(RawSecretFormat), false},
+		{"raw", types.StringValue(`{"dsn": "postgres://app:{{ secret }}@db/app"}`), types.StringValue(RawSecretFormat), false},
+		{"basic-auth", types.StringValue(`{"dsn": "postgres://{{ username }}:{{ password }}@db/app"}`), types.StringValue(BasicAuthSecretFormat), false},
+		{"unknown placeholder", types.StringValue(`{"dsn": "{{ password }}"}`), types.StringValue(RawSecretFormat), true},
+		{"invalid JSON", types.StringValue(`{"dsn": `), types.StringValue(RawSecretFormat), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			checkSecretTemplate(&diags, tt.template, tt.format)
+			if diags.HasError() != tt.wantError {
+				t.Fatalf("Wrong result for template %s and format %s: %v", tt.template, tt.format, diags)
+			}
+		})
+	}
+}
+
+func TestRenderSecretTemplate(t *testing.T) {
+	data := randomSecretData(BasicAuthSecretFormat, BytesValueType, "app", []byte{0xfb, 0xff, 0x00, 0x42})
+
+	if err := renderSecretTemplate(types.StringValue(`{"dsn": "postgres://{{ username }}:{{ password }}@db/app"}`), data); err != nil {
+		t.Fatal("error:", err)
+	}
+	if data[TemplateDataKey] != `{"dsn":"postgres://app:-_8AQg@db/app"}` {
+		t.Fatalf("Wrong rendered template: %v", data[TemplateDataKey])
+	}
+
+	// The rendered document isn't a placeholder of templates
+	if err := renderSecretTemplate(types.StringValue(`{"doc": "{{ rendered }}"}`), data); err == nil {
+		t.Fatalf("Expected an error for a placeholder without value")
+	}
+
+	if err := renderSecretTemplate(types.StringNull(), data); err != nil {
+		t.Fatal("error:", err)
+	}
+	if _, ok := data[TemplateDataKey]; ok {
+		t.Fatalf("Expected the rendered template to be removed without template")
+	}
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+const (
+	GeneratorMetadata       = "generator"
+	GeneratorRNGMetadata    = "generator_rng"
+	ProviderVersionMetadata = "provider_version"
+
+	generationPrivateStateKey = "generation"
+)
+
+// generationParams records how a secret was generated. It is stored both in the resource private state and in the
+// secret's custom metadata, so that secrets generated by a faulty generator or provider version can be found and
+// replaced.
+type generationParams struct {
+	Generator       string `json:"generator"`
+	RNG             string `json:"rng"`
+	ProviderVersion string `json:"provider_version"`
+}
+
+// privateState is implemented by the private state of resource requests and responses.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+func isGenerationMetadata(key string) bool {
+	return key == GeneratorMetadata || key == GeneratorRNGMetadata || key == ProviderVersionMetadata
+}
+
+// addMetadata stores the generation parameters in the secret's custom metadata.
+func (g generationParams) addMetadata(metadata map[string]string) {
+	metadata[GeneratorMetadata] = g.Generator
+	metadata[GeneratorRNGMetadata] = g.RNG
+	metadata[ProviderVersionMetadata] = g.ProviderVersion
+}
+
+// generationParamsFromMetadata reads the generation parameters from the secret's custom metadata. ok is false for
+// secrets generated before generation parameters were recorded.
+func generationParamsFromMetadata(metadata map[string]string) (g generationParams, ok bool) {
+	g.Generator, ok = metadata[GeneratorMetadata]
+	g.RNG = metadata[GeneratorRNGMetadata]
+	g.ProviderVersion = metadata[ProviderVersionMetadata]
+	return g, ok
+}
+
+func setGenerationPrivateState(ctx context.Context, private privateState, g generationParams) diag.Diagnostics {
+	value, err := json.Marshal(g)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Error storing generation parameters", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, generationPrivateStateKey, value)
+}
+
+// syncGenerationPrivateState copies the generation parameters found in metadata into the private state when they are
+// missing from it, e.g. after an import.
+func syncGenerationPrivateState(ctx context.Context, private privateState, metadata map[string]string) diag.Diagnostics {
+	value, diags := private.GetKey(ctx, generationPrivateStateKey)
+	if diags.HasError() || value != nil {
+		return diags
+	}
+
+	g, ok := generationParamsFromMetadata(metadata)
+	if !ok {
+		return diags
+	}
+
+	diags.Append(setGenerationPrivateState(ctx, private, g)...)
+	return diags
+}
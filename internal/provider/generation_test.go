@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestGenerationParamsMetadata(t *testing.T) {
+	generation := generationParams{
+		Generator:       "random_secret/v1",
+		RNG:             "crypto/rand",
+		ProviderVersion: "1.2.3",
+	}
+
+	metadata := map[string]string{"owner": "my_team"}
+	generation.addMetadata(metadata)
+
+	for k := range metadata {
+		if k != "owner" && !isGenerationMetadata(k) {
+			t.Fatalf("Unexpected metadata key %s", k)
+		}
+	}
+
+	read, ok := generationParamsFromMetadata(metadata)
+	if !ok {
+		t.Fatalf("Expected generation parameters to be found in metadata")
+	}
+	if read != generation {
+		t.Fatalf("Wrong generation parameters: %+v. Expected: %+v", read, generation)
+	}
+
+	if _, ok = generationParamsFromMetadata(map[string]string{"owner": "my_team"}); ok {
+		t.Fatalf("Expected no generation parameters in metadata")
+	}
+}
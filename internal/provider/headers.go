@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func extraHeadersAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		ElementType:         types.StringType,
+		Optional:            true,
+		MarkdownDescription: "HTTP headers sent on the Vault requests of this resource only, on top of the provider's `headers` (a header set in both takes the value set here), e.g. to route requests through Vault performance standbys or to tag them per team at the load balancer.",
+	}
+}
+
+// vaultApiWithHeaders returns the Vault API to use for one operation of a resource: a clone of vaultApi sending the
+// resource's extra_headers, or vaultApi itself when there are none.
+func vaultApiWithHeaders(vaultApi *vault.VaultApi, extraHeaders types.Map, diags *diag.Diagnostics) *vault.VaultApi {
+	if vaultApi == nil || len(extraHeaders.Elements()) == 0 {
+		return vaultApi
+	}
+
+	headers := make(map[string]string, len(extraHeaders.Elements()))
+	for k, v := range extraHeaders.Elements() {
+		headers[k] = v.(types.String).ValueString()
+	}
+
+	clone, err := vaultApi.WithHeaders(headers)
+	if err != nil {
+		diags.AddError("Error configuring Vault client", fmt.Sprintf("Can't clone Vault client with extra headers: %s", err.Error()))
+		return vaultApi
+	}
+	return clone
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+const (
+	// HistoryMetadata holds the lifecycle events of a secret recorded with lifecycle_history, oldest first
+	HistoryMetadata = "history"
+
+	HistoryCreated         = "created"
+	HistoryMetadataUpdated = "metadata-updated"
+	HistoryRotated         = "rotated"
+	HistoryImported        = "imported"
+	HistoryRestored        = "restored"
+	HistoryAdopted         = "adopted"
+
+	// historySeparator separates the events of HistoryMetadata
+	historySeparator = "; "
+)
+
+// isHistoryMetadata tells if key is the custom metadata recording the lifecycle events of a secret, see
+// lifecycle_history.
+func isHistoryMetadata(key string) bool {
+	return key == HistoryMetadata
+}
+
+// appendHistory appends the event, timestamped with now, to the events of history. The oldest events are dropped so
+// that the result fits in a custom metadata value.
+func appendHistory(history, event string, now time.Time) string {
+	entry := now.UTC().Format(time.RFC3339) + " " + event
+	if history == "" {
+		return entry
+	}
+
+	events := append(strings.Split(history, historySeparator), entry)
+	for len(events) > 1 && len(strings.Join(events, historySeparator)) > validators.MaxCustomMetadataValueLength {
+		events = events[1:]
+	}
+	return strings.Join(events, historySeparator)
+}
+
+// addHistoryMetadata records event in the custom metadata of a new secret, when lifecycle_history is enabled.
+func addHistoryMetadata(metadata map[string]string, enabled bool, event string) {
+	if enabled {
+		metadata[HistoryMetadata] = appendHistory("", event, time.Now())
+	}
+}
+
+// historyUpdate records event in metadata, the custom metadata about to be written on the secret at secretPath, on top
+// of the events already recorded on the secret. Nothing is read when lifecycle_history is disabled.
+func historyUpdate(ctx context.Context, store vault.SecretStore, enabled bool, secretPath string, metadata map[string]string, event string, diags *diag.Diagnostics) {
+	if !enabled {
+		return
+	}
+
+	secret, err := store.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error recording secret history", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+	var history string
+	if secret != nil {
+		history = secret.Metadata[HistoryMetadata]
+	}
+	metadata[HistoryMetadata] = appendHistory(history, event, time.Now())
+}
+
+// recordHistory records event on the secret at secretPath, when lifecycle_history is enabled.
+func recordHistory(ctx context.Context, store vault.SecretStore, enabled bool, secretPath, event string, diags *diag.Diagnostics) {
+	if !enabled {
+		return
+	}
+
+	metadata := make(map[string]string, 1)
+	historyUpdate(ctx, store, enabled, secretPath, metadata, event, diags)
+	if diags.HasError() {
+		return
+	}
+	if err := store.UpdateSecretMetadata(ctx, secretPath, metadata, nil); err != nil {
+		addVaultError(diags, "Error recording secret history", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+	}
+}
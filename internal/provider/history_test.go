@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+)
+
+func TestAppendHistory(t *testing.T) {
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	history := appendHistory("", HistoryCreated, created)
+	if history != "2024-03-01T10:00:00Z created" {
+		t.Fatalf("Wrong history: %s. Expected: 2024-03-01T10:00:00Z created", history)
+	}
+
+	// Dates are recorded in UTC
+	rotated := time.Date(2024, 6, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	history = appendHistory(history, HistoryRotated, rotated)
+	expected := "2024-03-01T10:00:00Z created; 2024-06-01T10:00:00Z rotated"
+	if history != expected {
+		t.Fatalf("Wrong history: %s. Expected: %s", history, expected)
+	}
+
+	// The oldest events are dropped to fit in a custom metadata value
+	for i := 0; i < 50; i++ {
+		history = appendHistory(history, HistoryMetadataUpdated, rotated)
+	}
+	if len(history) > validators.MaxCustomMetadataValueLength {
+		t.Fatalf("Wrong history length: %d. Expected at most %d", len(history), validators.MaxCustomMetadataValueLength)
+	}
+	if strings.Contains(history, HistoryCreated) || !strings.HasSuffix(history, "2024-06-01T10:00:00Z metadata-updated") {
+		t.Fatalf("Wrong history: %s", history)
+	}
+}
+
+func TestAddHistoryMetadata(t *testing.T) {
+	metadata := map[string]string{SecretTypeMetadata: RandomSecretType}
+	addHistoryMetadata(metadata, false, HistoryCreated)
+	if len(metadata) != 1 {
+		t.Fatalf("Unexpected history when disabled: %v", metadata)
+	}
+
+	addHistoryMetadata(metadata, true, HistoryCreated)
+	if !strings.HasSuffix(metadata[HistoryMetadata], " "+HistoryCreated) {
+		t.Fatalf("Wrong history: %v", metadata)
+	}
+}
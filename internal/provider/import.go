@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+// BulkImportIDPrefix marks import IDs meant to import every secret under a path, e.g. `prefix:/secret/services/*`
+const BulkImportIDPrefix = "prefix:"
+
+// checkImportID reports an error for bulk import IDs: Terraform imports a single resource per ID, so a prefix can't be
+// expanded by the provider. The secrets are listed with the inventory data source instead.
+func checkImportID(id, typeName string, diags *diag.Diagnostics) {
+	if !strings.HasPrefix(id, BulkImportIDPrefix) {
+		return
+	}
+
+	prefix := strings.TrimSuffix(strings.TrimPrefix(id, BulkImportIDPrefix), "*")
+	diags.AddError("Invalid import ID", fmt.Sprintf(`Terraform imports a single resource per ID, %q can't be expanded by the provider. List the secrets with the %[2]s_inventory data source and import them with an import block (Terraform 1.7 or later):
+
+data "%[2]s_inventory" "bulk" {
+  prefix      = %[3]q
+  secret_type = "%[4]s"
+}
+
+import {
+  for_each = { for s in data.%[2]s_inventory.bulk.secrets : s.path => s }
+  to       = %[2]s_%[4]s.imported[each.key]
+  id       = each.key
+}`, id, providerName, strings.TrimSuffix(prefix, "/"), typeName))
+}
+
+// importSecret imports the secret at the path given as import ID, checking that it is a secret of the given type. A
+// secret without secret_type custom metadata (not written by the provider) is accepted, unless in strict mode. The
+// import is recorded in the secret's history when lifecycle_history is enabled.
+func importSecret(ctx context.Context, store vault.SecretStore, secretType string, history bool, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
+	checkImportID(request.ID, secretType, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	secret, err := store.ReadSecretMetadata(ctx, request.ID)
+	if err != nil {
+		addVaultError(&response.Diagnostics, "Error importing secret", fmt.Sprintf("Error while reading secret %s", request.ID), err)
+		return
+	}
+	checkImportedSecretType(request.ID, secret, secretType, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	recordHistory(ctx, store, history, request.ID, HistoryImported, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	response.Diagnostics.Append(response.State.SetAttribute(ctx, path.Root("path"), request.ID)...)
+}
+
+// checkImportedSecretType reports an error when there's no secret to import, or when it is a secret of another type.
+func checkImportedSecretType(secretPath string, secret *vault.Secret, secretType string, diags *diag.Diagnostics) {
+	if secret == nil {
+		diags.AddError("Error importing secret", fmt.Sprintf("No secret at %s", secretPath))
+		return
+	}
+	if actual, ok := secret.Metadata[SecretTypeMetadata]; ok && actual != secretType {
+		diags.AddError("Error importing secret", fmt.Sprintf("Secret %s is a %s (custom metadata `%s`), it can't be imported as a %s.", secretPath, actual, SecretTypeMetadata, secretType))
+	}
+}
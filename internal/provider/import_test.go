@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestCheckImportID(t *testing.T) {
+	var diags diag.Diagnostics
+	checkImportID("/secret/services/billing", RandomSecretType, &diags)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkImportID("prefix:/secret/services/*", RandomSecretType, &diags)
+	if diags.ErrorsCount() != 1 {
+		t.Fatalf("Expected an error for a bulk import ID, got: %v", diags)
+	}
+}
+
+func TestCheckImportedSecretType(t *testing.T) {
+	var diags diag.Diagnostics
+	checkImportedSecretType("/secret/foo", &vault.Secret{Metadata: map[string]string{SecretTypeMetadata: RandomSecretType}}, RandomSecretType, &diags)
+	checkImportedSecretType("/secret/foo", &vault.Secret{Metadata: map[string]string{}}, RandomSecretType, &diags)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkImportedSecretType("/secret/foo", &vault.Secret{Metadata: map[string]string{SecretTypeMetadata: PGPKeyType}}, RandomSecretType, &diags)
+	checkImportedSecretType("/secret/foo", nil, RandomSecretType, &diags)
+	if diags.ErrorsCount() != 2 {
+		t.Fatalf("Expected errors for a %s and a missing secret, got: %v", PGPKeyType, diags)
+	}
+}
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// MaxLargeSecretLength is the upper bound of the `length` of random secrets with `large_secret` (10 MiB)
+const MaxLargeSecretLength = 10 << 20
+
+// checkLargeSecret checks the attributes of a random secret with large_secret. Large secrets are raw bytes written to a
+// KV v2 mount, encoded in place in the request body (see vault.Base64Value): the attributes deriving other values from
+// the secret would hold copies of it in memory, or in the state.
+func checkLargeSecret(diags *diag.Diagnostics, plan randomSecretModel) {
+	if !plan.LargeSecret.ValueBool() {
+		return
+	}
+
+	unsupported := []struct {
+		attribute string
+		set       bool
+	}{
+		{"type", plan.ValueType.ValueString() != BytesValueType},
+		{"format", plan.Format.ValueString() != RawSecretFormat},
+		{"mount_type", plan.MountType.ValueString() == CubbyholeMountType},
+		{"template", !plan.Template.IsNull()},
+		{"hash_algorithm", !plan.HashAlgorithm.IsNull()},
+		{"escrow_public_key", !plan.EscrowPublicKey.IsNull()},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			diags.AddAttributeError(path.Root(u.attribute), "Unsupported attribute", fmt.Sprintf("Attribute %s isn't supported with large_secret: large secrets are raw bytes stored in a KV v2 mount.", u.attribute))
+		}
+	}
+}
+
+// randomSecretMaxLength returns the upper bound of the length of a random secret: the provider's max_secret_length, or
+// MaxLargeSecretLength when it is lower and the secret has large_secret.
+func randomSecretMaxLength(largeSecret types.Bool, max int64) int64 {
+	if largeSecret.ValueBool() && max != 0 && max < MaxLargeSecretLength {
+		return MaxLargeSecretLength
+	}
+	return max
+}
+
+// largeSecretData lays out a large random secret in the secret data, see vault.Base64Value.
+func largeSecretData(key []byte) map[string]interface{} {
+	return map[string]interface{}{
+		SecretDataKey: vault.Base64Value(key),
+	}
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCheckLargeSecret(t *testing.T) {
+	plan := randomSecretModel{
+		LargeSecret:     types.BoolValue(true),
+		ValueType:       types.StringValue(BytesValueType),
+		Format:          types.StringValue(RawSecretFormat),
+		MountType:       types.StringValue(KVv2MountType),
+		Template:        types.StringNull(),
+		HashAlgorithm:   types.StringNull(),
+		EscrowPublicKey: types.StringNull(),
+	}
+
+	var diags diag.Diagnostics
+	checkLargeSecret(&diags, plan)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	plan.ValueType = types.StringValue(HexValueType)
+	plan.HashAlgorithm = types.StringValue("bcrypt")
+	checkLargeSecret(&diags, plan)
+	if diags.ErrorsCount() != 2 {
+		t.Fatalf("Expected errors for type and hash_algorithm, got: %v", diags)
+	}
+
+	diags = nil
+	plan.LargeSecret = types.BoolValue(false)
+	checkLargeSecret(&diags, plan)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error without large_secret: %v", diags)
+	}
+}
+
+func TestRandomSecretMaxLength(t *testing.T) {
+	tests := []struct {
+		name        string
+		largeSecret types.Bool
+		max         int64
+		expected    int64
+	}{
+		{"regular secret", types.BoolValue(false), DefaultMaxSecretLength, DefaultMaxSecretLength},
+		{"large secret", types.BoolValue(true), DefaultMaxSecretLength, MaxLargeSecretLength},
+		{"higher provider limit", types.BoolValue(true), 1 << 30, 1 << 30},
+		{"unconfigured provider", types.BoolValue(true), 0, 0},
+	}
+	for _, tt := range tests {
+		if max := randomSecretMaxLength(tt.largeSecret, tt.max); max != tt.expected {
+			t.Fatalf("Wrong max length for %s: %d. Expected: %d", tt.name, max, tt.expected)
+		}
+	}
+}
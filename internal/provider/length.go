@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// checkSecretLength reports an error on the `length` attribute when it exceeds the provider's `max_secret_length`.
+// Nothing is checked when the length is not known yet or when the provider is not configured (max is 0).
+//
+// The bound is what keeps generation memory in check: secrets are generated in a single buffer, at most a few copies of
+// max bytes once encoded. Secrets larger than the default bound are written with large_secret, encoded in place in the
+// request body (see checkLargeSecret), rather than generated in chunks.
+func checkSecretLength(diags *diag.Diagnostics, length types.Int64, max int64) {
+	if length.IsNull() || length.IsUnknown() || max == 0 {
+		return
+	}
+
+	if length.ValueInt64() > max {
+		diags.AddAttributeError(
+			path.Root("length"),
+			"Secret length too large",
+			fmt.Sprintf("The requested length %d exceeds the maximum of %d allowed by the provider. Check the value of `length` or raise the provider's `max_secret_length`.", length.ValueInt64(), max),
+		)
+	}
+}
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCheckSecretLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		length    types.Int64
+		max       int64
+		wantError bool
+	}{
+		{"under limit", types.Int64Value(32), DefaultMaxSecretLength, false},
+		{"at limit", types.Int64Value(DefaultMaxSecretLength), DefaultMaxSecretLength, false},
+		{"over limit", types.Int64Value(DefaultMaxSecretLength + 1), DefaultMaxSecretLength, true},
+		{"unknown length", types.Int64Unknown(), 16, false},
+		{"unconfigured provider", types.Int64Value(1 << 30), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			checkSecretLength(&diags, tt.length, tt.max)
+			if diags.HasError() != tt.wantError {
+				t.Fatalf("Wrong result for length %s and max %d: %v", tt.length, tt.max, diags)
+			}
+		})
+	}
+}
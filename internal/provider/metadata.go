@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// MaxUserMetadata is the number of custom metadata allowed in the `metadata` attribute of the resources. The remaining
+// custom metadata accepted by Vault are reserved for the ones managed by the provider (type, generation parameters...).
+const MaxUserMetadata = validators.MaxCustomMetadataKeys - 16
+
+// isStampMetadata tells if key is one of the custom metadata stamped by the vault package on the secrets it writes.
+func isStampMetadata(key string) bool {
+	return key == vault.ManagedVersionsMetadata || key == vault.CreateIDMetadata
+}
+
+// isIgnoredMetadata tells if key matches one of the ignore_metadata_keys patterns: a key, or a key prefix followed by
+// `*`.
+func isIgnoredMetadata(key string, ignored []string) bool {
+	for _, pattern := range ignored {
+		if key == pattern {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIgnoredMetadata reports an error when the `metadata` attribute sets a key ignored by the provider, which would
+// never be read back.
+func checkIgnoredMetadata(diags *diag.Diagnostics, ignored []string, metadata types.Map) {
+	for k := range metadata.Elements() {
+		if isIgnoredMetadata(k, ignored) {
+			diags.AddAttributeError(path.Root("metadata").AtMapKey(k), "Ignored metadata key", fmt.Sprintf("Custom metadata %s matches the provider's ignore_metadata_keys: it is managed outside Terraform and can't be set in `metadata`.", k))
+		}
+	}
+}
+
+// metadataValue builds the `metadata` attribute from the custom metadata read in Vault. Every key is reported, so
+// changes made outside Terraform show up as a diff, except the keys matching ignore_metadata_keys. An empty map is kept
+// null when it was null in state, to avoid a perpetual diff for resources without custom metadata.
+func metadataValue(prior types.Map, metadata map[string]attr.Value, ignored []string) types.Map {
+	for k := range metadata {
+		if isIgnoredMetadata(k, ignored) {
+			delete(metadata, k)
+		}
+	}
+	if len(metadata) == 0 && prior.IsNull() {
+		return prior
+	}
+	value, _ := types.MapValue(types.StringType, metadata)
+	return value
+}
+
+// removedMetadataKeys returns the keys present in the state metadata but not in the planned metadata.
+func removedMetadataKeys(state, plan types.Map) []string {
+	planned := plan.Elements()
+	removed := make([]string, 0)
+	for k := range state.Elements() {
+		if _, ok := planned[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	return removed
+}
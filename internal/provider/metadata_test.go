@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestMetadataValue(t *testing.T) {
+	empty := map[string]attr.Value{}
+
+	if value := metadataValue(types.MapNull(types.StringType), empty, nil); !value.IsNull() {
+		t.Fatalf("Expected null metadata, got %s", value)
+	}
+
+	prior, _ := types.MapValue(types.StringType, map[string]attr.Value{"owner": types.StringValue("my_team")})
+	if value := metadataValue(prior, empty, nil); value.IsNull() || len(value.Elements()) != 0 {
+		t.Fatalf("Expected empty metadata, got %s", value)
+	}
+
+	drifted := map[string]attr.Value{"owner": types.StringValue("some_other_team")}
+	if value := metadataValue(prior, drifted, nil); !value.Equal(types.MapValueMust(types.StringType, drifted)) {
+		t.Fatalf("Expected metadata read in Vault, got %s", value)
+	}
+
+	// Keys matching ignore_metadata_keys don't show up as a diff
+	controlled := map[string]attr.Value{
+		"owner":                        types.StringValue("my_team"),
+		"rotation-controller/last-run": types.StringValue("2024-03-01T10:00:00Z"),
+	}
+	if value := metadataValue(prior, controlled, []string{"rotation-controller/*"}); !value.Equal(prior) {
+		t.Fatalf("Expected ignored metadata to be left out, got %s", value)
+	}
+}
+
+func TestIsIgnoredMetadata(t *testing.T) {
+	ignored := []string{"rotation-controller/*", "last-rotation"}
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{"rotation-controller/last-run", true},
+		{"rotation-controller/", true},
+		{"rotation-controller", false},
+		{"last-rotation", true},
+		{"last-rotation-date", false},
+		{"owner", false},
+	}
+	for _, test := range tests {
+		if isIgnoredMetadata(test.key, ignored) != test.expected {
+			t.Fatalf("Wrong ignored flag for %s: %t. Expected: %t", test.key, !test.expected, test.expected)
+		}
+	}
+}
+
+func TestRemovedMetadataKeys(t *testing.T) {
+	state, _ := types.MapValue(types.StringType, map[string]attr.Value{
+		"owner": types.StringValue("my_team"),
+		"foo":   types.StringValue("bar"),
+		"baz":   types.StringValue("qux"),
+	})
+	plan, _ := types.MapValue(types.StringType, map[string]attr.Value{
+		"owner": types.StringValue("some_other_team"),
+	})
+
+	removed := removedMetadataKeys(state, plan)
+	sort.Strings(removed)
+	if len(removed) != 2 || removed[0] != "baz" || removed[1] != "foo" {
+		t.Fatalf("Wrong removed keys: %v. Expected: [baz foo]", removed)
+	}
+
+	if removed = removedMetadataKeys(state, types.MapNull(types.StringType)); len(removed) != 3 {
+		t.Fatalf("Expected every key to be removed when metadata is unset, got %v", removed)
+	}
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	KVv2MountType      = "kv-v2"
+	CubbyholeMountType = vault.CubbyholeMount
+)
+
+// checkCubbyhole checks that a random secret stored in the cubbyhole only relies on features the cubbyhole supports:
+// it has neither custom metadata nor versions.
+func checkCubbyhole(diags *diag.Diagnostics, plan randomSecretModel) {
+	if plan.MountType.ValueString() != CubbyholeMountType {
+		return
+	}
+
+	if !plan.Path.IsUnknown() && !strings.HasPrefix(strings.TrimPrefix(plan.Path.ValueString(), "/"), vault.CubbyholeMount+"/") {
+		diags.AddAttributeError(path.Root("path"), "Invalid cubbyhole path", fmt.Sprintf("Path must start with `%s/` with the %s mount type.", vault.CubbyholeMount, CubbyholeMountType))
+	}
+
+	unsupported := []struct {
+		attribute string
+		set       bool
+	}{
+		{"metadata", !plan.Metadata.IsNull()},
+		{"deletion_protection", plan.DeletionProtection.ValueBool()},
+		{"destroy_after", !plan.DestroyAfter.IsNull()},
+		{"restore_deleted", plan.RestoreDeleted.ValueBool()},
+		{"on_deleted_version", plan.OnDeletedVersion.ValueString() == OnDeletedVersionRecreate || plan.OnDeletedVersion.ValueString() == OnDeletedVersionRestore},
+		{"external_secret", plan.ExternalSecret != nil},
+		{"policy_template", plan.PolicyTemplate != nil},
+		{"delete_all_versions", !plan.DeleteAllVersions.IsUnknown() && !plan.DeleteAllVersions.ValueBool()},
+		{"use_latest_version", plan.UseLatestVersion.ValueBool()},
+		{"cas_version", plan.CasVersion.ValueBool()},
+		{"length_change_behavior", plan.LengthChange.ValueString() == LengthChangeNewVersion},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			diags.AddAttributeError(path.Root(u.attribute), "Unsupported attribute", fmt.Sprintf("Attribute %s isn't supported with the %s mount type: cubbyhole secrets have neither metadata nor versions.", u.attribute, CubbyholeMountType))
+		}
+	}
+}
+
+// checkKVMount checks that a secret path is under one of the KV v2 mounts detected when the provider was configured.
+// Nothing is checked when mounts weren't detected. Paths outside any mount are accepted with createMounts: the mount is
+// enabled on creation, see ensureKVMount.
+func checkKVMount(diags *diag.Diagnostics, mounts []vault.KVMount, createMounts bool, secretPath types.String) {
+	if mounts == nil || secretPath.IsUnknown() || secretPath.IsNull() {
+		return
+	}
+
+	mount := vault.FindKVMount(mounts, secretPath.ValueString())
+	if mount == nil {
+		if createMounts {
+			return
+		}
+		visible := make([]string, 0, len(mounts))
+		for _, m := range mounts {
+			visible = append(visible, m.String())
+		}
+		if len(visible) == 0 {
+			visible = append(visible, "none")
+		}
+		diags.AddAttributeError(path.Root("path"), "Invalid path", fmt.Sprintf("Path %s is not under any KV mount visible to this token. Visible KV mounts: %s.", secretPath.ValueString(), strings.Join(visible, ", ")))
+		return
+	}
+	if mount.Version != 2 {
+		diags.AddAttributeError(path.Root("path"), "Invalid path", fmt.Sprintf("Path %s is under the KV v%d mount %s, only KV v2 mounts are supported.", secretPath.ValueString(), mount.Version, mount.Path))
+	}
+}
+
+// ensureKVMount enables a KV v2 secrets engine for the secret path when it isn't under any mount yet, with the
+// provider's create_mount_if_missing.
+func ensureKVMount(ctx context.Context, vaultApi *vault.VaultApi, createMounts bool, secretPath string, diags *diag.Diagnostics) {
+	if !createMounts || vaultApi == nil {
+		return
+	}
+
+	mount, err := vaultApi.EnsureKVMount(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error creating mount", fmt.Sprintf("Couldn't enable a KV v2 secrets engine for secret %s", secretPath), err)
+		return
+	}
+	if mount != "" {
+		tflog.Info(ctx, "Enabled KV v2 secrets engine", map[string]interface{}{"mount": mount, "path": secretPath})
+	}
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCheckCubbyhole(t *testing.T) {
+	plan := randomSecretModel{
+		Path:               secretPathString("cubbyhole/ci/bootstrap"),
+		MountType:          types.StringValue(CubbyholeMountType),
+		Metadata:           types.MapNull(types.StringType),
+		DeletionProtection: types.BoolValue(false),
+		DestroyAfter:       types.StringNull(),
+		RestoreDeleted:     types.BoolValue(false),
+		DeleteAllVersions:  types.BoolValue(true),
+		UseLatestVersion:   types.BoolValue(false),
+	}
+
+	var diags diag.Diagnostics
+	checkCubbyhole(&diags, plan)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	plan.Path = secretPathString("secret/ci/bootstrap")
+	plan.DestroyAfter = types.StringValue("72h")
+	checkCubbyhole(&diags, plan)
+	if diags.ErrorsCount() != 2 {
+		t.Fatalf("Expected errors for path and destroy_after, got: %v", diags)
+	}
+
+	diags = nil
+	plan.MountType = types.StringValue(KVv2MountType)
+	checkCubbyhole(&diags, plan)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error with the %s mount type: %v", KVv2MountType, diags)
+	}
+}
+
+func TestCheckKVMount(t *testing.T) {
+	mounts := []vault.KVMount{{Path: "kv1/", Version: 1}, {Path: "secret/", Version: 2}}
+
+	var diags diag.Diagnostics
+	checkKVMount(&diags, mounts, false, types.StringValue("secret/foo"))
+	checkKVMount(&diags, nil, false, types.StringValue("transit/foo"))
+	checkKVMount(&diags, mounts, false, types.StringUnknown())
+	checkKVMount(&diags, mounts, true, types.StringValue("fresh/foo"))
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkKVMount(&diags, mounts, false, types.StringValue("transit/foo"))
+	checkKVMount(&diags, mounts, false, types.StringValue("kv1/foo"))
+	checkKVMount(&diags, []vault.KVMount{}, false, types.StringValue("secret/foo"))
+	if diags.ErrorsCount() != 3 {
+		t.Fatalf("Expected errors for transit/foo, kv1/foo and no visible mount, got: %v", diags)
+	}
+	if detail := diags.Errors()[0].Detail(); !strings.Contains(detail, "not under any KV mount visible to this token") {
+		t.Fatalf("Wrong error detail: %s", detail)
+	}
+}
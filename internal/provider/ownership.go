@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	TerraformWorkspaceMetadata = "terraform_workspace"
+	ModulePathMetadata         = "module_path"
+
+	// DefaultTerraformWorkspace is the workspace Terraform uses when none has been selected
+	DefaultTerraformWorkspace = "default"
+)
+
+// isOwnershipMetadata tells if key is one of the custom metadata recording which Terraform configuration owns a
+// secret, see ownership_metadata.
+func isOwnershipMetadata(key string) bool {
+	return key == TerraformWorkspaceMetadata || key == ModulePathMetadata
+}
+
+// addOwnershipMetadata stamps metadata with the ownership metadata, nil when ownership_metadata is disabled. Along
+// with provider_version, always recorded, they tell operators browsing Vault which Terraform configuration owns a
+// secret.
+func addOwnershipMetadata(metadata, ownership map[string]string) {
+	for k, v := range ownership {
+		metadata[k] = v
+	}
+}
+
+// terraformWorkspace detects the current Terraform workspace: providers aren't told about it, but Terraform runs them
+// in its working directory and with its environment. TF_WORKSPACE and TFC_WORKSPACE_NAME (HCP Terraform runs) take
+// precedence over the workspace selected with `terraform workspace select`.
+func terraformWorkspace() string {
+	for _, env := range []string{"TF_WORKSPACE", "TFC_WORKSPACE_NAME"} {
+		if workspace := os.Getenv(env); workspace != "" {
+			return workspace
+		}
+	}
+
+	dataDir := os.Getenv("TF_DATA_DIR")
+	if dataDir == "" {
+		dataDir = ".terraform"
+	}
+	selected, err := os.ReadFile(filepath.Join(dataDir, "environment"))
+	if err != nil || strings.TrimSpace(string(selected)) == "" {
+		return DefaultTerraformWorkspace
+	}
+	return strings.TrimSpace(string(selected))
+}
+
+// rootModulePath returns the path of the Terraform root module, i.e. the working directory, relative to the root of
+// its Git repository so that it is the same on every machine. The absolute path is returned outside a repository.
+func rootModulePath() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if _, err = os.Stat(filepath.Join(dir, ".git")); err == nil {
+			rel, err := filepath.Rel(dir, wd)
+			if err != nil {
+				return wd
+			}
+			return filepath.ToSlash(rel)
+		}
+		if filepath.Dir(dir) == dir {
+			return wd
+		}
+	}
+}
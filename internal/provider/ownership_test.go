@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTerraformWorkspace(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("TF_DATA_DIR", dataDir)
+	t.Setenv("TF_WORKSPACE", "")
+	t.Setenv("TFC_WORKSPACE_NAME", "")
+
+	if workspace := terraformWorkspace(); workspace != DefaultTerraformWorkspace {
+		t.Fatalf("Wrong workspace: %s. Expected: %s", workspace, DefaultTerraformWorkspace)
+	}
+
+	if err := os.WriteFile(filepath.Join(dataDir, "environment"), []byte("staging\n"), 0o644); err != nil {
+		t.Fatal("error:", err)
+	}
+	if workspace := terraformWorkspace(); workspace != "staging" {
+		t.Fatalf("Wrong workspace: %s. Expected: staging", workspace)
+	}
+
+	t.Setenv("TFC_WORKSPACE_NAME", "billing-prod")
+	if workspace := terraformWorkspace(); workspace != "billing-prod" {
+		t.Fatalf("Wrong workspace: %s. Expected: billing-prod", workspace)
+	}
+}
+
+func TestRootModulePath(t *testing.T) {
+	// Tests run in the package directory, inside the provider's repository
+	if modulePath := rootModulePath(); modulePath != "internal/provider" {
+		t.Fatalf("Wrong module path: %s. Expected: internal/provider", modulePath)
+	}
+}
+
+func TestOwnershipMetadata(t *testing.T) {
+	metadata := map[string]string{SecretTypeMetadata: RandomSecretType}
+	addOwnershipMetadata(metadata, nil)
+	if len(metadata) != 1 {
+		t.Fatalf("Unexpected ownership metadata when disabled: %v", metadata)
+	}
+
+	addOwnershipMetadata(metadata, map[string]string{TerraformWorkspaceMetadata: "staging", ModulePathMetadata: "infra/billing"})
+	if metadata[TerraformWorkspaceMetadata] != "staging" || metadata[ModulePathMetadata] != "infra/billing" {
+		t.Fatalf("Wrong ownership metadata: %v", metadata)
+	}
+}
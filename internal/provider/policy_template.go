@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// policyTemplateModel describes a Vault policy granting read access to the secret, written along with it and
+// optionally attached to the identity group or entity consuming the secret.
+type policyTemplateModel struct {
+	Name   types.String `tfsdk:"name"`
+	Group  types.String `tfsdk:"group"`
+	Entity types.String `tfsdk:"entity"`
+}
+
+func policyTemplateAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional: true,
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				MarkdownDescription: "Name of the Vault ACL policy granting `read` on the secret's data path. The policy is owned by the resource: it is overwritten on creation and deleted with the resource.",
+			},
+			"group": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("entity")),
+				},
+				MarkdownDescription: "Name of an existing identity group the policy is attached to. Its other policies are kept.",
+			},
+			"entity": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				MarkdownDescription: "Name of an existing identity entity the policy is attached to. Its other policies are kept.",
+			},
+		},
+		MarkdownDescription: "Writes a Vault policy granting read access to the secret, optionally attached to the identity group or entity consuming it, so that provisioning a secret and granting access to it happen together. Requires a token allowed to manage policies (`sys/policies/acl`) and, when attaching, identities.",
+	}
+}
+
+// identity returns the kind and name of the identity the policy is attached to, if any.
+func (p *policyTemplateModel) identity() (kind string, name string) {
+	if !p.Group.IsNull() {
+		return vault.IdentityGroup, p.Group.ValueString()
+	}
+	if !p.Entity.IsNull() {
+		return vault.IdentityEntity, p.Entity.ValueString()
+	}
+	return "", ""
+}
+
+func (p *policyTemplateModel) equal(other *policyTemplateModel) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.Name.Equal(other.Name) && p.Group.Equal(other.Group) && p.Entity.Equal(other.Entity)
+}
+
+// applyPolicyTemplate brings the policy of the secret from its prior configuration to the planned one: the prior
+// policy is detached and deleted when it changes or is removed, the planned one is written and attached.
+func applyPolicyTemplate(ctx context.Context, vaultApi *vault.VaultApi, secretPath string, prior, planned *policyTemplateModel, diags *diag.Diagnostics) {
+	if prior.equal(planned) {
+		return
+	}
+
+	if prior != nil {
+		if kind, name := prior.identity(); kind != "" {
+			if err := vaultApi.DetachPolicy(ctx, kind, name, prior.Name.ValueString()); err != nil {
+				addVaultError(diags, "Error updating policy", fmt.Sprintf("Couldn't detach policy %s from %s %s", prior.Name.ValueString(), kind, name), err)
+				return
+			}
+		}
+		if planned == nil || !planned.Name.Equal(prior.Name) {
+			if err := vaultApi.DeletePolicy(ctx, prior.Name.ValueString()); err != nil {
+				addVaultError(diags, "Error updating policy", fmt.Sprintf("Couldn't delete policy %s", prior.Name.ValueString()), err)
+				return
+			}
+		}
+	}
+
+	if planned == nil {
+		return
+	}
+
+	document, err := vaultApi.SecretReadPolicy(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error updating policy", fmt.Sprintf("Couldn't build policy for secret %s", secretPath), err)
+		return
+	}
+	if err = vaultApi.WritePolicy(ctx, planned.Name.ValueString(), document); err != nil {
+		addVaultError(diags, "Error updating policy", fmt.Sprintf("Couldn't write policy %s", planned.Name.ValueString()), err)
+		return
+	}
+	if kind, name := planned.identity(); kind != "" {
+		if err = vaultApi.AttachPolicy(ctx, kind, name, planned.Name.ValueString()); err != nil {
+			addVaultError(diags, "Error updating policy", fmt.Sprintf("Couldn't attach policy %s to %s %s", planned.Name.ValueString(), kind, name), err)
+		}
+	}
+}
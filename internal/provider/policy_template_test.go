@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPolicyTemplateIdentity(t *testing.T) {
+	p := &policyTemplateModel{Name: types.StringValue("app-read"), Group: types.StringValue("app"), Entity: types.StringNull()}
+	if kind, name := p.identity(); kind != vault.IdentityGroup || name != "app" {
+		t.Fatalf("Wrong identity: %s %s", kind, name)
+	}
+
+	p = &policyTemplateModel{Name: types.StringValue("app-read"), Group: types.StringNull(), Entity: types.StringNull()}
+	if kind, _ := p.identity(); kind != "" {
+		t.Fatalf("Unexpected identity kind: %s", kind)
+	}
+}
+
+func TestPolicyTemplateEqual(t *testing.T) {
+	p := &policyTemplateModel{Name: types.StringValue("app-read"), Group: types.StringValue("app"), Entity: types.StringNull()}
+	same := &policyTemplateModel{Name: types.StringValue("app-read"), Group: types.StringValue("app"), Entity: types.StringNull()}
+	other := &policyTemplateModel{Name: types.StringValue("app-read"), Group: types.StringNull(), Entity: types.StringValue("app")}
+
+	var none *policyTemplateModel
+	if !p.equal(same) || p.equal(other) || p.equal(nil) || !none.equal(nil) || none.equal(p) {
+		t.Fatalf("Wrong policy template comparison")
+	}
+}
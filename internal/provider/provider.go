@@ -3,29 +3,149 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/awssm"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/gcpsm"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/k8ssecret"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
 	vaultapi "github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	vault "github.com/hashicorp/vault/api"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
-const providerName = "vaultprov"
+const (
+	providerName = "vaultprov"
+
+	// DefaultMaxSecretLength is the default upper bound of the `length` attribute of the resources (1 MiB)
+	DefaultMaxSecretLength = 1 << 20
+
+	// TestRNGSeedEnvVar makes every generated secret derive from the given seed. Only honored in acceptance tests
+	// (TF_ACC set), secrets generated this way are predictable.
+	TestRNGSeedEnvVar = "VAULTPROV_TEST_RNG_SEED"
+	// acceptanceTestsEnvVar is set by Terraform's testing framework when running acceptance tests
+	acceptanceTestsEnvVar = "TF_ACC"
+
+	// RunIDHeader carries the ID of the Terraform run, when known, on every request sent to Vault
+	RunIDHeader = "X-Terraform-Run-ID"
+
+	// VaultBackend stores secrets in a Vault KV v2 mount, GCPSecretManagerBackend in GCP Secret Manager,
+	// AWSSecretsManagerBackend in AWS Secrets Manager and KubernetesBackend directly in Kubernetes Secrets
+	VaultBackend             = "vault"
+	GCPSecretManagerBackend  = "gcp-sm"
+	AWSSecretsManagerBackend = "aws-sm"
+	KubernetesBackend        = "kubernetes"
+)
 
 var _ provider.Provider = &vaultSecretProvider{}
 
 type vaultSecretProvider struct {
 	vaultApi *vaultapi.VaultApi
+	version  string
+}
+
+// providerData is handed to resources once the provider is configured
+type providerData struct {
+	// vaultApi is only set with the Vault backend
+	vaultApi        *vaultapi.VaultApi
+	secretStore     vaultapi.SecretStore
+	backend         string
+	version         string
+	maxSecretLength int64
+	strict          bool
+	// readOnly makes resources refuse to create, update or delete anything, only Read and Import work
+	readOnly bool
+	// ownership holds the ownership metadata stamped on new secrets, nil when ownership_metadata is disabled
+	ownership map[string]string
+	// history records the lifecycle events of secrets in their custom metadata, see lifecycle_history
+	history bool
+	// ignoredMetadata are the ignore_metadata_keys patterns, custom metadata left to other tools
+	ignoredMetadata []string
+	// fipsMode restricts the algorithms of generated secrets to FIPS approved ones, see fips_mode
+	fipsMode bool
+	// kvMounts are the KV mounts visible to the token, nil when detect_mounts is disabled or the detection failed
+	kvMounts []vaultapi.KVMount
+	// storage estimates the size of the secrets planned under each mount, nil without storage_warning_threshold
+	storage *storageEstimate
+	// endpoints are the Vault APIs of the named endpoints replicated secrets are written to, see endpoints
+	endpoints map[string]*vaultapi.VaultApi
+	// createMounts enables a KV v2 secrets engine when a new secret isn't under any mount
+	createMounts bool
 }
 
 // Provider schema struct
 type providerModel struct {
-	Address types.String       `tfsdk:"address"`
-	Token   types.String       `tfsdk:"token"`
-	Auth    *providerAuthModel `tfsdk:"auth"`
+	Backend         types.String                     `tfsdk:"backend"`
+	GCPProject      types.String                     `tfsdk:"gcp_project"`
+	AWSRegion       types.String                     `tfsdk:"aws_region"`
+	AWSKMSKeyID     types.String                     `tfsdk:"aws_kms_key_id"`
+	AWSReplicas     types.List                       `tfsdk:"aws_replica_regions"`
+	KubeConfigPath  types.String                     `tfsdk:"kubernetes_config_path"`
+	KubeContext     types.String                     `tfsdk:"kubernetes_context"`
+	Address         types.String                     `tfsdk:"address"`
+	AgentAddress    types.String                     `tfsdk:"agent_address"`
+	Token           types.String                     `tfsdk:"token"`
+	Auth            *providerAuthModel               `tfsdk:"auth"`
+	MaxSecretLength types.Int64                      `tfsdk:"max_secret_length"`
+	MaxConcurrent   types.Int64                      `tfsdk:"max_concurrent_requests"`
+	StorageWarning  types.Int64                      `tfsdk:"storage_warning_threshold"`
+	Metrics         types.Bool                       `tfsdk:"metrics"`
+	HealthCheck     types.Bool                       `tfsdk:"health_check"`
+	DetectMounts    types.Bool                       `tfsdk:"detect_mounts"`
+	CreateMounts    types.Bool                       `tfsdk:"create_mount_if_missing"`
+	MinTokenTTL     types.String                     `tfsdk:"min_token_ttl"`
+	Strict          types.Bool                       `tfsdk:"strict"`
+	ReadOnly        types.Bool                       `tfsdk:"read_only"`
+	Ownership       types.Bool                       `tfsdk:"ownership_metadata"`
+	History         types.Bool                       `tfsdk:"lifecycle_history"`
+	FIPSMode        types.Bool                       `tfsdk:"fips_mode"`
+	IgnoreMetadata  types.List                       `tfsdk:"ignore_metadata_keys"`
+	Workspace       types.String                     `tfsdk:"terraform_workspace"`
+	ModulePath      types.String                     `tfsdk:"module_path"`
+	Headers         types.Map                        `tfsdk:"headers"`
+	UserAgentSuffix types.String                     `tfsdk:"user_agent_suffix"`
+	Transport       *providerTransportModel          `tfsdk:"transport"`
+	Retry           *providerRetryModel              `tfsdk:"retry"`
+	Endpoints       map[string]providerEndpointModel `tfsdk:"endpoints"`
+}
+
+type providerTransportModel struct {
+	ProxyURL        types.String `tfsdk:"proxy_url"`
+	DialTimeout     types.String `tfsdk:"dial_timeout"`
+	KeepAlive       types.String `tfsdk:"keep_alive"`
+	IdleConnTimeout types.String `tfsdk:"idle_conn_timeout"`
+	MaxIdleConns    types.Int64  `tfsdk:"max_idle_conns_per_host"`
+}
+
+type providerRetryModel struct {
+	MaxRetries types.Map    `tfsdk:"max_retries"`
+	MinWait    types.String `tfsdk:"min_wait"`
+	MaxWait    types.String `tfsdk:"max_wait"`
+}
+
+type providerEndpointModel struct {
+	Address   types.String `tfsdk:"address"`
+	Namespace types.String `tfsdk:"namespace"`
+	Token     types.String `tfsdk:"token"`
 }
 
 type providerAuthModel struct {
@@ -34,32 +154,84 @@ type providerAuthModel struct {
 	Jwt  types.String `tfsdk:"jwt"`
 }
 
-func New() func() provider.Provider {
+func New(version string) func() provider.Provider {
 	return func() provider.Provider {
-		return &vaultSecretProvider{}
+		return &vaultSecretProvider{
+			version: version,
+		}
 	}
 }
 
 func (p *vaultSecretProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = providerName
+	resp.Version = p.version
 }
 
 func (p *vaultSecretProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewExternalSecretDataSource,
+		NewInventoryDataSource,
+		NewKeypairFingerprintDataSource,
+		NewSecretExistsDataSource,
+	}
 }
 
 func (p *vaultSecretProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewRandomSecret,
+		NewPGPKey,
+		NewAPIToken,
+		NewSecretBundle,
+		NewPolicyBinding,
+		NewSecretVersionsPurge,
+		NewSplitSecret,
+		NewExistingSecret,
+		NewReplicatedRandomSecret,
 	}
 }
 
 func (p *vaultSecretProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
+			"backend": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(VaultBackend, GCPSecretManagerBackend, AWSSecretsManagerBackend, KubernetesBackend),
+				},
+				MarkdownDescription: "Where generated secrets are stored: `" + VaultBackend + "` (a Vault KV v2 mount), `" + GCPSecretManagerBackend + "` (GCP Secret Manager, see `gcp_project`), `" + AWSSecretsManagerBackend + "` (AWS Secrets Manager, see the `aws_` attributes) or `" + KubernetesBackend + "` (experimental, Kubernetes Secrets written through the API server, see the `kubernetes_` attributes). Only `vaultprov_random_secret` supports the other backends than `" + VaultBackend + "`, without Vault-only features (cubbyhole, policies, deletion scheduling and restoration). Default is `" + VaultBackend + "`.",
+			},
+			"gcp_project": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "ID of the GCP project secrets are stored in with the `" + GCPSecretManagerBackend + "` backend. Secret paths are then secret IDs of this project. Authentication uses the Application Default Credentials. Can also be set with the `GOOGLE_CLOUD_PROJECT` environment variable.",
+			},
+			"aws_region": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "AWS region secrets are stored in with the `" + AWSSecretsManagerBackend + "` backend. Default is the region of the shared configuration or of the `AWS_REGION` environment variable. Authentication uses the default AWS credential chain.",
+			},
+			"aws_kms_key_id": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "ID, ARN or alias of the KMS key encrypting secrets created with the `" + AWSSecretsManagerBackend + "` backend. Default is the AWS managed key `aws/secretsmanager`.",
+			},
+			"aws_replica_regions": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "AWS regions secrets created with the `" + AWSSecretsManagerBackend + "` backend are replicated to. Replicas are encrypted with the AWS managed key of their region.",
+			},
+			"kubernetes_config_path": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Path of the kubeconfig file used with the `" + KubernetesBackend + "` backend. Default is the `KUBECONFIG` environment variable or `~/.kube/config`, and the in-cluster configuration when there's no kubeconfig file.",
+			},
+			"kubernetes_context": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Context of the kubeconfig file used with the `" + KubernetesBackend + "` backend. Default is the current context.",
+			},
 			"address": schema.StringAttribute{
 				Optional:            true,
-				MarkdownDescription: "Origin URL of the Vault server. This is a URL with a scheme, a hostname and a port but with no path.",
+				MarkdownDescription: "Origin URL of the Vault server. This is a URL with a scheme, a hostname and a port but with no path. A Unix socket can be used with `unix:///path/to/vault.sock`.",
+			},
+			"agent_address": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Address of a local Vault Agent handling authentication and caching, e.g. `unix:///var/run/vault/agent.sock` or `http://127.0.0.1:8100`. Takes precedence over `address`, and no credentials are needed in the provider configuration when the agent uses auto-auth. Can also be set with the `VAULT_AGENT_ADDR` environment variable.",
 			},
 			"token": schema.StringAttribute{
 				Optional:            true,
@@ -82,6 +254,176 @@ func (p *vaultSecretProvider) Schema(ctx context.Context, req provider.SchemaReq
 				},
 				Optional: true,
 			},
+			"max_secret_length": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				MarkdownDescription: fmt.Sprintf("Upper bound of the `length` attribute of the resources, to prevent generating huge secrets by mistake. Default is %d (1 MiB).", DefaultMaxSecretLength),
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				MarkdownDescription: "Maximum number of requests sent concurrently to Vault, whatever the Terraform parallelism, so that large applies don't overload small Vault clusters. Requests over the limit wait for a slot until their timeout. Unlimited by default.",
+			},
+			"storage_warning_threshold": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				MarkdownDescription: "Size in bytes (e.g. `104857600` for 100 MiB) above which a warning is reported at plan time when the secrets planned under a mount hold more data, as estimated from their attributes (length, type, PGP algorithm...), e.g. for the capacity planning of Raft storage. Secrets are grouped by KV mount with `detect_mounts`, by the first segment of their path otherwise. Only the latest version of each secret is counted. Only with the `" + VaultBackend + "` backend. Not checked by default.",
+			},
+			"metrics": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, every request sent to Vault is logged at the `INFO` level (`TF_LOG=INFO`) with structured fields: its operation (`read`, `list`, `write`, `patch` or `delete`), path, status and latency, and running counters of requests, failures and latencies, so that platform teams can monitor the load Terraform puts on Vault. Only with the `" + VaultBackend + "` backend. Default is `false`.",
+			},
+			"health_check": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, the provider checks that Vault is reachable and able to serve requests (initialized, unsealed, not a DR secondary) when it is configured, and fails fast with a diagnostic describing the problem. Default is `false`.",
+			},
+			"detect_mounts": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, the provider lists the KV mounts visible to its token when it is configured and logs them, and resources check at plan time that their `path` is under a KV v2 mount. Paths prefixed by a Vault namespace aren't supported by the check. Only with the `" + VaultBackend + "` backend. Default is `false`.",
+			},
+			"create_mount_if_missing": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, a KV v2 secrets engine is enabled at the first segment of a secret's `path` (e.g. `secret` for `secret/foo/bar`) when the secret is created and Vault denies the mount lookup of the path, i.e. when no mount exists yet, for the bootstrap of fresh environments. The token needs the `create` and `update` capabilities on `sys/mounts/<mount>`. A mount that already exists is left as is. Only with the `" + VaultBackend + "` backend. Default is `false`.",
+			},
+			"min_token_ttl": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Minimum remaining TTL (e.g. `30m`) of the provider's token, i.e. the estimated duration of an apply, checked when the provider is configured. A renewable token is renewed when its TTL is shorter, otherwise a warning is emitted: requests failing with 403 once the token has expired would look like policy errors. Only with the `" + VaultBackend + "` backend. Not checked by default.",
+			},
+			"strict": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, resources refuse to read, update or delete secrets without a `" + SecretTypeMetadata + "` custom metadata, i.e. secrets not written by the provider, so that hand-managed secrets are never modified by mistake (e.g. after importing the wrong path). Default is `false`.",
+			},
+			"read_only": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, resources fail to be created, updated or deleted with an explicit error, and only reads (refresh, data sources and imports) are sent to the backend, e.g. to run the configuration in audit or report pipelines with a read-only Vault token. Plans still show the changes that would be applied. Default is `false`.",
+			},
+			"ownership_metadata": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, new secrets are stamped with the Terraform configuration owning them, stored as custom metadata under the keys `" + TerraformWorkspaceMetadata + "` and `" + ModulePathMetadata + "` (`" + ProviderVersionMetadata + "` is always recorded), so that operators browsing Vault can tell which configuration owns a secret. Default is `false`.",
+			},
+			"lifecycle_history": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, the lifecycle events of secrets (`" + HistoryCreated + "`, `" + HistoryMetadataUpdated + "`, `" + HistoryRotated + "`, `" + HistoryImported + "`, `" + HistoryRestored + "` and `" + HistoryAdopted + "`) are recorded with their date in the custom metadata `" + HistoryMetadata + "`, e.g. `2024-03-01T10:00:00Z created; 2024-06-01T10:00:00Z rotated`, so that auditors can follow the changes made to a secret from Vault without access to the Terraform state history. The oldest events are dropped to fit in Vault's 512 bytes limit. Secrets written before it was enabled start their history with the next event, and imports are recorded when Terraform imports the secret, i.e. at plan time with `import` blocks. Not recorded in `read_only` mode. Default is `false`.",
+			},
+			"ignore_metadata_keys": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(
+						stringvalidator.RegexMatches(regexp.MustCompile(`^[^*]+\*?$`), "must be a custom metadata key, or a key prefix followed by `*`"),
+					),
+				},
+				MarkdownDescription: "Custom metadata keys the provider never manages nor reports in the `metadata` attribute of the resources, so that other tools writing their own custom metadata on the secrets (e.g. a rotation controller) don't cause perpetual diffs. A key, or a key prefix followed by `*` (e.g. `rotation-controller/*`). These keys are kept when the provider updates a secret, and can't be set in `metadata`. Custom metadata managed by the provider (`secret_type`, `deletion_protection`...) aren't affected.",
+			},
+			"fips_mode": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set to `true`, configurations are refused at plan time when they would generate keys or use algorithms not approved by FIPS 140: `ed25519` PGP keys (Curve25519, set `algorithm = \"rsa\"`), `escrow_public_key` (age encryption uses X25519 and ChaCha20-Poly1305) and the `bcrypt` and `argon2id` `hash_algorithm`. Random secrets are drawn from Go's `crypto/rand`, which is backed by the FIPS validated BoringCrypto module when the provider is built with `GOEXPERIMENT=boringcrypto` (`make release-fips`); a warning is reported otherwise. Default is `false`.",
+			},
+			"terraform_workspace": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Workspace recorded with `ownership_metadata`, e.g. `terraform.workspace`. Default is detected: the `TF_WORKSPACE` or `TFC_WORKSPACE_NAME` environment variables, then the workspace selected in the working directory.",
+			},
+			"module_path": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Module path recorded with `ownership_metadata`, e.g. a repository and directory. Default is the path of the root module (Terraform's working directory) relative to the root of its Git repository.",
+			},
+			"transport": schema.SingleNestedAttribute{
+				Attributes: map[string]schema.Attribute{
+					"proxy_url": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "URL of the HTTP(S) proxy used to reach Vault, e.g. `http://proxy.internal:3128`. Takes precedence over the `HTTPS_PROXY` environment variable.",
+					},
+					"dial_timeout": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Maximum duration (e.g. `10s`) to establish a connection to Vault. Default is `30s`.",
+					},
+					"keep_alive": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Interval (e.g. `15s`) between TCP keep-alive probes. Default is `30s`.",
+					},
+					"idle_conn_timeout": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Maximum duration (e.g. `90s`) an idle connection to Vault is kept open. Default is `90s`.",
+					},
+					"max_idle_conns_per_host": schema.Int64Attribute{
+						Optional: true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+						MarkdownDescription: "Maximum number of idle connections to Vault kept open to be reused by later requests. Default is the number of CPUs + 1. Setting it to `max_concurrent_requests` avoids opening new connections once the pool is warm.",
+					},
+				},
+				Optional:            true,
+				MarkdownDescription: "HTTP transport settings of the Vault client.",
+			},
+			"retry": schema.SingleNestedAttribute{
+				Attributes: map[string]schema.Attribute{
+					"max_retries": schema.MapAttribute{
+						ElementType: types.Int64Type,
+						Optional:    true,
+						Validators: []validator.Map{
+							mapvalidator.KeysAre(stringvalidator.OneOf(vaultapi.RequestOperations...)),
+							mapvalidator.ValueInt64sAre(int64validator.AtLeast(0)),
+						},
+						MarkdownDescription: "Budget of retries of a request per operation: `read`, `list`, `write`, `patch` or `delete`, e.g. `{ read = 5, write = 2 }`. Operations not listed are retried as many times as the `VAULT_MAX_RETRIES` environment variable, 2 by default.",
+					},
+					"min_wait": schema.StringAttribute{
+						Optional: true,
+						Validators: []validator.String{
+							validators.Duration(),
+						},
+						MarkdownDescription: "Wait (e.g. `1s`) before the first retry of a request, doubled at each retry, with jitter. Default is `500ms`.",
+					},
+					"max_wait": schema.StringAttribute{
+						Optional: true,
+						Validators: []validator.String{
+							validators.Duration(),
+						},
+						MarkdownDescription: "Maximum wait (e.g. `1m`) between two attempts of a request. A request is not retried when Vault asks, with a `Retry-After` header, to wait longer. Default is `30s`.",
+					},
+				},
+				Optional:            true,
+				MarkdownDescription: "Retries of the requests failing with a transient error: `412` (performance standby not caught up yet), `429` (rate limited), `502`, `503` and `504` responses, and connection errors. Waits grow exponentially with jitter and follow the `Retry-After` header of the responses. A request is not retried beyond the timeout of its resource operation. Only with the `" + VaultBackend + "` backend.",
+			},
+			"headers": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "HTTP headers sent on every request to Vault, e.g. to correlate Vault audit logs with Terraform runs. The `" + RunIDHeader + "` header is added automatically with the value of the `TFC_RUN_ID` or `TF_RUN_ID` environment variable when set. Headers only appear in audit logs once declared in Vault's `sys/config/auditing/request-headers`.",
+			},
+			"endpoints": schema.MapNestedAttribute{
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"address": schema.StringAttribute{
+							Required:            true,
+							MarkdownDescription: "Address of the Vault cluster, e.g. `https://vault.eu-west-1.example.com:8200`.",
+						},
+						"namespace": schema.StringAttribute{
+							Optional:            true,
+							MarkdownDescription: "Vault namespace the requests are sent to. Default is the namespace of the provider's client, if any.",
+						},
+						"token": schema.StringAttribute{
+							Optional:            true,
+							Sensitive:           true,
+							MarkdownDescription: "Vault token of the endpoint. Default is the provider's token, e.g. for namespaces of the same cluster.",
+						},
+					},
+				},
+				Optional:            true,
+				MarkdownDescription: "Other Vault clusters or namespaces, by name, that `" + providerName + "_replicated_random_secret` resources write copies of their secret to. Requests to the endpoints use the settings of the provider's client (`headers`, `transport`, `retry`...). Only with the `" + VaultBackend + "` backend.",
+			},
+			"user_agent_suffix": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Text appended to the `User-Agent` header of the requests sent to Vault, e.g. a team or pipeline name. The header is `terraform-provider-" + providerName + "/<provider version> terraform/<Terraform version>` by default, so that Vault request logs from this provider are distinguishable from other clients. A `User-Agent` set in `headers` takes precedence.",
+			},
 		},
 		MarkdownDescription: "A provider to generate secrets and have them stored directly into Vault without any copy in the Terraform State.  Once the secret has been generated, its value only exist into Vault. Terraform will not track any change in the value, only in the secret attribute (`metadata`, etc.`).",
 	}
@@ -95,20 +437,359 @@ func (p *vaultSecretProvider) Configure(ctx context.Context, req provider.Config
 		return
 	}
 
-	vaultConf := vault.DefaultConfig()
+	configureTestRNG(&resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := &providerData{
+		backend:         VaultBackend,
+		version:         p.version,
+		maxSecretLength: DefaultMaxSecretLength,
+		strict:          config.Strict.ValueBool(),
+		readOnly:        config.ReadOnly.ValueBool(),
+		createMounts:    config.CreateMounts.ValueBool(),
+	}
+	if !config.Backend.IsNull() {
+		data.backend = config.Backend.ValueString()
+	}
+	if !config.MaxSecretLength.IsNull() {
+		data.maxSecretLength = config.MaxSecretLength.ValueInt64()
+	}
+	if config.Ownership.ValueBool() {
+		data.ownership = ownershipMetadata(config)
+	}
+	// Imports are the only writes left in read-only mode, they aren't recorded either
+	data.history = config.History.ValueBool() && !data.readOnly
+	for _, key := range config.IgnoreMetadata.Elements() {
+		data.ignoredMetadata = append(data.ignoredMetadata, key.(types.String).ValueString())
+	}
+	data.fipsMode = config.FIPSMode.ValueBool()
+	if data.fipsMode {
+		checkFIPSCrypto(&resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	switch data.backend {
+	case GCPSecretManagerBackend:
+		data.secretStore = configureSecretManager(ctx, config.GCPProject, &resp.Diagnostics)
+	case AWSSecretsManagerBackend:
+		data.secretStore = configureSecretsManager(ctx, config, &resp.Diagnostics)
+	case KubernetesBackend:
+		data.secretStore = configureKubernetesSecrets(ctx, config, &resp.Diagnostics)
+	default:
+		p.vaultApi = configureVault(ctx, config, userAgent(p.version, req.TerraformVersion, config.UserAgentSuffix.ValueString()), &resp.Diagnostics)
+		if p.vaultApi != nil && data.readOnly {
+			// Also refused by the client, so no code path can write to Vault in read-only mode
+			p.vaultApi = p.vaultApi.ReadOnly()
+		}
+		data.vaultApi = p.vaultApi
+		data.secretStore = p.vaultApi
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !config.MinTokenTTL.IsNull() && data.vaultApi != nil {
+		checkTokenTTL(ctx, data.vaultApi, config.MinTokenTTL, &resp.Diagnostics)
+	}
+	if config.DetectMounts.ValueBool() && data.vaultApi != nil {
+		data.kvMounts = detectKVMounts(ctx, data.vaultApi, &resp.Diagnostics)
+	}
+	if data.vaultApi != nil {
+		data.endpoints = configureEndpoints(data.vaultApi, config.Endpoints, &resp.Diagnostics)
+		data.storage = newStorageEstimate(config.StorageWarning.ValueInt64(), data.kvMounts)
+	}
+
+	resp.ResourceData = data
+	resp.DataSourceData = resp.ResourceData
+}
+
+// configureTestRNG derives every generated secret from the seed set in TestRNGSeedEnvVar, in acceptance tests only: a
+// leftover variable elsewhere, e.g. in a CI job, must not make real secrets predictable.
+func configureTestRNG(diags *diag.Diagnostics) {
+	seed := os.Getenv(TestRNGSeedEnvVar)
+	if seed == "" {
+		return
+	}
+	if os.Getenv(acceptanceTestsEnvVar) == "" {
+		diags.AddWarning(
+			"Ignored seeded random generator",
+			fmt.Sprintf("%s is only honored in acceptance tests (%s set), secrets are generated by crypto/rand. Unset it.", TestRNGSeedEnvVar, acceptanceTestsEnvVar),
+		)
+		return
+	}
+
+	value, err := strconv.ParseInt(seed, 10, 64)
+	if err != nil {
+		diags.AddError(
+			"Error configuring provider",
+			fmt.Sprintf("Invalid %s value %q: %s", TestRNGSeedEnvVar, seed, err.Error()),
+		)
+		return
+	}
+	secrets.SetReader(secrets.NewSeededReader(value), secrets.SeededRNGSource)
+	diags.AddWarning(
+		"Seeded random generator in use",
+		fmt.Sprintf("%s is set: generated secrets are predictable. FOR TESTS ONLY, DO NOT USE IN PRODUCTION.", TestRNGSeedEnvVar),
+	)
+}
+
+// configureEndpoints returns the Vault APIs of the named endpoints, clones of the provider's API.
+func configureEndpoints(vaultApi *vaultapi.VaultApi, endpoints map[string]providerEndpointModel, diags *diag.Diagnostics) map[string]*vaultapi.VaultApi {
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	apis := make(map[string]*vaultapi.VaultApi, len(endpoints))
+	for name, endpoint := range endpoints {
+		api, err := vaultApi.WithEndpoint(endpoint.Address.ValueString(), endpoint.Namespace.ValueString(), endpoint.Token.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("endpoints").AtMapKey(name), "Error configuring provider", fmt.Sprintf("Can't create vault client for endpoint %s: %s", name, err.Error()))
+			continue
+		}
+		apis[name] = api
+	}
+	return apis
+}
+
+// checkTokenTTL renews the provider's token when its TTL is shorter than minTTL, and warns when it can't be renewed for
+// long enough. Tokens without TTL (e.g. root tokens) never expire.
+func checkTokenTTL(ctx context.Context, vaultApi *vaultapi.VaultApi, minTTL types.String, diags *diag.Diagnostics) {
+	var minimum time.Duration
+	if err := parseDuration(minTTL, "min_token_ttl", &minimum); err != nil {
+		diags.AddAttributeError(path.Root("min_token_ttl"), "Error configuring provider", err.Error())
+		return
+	}
+
+	ttl, renewable, err := vaultApi.TokenTTL(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to look up the provider's token", map[string]interface{}{"error": err})
+		diags.AddWarning("Token TTL check failed", fmt.Sprintf("Unable to look up the TTL of the provider's token: %s", err.Error()))
+		return
+	}
+	if ttl == 0 || ttl >= minimum {
+		return
+	}
+
+	if renewable {
+		renewed, err := vaultApi.RenewToken(ctx, minimum)
+		if err != nil {
+			tflog.Warn(ctx, "Unable to renew the provider's token", map[string]interface{}{"error": err})
+		} else {
+			tflog.Info(ctx, "Renewed the provider's token", map[string]interface{}{"ttl": ttl.String(), "renewed_ttl": renewed.String()})
+			ttl = renewed
+		}
+		if ttl >= minimum {
+			return
+		}
+	}
+
+	diags.AddWarning(
+		"Token expires soon",
+		fmt.Sprintf("The provider's token expires in %s, less than min_token_ttl (%s). Requests sent after that fail with 403 (permission denied), which is not a policy error: authenticate with a longer TTL, or a renewable token, before applying.", ttl, minimum),
+	)
+}
+
+// logRequestMetric logs a request sent to Vault, see the metrics attribute. Durations are in milliseconds.
+func logRequestMetric(ctx context.Context, metric vaultapi.RequestMetric) {
+	tflog.Info(ctx, "Vault request", map[string]interface{}{
+		"vault_operation":                metric.Operation,
+		"vault_path":                     metric.Path,
+		"vault_status":                   metric.Status,
+		"vault_duration_ms":              metric.Duration.Milliseconds(),
+		"vault_failed":                   metric.Failed,
+		"vault_requests_total":           metric.Requests,
+		"vault_failures_total":           metric.Failures,
+		"vault_operation_requests_total": metric.OperationRequests,
+		"vault_duration_ms_total":        metric.TotalDuration.Milliseconds(),
+	})
+}
+
+// logRequestRetry logs the requests sent to Vault failing with a transient error before they are retried.
+func logRequestRetry(ctx context.Context, retry vaultapi.RequestRetry) {
+	fields := map[string]interface{}{
+		"vault_operation": retry.Operation,
+		"vault_path":      retry.Path,
+		"vault_status":    retry.Status,
+		"attempt":         retry.Attempt,
+		"wait_ms":         retry.Wait.Milliseconds(),
+	}
+	if retry.Err != nil {
+		fields["error"] = retry.Err.Error()
+	}
+	tflog.Warn(ctx, "Retrying Vault request", fields)
+}
+
+// detectKVMounts lists and logs the KV mounts visible to the provider's token. A failed detection is only a warning:
+// the plan-time check of resource paths is then disabled.
+func detectKVMounts(ctx context.Context, vaultApi *vaultapi.VaultApi, diags *diag.Diagnostics) []vaultapi.KVMount {
+	mounts, err := vaultApi.ListKVMounts(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to list KV mounts", map[string]interface{}{"error": err})
+		diags.AddWarning(
+			"KV mounts detection failed",
+			fmt.Sprintf("Unable to list the KV mounts visible to the provider's token, paths won't be checked at plan time: %s", err.Error()),
+		)
+		return nil
+	}
+
+	report := map[string]interface{}{"kv_v1": []string{}, "kv_v2": []string{}}
+	for _, mount := range mounts {
+		key := fmt.Sprintf("kv_v%d", mount.Version)
+		report[key] = append(report[key].([]string), mount.Path)
+	}
+	tflog.Info(ctx, fmt.Sprintf("Detected %d KV mounts visible to the token", len(mounts)), report)
+	return mounts
+}
+
+// ownershipMetadata returns the ownership metadata stamped on new secrets, detected unless set in the configuration.
+func ownershipMetadata(config providerModel) map[string]string {
+	ownership := map[string]string{
+		TerraformWorkspaceMetadata: config.Workspace.ValueString(),
+		ModulePathMetadata:         config.ModulePath.ValueString(),
+	}
+	if config.Workspace.IsNull() {
+		ownership[TerraformWorkspaceMetadata] = terraformWorkspace()
+	}
+	if config.ModulePath.IsNull() {
+		ownership[ModulePathMetadata] = rootModulePath()
+	}
+	return ownership
+}
+
+// configureSecretManager creates the GCP Secret Manager client of the gcp-sm backend.
+func configureSecretManager(ctx context.Context, project types.String, diags *diag.Diagnostics) vaultapi.SecretStore {
+	gcpProject := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if !project.IsNull() {
+		gcpProject = project.ValueString()
+	}
+	if gcpProject == "" {
+		diags.AddAttributeError(
+			path.Root("gcp_project"),
+			"Error configuring provider",
+			fmt.Sprintf("A GCP project is required with the %s backend", GCPSecretManagerBackend),
+		)
+		return nil
+	}
+
+	secretManager, err := gcpsm.NewSecretManager(ctx, gcpProject)
+	if err != nil {
+		diags.AddError(
+			"Error configuring provider",
+			fmt.Sprintf("Can't create GCP Secret Manager client for project %s: %s", gcpProject, err.Error()),
+		)
+		return nil
+	}
+	return secretManager
+}
+
+// configureSecretsManager creates the AWS Secrets Manager client of the aws-sm backend.
+func configureSecretsManager(ctx context.Context, config providerModel, diags *diag.Diagnostics) vaultapi.SecretStore {
+	replicaRegions := make([]string, 0, len(config.AWSReplicas.Elements()))
+	for _, region := range config.AWSReplicas.Elements() {
+		replicaRegions = append(replicaRegions, region.(types.String).ValueString())
+	}
+
+	secretsManager, err := awssm.NewSecretsManager(ctx, config.AWSRegion.ValueString(), config.AWSKMSKeyID.ValueString(), replicaRegions)
+	if err != nil {
+		diags.AddError(
+			"Error configuring provider",
+			fmt.Sprintf("Can't create AWS Secrets Manager client: %s", err.Error()),
+		)
+		return nil
+	}
+	return secretsManager
+}
+
+// configureKubernetesSecrets creates the Kubernetes client of the kubernetes backend.
+func configureKubernetesSecrets(ctx context.Context, config providerModel, diags *diag.Diagnostics) vaultapi.SecretStore {
+	secrets, err := k8ssecret.NewSecrets(config.KubeConfigPath.ValueString(), config.KubeContext.ValueString())
+	if err != nil {
+		diags.AddError(
+			"Error configuring provider",
+			fmt.Sprintf("Can't create Kubernetes client: %s", err.Error()),
+		)
+		return nil
+	}
+	tflog.Warn(ctx, "The kubernetes backend is experimental.", nil)
+	return secrets
+}
+
+// configureVault creates the Vault client of the vault backend and authenticates it.
+func configureVault(ctx context.Context, config providerModel, userAgent string, diags *diag.Diagnostics) *vaultapi.VaultApi {
+	vaultConf, err := vaultConfig(config.Address, config.AgentAddress)
+	if err != nil {
+		diags.AddError(
+			"Error configuring provider",
+			fmt.Sprintf("Invalid Vault environment variables: %s", err.Error()),
+		)
+		return nil
+	}
+
+	if config.Transport != nil {
+		err := setupVaultClientTransport(vaultConf, config.Transport)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("transport"),
+				"Error configuring provider",
+				fmt.Sprintf("Invalid transport configuration: %s", err.Error()),
+			)
+			return nil
+		}
+	}
+
+	// Must be done before wrapping the transport, see dialUnixSocket
+	if err = dialUnixSocket(vaultConf); err != nil {
+		diags.AddError("Error configuring provider", fmt.Sprintf("Invalid unix socket address: %s", err.Error()))
+		return nil
+	}
+
+	// Instrumented before limiting concurrency, so that latencies don't include the wait for a slot
+	if config.Metrics.ValueBool() {
+		vaultapi.InstrumentRequests(vaultConf, logRequestMetric)
+	}
 
-	if !config.Address.IsNull() {
-		vaultConf.Address = config.Address.ValueString()
+	if !config.MaxConcurrent.IsNull() {
+		vaultapi.LimitConcurrentRequests(vaultConf, int(config.MaxConcurrent.ValueInt64()))
 	}
 
+	// Retries wrap the concurrency limit, so that requests waiting to be retried don't hold a slot
+	policy, err := retryPolicy(config.Retry, vaultConf.MaxRetries)
+	if err != nil {
+		diags.AddAttributeError(path.Root("retry"), "Error configuring provider", fmt.Sprintf("Invalid retry configuration: %s", err.Error()))
+		return nil
+	}
+	vaultapi.RetryRequests(vaultConf, policy, logRequestRetry)
+	vaultapi.RecordRequestIDs(vaultConf)
+
 	client, err := vault.NewClient(vaultConf)
 	if err != nil {
 		tflog.Error(ctx, "Error creating vault client", map[string]interface{}{"address": vaultConf.Address, "error": err})
-		resp.Diagnostics.AddError(
+		diags.AddError(
 			"Error configuring provider",
 			fmt.Sprintf("Can't create vault client for %s: %s", vaultConf.Address, err.Error()),
 		)
-		return
+		return nil
+	}
+
+	for k, v := range config.Headers.Elements() {
+		client.AddHeader(k, v.(types.String).ValueString())
+	}
+	if runID := terraformRunID(); runID != "" && client.Headers().Get(RunIDHeader) == "" {
+		client.AddHeader(RunIDHeader, runID)
+	}
+	if client.Headers().Get("User-Agent") == "" {
+		client.AddHeader("User-Agent", userAgent)
+	}
+
+	vaultApi := vaultapi.NewVaultApi(client)
+	if config.HealthCheck.ValueBool() {
+		if err := vaultApi.CheckHealth(ctx); err != nil {
+			addVaultError(diags, "Error configuring provider", "Vault health check failed", err)
+			return nil
+		}
 	}
 
 	authConf := config.Auth
@@ -119,22 +800,42 @@ func (p *vaultSecretProvider) Configure(ctx context.Context, req provider.Config
 		err = setupVaultClientAuth(client, authConf)
 		if err != nil {
 			tflog.Error(ctx, "Error while configuring vault client auth", map[string]interface{}{"address": vaultConf.Address, "error": err})
-			resp.Diagnostics.AddError(
+			diags.AddError(
 				"Error configuring provider",
 				fmt.Sprintf("Can't create vault client for %s: %s", vaultConf.Address, err.Error()),
 			)
 		}
 	}
 
-	// Still no token, let's try from the token helper
-	if client.Token() == "" {
+	// Still no token, let's try from the token helper. Not needed when a Vault Agent handles authentication
+	if client.Token() == "" && vaultConf.AgentAddress == "" {
 		if token, _ := vaultapi.TokenFromHelper(); token != "" { //Ignore error, it's best effort only
 			client.SetToken(token)
 		}
 	}
 
-	p.vaultApi = vaultapi.NewVaultApi(client)
-	resp.ResourceData = p.vaultApi
+	return vaultApi
+}
+
+// userAgent returns the User-Agent of the requests sent to Vault, identifying the provider and the Terraform version
+// running it, followed by suffix when set.
+func userAgent(providerVersion, terraformVersion, suffix string) string {
+	agent := fmt.Sprintf("terraform-provider-%s/%s", providerName, providerVersion)
+	if terraformVersion != "" {
+		agent += " terraform/" + terraformVersion
+	}
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		agent += " " + suffix
+	}
+	return agent
+}
+
+// terraformRunID returns the ID of the current Terraform run, as set by Terraform Cloud/Enterprise or by the user.
+func terraformRunID() string {
+	if runID := os.Getenv("TFC_RUN_ID"); runID != "" {
+		return runID
+	}
+	return os.Getenv("TF_RUN_ID")
 }
 
 func setupVaultClientAuth(client *vault.Client, authConf *providerAuthModel) error {
@@ -166,3 +867,137 @@ func setupVaultClientAuth(client *vault.Client, authConf *providerAuthModel) err
 
 	return nil
 }
+
+// vaultConfig builds the Vault client configuration. Standard Vault environment variables (VAULT_ADDR,
+// VAULT_MAX_RETRIES, VAULT_CACERT, VAULT_SRV_LOOKUP...) are honored as with the vault CLI, explicit attributes take
+// precedence over them.
+func vaultConfig(address, agentAddress types.String) (*vault.Config, error) {
+	vaultConf := vault.DefaultConfig()
+	if vaultConf.Error != nil {
+		return nil, vaultConf.Error
+	}
+
+	if !address.IsNull() {
+		vaultConf.Address = address.ValueString()
+		// VAULT_AGENT_ADDR would otherwise be used instead of the configured address
+		vaultConf.AgentAddress = ""
+	}
+
+	if !agentAddress.IsNull() {
+		vaultConf.AgentAddress = agentAddress.ValueString()
+	}
+
+	return vaultConf, nil
+}
+
+func setupVaultClientTransport(vaultConf *vault.Config, transportConf *providerTransportModel) error {
+	transport, ok := vaultConf.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected HTTP transport %T", vaultConf.HttpClient.Transport)
+	}
+
+	if !transportConf.ProxyURL.IsNull() {
+		proxyURL, err := url.Parse(transportConf.ProxyURL.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid proxy_url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if err := parseDuration(transportConf.DialTimeout, "dial_timeout", &dialer.Timeout); err != nil {
+		return err
+	}
+	if err := parseDuration(transportConf.KeepAlive, "keep_alive", &dialer.KeepAlive); err != nil {
+		return err
+	}
+	transport.DialContext = dialer.DialContext
+
+	if !transportConf.MaxIdleConns.IsNull() {
+		transport.MaxIdleConnsPerHost = int(transportConf.MaxIdleConns.ValueInt64())
+	}
+
+	return parseDuration(transportConf.IdleConnTimeout, "idle_conn_timeout", &transport.IdleConnTimeout)
+}
+
+// unixSocketHost is the host of the requests sent to a Vault server or agent listening on a unix socket.
+const unixSocketHost = "localhost"
+
+// dialUnixSocket makes the transport of vaultConf dial the unix socket of a unix:// address, or agent address, and
+// replaces the address with the HTTP URL of the requests sent over the socket. vault/api only handles unix:// addresses
+// when the client's transport is an *http.Transport, which isn't the case anymore once the provider's round trippers
+// (retries, request IDs, metrics...) wrap it. Other hosts, e.g. of the provider's endpoints, are still dialed as usual.
+func dialUnixSocket(vaultConf *vault.Config) error {
+	address := &vaultConf.Address
+	if vaultConf.AgentAddress != "" {
+		address = &vaultConf.AgentAddress
+	}
+	socket, ok := strings.CutPrefix(*address, "unix://")
+	if !ok {
+		return nil
+	}
+
+	transport, ok := vaultConf.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected HTTP transport %T", vaultConf.HttpClient.Transport)
+	}
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == net.JoinHostPort(unixSocketHost, "80") {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		return dial(ctx, network, addr)
+	}
+
+	*address = "http://" + unixSocketHost
+	return nil
+}
+
+// retryPolicy returns the retry policy configured by retryConf, defaultRetries being the budget of the operations not
+// configured.
+func retryPolicy(retryConf *providerRetryModel, defaultRetries int) (vaultapi.RetryPolicy, error) {
+	policy := vaultapi.RetryPolicy{
+		DefaultRetries: defaultRetries,
+		MinWait:        vaultapi.DefaultRetryMinWait,
+		MaxWait:        vaultapi.DefaultRetryMaxWait,
+	}
+	if retryConf == nil {
+		return policy, nil
+	}
+
+	policy.MaxRetries = make(map[string]int)
+	for operation, retries := range retryConf.MaxRetries.Elements() {
+		policy.MaxRetries[operation] = int(retries.(types.Int64).ValueInt64())
+	}
+	if err := parseDuration(retryConf.MinWait, "min_wait", &policy.MinWait); err != nil {
+		return policy, err
+	}
+	if err := parseDuration(retryConf.MaxWait, "max_wait", &policy.MaxWait); err != nil {
+		return policy, err
+	}
+	if policy.MinWait > policy.MaxWait {
+		return policy, fmt.Errorf("min_wait (%s) is greater than max_wait (%s)", policy.MinWait, policy.MaxWait)
+	}
+	return policy, nil
+}
+
+// parseDuration sets d from value when value is set
+func parseDuration(value types.String, name string, d *time.Duration) error {
+	if value.IsNull() {
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(value.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*d = parsed
+	return nil
+}
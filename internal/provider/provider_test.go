@@ -1,10 +1,31 @@
 package provider
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	vaultapi "github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	vault "github.com/hashicorp/vault/api"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 const (
@@ -30,7 +51,7 @@ provider "vaultprov" {
 // CLI command executed to create a provider server to which the CLI can
 // reattach.
 var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
-	"vaultprov": providerserver.NewProtocol6WithError(New()()),
+	"vaultprov": providerserver.NewProtocol6WithError(New("test")()),
 }
 
 func testAccPreCheck(t *testing.T) {
@@ -42,3 +63,377 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("VAULT_ADDR env var must be set for acceptance tests")
 	}
 }
+
+const (
+	// testAccAuthMount is where the Kubernetes authentication stub is mounted
+	testAccAuthMount = "kubernetes-acc"
+	testAccAuthRole  = "vaultprov"
+)
+
+func TestAccKubernetesAuth(t *testing.T) {
+	// The stub is set up before the test case, the JWT being part of the configuration
+	if os.Getenv(resource.EnvTfAcc) == "" {
+		t.Skipf("Acceptance tests skipped unless env '%s' set", resource.EnvTfAcc)
+	}
+	testAccPreCheck(t)
+	jwt := testAccKubernetesAuthStub(t)
+	// The provider must authenticate with the JWT only
+	t.Setenv(vault.EnvVaultToken, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccKubernetesAuthConfig(jwt),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vaultprov_random_secret.auth", "path", "/secret/acc/kubernetes-auth"),
+					resource.TestCheckResourceAttr("vaultprov_random_secret.auth", "length", "32"),
+				),
+			},
+		},
+	})
+}
+
+// testAccKubernetesAuthStub mounts a JWT auth method with the root token, standing in for the Kubernetes auth method:
+// both log in with a JWT and a role, but the JWT method validates tokens with a local key instead of a Kubernetes
+// TokenReview. It returns a JWT signed for the test role.
+func testAccKubernetesAuthStub(t *testing.T) string {
+	client, err := vault.NewClient(vault.DefaultConfig())
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if _, ok := auths[testAccAuthMount+"/"]; !ok {
+		if err = client.Sys().EnableAuthWithOptions(testAccAuthMount, &vault.EnableAuthOptions{Type: "jwt"}); err != nil {
+			t.Fatal("error:", err)
+		}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	err = client.Sys().PutPolicy(testAccAuthRole, `
+path "secret/*" {
+  capabilities = ["create", "read", "update", "delete", "list"]
+}
+`)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	_, err = client.Logical().Write("auth/"+testAccAuthMount+"/config", map[string]interface{}{
+		"jwt_validation_pubkeys": []string{string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))},
+		"bound_issuer":           "kubernetes/serviceaccount",
+	})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	_, err = client.Logical().Write("auth/"+testAccAuthMount+"/role/"+testAccAuthRole, map[string]interface{}{
+		"role_type":       "jwt",
+		"user_claim":      "sub",
+		"bound_audiences": []string{"vault"},
+		"bound_subject":   "system:serviceaccount:default:vaultprov",
+		"token_policies":  []string{testAccAuthRole},
+		"token_ttl":       "10m",
+	})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	return testAccSignJWT(t, key, map[string]interface{}{
+		"iss": "kubernetes/serviceaccount",
+		"sub": "system:serviceaccount:default:vaultprov",
+		"aud": "vault",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(30 * time.Minute).Unix(),
+	})
+}
+
+// testAccSignJWT returns a RS256 JWT with the given claims, as a Kubernetes service account token.
+func testAccSignJWT(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	encode := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	unsigned := encode(map[string]string{"alg": "RS256", "typ": "JWT"}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func testAccKubernetesAuthConfig(jwt string) string {
+	return fmt.Sprintf(`
+provider "vaultprov" {
+  auth = {
+    path = "auth/%s/login"
+    role = "%s"
+    jwt  = "%s"
+  }
+}
+
+resource "vaultprov_random_secret" "auth" {
+  path          = "/secret/acc/kubernetes-auth"
+  length        = 32
+  force_destroy = true
+  metadata = {
+    owner = "acc"
+  }
+}
+`, testAccAuthMount, testAccAuthRole, jwt)
+}
+
+func TestTerraformRunID(t *testing.T) {
+	t.Setenv("TFC_RUN_ID", "")
+	t.Setenv("TF_RUN_ID", "")
+	if runID := terraformRunID(); runID != "" {
+		t.Fatalf("Expected no run ID, got %s", runID)
+	}
+
+	t.Setenv("TF_RUN_ID", "local-run")
+	if runID := terraformRunID(); runID != "local-run" {
+		t.Fatalf("Wrong run ID: %s. Expected: local-run", runID)
+	}
+
+	t.Setenv("TFC_RUN_ID", "run-abc123")
+	if runID := terraformRunID(); runID != "run-abc123" {
+		t.Fatalf("Wrong run ID: %s. Expected: run-abc123", runID)
+	}
+}
+
+func TestConfigureTestRNG(t *testing.T) {
+	defer secrets.SetReader(rand.Reader, secrets.RNGSource)
+
+	t.Setenv(TestRNGSeedEnvVar, "42")
+	t.Setenv(acceptanceTestsEnvVar, "")
+	var diags diag.Diagnostics
+	configureTestRNG(&diags)
+	if diags.WarningsCount() != 1 || secrets.RNG() != secrets.RNGSource {
+		t.Fatalf("Expected the seed to be ignored with a warning outside acceptance tests, got %v (%s)", diags, secrets.RNG())
+	}
+
+	t.Setenv(acceptanceTestsEnvVar, "1")
+	diags = nil
+	configureTestRNG(&diags)
+	if diags.WarningsCount() != 1 || secrets.RNG() != secrets.SeededRNGSource {
+		t.Fatalf("Expected the seeded generator with a warning in acceptance tests, got %v (%s)", diags, secrets.RNG())
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		terraformVersion string
+		suffix           string
+		expected         string
+	}{
+		{"1.7.5", "", "terraform-provider-vaultprov/1.2.0 terraform/1.7.5"},
+		{"1.7.5", " team-billing ", "terraform-provider-vaultprov/1.2.0 terraform/1.7.5 team-billing"},
+		{"", "ci", "terraform-provider-vaultprov/1.2.0 ci"},
+	}
+	for _, tt := range tests {
+		if agent := userAgent("1.2.0", tt.terraformVersion, tt.suffix); agent != tt.expected {
+			t.Fatalf("Wrong User-Agent: %s. Expected: %s", agent, tt.expected)
+		}
+	}
+}
+
+func TestSetupVaultClientTransport(t *testing.T) {
+	vaultConf := vault.DefaultConfig()
+
+	err := setupVaultClientTransport(vaultConf, &providerTransportModel{
+		ProxyURL:        types.StringValue("http://proxy.internal:3128"),
+		DialTimeout:     types.StringValue("5s"),
+		KeepAlive:       types.StringNull(),
+		IdleConnTimeout: types.StringValue("2m"),
+		MaxIdleConns:    types.Int64Value(10),
+	})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	transport := vaultConf.HttpClient.Transport.(*http.Transport)
+	req, _ := http.NewRequest(http.MethodGet, "https://vault.internal:8200/v1/sys/health", nil)
+	proxyURL, err := transport.Proxy(req)
+	if err != nil || proxyURL.String() != "http://proxy.internal:3128" {
+		t.Fatalf("Wrong proxy: %v (%v). Expected: http://proxy.internal:3128", proxyURL, err)
+	}
+	if transport.IdleConnTimeout != 2*time.Minute {
+		t.Fatalf("Wrong idle connection timeout: %s. Expected: 2m", transport.IdleConnTimeout)
+	}
+	if transport.MaxIdleConnsPerHost != 10 {
+		t.Fatalf("Wrong max idle connections: %d. Expected: 10", transport.MaxIdleConnsPerHost)
+	}
+
+	err = setupVaultClientTransport(vault.DefaultConfig(), &providerTransportModel{
+		DialTimeout: types.StringValue("forever"),
+	})
+	if err == nil {
+		t.Fatalf("Expected an error for an invalid dial_timeout")
+	}
+}
+
+func TestVaultConfig(t *testing.T) {
+	t.Setenv(vault.EnvVaultAddress, "https://env.vault.internal:8200")
+	t.Setenv(vault.EnvVaultAgentAddr, "http://127.0.0.1:8100")
+	t.Setenv(vault.EnvVaultMaxRetries, "5")
+
+	vaultConf, err := vaultConfig(types.StringNull(), types.StringNull())
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if vaultConf.Address != "https://env.vault.internal:8200" || vaultConf.AgentAddress != "http://127.0.0.1:8100" {
+		t.Fatalf("Wrong addresses: %s, %s", vaultConf.Address, vaultConf.AgentAddress)
+	}
+	if vaultConf.MaxRetries != 5 {
+		t.Fatalf("Wrong max retries: %d. Expected: 5", vaultConf.MaxRetries)
+	}
+
+	vaultConf, err = vaultConfig(types.StringValue("https://vault.internal:8200"), types.StringNull())
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if vaultConf.Address != "https://vault.internal:8200" || vaultConf.AgentAddress != "" {
+		t.Fatalf("Wrong addresses: %s, %s. Expected the configured address only", vaultConf.Address, vaultConf.AgentAddress)
+	}
+
+	t.Setenv(vault.EnvVaultMaxRetries, "many")
+	if _, err = vaultConfig(types.StringNull(), types.StringNull()); err == nil {
+		t.Fatalf("Expected an error for an invalid %s", vault.EnvVaultMaxRetries)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy, err := retryPolicy(nil, 2)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if policy.DefaultRetries != 2 || policy.MaxRetries != nil || policy.MinWait != vaultapi.DefaultRetryMinWait || policy.MaxWait != vaultapi.DefaultRetryMaxWait {
+		t.Fatalf("Wrong default policy: %+v", policy)
+	}
+
+	policy, err = retryPolicy(&providerRetryModel{
+		MaxRetries: types.MapValueMust(types.Int64Type, map[string]attr.Value{"read": types.Int64Value(5), "write": types.Int64Value(0)}),
+		MinWait:    types.StringValue("1s"),
+		MaxWait:    types.StringNull(),
+	}, 2)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if policy.MaxRetries["read"] != 5 || policy.MaxRetries["write"] != 0 || len(policy.MaxRetries) != 2 || policy.MinWait != time.Second || policy.MaxWait != vaultapi.DefaultRetryMaxWait {
+		t.Fatalf("Wrong policy: %+v", policy)
+	}
+
+	_, err = retryPolicy(&providerRetryModel{
+		MaxRetries: types.MapNull(types.Int64Type),
+		MinWait:    types.StringValue("1m"),
+		MaxWait:    types.StringValue("10s"),
+	}, 2)
+	if err == nil {
+		t.Fatalf("Expected an error for min_wait greater than max_wait")
+	}
+}
+
+func TestCheckTokenTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		lookup   string
+		renewed  int
+		warnings int
+		renewal  bool
+	}{
+		{"long enough", `{"data":{"ttl":3600,"renewable":false}}`, 0, 0, false},
+		{"no expiry", `{"data":{"ttl":0,"renewable":false}}`, 0, 0, false},
+		{"not renewable", `{"data":{"ttl":60,"renewable":false}}`, 0, 1, false},
+		{"renewed", `{"data":{"ttl":60,"renewable":true}}`, 3600, 0, true},
+		{"max ttl reached", `{"data":{"ttl":60,"renewable":true}}`, 120, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var renewal bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/auth/token/lookup-self":
+					_, _ = w.Write([]byte(tt.lookup))
+				case "/v1/auth/token/renew-self":
+					renewal = true
+					_, _ = fmt.Fprintf(w, `{"auth":{"lease_duration":%d,"renewable":true}}`, tt.renewed)
+				}
+			}))
+			defer server.Close()
+
+			conf := vault.DefaultConfig()
+			conf.Address = server.URL
+			client, err := vault.NewClient(conf)
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+
+			var diags diag.Diagnostics
+			checkTokenTTL(context.Background(), vaultapi.NewVaultApi(client), types.StringValue("30m"), &diags)
+			if diags.HasError() || diags.WarningsCount() != tt.warnings {
+				t.Fatalf("Wrong diagnostics: %v. Expected %d warnings", diags, tt.warnings)
+			}
+			if renewal != tt.renewal {
+				t.Fatalf("Wrong renewal: %v. Expected: %v", renewal, tt.renewal)
+			}
+		})
+	}
+}
+
+func TestConfigureUnixSocket(t *testing.T) {
+	t.Setenv(vault.EnvVaultAddress, "")
+	t.Setenv(vault.EnvVaultAgentAddr, "")
+
+	tests := []struct {
+		name   string
+		config func(socket string) map[string]tftypes.Value
+	}{
+		{"address", func(socket string) map[string]tftypes.Value {
+			return map[string]tftypes.Value{"address": tftypes.NewValue(tftypes.String, "unix://"+socket)}
+		}},
+		// The agent address takes precedence over the address, unreachable here
+		{"agent_address", func(socket string) map[string]tftypes.Value {
+			return map[string]tftypes.Value{
+				"address":       tftypes.NewValue(tftypes.String, "http://127.0.0.1:1"),
+				"agent_address": tftypes.NewValue(tftypes.String, "unix://"+socket),
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv, socket := newUnixFakeKV(t)
+			// Retries, request IDs, metrics and concurrency limit all wrap the transport dialing the socket
+			r := newConfiguredTestResource(t, "vaultprov_random_secret", func(providerType tftypes.Object) map[string]tftypes.Value {
+				config := tt.config(socket)
+				config["token"] = tftypes.NewValue(tftypes.String, "test")
+				config["metrics"] = tftypes.NewValue(tftypes.Bool, true)
+				config["max_concurrent_requests"] = tftypes.NewValue(tftypes.Number, 4)
+				return config
+			})
+
+			r.apply(r.config(map[string]tftypes.Value{
+				"path": tftypes.NewValue(tftypes.String, "secret/foo"),
+			}))
+			if kv.value("foo") == "" {
+				t.Fatal("Secret not written through the unix socket")
+			}
+		})
+	}
+}
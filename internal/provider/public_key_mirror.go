@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// PGPPublicKeyType is the secret_type of the secrets holding a copy of the public key of a PGP key
+	PGPPublicKeyType = "pgp_public_key"
+	// PublishedFromMetadata holds the path of the PGP key secret a public key has been published from
+	PublishedFromMetadata = "published_from"
+)
+
+func publishPublicKeyToAttribute() schema.ListAttribute {
+	return schema.ListAttribute{
+		ElementType: types.StringType,
+		Optional:    true,
+		Validators: []validator.List{
+			listvalidator.UniqueValues(),
+			listvalidator.ValueStringsAre(validators.SecretPath()),
+		},
+		MarkdownDescription: "Paths of Vault KV v2 secrets the public key is published to, e.g. in a mount readable by the consumers of the key while the private key stays in a restricted mount. Each secret holds the ASCII armored public key under the `public_key` key, with the custom metadata `secret_type` (`" + PGPPublicKeyType + "`), `pgp_fingerprint` and `" + PublishedFromMetadata + "` (the `path` of the key). Secrets are overwritten when they hold a public key published from the same `path`, creating the resource fails on any other existing secret. A published secret is deleted when its path is removed from the list or when the resource is destroyed, and published again when it was deleted outside Terraform.",
+	}
+}
+
+// publishPublicKey writes publicKey to the secrets at targets, marked as published from the key secret at keyPath.
+// Targets already holding the public key are left untouched.
+func publishPublicKey(ctx context.Context, vaultApi *vault.VaultApi, keyPath, publicKey, fingerprint string, targets []string, ownership map[string]string, diags *diag.Diagnostics) {
+	source := vault.NormalizePath(keyPath)
+	for _, target := range targets {
+		metadata := map[string]string{
+			SecretTypeMetadata:     PGPPublicKeyType,
+			PGPFingerprintMetadata: fingerprint,
+			PublishedFromMetadata:  source,
+		}
+		data := map[string]interface{}{PGPPublicKeyDataKey: publicKey}
+
+		existing, err := vaultApi.ReadSecretMetadata(ctx, target)
+		var deletedErr *vault.SecretDeletedError
+		if errors.As(err, &deletedErr) {
+			diags.AddError("Error publishing public key", fmt.Sprintf("The latest version of secret %s has been deleted outside Terraform. Undelete it, or delete the secret with its metadata (`vault kv metadata delete`) so that the public key is published again.", target))
+			return
+		}
+		if err != nil {
+			addVaultError(diags, "Error publishing public key", fmt.Sprintf("Error while reading secret %s", target), err)
+			return
+		}
+
+		if existing == nil {
+			addOwnershipMetadata(metadata, ownership)
+			if _, err = vaultApi.CreateSecret(ctx, vault.Secret{Path: target, Data: data, Metadata: metadata}); err != nil {
+				addVaultError(diags, "Error publishing public key", fmt.Sprintf("Couldn't create secret %s", target), err)
+				return
+			}
+			continue
+		}
+
+		if existing.Metadata[PublishedFromMetadata] != source {
+			diags.AddError("Error publishing public key", fmt.Sprintf("Secret %s already exists and doesn't hold a public key published from %s. Remove it or publish the public key to another path.", target, source))
+			return
+		}
+		if existing.Metadata[PGPFingerprintMetadata] == fingerprint {
+			continue
+		}
+
+		// Published by a key previously generated at the same path
+		if err = vaultApi.UpdateSecretData(ctx, target, data, existing.Version); err != nil {
+			addVaultError(diags, "Error publishing public key", fmt.Sprintf("Couldn't write secret %s", target), err)
+			return
+		}
+		if err = vaultApi.UpdateSecretMetadata(ctx, target, metadata, nil); err != nil {
+			addVaultError(diags, "Error publishing public key", fmt.Sprintf("Couldn't update metadata of secret %s", target), err)
+			return
+		}
+	}
+}
+
+// unpublishPublicKey deletes the secrets at targets holding the public key with the given fingerprint published from
+// the key secret at keyPath. Secrets published by another key, e.g. the replacement of the key created before it was
+// destroyed, are left untouched.
+func unpublishPublicKey(ctx context.Context, vaultApi *vault.VaultApi, keyPath, fingerprint string, targets []string, diags *diag.Diagnostics) {
+	for _, target := range publishedPublicKeys(ctx, vaultApi, keyPath, fingerprint, targets, diags) {
+		if err := vaultApi.DeleteSecret(ctx, target); err != nil {
+			addVaultError(diags, "Error deleting published public key", fmt.Sprintf("Error while deleting secret %s", target), err)
+		}
+	}
+}
+
+// publishedPublicKeys returns, in order, the targets holding the public key with the given fingerprint published from
+// the key secret at keyPath.
+func publishedPublicKeys(ctx context.Context, vaultApi *vault.VaultApi, keyPath, fingerprint string, targets []string, diags *diag.Diagnostics) []string {
+	source := vault.NormalizePath(keyPath)
+	published := make([]string, 0, len(targets))
+	for _, target := range targets {
+		secret, err := vaultApi.ReadSecretMetadata(ctx, target)
+		var deletedErr *vault.SecretDeletedError
+		if errors.As(err, &deletedErr) {
+			continue
+		}
+		if err != nil {
+			addVaultError(diags, "Error reading published public key", fmt.Sprintf("Error while reading secret %s", target), err)
+			return nil
+		}
+		if isPublishedPublicKey(secret, source, fingerprint) {
+			published = append(published, target)
+		}
+	}
+	return published
+}
+
+func isPublishedPublicKey(secret *vault.Secret, source, fingerprint string) bool {
+	return secret != nil && secret.Metadata[PublishedFromMetadata] == source && secret.Metadata[PGPFingerprintMetadata] == fingerprint
+}
+
+// removedTargets returns the targets of prior missing from planned.
+func removedTargets(prior, planned []string) []string {
+	kept := make(map[string]bool, len(planned))
+	for _, target := range planned {
+		kept[vault.NormalizePath(target)] = true
+	}
+	var removed []string
+	for _, target := range prior {
+		if !kept[vault.NormalizePath(target)] {
+			removed = append(removed, target)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,16 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// checkReadOnly reports an error when the provider is in read-only mode, operation (e.g. `create`) writing to the
+// backend. Terraform attaches the address of the resource to the diagnostic.
+func checkReadOnly(diags *diag.Diagnostics, readOnly bool, operation string) {
+	if !readOnly {
+		return
+	}
+	diags.AddError("Provider in read-only mode", fmt.Sprintf("Can't %s the resource: the provider is configured with `read_only = true`, only reads and imports are allowed. Apply the configuration with a provider not in read-only mode.", operation))
+}
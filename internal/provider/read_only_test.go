@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccReadOnly(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "vaultprov" {
+  read_only = true
+}
+
+resource "vaultprov_random_secret" "test" {
+  path          = "/secret/acc/read-only"
+  force_destroy = true
+}
+`,
+				ExpectError: regexp.MustCompile("Provider in read-only mode"),
+			},
+		},
+	})
+}
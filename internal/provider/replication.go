@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	ReplicatedRandomSecretType = "replicated_random_secret"
+
+	// Status of the copy of a replicated secret on one of its targets, see target_status
+	TargetInSync  = "in_sync"
+	TargetDrifted = "drifted"
+	TargetMissing = "missing"
+)
+
+// replicaStatus tells if secret, the copy of a replicated secret read on a target (nil when missing), holds the value
+// whose fingerprint is keyFingerprint.
+func replicaStatus(secret *vault.Secret, valueType, keyFingerprint string) string {
+	if secret == nil {
+		return TargetMissing
+	}
+
+	value, _ := secret.Data[SecretDataKey].(string)
+	key, err := decodeRandomValue(RawSecretFormat, valueType, value)
+	if err != nil {
+		return TargetDrifted
+	}
+	defer secrets.Wipe(key)
+
+	if secrets.Fingerprint(key) != keyFingerprint {
+		return TargetDrifted
+	}
+	return TargetInSync
+}
+
+// readReplica reads the copy of a replicated secret on a target. A copy whose latest version is deleted is reported as
+// missing, with deleted set.
+func readReplica(ctx context.Context, api *vault.VaultApi, secretPath string) (secret *vault.Secret, deleted bool, err error) {
+	secret, err = api.ReadSecret(ctx, secretPath)
+	var deletedErr *vault.SecretDeletedError
+	if errors.As(err, &deletedErr) {
+		return nil, true, nil
+	}
+	return secret, false, err
+}
+
+// isReplicatedSecretMetadata tells if key is one of the custom metadata describing a replicated secret.
+func isReplicatedSecretMetadata(key string) bool {
+	switch key {
+	case SecretTypeMetadata, SecretLengthMetadata, SecretValueTypeMetadata:
+		return true
+	}
+	return false
+}
+
+// targetNames returns the names of the endpoints of targets, sorted so that copies are always written in the same
+// order.
+func targetNames(targets types.Set) []string {
+	names := make([]string, 0, len(targets.Elements()))
+	for _, target := range targets.Elements() {
+		names = append(names, target.(types.String).ValueString())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// syncedTargetStatus returns the target_status of targets once every copy is in sync.
+func syncedTargetStatus(targets []string) types.Map {
+	status := make(map[string]attr.Value, len(targets))
+	for _, target := range targets {
+		status[target] = types.StringValue(TargetInSync)
+	}
+	return types.MapValueMust(types.StringType, status)
+}
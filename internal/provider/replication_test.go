@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestReplicaStatus(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	fingerprint := secrets.Fingerprint(key)
+	replica := func(value string) *vault.Secret {
+		return &vault.Secret{Path: "secret/foo", Data: map[string]interface{}{SecretDataKey: value}}
+	}
+
+	tests := []struct {
+		name     string
+		secret   *vault.Secret
+		expected string
+	}{
+		{"same value", replica(encodeRandomValue(RawSecretFormat, BytesValueType, key)), TargetInSync},
+		{"other value", replica(encodeRandomValue(RawSecretFormat, BytesValueType, []byte("fedcba9876543210fedcba9876543210"))), TargetDrifted},
+		{"not decodable", replica("not base64!"), TargetDrifted},
+		{"no value", &vault.Secret{Path: "secret/foo", Data: map[string]interface{}{}}, TargetDrifted},
+		{"missing", nil, TargetMissing},
+	}
+	for _, test := range tests {
+		if status := replicaStatus(test.secret, BytesValueType, fingerprint); status != test.expected {
+			t.Fatalf("Wrong status for %s: %s. Expected: %s", test.name, status, test.expected)
+		}
+	}
+}
+
+func TestTargetNames(t *testing.T) {
+	targets := types.SetValueMust(types.StringType, []attr.Value{
+		types.StringValue("us-east"),
+		types.StringValue("eu-west"),
+		types.StringValue("ap-south"),
+	})
+
+	names := targetNames(targets)
+	expected := []string{"ap-south", "eu-west", "us-east"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Wrong target names: %v. Expected: %v", names, expected)
+	}
+
+	status := syncedTargetStatus(names)
+	if len(status.Elements()) != 3 || !status.Elements()["eu-west"].Equal(types.StringValue(TargetInSync)) {
+		t.Fatalf("Wrong target status: %s. Expected every target %s", status, TargetInSync)
+	}
+}
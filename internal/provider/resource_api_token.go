@@ -0,0 +1,850 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"regexp"
+	"strconv"
+)
+
+const (
+	APITokenType             = "api_token"
+	APITokenPrefixMetadata   = "api_token_prefix"
+	APITokenChecksumMetadata = "api_token_checksum"
+	APITokenDataKey          = "token"
+	DefaultAPITokenLength    = 32
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &APIToken{}
+var _ resource.ResourceWithImportState = &APIToken{}
+var _ resource.ResourceWithModifyPlan = &APIToken{}
+var _ resource.ResourceWithUpgradeState = &APIToken{}
+
+type APIToken struct {
+	vaultApi        *vault.VaultApi
+	providerVersion string
+	maxSecretLength int64
+	strict          bool
+	readOnly        bool
+	ownership       map[string]string
+	history         bool
+	ignoredMetadata []string
+	fipsMode        bool
+	kvMounts        []vault.KVMount
+	storage         *storageEstimate
+	createMounts    bool
+}
+
+type apiTokenModel struct {
+	ID                 types.String         `tfsdk:"id"`
+	Path               secretPathValue      `tfsdk:"path"`
+	Prefix             types.String         `tfsdk:"prefix"`
+	Length             types.Int64          `tfsdk:"length"`
+	Checksum           types.Bool           `tfsdk:"checksum"`
+	LookupHash         types.String         `tfsdk:"lookup_hash"`
+	Metadata           types.Map            `tfsdk:"metadata"`
+	ForceDestroy       types.Bool           `tfsdk:"force_destroy"`
+	OnDeletedVersion   types.String         `tfsdk:"on_deleted_version"`
+	DeleteAllVersions  types.Bool           `tfsdk:"delete_all_versions"`
+	DeleteMetadata     types.Bool           `tfsdk:"delete_metadata"`
+	DeletionProtection types.Bool           `tfsdk:"deletion_protection"`
+	DestroyAfter       types.String         `tfsdk:"destroy_after"`
+	RestoreDeleted     types.Bool           `tfsdk:"restore_deleted"`
+	ExternalSecret     *externalSecretModel `tfsdk:"external_secret"`
+	PolicyTemplate     *policyTemplateModel `tfsdk:"policy_template"`
+	EscrowPublicKey    types.String         `tfsdk:"escrow_public_key"`
+	EscrowCiphertext   types.String         `tfsdk:"escrow_ciphertext"`
+	VersionsKept       types.Int64          `tfsdk:"versions_kept"`
+	Version            types.Int64          `tfsdk:"version"`
+	UseLatestVersion   types.Bool           `tfsdk:"use_latest_version"`
+	CasVersion         types.Bool           `tfsdk:"cas_version"`
+	DataPath           types.String         `tfsdk:"data_path"`
+	MetadataPath       types.String         `tfsdk:"metadata_path"`
+	ExtraHeaders       types.Map            `tfsdk:"extra_headers"`
+	Timeouts           timeouts.Value       `tfsdk:"timeouts"`
+}
+
+func NewAPIToken() resource.Resource {
+	return &APIToken{}
+}
+
+func (r *APIToken) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "api_token")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.vaultApi = data.vaultApi
+	r.providerVersion = data.version
+	r.strict = data.strict
+	r.readOnly = data.readOnly
+	r.ownership = data.ownership
+	r.history = data.history
+	r.ignoredMetadata = data.ignoredMetadata
+	r.fipsMode = data.fipsMode
+	r.kvMounts = data.kvMounts
+	r.storage = data.storage
+	r.createMounts = data.createMounts
+	r.maxSecretLength = data.maxSecretLength
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (r *APIToken) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *APIToken {
+	clone := *r
+	clone.vaultApi = vaultApiWithHeaders(r.vaultApi, extraHeaders, diags)
+	return &clone
+}
+
+func (r *APIToken) ImportState(ctx context.Context, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
+	importSecret(ctx, r.vaultApi, APITokenType, r.history, request, response)
+}
+
+// UpgradeState migrates states stored with a prior schema version, see apiTokenSchemaVersion.
+func (r *APIToken) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: rawStateUpgrader(normalizeSecretPathID),
+	}
+}
+
+func (r *APIToken) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_api_token"
+}
+
+func (r *APIToken) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: apiTokenSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret. For a nested secret the name is the nested path excluding the mount and data prefix. For example, for a secret at `keys/data/foo/bar/baz` the name is `foo/bar/baz`. Serves as the secret id.",
+			},
+			"prefix": schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[A-Za-z0-9_-]*$`), "must only contain URL-safe characters (letters, digits, '_' and '-')"),
+				},
+				MarkdownDescription: "A prefix identifying the kind of token, for example `sk_live_`. This information will be stored as a custom metadata under the key `api_token_prefix`",
+			},
+			"length": schema.Int64Attribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					planmodifiers.Int64DefaultValue(types.Int64Value(DefaultAPITokenLength)),
+					int64planmodifier.RequiresReplace(),
+				},
+				Validators: []validator.Int64{
+					int64validator.AtLeast(16),
+				},
+				MarkdownDescription: "The number of random base62 characters of the token, excluding prefix and checksum. Default is 32. This information will be stored as a custom metadata under the key `secret_length`",
+			},
+			"checksum": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(true)),
+					boolplanmodifier.RequiresReplace(),
+				},
+				MarkdownDescription: "If set to `true`, a 6 characters base62 CRC32 checksum of the token is appended to it (as done by GitHub tokens), so that typos and fake tokens can be detected without a lookup. Default is `true`. This information will be stored as a custom metadata under the key `api_token_checksum`",
+			},
+			"lookup_hash": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "The hex encoded SHA-256 of the token, to be stored in server side verification tables in place of the token itself.",
+			},
+			"metadata": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					validators.CustomMetadata(MaxUserMetadata),
+				},
+				MarkdownDescription: "A map of key/value strings that will be stored along the secret as custom metadata. Vault limits keys to 128 bytes and values to 512 bytes. At most 48 entries are allowed, the remaining custom metadata being reserved for the provider.",
+			},
+			"force_destroy": schema.BoolAttribute{
+				Optional:            true,
+				Required:            false,
+				MarkdownDescription: "If set to `true`, removing the resource will delete the secret and all versions in Vault. If set to `false` or not defined, removing the resource will fail.",
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+			},
+			"delete_all_versions": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(true)),
+				},
+				MarkdownDescription: "If set to `false`, removing the resource only deletes the versions of the secret written by the provider (listed in the custom metadata `managed_versions`), leaving the versions written by other systems and the secret's metadata intact. Meant for shared paths that predate Terraform management. `destroy_after` is ignored in that case. Default is `true`.",
+			},
+			"delete_metadata": deleteMetadataAttribute(),
+			"deletion_protection": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true`, the secret can't be deleted, even with `force_destroy` set to `true`. The flag must first be set to `false` and applied. This information will be stored as a custom metadata under the key `deletion_protection`, deletion is also refused when this metadata is set directly in Vault.",
+			},
+			"destroy_after": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Grace period (e.g. `72h`) before the secret is deleted when the resource is destroyed. Instead of being deleted right away, the secret's `delete_version_after` is set so that Vault deletes it once the period is over, and the deletion date is stored as a custom metadata under the key `scheduled_destroy_at`. Gives a recovery window after an accidental destroy.",
+			},
+			"restore_deleted": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true` and the latest version of the secret at `path` has been deleted (but not destroyed), it is restored on creation instead of generating a new secret. The deleted secret must have been generated with the same parameters. Default is `false`.",
+			},
+			"external_secret":    externalSecretAttribute(),
+			"policy_template":    policyTemplateAttribute(),
+			"escrow_public_key":  escrowPublicKeyAttribute(),
+			"escrow_ciphertext":  escrowCiphertextAttribute(),
+			"version":            versionAttribute(),
+			"use_latest_version": useLatestVersionAttribute(),
+			"cas_version":        casVersionAttribute(),
+			"on_deleted_version": onDeletedVersionAttribute(),
+			"data_path":          dataPathAttribute(),
+			"metadata_path":      metadataPathAttribute(),
+			"versions_kept": schema.Int64Attribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Number of versions of the secret retained by Vault (bounded by the secret's `max_versions`), i.e. how many rotations can still be rolled back.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "A URL-safe random API token stored in a Vault secret. Only a lookup hash of the token is exposed in Terraform. The resulting Vault secret will have a custom metadata `secret_type` with the value `api_token`, a custom metadata `secret_length` with the same value as the `length` attribute and custom metadata `api_token_prefix` and `api_token_checksum`.",
+	}
+}
+
+func (r *APIToken) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource, the deletion is summarized for reviewers
+	if req.Plan.Raw.IsNull() {
+		var state apiTokenModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() {
+			warnDestroy(&resp.Diagnostics, secretDestroy{
+				paths:              []string{state.Path.ValueString()},
+				forceDestroy:       state.ForceDestroy,
+				deletionProtection: state.DeletionProtection,
+				deleteAllVersions:  state.DeleteAllVersions,
+				deleteMetadata:     state.DeleteMetadata,
+				destroyAfter:       state.DestroyAfter,
+				versionsKept:       state.VersionsKept,
+			})
+		}
+		return
+	}
+
+	var plan apiTokenModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	checkKVMount(&resp.Diagnostics, r.kvMounts, r.createMounts, plan.Path.StringValue)
+	checkIgnoredMetadata(&resp.Diagnostics, r.ignoredMetadata, plan.Metadata)
+	checkDeleteMetadata(&resp.Diagnostics, plan.DeleteMetadata, plan.DeleteAllVersions, plan.DestroyAfter)
+	checkFIPSMode(&resp.Diagnostics, r.fipsMode, "escrow_public_key", plan.EscrowPublicKey, fipsEscrowReason)
+	r.storage.add(&resp.Diagnostics, plan.Path.StringValue, apiTokenSize(plan))
+
+	if !req.State.Raw.IsNull() {
+		var state apiTokenModel
+		diags = req.State.Get(ctx, &state)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		planVersion(ctx, state.UseLatestVersion, plan.UseLatestVersion, resp)
+		planDeletedVersion(ctx, req.Private, plan.OnDeletedVersion, resp)
+		planEscrow(ctx, state.EscrowPublicKey, plan.EscrowPublicKey, resp)
+
+		// Existing secrets are not affected by a lower limit as long as they are not re-created
+		if state.Length.Equal(plan.Length) {
+			return
+		}
+	}
+
+	checkSecretLength(&resp.Diagnostics, plan.Length, r.maxSecretLength)
+}
+
+func (r *APIToken) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan *apiTokenModel
+
+	// Retrieve values from plan
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	ensureKVMount(ctx, r.vaultApi, r.createMounts, plan.Path.ValueString(), &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	checkSecretLength(&response.Diagnostics, plan.Length, r.maxSecretLength)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	// Restore the deleted secret, if any, instead of generating a new one
+	if plan.RestoreDeleted.ValueBool() {
+		restored := r.restore(ctx, plan, response.Private, &response.Diagnostics)
+		if response.Diagnostics.HasError() {
+			return
+		}
+		if restored {
+			applyPolicyTemplate(ctx, r.vaultApi, plan.Path.ValueString(), nil, plan.PolicyTemplate, &response.Diagnostics)
+			plan.ID = secretPathID(plan.Path)
+
+			diags = response.State.Set(ctx, &plan)
+			response.Diagnostics.Append(diags...)
+			return
+		}
+	}
+
+	prefix := plan.Prefix.ValueString()
+	tokenLength := int(plan.Length.ValueInt64())
+	checksum := plan.Checksum.ValueBool()
+
+	token, err := secrets.GenerateAPIToken(prefix, tokenLength, checksum)
+	if err != nil {
+		response.Diagnostics.AddError("Error creating API token", fmt.Sprintf("Couldn't generate API token, unexpected error: %s", err.Error()))
+		return
+	}
+
+	// Prepare metadata
+	customMetadata := make(map[string]string)
+	if !plan.Metadata.IsNull() {
+		for k, v := range plan.Metadata.Elements() {
+			customMetadata[k] = v.(types.String).ValueString()
+		}
+	}
+	customMetadata[SecretTypeMetadata] = APITokenType
+	customMetadata[SecretLengthMetadata] = fmt.Sprintf("%d", tokenLength)
+	customMetadata[APITokenPrefixMetadata] = prefix
+	customMetadata[APITokenChecksumMetadata] = strconv.FormatBool(checksum)
+
+	generation := generationParams{
+		Generator:       secrets.APITokenGenerator,
+		RNG:             secrets.RNG(),
+		ProviderVersion: r.providerVersion,
+	}
+	generation.addMetadata(customMetadata)
+	addOwnershipMetadata(customMetadata, r.ownership)
+	addHistoryMetadata(customMetadata, r.history, HistoryCreated)
+	if plan.DeletionProtection.ValueBool() {
+		customMetadata[DeletionProtectionMetadata] = "true"
+	}
+	externalSecretMetadata(customMetadata, nil, plan.ExternalSecret)
+
+	data := map[string]interface{}{
+		APITokenDataKey: token,
+	}
+
+	secret := vault.Secret{
+		Path:     plan.Path.ValueString(),
+		Data:     data,
+		Metadata: customMetadata,
+	}
+
+	plan.DataPath, plan.MetadataPath = secretAPIPaths(ctx, r.vaultApi, secret.Path, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.EscrowCiphertext = escrowSecret(plan.EscrowPublicKey, data, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	secret.CreateID = newCreateID(&response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	defer keepFailedCreate(ctx, r.vaultApi, plan.Path, secret.CreateID, response)
+
+	version, err := createSecret(ctx, r.vaultApi, secret, plan.OnDeletedVersion)
+	if err != nil {
+		addVaultError(&response.Diagnostics, "Error creating API token", "Couldn't create Vault secret", err)
+		return
+	}
+
+	plan.LookupHash = types.StringValue(secrets.TokenLookupHash(token))
+
+	plan.VersionsKept = types.Int64Value(1)
+	plan.Version = types.Int64Value(int64(version))
+
+	diags = setGenerationPrivateState(ctx, response.Private, generation)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	applyPolicyTemplate(ctx, r.vaultApi, plan.Path.ValueString(), nil, plan.PolicyTemplate, &response.Diagnostics)
+	plan.ID = secretPathID(plan.Path)
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+// restore restores the deleted API token at the resource's path, when there's one, instead of generating a new one.
+func (r *APIToken) restore(ctx context.Context, plan *apiTokenModel, private privateState, diags *diag.Diagnostics) bool {
+	secretPath := plan.Path.ValueString()
+
+	managed := map[string]string{
+		SecretTypeMetadata:       APITokenType,
+		SecretLengthMetadata:     plan.Length.String(),
+		APITokenPrefixMetadata:   plan.Prefix.ValueString(),
+		APITokenChecksumMetadata: strconv.FormatBool(plan.Checksum.ValueBool()),
+	}
+
+	restored := restoreDeletedSecret(ctx, r.vaultApi, secretPath, managed, diags)
+	if restored == nil {
+		return false
+	}
+
+	token, ok := restored.Data[APITokenDataKey].(string)
+	if !ok {
+		diags.AddError("Error restoring API token", fmt.Sprintf("Restored secret %s has no %s field", secretPath, APITokenDataKey))
+		return false
+	}
+
+	externalSecretMetadata(managed, nil, plan.ExternalSecret)
+	adoptRestoredSecret(ctx, r.vaultApi, restored, plan.Metadata, plan.DeletionProtection, managed, r.history, private, diags)
+	plan.VersionsKept = types.Int64Value(int64(restored.VersionsKept))
+	plan.Version = secretVersion(restored, plan.UseLatestVersion)
+	plan.EscrowCiphertext = escrowSecret(plan.EscrowPublicKey, restored.Data, diags)
+	plan.DataPath = types.StringValue(restored.DataPath)
+	plan.MetadataPath = types.StringValue(restored.MetadataPath)
+
+	plan.LookupHash = types.StringValue(secrets.TokenLookupHash(token))
+	return !diags.HasError()
+}
+
+func (r *APIToken) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state
+	var data apiTokenModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The partial state of a failed creation is kept as is, until the resource is replaced
+	if failedCreateID(ctx, req.Private, &resp.Diagnostics) != "" || resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	secretPath := data.Path.ValueString()
+
+	// Secret data is only needed to compute the lookup hash when it's missing from state, i.e. after an import
+	var secret *vault.Secret
+	var err error
+	if data.LookupHash.IsNull() {
+		secret, err = r.vaultApi.ReadSecret(ctx, secretPath)
+	} else {
+		secret, err = r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	}
+	if err != nil {
+		secret, err = readDeletedVersion(ctx, r.vaultApi, data.OnDeletedVersion, resp.Private, err, &resp.Diagnostics)
+	} else {
+		resp.Diagnostics.Append(clearDeletedVersion(ctx, resp.Private)...)
+	}
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+
+	if secret == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(setUpdatedTimePrivateState(ctx, resp.Private, secret)...)
+
+	if secret.Data != nil {
+		token, ok := secret.Data[APITokenDataKey].(string)
+		if !ok {
+			resp.Diagnostics.AddError("Error reading API token", fmt.Sprintf("Secret %s has no %s field", secretPath, APITokenDataKey))
+			return
+		}
+		data.LookupHash = types.StringValue(secrets.TokenLookupHash(token))
+	}
+
+	customMetadata := secret.Metadata
+
+	data.VersionsKept = types.Int64Value(int64(secret.VersionsKept))
+	data.Version = secretVersion(secret, data.UseLatestVersion)
+	data.DataPath = types.StringValue(secret.DataPath)
+	data.MetadataPath = types.StringValue(secret.MetadataPath)
+	data.DeletionProtection = types.BoolValue(false)
+
+	additionalMetadata := make(map[string]attr.Value)
+	for k, v := range customMetadata {
+		if isGenerationMetadata(k) || isExternalSecretMetadata(k) || isStampMetadata(k) || isOwnershipMetadata(k) || isHistoryMetadata(k) {
+			continue
+		}
+		switch k {
+		case DeletionProtectionMetadata:
+			data.DeletionProtection = types.BoolValue(v == "true")
+			continue
+		case SecretTypeMetadata:
+			continue
+		case SecretLengthMetadata:
+			len, err := strconv.Atoi(v)
+			if err != nil {
+				resp.Diagnostics.AddError("Error reading token length: "+v, fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
+				return
+			}
+			data.Length = types.Int64Value(int64(len))
+			continue
+		case APITokenPrefixMetadata:
+			if v != "" {
+				data.Prefix = types.StringValue(v)
+			}
+			continue
+		case APITokenChecksumMetadata:
+			checksum, err := strconv.ParseBool(v)
+			if err != nil {
+				resp.Diagnostics.AddError("Error reading token checksum: "+v, fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
+				return
+			}
+			data.Checksum = types.BoolValue(checksum)
+			continue
+		}
+		additionalMetadata[k] = types.StringValue(v)
+	}
+	data.Metadata = metadataValue(data.Metadata, additionalMetadata, r.ignoredMetadata)
+	data.ExternalSecret = externalSecretValue(data.ExternalSecret, customMetadata)
+
+	diags = syncGenerationPrivateState(ctx, resp.Private, customMetadata)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only path is set in state when importing an existing resource
+	data.ID = secretPathID(data.Path)
+
+	// ForceDestroy may be null in state when importing an existing resource
+	if data.ForceDestroy.IsNull() {
+		data.ForceDestroy = types.BoolValue(false)
+	}
+	if data.OnDeletedVersion.IsNull() {
+		data.OnDeletedVersion = types.StringValue(OnDeletedVersionError)
+	}
+	if data.DeleteAllVersions.IsNull() {
+		data.DeleteAllVersions = types.BoolValue(true)
+	}
+	if data.DeleteMetadata.IsNull() {
+		data.DeleteMetadata = types.BoolValue(false)
+	}
+	if data.UseLatestVersion.IsNull() {
+		data.UseLatestVersion = types.BoolValue(false)
+	}
+	if data.CasVersion.IsNull() {
+		data.CasVersion = types.BoolValue(false)
+	}
+	if data.RestoreDeleted.IsNull() {
+		data.RestoreDeleted = types.BoolValue(false)
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *APIToken) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan apiTokenModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get current state
+	var state apiTokenModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	// Rolled back first, the checks below fail on a deleted latest version
+	restoreDeletedVersion(ctx, r.vaultApi, state.Path.ValueString(), plan.OnDeletedVersion, req.Private, resp.Private, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	checkStrictMode(ctx, r.vaultApi, r.strict, state.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := state.Path.ValueString()
+
+	metadata := make(map[string]string)
+	for k, v := range plan.Metadata.Elements() {
+		metadata[k] = v.(types.String).ValueString()
+	}
+
+	metadata[SecretTypeMetadata] = APITokenType
+	metadata[SecretLengthMetadata] = state.Length.String()
+	metadata[APITokenPrefixMetadata] = state.Prefix.ValueString()
+	metadata[APITokenChecksumMetadata] = strconv.FormatBool(state.Checksum.ValueBool())
+
+	removed := removedMetadataKeys(state.Metadata, plan.Metadata)
+	removed = deletionProtectionMetadata(metadata, removed, plan.DeletionProtection)
+	removed = externalSecretMetadata(metadata, removed, plan.ExternalSecret)
+
+	checkSecretUnchanged(ctx, r.vaultApi, secretPath, plan.CasVersion, resp.Private, resp.Private, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	historyUpdate(ctx, r.vaultApi, r.history, secretPath, metadata, HistoryMetadataUpdated, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.vaultApi.UpdateSecretMetadata(ctx, secretPath, metadata, removed)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+		return
+	}
+
+	applyPolicyTemplate(ctx, r.vaultApi, secretPath, state.PolicyTemplate, plan.PolicyTemplate, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Version.IsUnknown() {
+		state.Version = refreshVersion(ctx, r.vaultApi, secretPath, plan.UseLatestVersion, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if plan.EscrowCiphertext.IsUnknown() {
+		plan.EscrowCiphertext = refreshEscrow(ctx, r.vaultApi.ReadSecret, secretPath, plan.EscrowPublicKey, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	state.Metadata = plan.Metadata
+	state.ForceDestroy = plan.ForceDestroy
+	state.OnDeletedVersion = plan.OnDeletedVersion
+	state.DeleteAllVersions = plan.DeleteAllVersions
+	state.DeleteMetadata = plan.DeleteMetadata
+	state.UseLatestVersion = plan.UseLatestVersion
+	state.CasVersion = plan.CasVersion
+	state.DeletionProtection = plan.DeletionProtection
+	state.DestroyAfter = plan.DestroyAfter
+	state.RestoreDeleted = plan.RestoreDeleted
+	state.ExternalSecret = plan.ExternalSecret
+	state.PolicyTemplate = plan.PolicyTemplate
+	state.EscrowPublicKey = plan.EscrowPublicKey
+	state.EscrowCiphertext = plan.EscrowCiphertext
+	state.ExtraHeaders = plan.ExtraHeaders
+	state.Timeouts = plan.Timeouts
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *APIToken) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state apiTokenModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if deleteFailedCreate(ctx, r.vaultApi, state.Path.ValueString(), req.Private, &resp.Diagnostics) {
+		return
+	}
+
+	checkStrictMode(ctx, r.vaultApi, r.strict, state.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !state.ForceDestroy.ValueBool() {
+		resp.Diagnostics.AddError("Error deleting secret", "Can't delete resource for Vault secret '"+state.Path.ValueString()+"': 'force_destroy' must be set to 'true'")
+		return
+	}
+
+	secretPath := state.Path.ValueString()
+
+	checkDeletionProtection(ctx, r.vaultApi, secretPath, state.DeletionProtection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case !state.DeleteAllVersions.ValueBool():
+		deleteManagedVersions(ctx, r.vaultApi, secretPath, state.DeleteMetadata, &resp.Diagnostics)
+	case !state.DestroyAfter.IsNull():
+		scheduleSecretDeletion(ctx, r.vaultApi, secretPath, state.DestroyAfter, &resp.Diagnostics)
+	default:
+		err := r.vaultApi.DeleteSecret(ctx, secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error deleting secret", fmt.Sprintf("Error while deleting secret %s", secretPath), err)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Revoked last, readers keep their access to a secret whose deletion failed
+	applyPolicyTemplate(ctx, r.vaultApi, secretPath, state.PolicyTemplate, nil, &resp.Diagnostics)
+}
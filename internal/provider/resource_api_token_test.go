@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const apiTokenResourceName = "vaultprov_api_token.test"
+
+func TestAccAPIToken(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccAPITokenResourceConfig("my_team", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(apiTokenResourceName, "path", "/secret/token/foo"),
+					resource.TestCheckResourceAttr(apiTokenResourceName, "id", "secret/token/foo"),
+					resource.TestCheckResourceAttr(apiTokenResourceName, "prefix", "sk_test_"),
+					resource.TestCheckResourceAttr(apiTokenResourceName, "length", "32"),
+					resource.TestCheckResourceAttr(apiTokenResourceName, "checksum", "true"),
+					resource.TestCheckResourceAttrSet(apiTokenResourceName, "lookup_hash"),
+					resource.TestCheckResourceAttr(apiTokenResourceName, "metadata.owner", "my_team"),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      apiTokenResourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateId:     "/secret/token/foo",
+			},
+			// ForceDestroy testing (also needed at the end so the resource can be automatically deleted)
+			{
+				Config: testAccAPITokenResourceConfig("my_team", true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(apiTokenResourceName, "force_destroy", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccAPITokenResourceConfig(team string, forceDestroy bool) string {
+	return fmt.Sprintf(`
+resource "vaultprov_api_token" "test" {
+  path     = "/secret/token/foo"
+  prefix   = "sk_test_"
+  metadata = {
+    owner = "%s"
+  }
+  force_destroy = %t
+}
+`, team, forceDestroy)
+}
@@ -0,0 +1,593 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ExistingSecretType is the secret_type of the secrets adopted by a vaultprov_existing_secret resource
+const ExistingSecretType = "existing_secret"
+
+// adoptedMetadataPrivateStateKey holds the custom metadata keys added to the secret by the resource, removed when the
+// secret is released
+const adoptedMetadataPrivateStateKey = "adopted_metadata"
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &ExistingSecret{}
+var _ resource.ResourceWithImportState = &ExistingSecret{}
+var _ resource.ResourceWithModifyPlan = &ExistingSecret{}
+var _ resource.ResourceWithUpgradeState = &ExistingSecret{}
+
+// ExistingSecret adopts a secret written outside the provider and manages its custom metadata, retention settings and
+// deletion protection only. The secret's data is never read nor written, and the secret is kept when the resource is
+// destroyed.
+type ExistingSecret struct {
+	vaultApi        *vault.VaultApi
+	strict          bool
+	readOnly        bool
+	ownership       map[string]string
+	history         bool
+	ignoredMetadata []string
+	kvMounts        []vault.KVMount
+}
+
+type existingSecretModel struct {
+	ID                 types.String    `tfsdk:"id"`
+	Path               secretPathValue `tfsdk:"path"`
+	Metadata           types.Map       `tfsdk:"metadata"`
+	MaxVersions        types.Int64     `tfsdk:"max_versions"`
+	DeleteVersionAfter types.String    `tfsdk:"delete_version_after"`
+	DeletionProtection types.Bool      `tfsdk:"deletion_protection"`
+	ExtraHeaders       types.Map       `tfsdk:"extra_headers"`
+	Timeouts           timeouts.Value  `tfsdk:"timeouts"`
+}
+
+func NewExistingSecret() resource.Resource {
+	return &ExistingSecret{}
+}
+
+func (r *ExistingSecret) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "existing_secret")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.vaultApi = data.vaultApi
+	r.strict = data.strict
+	r.readOnly = data.readOnly
+	r.ownership = data.ownership
+	r.history = data.history
+	r.ignoredMetadata = data.ignoredMetadata
+	r.kvMounts = data.kvMounts
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (r *ExistingSecret) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *ExistingSecret {
+	clone := *r
+	clone.vaultApi = vaultApiWithHeaders(r.vaultApi, extraHeaders, diags)
+	return &clone
+}
+
+func (r *ExistingSecret) ImportState(ctx context.Context, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
+	importSecret(ctx, r.vaultApi, ExistingSecretType, r.history, request, response)
+}
+
+// UpgradeState migrates states stored with a prior schema version, see existingSecretSchemaVersion.
+func (r *ExistingSecret) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{}
+}
+
+func (r *ExistingSecret) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_existing_secret"
+}
+
+func (r *ExistingSecret) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: existingSecretSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the existing Vault secret, as the `path` of the secret resources (e.g. `secret/foo/bar`). Creating the resource fails when there's no secret at this path, or when it is already managed by another resource of the provider.",
+			},
+			"metadata": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					validators.CustomMetadata(MaxUserMetadata),
+				},
+				MarkdownDescription: "A map of key/value strings stored as custom metadata of the secret. Custom metadata set before the secret was adopted show up as a diff until they are added to the map. Vault limits keys to 128 bytes and values to 512 bytes. At most 48 entries are allowed, the remaining custom metadata being reserved for the provider.",
+			},
+			"max_versions": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+				MarkdownDescription: "Number of versions of the secret kept by Vault, `0` for the setting of the mount. Left untouched when not set.",
+			},
+			"delete_version_after": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Duration (e.g. `2160h`) after which Vault deletes the versions of the secret, `0s` for the setting of the mount. Left untouched when not set.",
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true`, the resource can't be destroyed, and the provider's other resources refuse to delete the secret. The flag must first be set to `false` and applied. This information will be stored as a custom metadata under the key `deletion_protection`.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "An existing Vault secret, e.g. a legacy secret written by hand, whose custom metadata, retention settings and deletion protection are managed without generating a new secret. The secret's data is never read nor written, and the secret is marked with a custom metadata `secret_type` with the value `" + ExistingSecretType + "`. Destroying the resource keeps the secret and its retention settings: only the custom metadata keys added by the resource are removed, keys the secret held before being adopted are kept.",
+	}
+}
+
+func (r *ExistingSecret) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan existingSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	checkKVMount(&resp.Diagnostics, r.kvMounts, false, plan.Path.StringValue)
+	checkIgnoredMetadata(&resp.Diagnostics, r.ignoredMetadata, plan.Metadata)
+}
+
+func (r *ExistingSecret) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan existingSecretModel
+
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	secretPath := plan.Path.ValueString()
+
+	secret, err := r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	var deletedErr *vault.SecretDeletedError
+	if errors.As(err, &deletedErr) {
+		response.Diagnostics.AddError("Error adopting secret", fmt.Sprintf("The latest version of secret %s has been deleted. Undelete it before adopting the secret.", secretPath))
+		return
+	}
+	if err != nil {
+		addVaultError(&response.Diagnostics, "Error adopting secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+	if secret == nil {
+		response.Diagnostics.AddError("Error adopting secret", fmt.Sprintf("No secret at %s: only existing secrets can be adopted, use a resource generating the secret instead.", secretPath))
+		return
+	}
+	if secretType, ok := secret.Metadata[SecretTypeMetadata]; ok && secretType != ExistingSecretType {
+		response.Diagnostics.AddError("Error adopting secret", fmt.Sprintf("Secret %s is a %s (custom metadata `%s`) written by the provider, import it in a %s_%s resource instead.", secretPath, secretType, SecretTypeMetadata, providerName, secretType))
+		return
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range plan.Metadata.Elements() {
+		metadata[k] = v.(types.String).ValueString()
+	}
+	metadata[SecretTypeMetadata] = ExistingSecretType
+	addOwnershipMetadata(metadata, r.ownership)
+	if r.history {
+		metadata[HistoryMetadata] = appendHistory(secret.Metadata[HistoryMetadata], HistoryAdopted, time.Now())
+	}
+	removed := deletionProtectionMetadata(metadata, nil, plan.DeletionProtection)
+
+	err = r.vaultApi.UpdateSecretMetadata(ctx, secretPath, metadata, removed)
+	if err != nil {
+		addVaultError(&response.Diagnostics, "Error adopting secret", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+		return
+	}
+
+	diags = recordAdoptedMetadata(ctx, response.Private, nil, secret.Metadata, metadata, removed)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r.updateRetention(ctx, secretPath, existingSecretModel{}, plan, secret, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = secretPathID(plan.Path)
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+func (r *ExistingSecret) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data existingSecretModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	secretPath := data.Path.ValueString()
+
+	secret, err := r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	var deletedErr *vault.SecretDeletedError
+	if errors.As(err, &deletedErr) {
+		secret, err = nil, nil
+	}
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+
+	// Not re-created: adopting the secret again fails with an explicit error
+	if secret == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.DeletionProtection = types.BoolValue(false)
+
+	additionalMetadata := make(map[string]attr.Value)
+	for k, v := range secret.Metadata {
+		if k == SecretTypeMetadata || isGenerationMetadata(k) || isExternalSecretMetadata(k) || isStampMetadata(k) || isOwnershipMetadata(k) || isHistoryMetadata(k) {
+			continue
+		}
+		if k == DeletionProtectionMetadata {
+			data.DeletionProtection = types.BoolValue(v == "true")
+			continue
+		}
+		additionalMetadata[k] = types.StringValue(v)
+	}
+	data.Metadata = metadataValue(data.Metadata, additionalMetadata, r.ignoredMetadata)
+
+	// Retention settings are only refreshed when managed by the resource
+	if !data.MaxVersions.IsNull() {
+		data.MaxVersions = types.Int64Value(int64(secret.MaxVersions))
+	}
+	if !data.DeleteVersionAfter.IsNull() {
+		data.DeleteVersionAfter = durationValue(data.DeleteVersionAfter, secret.DeleteVersionAfter)
+	}
+
+	// Only path is set in state when importing an existing resource
+	data.ID = secretPathID(data.Path)
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ExistingSecret) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan existingSecretModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state existingSecretModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	secretPath := state.Path.ValueString()
+
+	secret, err := r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error updating secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return
+	}
+	checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range plan.Metadata.Elements() {
+		metadata[k] = v.(types.String).ValueString()
+	}
+	metadata[SecretTypeMetadata] = ExistingSecretType
+	if r.history {
+		metadata[HistoryMetadata] = appendHistory(secret.Metadata[HistoryMetadata], HistoryMetadataUpdated, time.Now())
+	}
+
+	removed := removedMetadataKeys(state.Metadata, plan.Metadata)
+	removed = deletionProtectionMetadata(metadata, removed, plan.DeletionProtection)
+
+	adopted, diags := adoptedMetadataKeys(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err = r.vaultApi.UpdateSecretMetadata(ctx, secretPath, metadata, removed)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+		return
+	}
+
+	diags = recordAdoptedMetadata(ctx, resp.Private, adopted, secret.Metadata, metadata, removed)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.updateRetention(ctx, secretPath, state, plan, secret, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Metadata = plan.Metadata
+	state.MaxVersions = plan.MaxVersions
+	state.DeleteVersionAfter = plan.DeleteVersionAfter
+	state.DeletionProtection = plan.DeletionProtection
+	state.ExtraHeaders = plan.ExtraHeaders
+	state.Timeouts = plan.Timeouts
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete releases the secret: the custom metadata added by the resource are removed, the secret itself is kept.
+func (r *ExistingSecret) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state existingSecretModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	secretPath := state.Path.ValueString()
+
+	secret, err := r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	var deletedErr *vault.SecretDeletedError
+	if secret == nil && (err == nil || errors.As(err, &deletedErr)) {
+		// Nothing left to release
+		return
+	}
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error releasing secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+
+	checkDeletionProtection(ctx, r.vaultApi, secretPath, state.DeletionProtection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Keys the secret held before being adopted are kept. Imported secrets have no record, only their type is removed
+	removed, diags := adoptedMetadataKeys(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !slices.Contains(removed, SecretTypeMetadata) {
+		removed = append(removed, SecretTypeMetadata)
+	}
+
+	err = r.vaultApi.UpdateSecretMetadata(ctx, secretPath, nil, removed)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error releasing secret", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+	}
+}
+
+// updateRetention writes the planned retention settings when they changed. Settings not managed by the resource keep
+// the value read in Vault.
+func (r *ExistingSecret) updateRetention(ctx context.Context, secretPath string, state, plan existingSecretModel, secret *vault.Secret, diags *diag.Diagnostics) {
+	if (plan.MaxVersions.IsNull() || plan.MaxVersions.Equal(state.MaxVersions)) && (plan.DeleteVersionAfter.IsNull() || plan.DeleteVersionAfter.Equal(state.DeleteVersionAfter)) {
+		return
+	}
+
+	maxVersions := secret.MaxVersions
+	if !plan.MaxVersions.IsNull() {
+		maxVersions = int(plan.MaxVersions.ValueInt64())
+	}
+	deleteVersionAfter := secret.DeleteVersionAfter
+	if !plan.DeleteVersionAfter.IsNull() {
+		var err error
+		deleteVersionAfter, err = time.ParseDuration(plan.DeleteVersionAfter.ValueString())
+		if err != nil {
+			diags.AddError("Error updating secret", fmt.Sprintf("Invalid delete_version_after %q: %s", plan.DeleteVersionAfter.ValueString(), err.Error()))
+			return
+		}
+	}
+
+	err := r.vaultApi.UpdateSecretRetention(ctx, secretPath, maxVersions, deleteVersionAfter)
+	if err != nil {
+		addVaultError(diags, "Error updating secret", fmt.Sprintf("Error while updating retention settings of secret %s", secretPath), err)
+	}
+}
+
+// durationValue returns the duration read in Vault, keeping the value in state when it is the same duration written
+// differently (e.g. `72h` and `72h0m0s`).
+func durationValue(prior types.String, actual time.Duration) types.String {
+	if duration, err := time.ParseDuration(prior.ValueString()); err == nil && duration == actual {
+		return prior
+	}
+	return types.StringValue(actual.String())
+}
+
+// adoptedMetadataKeys returns the custom metadata keys added to the secret by the resource, nil when none were recorded
+// (e.g. after an import).
+func adoptedMetadataKeys(ctx context.Context, private privateState) ([]string, diag.Diagnostics) {
+	value, diags := private.GetKey(ctx, adoptedMetadataPrivateStateKey)
+	if diags.HasError() || len(value) == 0 {
+		return nil, diags
+	}
+
+	var keys []string
+	if err := json.Unmarshal(value, &keys); err != nil {
+		diags.AddError("Error reading adopted metadata", err.Error())
+	}
+	return keys, diags
+}
+
+// recordAdoptedMetadata records the custom metadata keys added by the resource: the keys already recorded and the keys
+// written that the secret didn't hold before, except the removed ones.
+func recordAdoptedMetadata(ctx context.Context, private privateState, recorded []string, before, written map[string]string, removed []string) diag.Diagnostics {
+	keys := make([]string, 0, len(recorded)+len(written))
+	for _, k := range recorded {
+		if !slices.Contains(removed, k) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range written {
+		if _, existed := before[k]; !existed && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	value, err := json.Marshal(keys)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Error recording adopted metadata", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, adoptedMetadataPrivateStateKey, value)
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestAccExistingSecret(t *testing.T) {
+	// The legacy secret is written by hand before the test case
+	if os.Getenv(resource.EnvTfAcc) == "" {
+		t.Skipf("Acceptance tests skipped unless env '%s' set", resource.EnvTfAcc)
+	}
+	testAccPreCheck(t)
+	testAccLegacySecret(t, "secret", "acc/existing-secret")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccExistingSecretConfig("team_a", 10),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vaultprov_existing_secret.legacy", "id", "secret/acc/existing-secret"),
+					resource.TestCheckResourceAttr("vaultprov_existing_secret.legacy", "metadata.owner", "team_a"),
+					resource.TestCheckResourceAttr("vaultprov_existing_secret.legacy", "max_versions", "10"),
+					resource.TestCheckResourceAttr("vaultprov_existing_secret.legacy", "delete_version_after", "2160h"),
+					resource.TestCheckResourceAttr("vaultprov_existing_secret.legacy", "deletion_protection", "false"),
+				),
+			},
+			{
+				ResourceName:            "vaultprov_existing_secret.legacy",
+				ImportState:             true,
+				ImportStateId:           "/secret/acc/existing-secret",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"id", "path", "max_versions", "delete_version_after"},
+			},
+			{
+				Config: testAccExistingSecretConfig("team_b", 5),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vaultprov_existing_secret.legacy", "metadata.owner", "team_b"),
+					resource.TestCheckResourceAttr("vaultprov_existing_secret.legacy", "max_versions", "5"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccExistingSecretMissing(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "vaultprov_existing_secret" "missing" {
+  path = "/secret/acc/existing-secret-missing"
+}
+`,
+				ExpectError: regexp.MustCompile("only existing secrets can be adopted"),
+			},
+		},
+	})
+}
+
+// testAccLegacySecret writes a secret without custom metadata with the root token, as a secret written by hand.
+func testAccLegacySecret(t *testing.T, mount, secretPath string) {
+	client, err := vault.NewClient(vault.DefaultConfig())
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	_, err = client.KVv2(mount).Put(context.Background(), secretPath, map[string]interface{}{"password": "hunter2"})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+}
+
+func testAccExistingSecretConfig(team string, maxVersions int) string {
+	return providerConfig + `
+resource "vaultprov_existing_secret" "legacy" {
+  path                 = "/secret/acc/existing-secret"
+  max_versions         = ` + fmt.Sprint(maxVersions) + `
+  delete_version_after = "2160h"
+  metadata = {
+    owner = "` + team + `"
+  }
+}
+`
+}
+
+func TestDurationValue(t *testing.T) {
+	tests := []struct {
+		prior    types.String
+		actual   time.Duration
+		expected string
+	}{
+		{types.StringValue("72h"), 72 * time.Hour, "72h"},
+		{types.StringValue("72h"), 24 * time.Hour, "24h0m0s"},
+		{types.StringValue("0s"), 0, "0s"},
+	}
+	for _, test := range tests {
+		if value := durationValue(test.prior, test.actual); value.ValueString() != test.expected {
+			t.Fatalf("Wrong duration for %s and %v: %s. Expected: %s", test.prior, test.actual, value.ValueString(), test.expected)
+		}
+	}
+}
+
+func TestRecordAdoptedMetadata(t *testing.T) {
+	ctx := context.Background()
+	private := testPrivateState{}
+
+	// Adoption: owner was set by hand before, only the keys added by the resource are recorded
+	before := map[string]string{"owner": "team_a", "legacy": "true"}
+	written := map[string]string{"owner": "team_b", "cost_center": "42", SecretTypeMetadata: ExistingSecretType}
+	if diags := recordAdoptedMetadata(ctx, private, nil, before, written, nil); diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+	keys, diags := adoptedMetadataKeys(ctx, private)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+	if expected := []string{"cost_center", SecretTypeMetadata}; !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("Wrong adopted metadata: %v. Expected: %v", keys, expected)
+	}
+
+	// Update: cost_center is removed, team is added
+	before = map[string]string{"owner": "team_b", "legacy": "true", "cost_center": "42", SecretTypeMetadata: ExistingSecretType}
+	written = map[string]string{"owner": "team_b", "team": "payments", SecretTypeMetadata: ExistingSecretType}
+	if diags := recordAdoptedMetadata(ctx, private, keys, before, written, []string{"cost_center"}); diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+	keys, _ = adoptedMetadataKeys(ctx, private)
+	if expected := []string{SecretTypeMetadata, "team"}; !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("Wrong adopted metadata: %v. Expected: %v", keys, expected)
+	}
+
+	// Nothing recorded after an import
+	if keys, _ := adoptedMetadataKeys(ctx, testPrivateState{}); keys != nil {
+		t.Fatalf("Unexpected adopted metadata: %v", keys)
+	}
+}
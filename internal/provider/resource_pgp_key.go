@@ -0,0 +1,991 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	PGPKeyType             = "pgp_key"
+	PGPAlgorithmMetadata   = "pgp_algorithm"
+	PGPFingerprintMetadata = "pgp_fingerprint"
+	PGPPrivateKeyDataKey   = "private_key"
+	PGPPublicKeyDataKey    = "public_key"
+	DefaultPGPAlgorithm    = secrets.PGPAlgorithmEd25519
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &PGPKey{}
+var _ resource.ResourceWithImportState = &PGPKey{}
+var _ resource.ResourceWithModifyPlan = &PGPKey{}
+var _ resource.ResourceWithUpgradeState = &PGPKey{}
+
+type PGPKey struct {
+	vaultApi        *vault.VaultApi
+	providerVersion string
+	strict          bool
+	readOnly        bool
+	ownership       map[string]string
+	history         bool
+	ignoredMetadata []string
+	fipsMode        bool
+	kvMounts        []vault.KVMount
+	storage         *storageEstimate
+	createMounts    bool
+}
+
+type pgpKeyModel struct {
+	ID                 types.String         `tfsdk:"id"`
+	Path               secretPathValue      `tfsdk:"path"`
+	Name               types.String         `tfsdk:"name"`
+	Email              types.String         `tfsdk:"email"`
+	Algorithm          types.String         `tfsdk:"algorithm"`
+	PublicKey          types.String         `tfsdk:"public_key"`
+	PublicKeyOnly      types.Bool           `tfsdk:"public_key_only_secret"`
+	PublishPublicKeyTo types.List           `tfsdk:"publish_public_key_to"`
+	Fingerprint        types.String         `tfsdk:"fingerprint"`
+	Metadata           types.Map            `tfsdk:"metadata"`
+	ForceDestroy       types.Bool           `tfsdk:"force_destroy"`
+	OnDeletedVersion   types.String         `tfsdk:"on_deleted_version"`
+	DeleteAllVersions  types.Bool           `tfsdk:"delete_all_versions"`
+	DeleteMetadata     types.Bool           `tfsdk:"delete_metadata"`
+	DeletionProtection types.Bool           `tfsdk:"deletion_protection"`
+	DestroyAfter       types.String         `tfsdk:"destroy_after"`
+	RestoreDeleted     types.Bool           `tfsdk:"restore_deleted"`
+	ExternalSecret     *externalSecretModel `tfsdk:"external_secret"`
+	PolicyTemplate     *policyTemplateModel `tfsdk:"policy_template"`
+	EscrowPublicKey    types.String         `tfsdk:"escrow_public_key"`
+	EscrowCiphertext   types.String         `tfsdk:"escrow_ciphertext"`
+	VersionsKept       types.Int64          `tfsdk:"versions_kept"`
+	Version            types.Int64          `tfsdk:"version"`
+	UseLatestVersion   types.Bool           `tfsdk:"use_latest_version"`
+	CasVersion         types.Bool           `tfsdk:"cas_version"`
+	DataPath           types.String         `tfsdk:"data_path"`
+	MetadataPath       types.String         `tfsdk:"metadata_path"`
+	KeyFingerprint     types.String         `tfsdk:"key_fingerprint"`
+	ExtraHeaders       types.Map            `tfsdk:"extra_headers"`
+	Timeouts           timeouts.Value       `tfsdk:"timeouts"`
+}
+
+func NewPGPKey() resource.Resource {
+	return &PGPKey{}
+}
+
+func (r *PGPKey) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "pgp_key")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.vaultApi = data.vaultApi
+	r.providerVersion = data.version
+	r.strict = data.strict
+	r.readOnly = data.readOnly
+	r.ownership = data.ownership
+	r.history = data.history
+	r.ignoredMetadata = data.ignoredMetadata
+	r.fipsMode = data.fipsMode
+	r.kvMounts = data.kvMounts
+	r.storage = data.storage
+	r.createMounts = data.createMounts
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (r *PGPKey) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *PGPKey {
+	clone := *r
+	clone.vaultApi = vaultApiWithHeaders(r.vaultApi, extraHeaders, diags)
+	return &clone
+}
+
+// ImportState reads the imported secret to set `algorithm`, which can't be read from metadata when the secret wasn't
+// written by the provider, and fails when the secret doesn't hold a PGP key the provider can manage.
+func (r *PGPKey) ImportState(ctx context.Context, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
+	secretPath := request.ID
+
+	checkImportID(secretPath, PGPKeyType, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	secret, err := r.vaultApi.ReadSecret(ctx, secretPath)
+	if err != nil {
+		addVaultError(&response.Diagnostics, "Error importing PGP key", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+	if secret == nil {
+		response.Diagnostics.AddError("Error importing PGP key", fmt.Sprintf("No secret at %s", secretPath))
+		return
+	}
+
+	algorithm, err := importedPGPKeyAlgorithm(secret)
+	if err != nil {
+		response.Diagnostics.AddError("Error importing PGP key", fmt.Sprintf("Secret %s doesn't look like a PGP key managed by the provider: %s", secretPath, err.Error()))
+		return
+	}
+
+	recordHistory(ctx, r.vaultApi, r.history, secretPath, HistoryImported, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	response.Diagnostics.Append(response.State.SetAttribute(ctx, path.Root("path"), secretPath)...)
+	response.Diagnostics.Append(response.State.SetAttribute(ctx, path.Root("algorithm"), algorithm)...)
+}
+
+// importedPGPKeyAlgorithm returns the algorithm of the PGP key held by an imported secret. The secret must hold both
+// keys, and its metadata, when set, must match the public key.
+func importedPGPKeyAlgorithm(secret *vault.Secret) (string, error) {
+	if secretType, ok := secret.Metadata[SecretTypeMetadata]; ok && secretType != PGPKeyType {
+		return "", fmt.Errorf("custom metadata %s is %q, expected %q", SecretTypeMetadata, secretType, PGPKeyType)
+	}
+
+	if _, ok := secret.Data[PGPPrivateKeyDataKey].(string); !ok {
+		return "", fmt.Errorf("no %s field", PGPPrivateKeyDataKey)
+	}
+	publicKey, err := pgpPublicKey(secret)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := secrets.ParsePGPPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	if algorithm, ok := secret.Metadata[PGPAlgorithmMetadata]; ok && algorithm != key.Algorithm {
+		return "", fmt.Errorf("custom metadata %s is %q but the public key is a %s key", PGPAlgorithmMetadata, algorithm, key.Algorithm)
+	}
+	return key.Algorithm, nil
+}
+
+// pgpPublicKey returns the public key stored in a PGP key secret, or derives it from the private key when the secret
+// only holds the private key (see public_key_only_secret).
+func pgpPublicKey(secret *vault.Secret) (string, error) {
+	if publicKey, ok := secret.Data[PGPPublicKeyDataKey].(string); ok {
+		return publicKey, nil
+	}
+	privateKey, ok := secret.Data[PGPPrivateKeyDataKey].(string)
+	if !ok {
+		return "", fmt.Errorf("no %s or %s field", PGPPublicKeyDataKey, PGPPrivateKeyDataKey)
+	}
+	return secrets.PGPPublicKey(privateKey)
+}
+
+// publishPublicKey publishes the public key of the resource to the paths of publish_public_key_to.
+func (r *PGPKey) publishPublicKey(ctx context.Context, data *pgpKeyModel, diags *diag.Diagnostics) {
+	var targets []string
+	diags.Append(data.PublishPublicKeyTo.ElementsAs(ctx, &targets, false)...)
+	if diags.HasError() {
+		return
+	}
+	publishPublicKey(ctx, r.vaultApi, data.Path.ValueString(), data.PublicKey.ValueString(), data.Fingerprint.ValueString(), targets, r.ownership, diags)
+}
+
+// UpgradeState migrates states stored with a prior schema version, see pgpKeySchemaVersion.
+func (r *PGPKey) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: rawStateUpgrader(normalizeSecretPathID),
+	}
+}
+
+func (r *PGPKey) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_pgp_key"
+}
+
+func (r *PGPKey) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: pgpKeySchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret. For a nested secret the name is the nested path excluding the mount and data prefix. For example, for a secret at `keys/data/foo/bar/baz` the name is `foo/bar/baz`. Serves as the secret id.",
+			},
+			"name": schema.StringAttribute{
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				MarkdownDescription: "Name of the key's user identity.",
+			},
+			"email": schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				MarkdownDescription: "Email of the key's user identity.",
+			},
+			"algorithm": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					planmodifiers.StringDefaultValue(types.StringValue(DefaultPGPAlgorithm)),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(secrets.PGPAlgorithmEd25519, secrets.PGPAlgorithmRSA),
+				},
+				MarkdownDescription: "Algorithm of the key: `ed25519` (EdDSA signing key with a Curve25519 encryption subkey) or `rsa` (4096 bits). Default is `ed25519`. This information will be stored as a custom metadata under the key `pgp_algorithm`",
+			},
+			"public_key": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "The ASCII armored public key.",
+			},
+			"public_key_only_secret": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+					boolplanmodifier.RequiresReplace(),
+				},
+				MarkdownDescription: "If set to `true`, the public key isn't stored in Vault: the secret only holds the private key, and the public key is only exposed by the `public_key` attribute. For Vault policy conventions forbidding non-sensitive data in secrets mounts. The public key is derived from the private key when the secret is read (e.g. on import). Changing it forces a new key. Default is `false`.",
+			},
+			"publish_public_key_to": publishPublicKeyToAttribute(),
+			"fingerprint": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "The fingerprint of the key. This information will be stored as a custom metadata under the key `pgp_fingerprint`",
+			},
+			"metadata": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					validators.CustomMetadata(MaxUserMetadata),
+				},
+				MarkdownDescription: "A map of key/value strings that will be stored along the secret as custom metadata. Vault limits keys to 128 bytes and values to 512 bytes. At most 48 entries are allowed, the remaining custom metadata being reserved for the provider.",
+			},
+			"force_destroy": schema.BoolAttribute{
+				Optional:            true,
+				Required:            false,
+				MarkdownDescription: "If set to `true`, removing the resource will delete the secret and all versions in Vault. If set to `false` or not defined, removing the resource will fail.",
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+			},
+			"delete_all_versions": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(true)),
+				},
+				MarkdownDescription: "If set to `false`, removing the resource only deletes the versions of the secret written by the provider (listed in the custom metadata `managed_versions`), leaving the versions written by other systems and the secret's metadata intact. Meant for shared paths that predate Terraform management. `destroy_after` is ignored in that case. Default is `true`.",
+			},
+			"delete_metadata": deleteMetadataAttribute(),
+			"deletion_protection": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true`, the secret can't be deleted, even with `force_destroy` set to `true`. The flag must first be set to `false` and applied. This information will be stored as a custom metadata under the key `deletion_protection`, deletion is also refused when this metadata is set directly in Vault.",
+			},
+			"destroy_after": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Grace period (e.g. `72h`) before the secret is deleted when the resource is destroyed. Instead of being deleted right away, the secret's `delete_version_after` is set so that Vault deletes it once the period is over, and the deletion date is stored as a custom metadata under the key `scheduled_destroy_at`. Gives a recovery window after an accidental destroy.",
+			},
+			"restore_deleted": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true` and the latest version of the secret at `path` has been deleted (but not destroyed), it is restored on creation instead of generating a new secret. The deleted secret must have been generated with the same parameters. Default is `false`.",
+			},
+			"external_secret":    externalSecretAttribute(),
+			"policy_template":    policyTemplateAttribute(),
+			"escrow_public_key":  escrowPublicKeyAttribute(),
+			"escrow_ciphertext":  escrowCiphertextAttribute(),
+			"version":            versionAttribute(),
+			"use_latest_version": useLatestVersionAttribute(),
+			"cas_version":        casVersionAttribute(),
+			"on_deleted_version": onDeletedVersionAttribute(),
+			"data_path":          dataPathAttribute(),
+			"metadata_path":      metadataPathAttribute(),
+			"versions_kept": schema.Int64Attribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Number of versions of the secret retained by Vault (bounded by the secret's `max_versions`), i.e. how many rotations can still be rolled back.",
+			},
+			"key_fingerprint": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Hex encoded SHA-256 of the binary public key, e.g. to be used as a key ID.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "An OpenPGP key stored as an ASCII armored private key in a Vault secret, for example to sign artifacts. The armored public key is also stored in the secret and exposed as an attribute. The resulting Vault secret will have a custom metadata `secret_type` with the value `pgp_key`, a custom metadata `pgp_algorithm` and a custom metadata `pgp_fingerprint`.",
+	}
+}
+
+func (r *PGPKey) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource, the deletion is summarized for reviewers
+	if req.Plan.Raw.IsNull() {
+		var state pgpKeyModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() {
+			warnDestroy(&resp.Diagnostics, secretDestroy{
+				paths:              []string{state.Path.ValueString()},
+				forceDestroy:       state.ForceDestroy,
+				deletionProtection: state.DeletionProtection,
+				deleteAllVersions:  state.DeleteAllVersions,
+				deleteMetadata:     state.DeleteMetadata,
+				destroyAfter:       state.DestroyAfter,
+				versionsKept:       state.VersionsKept,
+			})
+		}
+		return
+	}
+
+	var plan pgpKeyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	checkKVMount(&resp.Diagnostics, r.kvMounts, r.createMounts, plan.Path.StringValue)
+	checkIgnoredMetadata(&resp.Diagnostics, r.ignoredMetadata, plan.Metadata)
+	checkDeleteMetadata(&resp.Diagnostics, plan.DeleteMetadata, plan.DeleteAllVersions, plan.DestroyAfter)
+	checkFIPSMode(&resp.Diagnostics, r.fipsMode, "algorithm", plan.Algorithm, fipsAlgorithmReason, secrets.PGPAlgorithmRSA)
+	checkFIPSMode(&resp.Diagnostics, r.fipsMode, "escrow_public_key", plan.EscrowPublicKey, fipsEscrowReason)
+	r.storage.add(&resp.Diagnostics, plan.Path.StringValue, pgpKeySizes[plan.Algorithm.ValueString()])
+	if !plan.Path.IsUnknown() {
+		for _, target := range plan.PublishPublicKeyTo.Elements() {
+			if t, ok := target.(types.String); ok && !t.IsUnknown() && vault.NormalizePath(t.ValueString()) == vault.NormalizePath(plan.Path.ValueString()) {
+				resp.Diagnostics.AddAttributeError(path.Root("publish_public_key_to"), "Invalid publication path", fmt.Sprintf("The public key can't be published to %s: it is the path of the key itself.", t.ValueString()))
+			}
+		}
+	}
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var state pgpKeyModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planVersion(ctx, state.UseLatestVersion, plan.UseLatestVersion, resp)
+	planDeletedVersion(ctx, req.Private, plan.OnDeletedVersion, resp)
+	planEscrow(ctx, state.EscrowPublicKey, plan.EscrowPublicKey, resp)
+}
+
+func (r *PGPKey) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan *pgpKeyModel
+
+	// Retrieve values from plan
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	ensureKVMount(ctx, r.vaultApi, r.createMounts, plan.Path.ValueString(), &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	// Restore the deleted secret, if any, instead of generating a new one
+	if plan.RestoreDeleted.ValueBool() {
+		restored := r.restore(ctx, plan, response.Private, &response.Diagnostics)
+		if response.Diagnostics.HasError() {
+			return
+		}
+		if restored {
+			r.publishPublicKey(ctx, plan, &response.Diagnostics)
+			applyPolicyTemplate(ctx, r.vaultApi, plan.Path.ValueString(), nil, plan.PolicyTemplate, &response.Diagnostics)
+			plan.ID = secretPathID(plan.Path)
+
+			diags = response.State.Set(ctx, &plan)
+			response.Diagnostics.Append(diags...)
+			return
+		}
+	}
+
+	algorithm := plan.Algorithm.ValueString()
+
+	key, err := secrets.GeneratePGPKey(plan.Name.ValueString(), plan.Email.ValueString(), algorithm)
+	if err != nil {
+		response.Diagnostics.AddError("Error creating PGP key", fmt.Sprintf("Couldn't generate PGP key, unexpected error: %s", err.Error()))
+		return
+	}
+
+	// Prepare metadata
+	customMetadata := make(map[string]string)
+	if !plan.Metadata.IsNull() {
+		for k, v := range plan.Metadata.Elements() {
+			customMetadata[k] = v.(types.String).ValueString()
+		}
+	}
+	customMetadata[SecretTypeMetadata] = PGPKeyType
+	customMetadata[PGPAlgorithmMetadata] = algorithm
+	customMetadata[PGPFingerprintMetadata] = key.Fingerprint
+
+	generation := generationParams{
+		Generator:       secrets.PGPKeyGenerator,
+		RNG:             secrets.RNG(),
+		ProviderVersion: r.providerVersion,
+	}
+	generation.addMetadata(customMetadata)
+	addOwnershipMetadata(customMetadata, r.ownership)
+	addHistoryMetadata(customMetadata, r.history, HistoryCreated)
+	if plan.DeletionProtection.ValueBool() {
+		customMetadata[DeletionProtectionMetadata] = "true"
+	}
+	externalSecretMetadata(customMetadata, nil, plan.ExternalSecret)
+
+	data := map[string]interface{}{
+		PGPPrivateKeyDataKey: key.PrivateKey,
+	}
+	if !plan.PublicKeyOnly.ValueBool() {
+		data[PGPPublicKeyDataKey] = key.PublicKey
+	}
+
+	secret := vault.Secret{
+		Path:     plan.Path.ValueString(),
+		Data:     data,
+		Metadata: customMetadata,
+	}
+
+	plan.DataPath, plan.MetadataPath = secretAPIPaths(ctx, r.vaultApi, secret.Path, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.EscrowCiphertext = escrowSecret(plan.EscrowPublicKey, data, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	secret.CreateID = newCreateID(&response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	defer keepFailedCreate(ctx, r.vaultApi, plan.Path, secret.CreateID, response)
+
+	version, err := createSecret(ctx, r.vaultApi, secret, plan.OnDeletedVersion)
+	if err != nil {
+		addVaultError(&response.Diagnostics, "Error creating PGP key", "Couldn't create Vault secret", err)
+		return
+	}
+
+	plan.PublicKey = types.StringValue(key.PublicKey)
+	plan.Fingerprint = types.StringValue(key.Fingerprint)
+
+	plan.VersionsKept = types.Int64Value(1)
+	plan.Version = types.Int64Value(int64(version))
+	plan.KeyFingerprint = types.StringValue(key.KeyFingerprint)
+
+	diags = setGenerationPrivateState(ctx, response.Private, generation)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r.publishPublicKey(ctx, plan, &response.Diagnostics)
+	applyPolicyTemplate(ctx, r.vaultApi, plan.Path.ValueString(), nil, plan.PolicyTemplate, &response.Diagnostics)
+	plan.ID = secretPathID(plan.Path)
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+// restore restores the deleted PGP key at the resource's path, when there's one, instead of generating a new one.
+func (r *PGPKey) restore(ctx context.Context, plan *pgpKeyModel, private privateState, diags *diag.Diagnostics) bool {
+	secretPath := plan.Path.ValueString()
+
+	restored := restoreDeletedSecret(ctx, r.vaultApi, secretPath, map[string]string{
+		SecretTypeMetadata:   PGPKeyType,
+		PGPAlgorithmMetadata: plan.Algorithm.ValueString(),
+	}, diags)
+	if restored == nil {
+		return false
+	}
+
+	publicKey, err := pgpPublicKey(restored)
+	if err != nil {
+		diags.AddError("Error restoring PGP key", fmt.Sprintf("Error while reading public key of restored secret %s: %s", secretPath, err.Error()))
+		return false
+	}
+	key, err := secrets.ParsePGPPublicKey(publicKey)
+	if err != nil {
+		diags.AddError("Error restoring PGP key", fmt.Sprintf("Error while reading public key of restored secret %s: %s", secretPath, err.Error()))
+		return false
+	}
+	if key.Name != plan.Name.ValueString() || key.Email != plan.Email.ValueString() {
+		diags.AddError("Error restoring PGP key", fmt.Sprintf("Restored PGP key %s identity (%s <%s>) doesn't match the configuration", secretPath, key.Name, key.Email))
+		return false
+	}
+
+	managed := map[string]string{
+		SecretTypeMetadata:     PGPKeyType,
+		PGPAlgorithmMetadata:   plan.Algorithm.ValueString(),
+		PGPFingerprintMetadata: key.Fingerprint,
+	}
+	externalSecretMetadata(managed, nil, plan.ExternalSecret)
+	adoptRestoredSecret(ctx, r.vaultApi, restored, plan.Metadata, plan.DeletionProtection, managed, r.history, private, diags)
+	plan.VersionsKept = types.Int64Value(int64(restored.VersionsKept))
+	plan.Version = secretVersion(restored, plan.UseLatestVersion)
+	plan.EscrowCiphertext = escrowSecret(plan.EscrowPublicKey, restored.Data, diags)
+	plan.DataPath = types.StringValue(restored.DataPath)
+	plan.MetadataPath = types.StringValue(restored.MetadataPath)
+
+	plan.PublicKey = types.StringValue(key.PublicKey)
+	plan.Fingerprint = types.StringValue(key.Fingerprint)
+	plan.KeyFingerprint = types.StringValue(key.KeyFingerprint)
+	return !diags.HasError()
+}
+
+func (r *PGPKey) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state
+	var data pgpKeyModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The partial state of a failed creation is kept as is, until the resource is replaced
+	if failedCreateID(ctx, req.Private, &resp.Diagnostics) != "" || resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	secretPath := data.Path.ValueString()
+
+	// Secret data is only needed to get the public key when it's missing from state, e.g. after an import
+	var secret *vault.Secret
+	var err error
+	if data.PublicKey.IsNull() || data.KeyFingerprint.IsNull() {
+		secret, err = r.vaultApi.ReadSecret(ctx, secretPath)
+	} else {
+		secret, err = r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	}
+	if err != nil {
+		secret, err = readDeletedVersion(ctx, r.vaultApi, data.OnDeletedVersion, resp.Private, err, &resp.Diagnostics)
+	} else {
+		resp.Diagnostics.Append(clearDeletedVersion(ctx, resp.Private)...)
+	}
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+
+	if secret == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(setUpdatedTimePrivateState(ctx, resp.Private, secret)...)
+
+	if secret.Data != nil {
+		publicKey, err := pgpPublicKey(secret)
+		if err != nil {
+			resp.Diagnostics.AddError("Error reading PGP key", fmt.Sprintf("Error while reading public key of secret %s: %s", secretPath, err.Error()))
+			return
+		}
+		_, stored := secret.Data[PGPPublicKeyDataKey]
+		data.PublicKeyOnly = types.BoolValue(!stored)
+
+		key, err := secrets.ParsePGPPublicKey(publicKey)
+		if err != nil {
+			resp.Diagnostics.AddError("Error reading PGP key", fmt.Sprintf("Error while reading public key of secret %s: %s", secretPath, err.Error()))
+			return
+		}
+
+		data.PublicKey = types.StringValue(key.PublicKey)
+		data.Fingerprint = types.StringValue(key.Fingerprint)
+		data.KeyFingerprint = types.StringValue(key.KeyFingerprint)
+		data.Name = types.StringValue(key.Name)
+		// Overridden by the algorithm metadata when set
+		data.Algorithm = types.StringValue(key.Algorithm)
+		if key.Email != "" {
+			data.Email = types.StringValue(key.Email)
+		}
+	}
+
+	customMetadata := secret.Metadata
+
+	data.VersionsKept = types.Int64Value(int64(secret.VersionsKept))
+	data.Version = secretVersion(secret, data.UseLatestVersion)
+	data.DataPath = types.StringValue(secret.DataPath)
+	data.MetadataPath = types.StringValue(secret.MetadataPath)
+	data.DeletionProtection = types.BoolValue(false)
+
+	additionalMetadata := make(map[string]attr.Value)
+	for k, v := range customMetadata {
+		if isGenerationMetadata(k) || isExternalSecretMetadata(k) || isStampMetadata(k) || isOwnershipMetadata(k) || isHistoryMetadata(k) {
+			continue
+		}
+		switch k {
+		case DeletionProtectionMetadata:
+			data.DeletionProtection = types.BoolValue(v == "true")
+			continue
+		case SecretTypeMetadata, PGPFingerprintMetadata:
+			continue
+		case PGPAlgorithmMetadata:
+			data.Algorithm = types.StringValue(v)
+			continue
+		}
+		additionalMetadata[k] = types.StringValue(v)
+	}
+	data.Metadata = metadataValue(data.Metadata, additionalMetadata, r.ignoredMetadata)
+	data.ExternalSecret = externalSecretValue(data.ExternalSecret, customMetadata)
+
+	// Public keys deleted outside Terraform are dropped from the list, to be published again
+	if !data.PublishPublicKeyTo.IsNull() {
+		var targets []string
+		resp.Diagnostics.Append(data.PublishPublicKeyTo.ElementsAs(ctx, &targets, false)...)
+		published := publishedPublicKeys(ctx, r.vaultApi, secretPath, data.Fingerprint.ValueString(), targets, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		data.PublishPublicKeyTo, diags = types.ListValueFrom(ctx, types.StringType, published)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	diags = syncGenerationPrivateState(ctx, resp.Private, customMetadata)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only path is set in state when importing an existing resource
+	data.ID = secretPathID(data.Path)
+
+	// ForceDestroy may be null in state when importing an existing resource
+	if data.ForceDestroy.IsNull() {
+		data.ForceDestroy = types.BoolValue(false)
+	}
+	if data.OnDeletedVersion.IsNull() {
+		data.OnDeletedVersion = types.StringValue(OnDeletedVersionError)
+	}
+	if data.DeleteAllVersions.IsNull() {
+		data.DeleteAllVersions = types.BoolValue(true)
+	}
+	if data.DeleteMetadata.IsNull() {
+		data.DeleteMetadata = types.BoolValue(false)
+	}
+	if data.UseLatestVersion.IsNull() {
+		data.UseLatestVersion = types.BoolValue(false)
+	}
+	if data.CasVersion.IsNull() {
+		data.CasVersion = types.BoolValue(false)
+	}
+	if data.RestoreDeleted.IsNull() {
+		data.RestoreDeleted = types.BoolValue(false)
+	}
+	if data.PublicKeyOnly.IsNull() {
+		data.PublicKeyOnly = types.BoolValue(false)
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *PGPKey) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan pgpKeyModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get current state
+	var state pgpKeyModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	// Rolled back first, the checks below fail on a deleted latest version
+	restoreDeletedVersion(ctx, r.vaultApi, state.Path.ValueString(), plan.OnDeletedVersion, req.Private, resp.Private, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	checkStrictMode(ctx, r.vaultApi, r.strict, state.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := state.Path.ValueString()
+
+	metadata := make(map[string]string)
+	for k, v := range plan.Metadata.Elements() {
+		metadata[k] = v.(types.String).ValueString()
+	}
+
+	metadata[SecretTypeMetadata] = PGPKeyType
+	metadata[PGPAlgorithmMetadata] = state.Algorithm.ValueString()
+	metadata[PGPFingerprintMetadata] = state.Fingerprint.ValueString()
+
+	removed := removedMetadataKeys(state.Metadata, plan.Metadata)
+	removed = deletionProtectionMetadata(metadata, removed, plan.DeletionProtection)
+	removed = externalSecretMetadata(metadata, removed, plan.ExternalSecret)
+
+	checkSecretUnchanged(ctx, r.vaultApi, secretPath, plan.CasVersion, resp.Private, resp.Private, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	historyUpdate(ctx, r.vaultApi, r.history, secretPath, metadata, HistoryMetadataUpdated, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.vaultApi.UpdateSecretMetadata(ctx, secretPath, metadata, removed)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+		return
+	}
+
+	applyPolicyTemplate(ctx, r.vaultApi, secretPath, state.PolicyTemplate, plan.PolicyTemplate, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var prior, planned []string
+	resp.Diagnostics.Append(state.PublishPublicKeyTo.ElementsAs(ctx, &prior, false)...)
+	resp.Diagnostics.Append(plan.PublishPublicKeyTo.ElementsAs(ctx, &planned, false)...)
+	unpublishPublicKey(ctx, r.vaultApi, secretPath, state.Fingerprint.ValueString(), removedTargets(prior, planned), &resp.Diagnostics)
+	state.PublishPublicKeyTo = plan.PublishPublicKeyTo
+	r.publishPublicKey(ctx, &state, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Version.IsUnknown() {
+		state.Version = refreshVersion(ctx, r.vaultApi, secretPath, plan.UseLatestVersion, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if plan.EscrowCiphertext.IsUnknown() {
+		plan.EscrowCiphertext = refreshEscrow(ctx, r.vaultApi.ReadSecret, secretPath, plan.EscrowPublicKey, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	state.Metadata = plan.Metadata
+	state.ForceDestroy = plan.ForceDestroy
+	state.OnDeletedVersion = plan.OnDeletedVersion
+	state.DeleteAllVersions = plan.DeleteAllVersions
+	state.DeleteMetadata = plan.DeleteMetadata
+	state.UseLatestVersion = plan.UseLatestVersion
+	state.CasVersion = plan.CasVersion
+	state.DeletionProtection = plan.DeletionProtection
+	state.DestroyAfter = plan.DestroyAfter
+	state.RestoreDeleted = plan.RestoreDeleted
+	state.ExternalSecret = plan.ExternalSecret
+	state.PolicyTemplate = plan.PolicyTemplate
+	state.EscrowPublicKey = plan.EscrowPublicKey
+	state.EscrowCiphertext = plan.EscrowCiphertext
+	state.ExtraHeaders = plan.ExtraHeaders
+	state.Timeouts = plan.Timeouts
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *PGPKey) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state pgpKeyModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if deleteFailedCreate(ctx, r.vaultApi, state.Path.ValueString(), req.Private, &resp.Diagnostics) {
+		return
+	}
+
+	checkStrictMode(ctx, r.vaultApi, r.strict, state.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !state.ForceDestroy.ValueBool() {
+		resp.Diagnostics.AddError("Error deleting secret", "Can't delete resource for Vault secret '"+state.Path.ValueString()+"': 'force_destroy' must be set to 'true'")
+		return
+	}
+
+	secretPath := state.Path.ValueString()
+
+	checkDeletionProtection(ctx, r.vaultApi, secretPath, state.DeletionProtection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var targets []string
+	resp.Diagnostics.Append(state.PublishPublicKeyTo.ElementsAs(ctx, &targets, false)...)
+	unpublishPublicKey(ctx, r.vaultApi, secretPath, state.Fingerprint.ValueString(), targets, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case !state.DeleteAllVersions.ValueBool():
+		deleteManagedVersions(ctx, r.vaultApi, secretPath, state.DeleteMetadata, &resp.Diagnostics)
+	case !state.DestroyAfter.IsNull():
+		scheduleSecretDeletion(ctx, r.vaultApi, secretPath, state.DestroyAfter, &resp.Diagnostics)
+	default:
+		err := r.vaultApi.DeleteSecret(ctx, secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error deleting secret", fmt.Sprintf("Error while deleting secret %s", secretPath), err)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Revoked last, readers keep their access to a secret whose deletion failed
+	applyPolicyTemplate(ctx, r.vaultApi, secretPath, state.PolicyTemplate, nil, &resp.Diagnostics)
+}
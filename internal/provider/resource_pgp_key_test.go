@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const pgpKeyResourceName = "vaultprov_pgp_key.test"
+
+func TestAccPGPKey(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccPGPKeyResourceConfig("my_team", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "path", "/secret/pgp/foo"),
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "id", "secret/pgp/foo"),
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "name", "Release Bot"),
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "email", "release@example.com"),
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "algorithm", "ed25519"),
+					resource.TestCheckResourceAttrSet(pgpKeyResourceName, "public_key"),
+					resource.TestCheckResourceAttrSet(pgpKeyResourceName, "fingerprint"),
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "metadata.owner", "my_team"),
+				),
+			},
+			// Metadata update testing
+			{
+				Config: testAccPGPKeyResourceConfig("some_other_team", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "metadata.owner", "some_other_team"),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      pgpKeyResourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateId:     "/secret/pgp/foo",
+			},
+			// ForceDestroy testing (also needed at the end so the resource can be automatically deleted)
+			{
+				Config: testAccPGPKeyResourceConfig("some_other_team", true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "force_destroy", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccPGPKeyResourceConfig(team string, forceDestroy bool) string {
+	return fmt.Sprintf(`
+resource "vaultprov_pgp_key" "test" {
+  path     = "/secret/pgp/foo"
+  name     = "Release Bot"
+  email    = "release@example.com"
+  metadata = {
+    owner = "%s"
+  }
+  force_destroy = %t
+}
+`, team, forceDestroy)
+}
+
+func TestAccPGPKeyPublishPublicKey(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPGPKeyPublishConfig(`["/secret/public/foo", "/secret/public/bar"]`) + `
+data "vaultprov_keypair_fingerprint" "published" {
+  path       = "/secret/public/bar"
+  depends_on = [vaultprov_pgp_key.test]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "publish_public_key_to.#", "2"),
+					resource.TestCheckResourceAttr("data.vaultprov_keypair_fingerprint.published", "secret_type", PGPPublicKeyType),
+					resource.TestCheckResourceAttrPair("data.vaultprov_keypair_fingerprint.published", "fingerprint", pgpKeyResourceName, "fingerprint"),
+				),
+			},
+			// Unpublishing
+			{
+				Config: testAccPGPKeyPublishConfig(`["/secret/public/foo"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(pgpKeyResourceName, "publish_public_key_to.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccPGPKeyPublishConfig(targets string) string {
+	return fmt.Sprintf(`
+resource "vaultprov_pgp_key" "test" {
+  path                  = "/secret/pgp/published"
+  name                  = "Release Bot"
+  publish_public_key_to = %s
+  force_destroy         = true
+}
+`, targets)
+}
+
+func TestImportedPGPKeyAlgorithm(t *testing.T) {
+	key, err := secrets.GeneratePGPKey("Release Bot", "", secrets.PGPAlgorithmEd25519)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	// Secret written by another system, without metadata
+	secret := &vault.Secret{
+		Data: map[string]interface{}{
+			PGPPrivateKeyDataKey: key.PrivateKey,
+			PGPPublicKeyDataKey:  key.PublicKey,
+		},
+		Metadata: map[string]string{},
+	}
+	algorithm, err := importedPGPKeyAlgorithm(secret)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if algorithm != secrets.PGPAlgorithmEd25519 {
+		t.Fatalf("Wrong algorithm: %s. Expected: %s", algorithm, secrets.PGPAlgorithmEd25519)
+	}
+
+	// Secret written with public_key_only_secret
+	delete(secret.Data, PGPPublicKeyDataKey)
+	if algorithm, err = importedPGPKeyAlgorithm(secret); err != nil || algorithm != secrets.PGPAlgorithmEd25519 {
+		t.Fatalf("Wrong algorithm without public key: %s (%v). Expected: %s", algorithm, err, secrets.PGPAlgorithmEd25519)
+	}
+
+	secret.Metadata[PGPAlgorithmMetadata] = secrets.PGPAlgorithmRSA
+	if _, err = importedPGPKeyAlgorithm(secret); err == nil {
+		t.Fatalf("Expected an error for mismatching algorithm metadata")
+	}
+
+	secret.Metadata = map[string]string{SecretTypeMetadata: RandomSecretType}
+	if _, err = importedPGPKeyAlgorithm(secret); err == nil {
+		t.Fatalf("Expected an error for a %s secret", RandomSecretType)
+	}
+
+	secret.Metadata = map[string]string{}
+	delete(secret.Data, PGPPrivateKeyDataKey)
+	if _, err = importedPGPKeyAlgorithm(secret); err == nil {
+		t.Fatalf("Expected an error without private key")
+	}
+}
+
+func TestPGPPublicKey(t *testing.T) {
+	key, err := secrets.GeneratePGPKey("Release Bot", "", secrets.PGPAlgorithmEd25519)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	publicKey, err := pgpPublicKey(&vault.Secret{Data: map[string]interface{}{PGPPrivateKeyDataKey: key.PrivateKey}})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if publicKey != key.PublicKey {
+		t.Fatalf("Wrong public key: %s. Expected: %s", publicKey, key.PublicKey)
+	}
+
+	if _, err = pgpPublicKey(&vault.Secret{Data: map[string]interface{}{}}); err == nil {
+		t.Fatalf("Expected an error without keys")
+	}
+}
+
+func TestRemovedTargets(t *testing.T) {
+	removed := removedTargets([]string{"/public/a", "public/b/", "public/c"}, []string{"public/a", "/public/b"})
+	if !reflect.DeepEqual(removed, []string{"public/c"}) {
+		t.Fatalf("Wrong removed targets: %v. Expected: [public/c]", removed)
+	}
+	if removed = removedTargets(nil, []string{"public/a"}); removed != nil {
+		t.Fatalf("Wrong removed targets: %v. Expected none", removed)
+	}
+}
@@ -0,0 +1,326 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &PolicyBinding{}
+var _ resource.ResourceWithModifyPlan = &PolicyBinding{}
+var _ resource.ResourceWithUpgradeState = &PolicyBinding{}
+
+// PolicyBinding manages a Vault ACL policy granting read access to a secret, computing the KV v2 data path of the
+// secret so that users don't have to.
+type PolicyBinding struct {
+	vaultApi *vault.VaultApi
+	readOnly bool
+}
+
+type policyBindingModel struct {
+	ID           types.String    `tfsdk:"id"`
+	Path         secretPathValue `tfsdk:"path"`
+	PolicyName   types.String    `tfsdk:"policy_name"`
+	Policy       types.String    `tfsdk:"policy"`
+	ExtraHeaders types.Map       `tfsdk:"extra_headers"`
+	Timeouts     timeouts.Value  `tfsdk:"timeouts"`
+}
+
+func NewPolicyBinding() resource.Resource {
+	return &PolicyBinding{}
+}
+
+func (r *PolicyBinding) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "policy_binding")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.vaultApi = data.vaultApi
+	r.readOnly = data.readOnly
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (r *PolicyBinding) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *PolicyBinding {
+	clone := *r
+	clone.vaultApi = vaultApiWithHeaders(r.vaultApi, extraHeaders, diags)
+	return &clone
+}
+
+// UpgradeState migrates states stored with a prior schema version, see policyBindingSchemaVersion.
+func (r *PolicyBinding) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{}
+}
+
+func (r *PolicyBinding) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_policy_binding"
+}
+
+func (r *PolicyBinding) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: policyBindingSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource. Always equal to `policy_name`.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret, as the `path` of the secret resources (e.g. `secret/foo/bar`, without the `data/` prefix).",
+			},
+			"policy_name": schema.StringAttribute{
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				MarkdownDescription: "Name of the Vault ACL policy. The policy is owned by the resource: it is overwritten on creation and deleted with the resource.",
+			},
+			"policy": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "HCL document of the policy, granting `read` on the secret's KV v2 data path. Changes made outside Terraform are reverted.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "A Vault ACL policy granting read access to a secret. The KV v2 `data/` path of the secret is computed from the mount layout, instead of being written by hand in a `vault_policy` resource.",
+	}
+}
+
+func (r *PolicyBinding) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when creating or destroying the resource
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state policyBindingModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || plan.Path.IsUnknown() || !sameSecretPath(plan.Path.ValueString(), state.Path.ValueString()) {
+		return
+	}
+
+	// Revert the policy when it has been changed outside Terraform
+	document, err := r.vaultApi.SecretReadPolicy(ctx, plan.Path.ValueString())
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error planning policy", fmt.Sprintf("Couldn't build policy for secret %s", plan.Path.ValueString()), err)
+		return
+	}
+	resp.Plan.SetAttribute(ctx, path.Root("policy"), types.StringValue(document))
+}
+
+func (r *PolicyBinding) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan policyBindingModel
+
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	plan.Policy = r.writePolicy(ctx, plan, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = plan.PolicyName
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+func (r *PolicyBinding) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data policyBindingModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	document, err := r.vaultApi.GetPolicy(ctx, data.PolicyName.ValueString())
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading policy", fmt.Sprintf("Error while reading policy %s", data.PolicyName.ValueString()), err)
+		return
+	}
+
+	if document == "" {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	data.Policy = types.StringValue(document)
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *PolicyBinding) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan policyBindingModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	plan.Policy = r.writePolicy(ctx, plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *PolicyBinding) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state policyBindingModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	err := r.vaultApi.DeletePolicy(ctx, state.PolicyName.ValueString())
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error deleting policy", fmt.Sprintf("Error while deleting policy %s", state.PolicyName.ValueString()), err)
+	}
+}
+
+// writePolicy writes the policy granting read access to the secret and returns its document.
+func (r *PolicyBinding) writePolicy(ctx context.Context, plan policyBindingModel, diags *diag.Diagnostics) types.String {
+	secretPath := plan.Path.ValueString()
+	policyName := plan.PolicyName.ValueString()
+
+	document, err := r.vaultApi.SecretReadPolicy(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error writing policy", fmt.Sprintf("Couldn't build policy for secret %s", secretPath), err)
+		return types.StringNull()
+	}
+
+	err = r.vaultApi.WritePolicy(ctx, policyName, document)
+	if err != nil {
+		addVaultError(diags, "Error writing policy", fmt.Sprintf("Error while writing policy %s", policyName), err)
+		return types.StringNull()
+	}
+	return types.StringValue(document)
+}
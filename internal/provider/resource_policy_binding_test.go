@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const policyBindingResourceName = "vaultprov_policy_binding.test"
+
+func TestAccPolicyBinding(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccPolicyBindingResourceConfig("/secret/binding/foo"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(policyBindingResourceName, "id", "test-binding-read"),
+					resource.TestCheckResourceAttr(policyBindingResourceName, "policy", "path \"secret/data/binding/foo\" {\n  capabilities = [\"read\"]\n}\n"),
+				),
+			},
+			// Replace testing
+			{
+				Config: testAccPolicyBindingResourceConfig("/secret/binding/bar"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(policyBindingResourceName, "policy", "path \"secret/data/binding/bar\" {\n  capabilities = [\"read\"]\n}\n"),
+				),
+			},
+		},
+	})
+}
+
+func testAccPolicyBindingResourceConfig(secretPath string) string {
+	return fmt.Sprintf(`
+resource "vaultprov_policy_binding" "test" {
+  path        = "%s"
+  policy_name = "test-binding-read"
+}
+`, secretPath)
+}
@@ -2,13 +2,16 @@ package provider
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
 	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
 	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -19,6 +22,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	_ "github.com/hashicorp/terraform-plugin-go/tftypes"
 	"strconv"
+	"time"
 )
 
 const (
@@ -27,21 +31,71 @@ const (
 	RandomSecretType          = "random_secret"
 	SecretDataKey             = "secret"
 	DefaultRandomSecretLength = 32
+	DefaultOperationTimeout   = 5 * time.Minute
+
+	// LengthChangeReplace re-creates the secret when its length changes, LengthChangeNewVersion writes a new version of
+	// the secret generated with the new length
+	LengthChangeReplace    = "replace"
+	LengthChangeNewVersion = "new_version"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces
 var _ resource.Resource = &RandomSecret{}
 var _ resource.ResourceWithImportState = &RandomSecret{}
+var _ resource.ResourceWithModifyPlan = &RandomSecret{}
+var _ resource.ResourceWithUpgradeState = &RandomSecret{}
 
 type RandomSecret struct {
-	vaultApi *vault.VaultApi
+	vaultApi        *vault.VaultApi
+	store           vault.SecretStore
+	backend         string
+	providerVersion string
+	maxSecretLength int64
+	strict          bool
+	readOnly        bool
+	ownership       map[string]string
+	history         bool
+	ignoredMetadata []string
+	fipsMode        bool
+	kvMounts        []vault.KVMount
+	storage         *storageEstimate
+	createMounts    bool
 }
 
 type randomSecretModel struct {
-	Path         types.String `tfsdk:"path"`
-	Length       types.Int64  `tfsdk:"length"`
-	Metadata     types.Map    `tfsdk:"metadata"`
-	ForceDestroy types.Bool   `tfsdk:"force_destroy"`
+	ID                 types.String         `tfsdk:"id"`
+	Path               secretPathValue      `tfsdk:"path"`
+	Length             types.Int64          `tfsdk:"length"`
+	LengthChange       types.String         `tfsdk:"length_change_behavior"`
+	LargeSecret        types.Bool           `tfsdk:"large_secret"`
+	Format             types.String         `tfsdk:"format"`
+	ValueType          types.String         `tfsdk:"type"`
+	Username           types.String         `tfsdk:"username"`
+	Template           types.String         `tfsdk:"template"`
+	MountType          types.String         `tfsdk:"mount_type"`
+	Metadata           types.Map            `tfsdk:"metadata"`
+	ForceDestroy       types.Bool           `tfsdk:"force_destroy"`
+	OnDeletedVersion   types.String         `tfsdk:"on_deleted_version"`
+	DeleteAllVersions  types.Bool           `tfsdk:"delete_all_versions"`
+	DeleteMetadata     types.Bool           `tfsdk:"delete_metadata"`
+	DeletionProtection types.Bool           `tfsdk:"deletion_protection"`
+	DestroyAfter       types.String         `tfsdk:"destroy_after"`
+	RestoreDeleted     types.Bool           `tfsdk:"restore_deleted"`
+	ExternalSecret     *externalSecretModel `tfsdk:"external_secret"`
+	PolicyTemplate     *policyTemplateModel `tfsdk:"policy_template"`
+	EscrowPublicKey    types.String         `tfsdk:"escrow_public_key"`
+	EscrowCiphertext   types.String         `tfsdk:"escrow_ciphertext"`
+	VersionsKept       types.Int64          `tfsdk:"versions_kept"`
+	Version            types.Int64          `tfsdk:"version"`
+	UseLatestVersion   types.Bool           `tfsdk:"use_latest_version"`
+	CasVersion         types.Bool           `tfsdk:"cas_version"`
+	DataPath           types.String         `tfsdk:"data_path"`
+	MetadataPath       types.String         `tfsdk:"metadata_path"`
+	KeyFingerprint     types.String         `tfsdk:"key_fingerprint"`
+	HashAlgorithm      types.String         `tfsdk:"hash_algorithm"`
+	PasswordHash       types.String         `tfsdk:"password_hash"`
+	ExtraHeaders       types.Map            `tfsdk:"extra_headers"`
+	Timeouts           timeouts.Value       `tfsdk:"timeouts"`
 }
 
 func NewRandomSecret() resource.Resource {
@@ -54,22 +108,52 @@ func (s *RandomSecret) Configure(ctx context.Context, req resource.ConfigureRequ
 		return
 	}
 
-	vaultApi, ok := req.ProviderData.(*vault.VaultApi)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	s.vaultApi = vaultApi
+	s.vaultApi = data.vaultApi
+	s.store = data.secretStore
+	s.backend = data.backend
+	s.providerVersion = data.version
+	s.strict = data.strict
+	s.readOnly = data.readOnly
+	s.ownership = data.ownership
+	s.history = data.history
+	s.ignoredMetadata = data.ignoredMetadata
+	s.fipsMode = data.fipsMode
+	s.kvMounts = data.kvMounts
+	s.storage = data.storage
+	s.createMounts = data.createMounts
+	s.maxSecretLength = data.maxSecretLength
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (s *RandomSecret) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *RandomSecret {
+	clone := *s
+	clone.vaultApi = vaultApiWithHeaders(s.vaultApi, extraHeaders, diags)
+	if clone.backend == VaultBackend {
+		clone.store = clone.vaultApi
+	}
+	return &clone
 }
 
 func (s *RandomSecret) ImportState(ctx context.Context, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("path"), request, response)
+	importSecret(ctx, s.store, RandomSecretType, s.history, request, response)
+}
+
+// UpgradeState migrates states stored with a prior schema version, see randomSecretSchemaVersion.
+func (s *RandomSecret) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: rawStateUpgrader(normalizeSecretPathID),
+	}
 }
 
 func (s *RandomSecret) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
@@ -78,11 +162,23 @@ func (s *RandomSecret) Metadata(ctx context.Context, request resource.MetadataRe
 
 func (s *RandomSecret) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
 	response.Schema = schema.Schema{
+		Version: randomSecretSchemaVersion,
 		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
 			"path": schema.StringAttribute{
-				Required: true,
+				Required:   true,
+				CustomType: secretPathType{},
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
 				},
 				MarkdownDescription: "Full name of the Vault secret. For a nested secret the name is the nested path excluding the mount and data prefix. For example, for a secret at `keys/data/foo/bar/baz` the name is `foo/bar/baz`. Serves as the secret id.",
 			},
@@ -91,17 +187,96 @@ func (s *RandomSecret) Schema(ctx context.Context, request resource.SchemaReques
 				Computed: true,
 				PlanModifiers: []planmodifier.Int64{
 					planmodifiers.Int64DefaultValue(types.Int64Value(DefaultRandomSecretLength)),
-					int64planmodifier.RequiresReplace(),
+					int64planmodifier.RequiresReplaceIf(
+						requiresReplaceOnLengthChange,
+						"Changing the length re-creates the secret, unless length_change_behavior is new_version.",
+						"Changing the length re-creates the secret, unless `length_change_behavior` is `new_version`.",
+					),
 				},
 				Validators: []validator.Int64{
 					int64validator.AtLeast(1),
 				},
-				MarkdownDescription: "The length (in bytes) of the secret. Default is 32. This information will be stored as a custom metadata under the key `secret_length` ",
+				MarkdownDescription: "The length (in bytes, or characters with the `alphanumeric` type) of the secret. Default is 32. Not allowed with the `uuid` type. This information will be stored as a custom metadata under the key `secret_length` ",
+			},
+			"length_change_behavior": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					planmodifiers.StringDefaultValue(types.StringValue(LengthChangeReplace)),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(LengthChangeReplace, LengthChangeNewVersion),
+				},
+				MarkdownDescription: "What changing `length` does. `" + LengthChangeReplace + "` (default) re-creates the secret, losing its version history. `" + LengthChangeNewVersion + "` generates a new value with the new length and writes it as a new version of the secret at the same path, like a rotation: previous versions are kept. Only with the `" + VaultBackend + "` backend, not with the `" + CubbyholeMountType + "` mount type.",
+			},
+			"large_secret": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: fmt.Sprintf("If set to `true`, `length` may exceed the provider's `max_secret_length`, up to %d (10 MiB), for legacy applications requiring large keyfiles (e.g. seed files of a few MiB). The secret is then encoded in base64 straight into the request sent to Vault, without intermediate copies. Only with the `bytes` type, the `raw` format and a KV v2 mount, not with `template`, `hash_algorithm` nor `escrow_public_key`. Vault must accept such entries: with Raft storage, raise `max_entry_size` (1 MiB by default). Default is `false`.", MaxLargeSecretLength),
+			},
+			"format": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					planmodifiers.StringDefaultValue(types.StringValue(RawSecretFormat)),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(RawSecretFormat, BasicAuthSecretFormat),
+				},
+				MarkdownDescription: "Layout of the secret data. `raw` (default) stores the base64 encoded secret under the `secret` key. `kubernetes.io/basic-auth` stores `username` and `password` keys, so that the secret can be materialized as a typed Kubernetes Secret by external-secrets, the password being the base64url encoded (without padding) secret. Formats other than `raw` are stored as a custom metadata under the key `secret_format`.",
+			},
+			"type": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					planmodifiers.StringDefaultValue(types.StringValue(BytesValueType)),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(BytesValueType, HexValueType, UUIDValueType, AlphanumericValueType),
+				},
+				MarkdownDescription: "Type of the secret value. `bytes` (default) encodes `length` random bytes in base64 (base64url with the `kubernetes.io/basic-auth` format), `hex` encodes them in hexadecimal, `alphanumeric` generates `length` random base62 characters and `uuid` a random (version 4) UUID, in which case `length` must not be set. Types other than `bytes` are stored as a custom metadata under the key `secret_value_type`.",
+			},
+			"username": schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				MarkdownDescription: "Username stored along the password. Required with the `kubernetes.io/basic-auth` format, not allowed otherwise.",
+			},
+			"template": schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIf(requiresReplaceOnCubbyhole, "Cubbyhole secrets are re-created when their template changes.", "Cubbyhole secrets are re-created when their template changes."),
+				},
+				MarkdownDescription: "JSON document with placeholders, e.g. `{\"dsn\": \"postgres://app:{{ secret }}@db/app\"}`, rendered with the generated secret and stored under the `rendered` key of the secret data, so that applications get a ready-to-use configuration from a single field. Placeholders are the other keys of the secret data: `{{ secret }}`, or `{{ username }}` and `{{ password }}` with the `kubernetes.io/basic-auth` format. Changing the template writes a new version of the secret rendered from the current value. The template can't be read from Vault: it isn't set on import. Only with the `vault` backend.",
+			},
+			"mount_type": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					planmodifiers.StringDefaultValue(types.StringValue(KVv2MountType)),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(KVv2MountType, CubbyholeMountType),
+				},
+				MarkdownDescription: "Type of the mount the secret is stored in. `kv-v2` (default) or `cubbyhole` for short-lived bootstrap secrets, only existing for the lifetime of the provider's token: `path` must then start with `cubbyhole/`, and `metadata`, `deletion_protection`, `destroy_after`, `restore_deleted`, `on_deleted_version`, `external_secret`, `policy_template`, `delete_all_versions`, `use_latest_version`, `cas_version` and `length_change_behavior` aren't supported. The secret is generated again when the provider's token changes. Cubbyhole secrets can't be imported.",
 			},
 			"metadata": schema.MapAttribute{
-				ElementType:         types.StringType,
-				Optional:            true,
-				MarkdownDescription: "A map of key/value strings that will be stored along the secret as custom metadata",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					validators.CustomMetadata(MaxUserMetadata),
+				},
+				MarkdownDescription: "A map of key/value strings that will be stored along the secret as custom metadata. Vault limits keys to 128 bytes and values to 512 bytes. At most 48 entries are allowed, the remaining custom metadata being reserved for the provider.",
 			},
 			"force_destroy": schema.BoolAttribute{
 				Optional:            true,
@@ -111,12 +286,207 @@ func (s *RandomSecret) Schema(ctx context.Context, request resource.SchemaReques
 					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
 				},
 			},
+			"delete_all_versions": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(true)),
+				},
+				MarkdownDescription: "If set to `false`, removing the resource only deletes the versions of the secret written by the provider (listed in the custom metadata `managed_versions`), leaving the versions written by other systems and the secret's metadata intact. Meant for shared paths that predate Terraform management. `destroy_after` is ignored in that case. Default is `true`.",
+			},
+			"delete_metadata": deleteMetadataAttribute(),
+			"deletion_protection": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true`, the secret can't be deleted, even with `force_destroy` set to `true`. The flag must first be set to `false` and applied. This information will be stored as a custom metadata under the key `deletion_protection`, deletion is also refused when this metadata is set directly in Vault.",
+			},
+			"destroy_after": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Grace period (e.g. `72h`) before the secret is deleted when the resource is destroyed. Instead of being deleted right away, the secret's `delete_version_after` is set so that Vault deletes it once the period is over, and the deletion date is stored as a custom metadata under the key `scheduled_destroy_at`. Gives a recovery window after an accidental destroy.",
+			},
+			"restore_deleted": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true` and the latest version of the secret at `path` has been deleted (but not destroyed), it is restored on creation instead of generating a new secret. The deleted secret must have been generated with the same parameters. Default is `false`.",
+			},
+			"external_secret":    externalSecretAttribute(),
+			"policy_template":    policyTemplateAttribute(),
+			"escrow_public_key":  escrowPublicKeyAttribute(),
+			"escrow_ciphertext":  escrowCiphertextAttribute(),
+			"version":            versionAttribute(),
+			"use_latest_version": useLatestVersionAttribute(),
+			"cas_version":        casVersionAttribute(),
+			"on_deleted_version": onDeletedVersionAttribute(),
+			"data_path":          dataPathAttribute(),
+			"metadata_path":      metadataPathAttribute(),
+			"versions_kept": schema.Int64Attribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Number of versions of the secret retained by Vault (bounded by the secret's `max_versions`), i.e. how many rotations can still be rolled back.",
+			},
+			"key_fingerprint": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Hex encoded SHA-256 of the secret value, to detect changes and reference the secret without exposing it. Only safe to share for secrets long enough not to be brute-forced (the default 32 bytes are).",
+			},
+			"hash_algorithm": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(secrets.HashBcrypt, secrets.HashArgon2id),
+				},
+				MarkdownDescription: "If set (`bcrypt` or `argon2id`), a salted hash of the secret is exposed in `password_hash`. The hashed value is the secret as stored in Vault, i.e. encoded, as used as a password.",
+			},
+			"password_hash": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Salted hash of the secret (modular crypt format, e.g. `$2a$10$...` or `$argon2id$v=19$...`) when `hash_algorithm` is set, e.g. for htpasswd files or to seed databases with pre-hashed passwords without handling the secret itself. bcrypt only hashes the first 72 bytes, longer secrets are refused.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
 		},
-		MarkdownDescription: "A cryptographic randomly generated secret stored as bytes in a Vault secret. The resulting Vault secret will have a custom metadata `secret_type` with the value `random_secret` and a custom metadata `secret_length` with the same value as the `length` attribute.",
+		MarkdownDescription: "A cryptographic randomly generated secret stored as bytes in a Vault secret. The resulting Vault secret will have a custom metadata `secret_type` with the value `random_secret` and a custom metadata `secret_length` with the same value as the `length` attribute. The secret data layout depends on `format`.",
 	}
 }
 
+func (s *RandomSecret) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource, the deletion is summarized for reviewers
+	if req.Plan.Raw.IsNull() {
+		var state randomSecretModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() {
+			warnDestroy(&resp.Diagnostics, secretDestroy{
+				paths:              []string{state.Path.ValueString()},
+				forceDestroy:       state.ForceDestroy,
+				deletionProtection: state.DeletionProtection,
+				deleteAllVersions:  state.DeleteAllVersions,
+				deleteMetadata:     state.DeleteMetadata,
+				destroyAfter:       state.DestroyAfter,
+				versionsKept:       state.VersionsKept,
+			})
+		}
+		return
+	}
+
+	var plan randomSecretModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	checkSecretFormat(&resp.Diagnostics, plan.Format, plan.Username)
+	checkSecretTemplate(&resp.Diagnostics, plan.Template, plan.Format)
+
+	var configLength types.Int64
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("length"), &configLength)...)
+	checkSecretValueType(&resp.Diagnostics, plan.ValueType, configLength)
+	checkCubbyhole(&resp.Diagnostics, plan)
+	checkRandomSecretBackend(&resp.Diagnostics, s.backend, plan)
+	checkLargeSecret(&resp.Diagnostics, plan)
+	if plan.MountType.ValueString() != CubbyholeMountType {
+		checkKVMount(&resp.Diagnostics, s.kvMounts, s.createMounts, plan.Path.StringValue)
+		s.storage.add(&resp.Diagnostics, plan.Path.StringValue, randomSecretSize(plan))
+	}
+	checkDeleteMetadata(&resp.Diagnostics, plan.DeleteMetadata, plan.DeleteAllVersions, plan.DestroyAfter)
+	checkIgnoredMetadata(&resp.Diagnostics, s.ignoredMetadata, plan.Metadata)
+	checkFIPSMode(&resp.Diagnostics, s.fipsMode, "escrow_public_key", plan.EscrowPublicKey, fipsEscrowReason)
+	checkFIPSMode(&resp.Diagnostics, s.fipsMode, "hash_algorithm", plan.HashAlgorithm, fipsHashReason)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Existing secrets are not affected by a lower limit as long as they are not re-created
+	if !req.State.Raw.IsNull() {
+		var state randomSecretModel
+		diags = req.State.Get(ctx, &state)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		planVersion(ctx, state.UseLatestVersion, plan.UseLatestVersion, resp)
+		planDeletedVersion(ctx, req.Private, plan.OnDeletedVersion, resp)
+		planEscrow(ctx, state.EscrowPublicKey, plan.EscrowPublicKey, resp)
+
+		// The hash is computed again when the algorithm changes
+		if !state.HashAlgorithm.Equal(plan.HashAlgorithm) {
+			resp.Plan.SetAttribute(ctx, path.Root("password_hash"), types.StringUnknown())
+		}
+
+		// A new version of the secret is rendered from the new template
+		if !state.Template.Equal(plan.Template) && plan.MountType.ValueString() != CubbyholeMountType {
+			resp.Plan.SetAttribute(ctx, path.Root("versions_kept"), types.Int64Unknown())
+			resp.Plan.SetAttribute(ctx, path.Root("version"), types.Int64Unknown())
+			if !plan.EscrowPublicKey.IsNull() {
+				resp.Plan.SetAttribute(ctx, path.Root("escrow_ciphertext"), types.StringUnknown())
+			}
+		}
+
+		if state.Length.Equal(plan.Length) {
+			return
+		}
+
+		// A new version of the secret is generated with the new length
+		if plan.LengthChange.ValueString() == LengthChangeNewVersion {
+			resp.Plan.SetAttribute(ctx, path.Root("key_fingerprint"), types.StringUnknown())
+			resp.Plan.SetAttribute(ctx, path.Root("versions_kept"), types.Int64Unknown())
+			resp.Plan.SetAttribute(ctx, path.Root("version"), types.Int64Unknown())
+			if !plan.HashAlgorithm.IsNull() {
+				resp.Plan.SetAttribute(ctx, path.Root("password_hash"), types.StringUnknown())
+			}
+			if !plan.EscrowPublicKey.IsNull() {
+				resp.Plan.SetAttribute(ctx, path.Root("escrow_ciphertext"), types.StringUnknown())
+			}
+		}
+	}
+
+	checkSecretLength(&resp.Diagnostics, plan.Length, randomSecretMaxLength(plan.LargeSecret, s.maxSecretLength))
+}
+
+// requiresReplaceOnLengthChange re-creates the secret when its length changes, unless length_change_behavior is
+// new_version. The configured value is read: the default of length_change_behavior may not be planned yet.
+func requiresReplaceOnLengthChange(ctx context.Context, req planmodifier.Int64Request, resp *int64planmodifier.RequiresReplaceIfFuncResponse) {
+	var behavior types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("length_change_behavior"), &behavior)...)
+	resp.RequiresReplace = behavior.ValueString() != LengthChangeNewVersion
+}
+
+// requiresReplaceOnCubbyhole re-creates cubbyhole secrets, which can't be written again in place. The configured value
+// of mount_type is read: its default may not be planned yet.
+func requiresReplaceOnCubbyhole(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	var mountType types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("mount_type"), &mountType)...)
+	resp.RequiresReplace = mountType.ValueString() == CubbyholeMountType
+}
+
 func (s *RandomSecret) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, s.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
 	var plan *randomSecretModel
 
 	// Retrieve values from plan
@@ -126,16 +496,59 @@ func (s *RandomSecret) Create(ctx context.Context, request resource.CreateReques
 		return
 	}
 
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	s = s.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	checkSecretLength(&response.Diagnostics, plan.Length, randomSecretMaxLength(plan.LargeSecret, s.maxSecretLength))
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.MountType.ValueString() != CubbyholeMountType {
+		ensureKVMount(ctx, s.vaultApi, s.createMounts, plan.Path.ValueString(), &response.Diagnostics)
+		if response.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Restore the deleted secret, if any, instead of generating a new one
+	if plan.RestoreDeleted.ValueBool() {
+		restored := s.restore(ctx, plan, response.Private, &response.Diagnostics)
+		if response.Diagnostics.HasError() {
+			return
+		}
+		if restored {
+			applyPolicyTemplate(ctx, s.vaultApi, plan.Path.ValueString(), nil, plan.PolicyTemplate, &response.Diagnostics)
+			plan.ID = secretPathID(plan.Path)
+
+			diags = response.State.Set(ctx, &plan)
+			response.Diagnostics.Append(diags...)
+			return
+		}
+	}
+
 	var key []byte
 
 	secretType := RandomSecretType
 	secretLength := int(plan.Length.ValueInt64())
 
-	key, err := secrets.GenerateRandomSecret(secretLength)
+	key, err := generateRandomValue(plan.ValueType.ValueString(), secretLength)
 	if err != nil {
 		response.Diagnostics.AddError("Error creating random key", fmt.Sprintf("Could generate random bytes, unexpected error: %s", err.Error()))
 		return
 	}
+	defer secrets.Wipe(key)
 
 	// Prepare metadata
 	customMetadata := make(map[string]string)
@@ -145,28 +558,130 @@ func (s *RandomSecret) Create(ctx context.Context, request resource.CreateReques
 		}
 	}
 	customMetadata[SecretTypeMetadata] = secretType
-	customMetadata[SecretLengthMetadata] = fmt.Sprintf("%d", secretLength)
+	randomSecretMetadata(customMetadata, plan.Format.ValueString(), plan.ValueType.ValueString(), plan.Length)
+
+	generation := generationParams{
+		Generator:       secrets.RandomSecretGenerator,
+		RNG:             secrets.RNG(),
+		ProviderVersion: s.providerVersion,
+	}
+	generation.addMetadata(customMetadata)
+	addOwnershipMetadata(customMetadata, s.ownership)
+	addHistoryMetadata(customMetadata, s.history, HistoryCreated)
+	if plan.DeletionProtection.ValueBool() {
+		customMetadata[DeletionProtectionMetadata] = "true"
+	}
+	externalSecretMetadata(customMetadata, nil, plan.ExternalSecret)
+
+	// Large secrets are encoded in the request body only
+	var data map[string]interface{}
+	if plan.LargeSecret.ValueBool() {
+		data = largeSecretData(key)
+	} else {
+		data = randomSecretData(plan.Format.ValueString(), plan.ValueType.ValueString(), plan.Username.ValueString(), key)
+	}
+	if err = renderSecretTemplate(plan.Template, data); err != nil {
+		response.Diagnostics.AddError("Error creating random key", fmt.Sprintf("Couldn't render template: %s", err.Error()))
+		return
+	}
 
-	data := map[string]interface{}{
-		SecretDataKey: base64.StdEncoding.EncodeToString(key),
+	plan.PasswordHash, err = randomSecretHash(plan.HashAlgorithm, plan.Format.ValueString(), data)
+	if err != nil {
+		response.Diagnostics.AddError("Error creating random key", fmt.Sprintf("Couldn't hash secret: %s", err.Error()))
+		return
 	}
 
-	secret := vault.Secret{
-		Path:     plan.Path.ValueString(),
-		Data:     data,
-		Metadata: customMetadata,
+	plan.EscrowCiphertext = escrowSecret(plan.EscrowPublicKey, data, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
 	}
 
-	err = s.vaultApi.CreateSecret(secret)
+	if plan.MountType.ValueString() == CubbyholeMountType {
+		err = s.vaultApi.CreateCubbyholeSecret(ctx, plan.Path.ValueString(), data)
+		plan.VersionsKept = types.Int64Null()
+		plan.Version = types.Int64Null()
+		// The path was already checked by CreateCubbyholeSecret
+		dataPath, _ := vault.CubbyholeAPIPath(plan.Path.ValueString())
+		plan.DataPath = types.StringValue(dataPath)
+		plan.MetadataPath = types.StringNull()
+	} else {
+		secret := vault.Secret{
+			Path:     plan.Path.ValueString(),
+			Data:     data,
+			Metadata: customMetadata,
+		}
+
+		plan.DataPath, plan.MetadataPath = secretAPIPaths(ctx, s.store, secret.Path, &response.Diagnostics)
+		if response.Diagnostics.HasError() {
+			return
+		}
+
+		secret.CreateID = newCreateID(&response.Diagnostics)
+		if response.Diagnostics.HasError() {
+			return
+		}
+		defer keepFailedCreate(ctx, s.store, plan.Path, secret.CreateID, response)
+
+		var version int
+		version, err = createSecret(ctx, s.store, secret, plan.OnDeletedVersion)
+		plan.VersionsKept = types.Int64Value(1)
+		plan.Version = types.Int64Value(int64(version))
+	}
 	if err != nil {
-		response.Diagnostics.AddError("Error creating random key", fmt.Sprintf("Couldn't create Vault secret: %s", err.Error()))
+		addVaultError(&response.Diagnostics, "Error creating random key", "Couldn't create Vault secret", err)
+		return
+	}
+
+	plan.KeyFingerprint = types.StringValue(secrets.Fingerprint(key))
+
+	diags = setGenerationPrivateState(ctx, response.Private, generation)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
 		return
 	}
 
+	applyPolicyTemplate(ctx, s.vaultApi, plan.Path.ValueString(), nil, plan.PolicyTemplate, &response.Diagnostics)
+	plan.ID = secretPathID(plan.Path)
+
 	diags = response.State.Set(ctx, &plan)
 	response.Diagnostics.Append(diags...)
 }
 
+// restore restores the deleted secret at the resource's path, when there's one, instead of generating a new one.
+func (s *RandomSecret) restore(ctx context.Context, plan *randomSecretModel, private privateState, diags *diag.Diagnostics) bool {
+	managed := map[string]string{
+		SecretTypeMetadata: RandomSecretType,
+	}
+	randomSecretMetadata(managed, plan.Format.ValueString(), plan.ValueType.ValueString(), plan.Length)
+
+	restored := restoreDeletedSecret(ctx, s.vaultApi, plan.Path.ValueString(), managed, diags)
+	if restored == nil {
+		return false
+	}
+
+	fingerprint, err := randomSecretFingerprint(plan.Format.ValueString(), plan.ValueType.ValueString(), restored.Data)
+	if err != nil {
+		diags.AddError("Error restoring secret", fmt.Sprintf("Error while reading restored secret %s: %s", restored.Path, err.Error()))
+		return false
+	}
+	passwordHash, err := randomSecretHash(plan.HashAlgorithm, plan.Format.ValueString(), restored.Data)
+	if err != nil {
+		diags.AddError("Error restoring secret", fmt.Sprintf("Couldn't hash restored secret %s: %s", restored.Path, err.Error()))
+		return false
+	}
+
+	externalSecretMetadata(managed, nil, plan.ExternalSecret)
+	adoptRestoredSecret(ctx, s.store, restored, plan.Metadata, plan.DeletionProtection, managed, s.history, private, diags)
+	plan.VersionsKept = types.Int64Value(int64(restored.VersionsKept))
+	plan.Version = secretVersion(restored, plan.UseLatestVersion)
+	plan.EscrowCiphertext = escrowSecret(plan.EscrowPublicKey, restored.Data, diags)
+	plan.DataPath = types.StringValue(restored.DataPath)
+	plan.MetadataPath = types.StringValue(restored.MetadataPath)
+	plan.KeyFingerprint = types.StringValue(fingerprint)
+	plan.PasswordHash = passwordHash
+	return !diags.HasError()
+}
+
 func (s *RandomSecret) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	// Get current state
 	var data randomSecretModel
@@ -176,11 +691,47 @@ func (s *RandomSecret) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	// The partial state of a failed creation is kept as is, until the resource is replaced
+	if failedCreateID(ctx, req.Private, &resp.Diagnostics) != "" || resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s = s.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
 	secretPath := data.Path.ValueString()
 
-	secret, err := s.vaultApi.ReadSecret(secretPath)
+	if data.MountType.ValueString() == CubbyholeMountType {
+		s.readCubbyhole(ctx, &data, resp)
+		return
+	}
+
+	// Secret data is only needed to compute the fingerprint when it's missing from state, e.g. after an import
+	var secret *vault.Secret
+	var err error
+	if data.KeyFingerprint.IsNull() {
+		secret, err = s.store.ReadSecret(ctx, secretPath)
+	} else {
+		secret, err = s.store.ReadSecretMetadata(ctx, secretPath)
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Error reading secret", fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
+		secret, err = readDeletedVersion(ctx, s.store, data.OnDeletedVersion, resp.Private, err, &resp.Diagnostics)
+	} else {
+		resp.Diagnostics.Append(clearDeletedVersion(ctx, resp.Private)...)
+	}
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
 		return
 	}
 
@@ -189,39 +740,161 @@ func (s *RandomSecret) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	checkManagedSecret(secret, s.strict, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(setUpdatedTimePrivateState(ctx, resp.Private, secret)...)
+
 	customMetadata := secret.Metadata
 
-	if len(customMetadata) > 0 {
-		additionalMetadata := make(map[string]attr.Value)
-		for k, v := range customMetadata {
-			if k == SecretTypeMetadata {
-				continue
-			}
-			if k == SecretLengthMetadata {
-				len, err := strconv.Atoi(v)
-				if err != nil {
-					resp.Diagnostics.AddError("Error reading secret length: "+v, fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
-					return
-				}
-				data.Length = types.Int64Value(int64(len))
-				continue
+	// Secrets created before formats were introduced have no format metadata
+	data.Format = types.StringValue(RawSecretFormat)
+	if format, ok := customMetadata[SecretFormatMetadata]; ok {
+		data.Format = types.StringValue(format)
+	}
+	data.ValueType = types.StringValue(BytesValueType)
+	if valueType, ok := customMetadata[SecretValueTypeMetadata]; ok {
+		data.ValueType = types.StringValue(valueType)
+	}
+
+	if secret.Data != nil {
+		fingerprint, err := randomSecretFingerprint(data.Format.ValueString(), data.ValueType.ValueString(), secret.Data)
+		if err != nil {
+			resp.Diagnostics.AddError("Error reading secret", fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
+			return
+		}
+		data.KeyFingerprint = types.StringValue(fingerprint)
+
+		if username, ok := secret.Data[BasicAuthUsernameKey].(string); ok && data.Format.ValueString() == BasicAuthSecretFormat {
+			data.Username = types.StringValue(username)
+		}
+	}
+
+	data.VersionsKept = types.Int64Value(int64(secret.VersionsKept))
+	data.Version = secretVersion(secret, data.UseLatestVersion)
+	data.DataPath = types.StringValue(secret.DataPath)
+	data.MetadataPath = types.StringValue(secret.MetadataPath)
+	data.DeletionProtection = types.BoolValue(false)
+
+	additionalMetadata := make(map[string]attr.Value)
+	for k, v := range customMetadata {
+		if k == SecretTypeMetadata || k == SecretFormatMetadata || k == SecretValueTypeMetadata || isGenerationMetadata(k) || isExternalSecretMetadata(k) || isStampMetadata(k) || isOwnershipMetadata(k) || isHistoryMetadata(k) {
+			continue
+		}
+		if k == DeletionProtectionMetadata {
+			data.DeletionProtection = types.BoolValue(v == "true")
+			continue
+		}
+		if k == SecretLengthMetadata {
+			len, err := strconv.Atoi(v)
+			if err != nil {
+				resp.Diagnostics.AddError("Error reading secret length: "+v, fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
+				return
 			}
-			additionalMetadata[k] = types.StringValue(v)
+			data.Length = types.Int64Value(int64(len))
+			continue
 		}
-		data.Metadata, _ = types.MapValue(types.StringType, additionalMetadata)
+		additionalMetadata[k] = types.StringValue(v)
+	}
+	data.Metadata = metadataValue(data.Metadata, additionalMetadata, s.ignoredMetadata)
+	data.ExternalSecret = externalSecretValue(data.ExternalSecret, customMetadata)
+
+	// UUIDs have no length metadata, the length attribute keeps its default value
+	if data.Length.IsNull() {
+		data.Length = types.Int64Value(DefaultRandomSecretLength)
+	}
+
+	diags = syncGenerationPrivateState(ctx, resp.Private, customMetadata)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
+	// Only path is set in state when importing an existing resource
+	data.ID = secretPathID(data.Path)
+
 	// ForceDestroy may be null in state when importing an existing resource
 	if data.ForceDestroy.IsNull() {
 		data.ForceDestroy = types.BoolValue(false)
 	}
+	if data.OnDeletedVersion.IsNull() {
+		data.OnDeletedVersion = types.StringValue(OnDeletedVersionError)
+	}
+	if data.DeleteAllVersions.IsNull() {
+		data.DeleteAllVersions = types.BoolValue(true)
+	}
+	if data.DeleteMetadata.IsNull() {
+		data.DeleteMetadata = types.BoolValue(false)
+	}
+	if data.UseLatestVersion.IsNull() {
+		data.UseLatestVersion = types.BoolValue(false)
+	}
+	if data.CasVersion.IsNull() {
+		data.CasVersion = types.BoolValue(false)
+	}
+	if data.LengthChange.IsNull() {
+		data.LengthChange = types.StringValue(LengthChangeReplace)
+	}
+	if data.LargeSecret.IsNull() {
+		data.LargeSecret = types.BoolValue(false)
+	}
+	if data.MountType.IsNull() {
+		data.MountType = types.StringValue(KVv2MountType)
+	}
+	if data.RestoreDeleted.IsNull() {
+		data.RestoreDeleted = types.BoolValue(false)
+	}
 
 	// Set state
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
 
+// readCubbyhole refreshes a secret stored in the cubbyhole. Only its data can be read back, the other attributes are
+// kept from state. The secret is gone when the provider's token has changed, and is then generated again.
+func (s *RandomSecret) readCubbyhole(ctx context.Context, data *randomSecretModel, resp *resource.ReadResponse) {
+	secretPath := data.Path.ValueString()
+
+	secret, err := s.vaultApi.ReadCubbyholeSecret(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+
+	if secret == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	fingerprint, err := randomSecretFingerprint(data.Format.ValueString(), data.ValueType.ValueString(), secret.Data)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading secret", fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
+		return
+	}
+	data.KeyFingerprint = types.StringValue(fingerprint)
+	data.DataPath = types.StringValue(secret.DataPath)
+	data.MetadataPath = types.StringNull()
+
+	diags := resp.State.Set(ctx, data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// readSecret reads the secret, data included, from the mount it is stored in.
+func (s *RandomSecret) readSecret(ctx context.Context, mountType types.String, secretPath string) (*vault.Secret, error) {
+	if mountType.ValueString() == CubbyholeMountType {
+		return s.vaultApi.ReadCubbyholeSecret(ctx, secretPath)
+	}
+	return s.store.ReadSecret(ctx, secretPath)
+}
+
 func (s *RandomSecret) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, s.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan randomSecretModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -238,8 +911,35 @@ func (s *RandomSecret) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	// Check that path, hasn't changed
-	if state.Path.ValueString() != plan.Path.ValueString() {
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s = s.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	// Cubbyhole secrets have no metadata
+	if state.MountType.ValueString() != CubbyholeMountType {
+		// Rolled back first, the checks below fail on a deleted latest version
+		restoreDeletedVersion(ctx, s.store, state.Path.ValueString(), plan.OnDeletedVersion, req.Private, resp.Private, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		checkStrictMode(ctx, s.store, s.strict, state.Path.ValueString(), &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Check that path still refers to the same secret, its spelling may change
+	if !sameSecretPath(state.Path.ValueString(), plan.Path.ValueString()) {
 		resp.Diagnostics.AddError("Error updating random key", fmt.Sprintf("Invalid path change. Random key can't have their path changed (old: %s, new: %s). Only metadata changes are authorized. Delete and recreate the key instead.", state.Path.ValueString(), plan.Path.ValueString()))
 		return
 	}
@@ -252,23 +952,229 @@ func (s *RandomSecret) Update(ctx context.Context, req resource.UpdateRequest, r
 	}
 
 	metadata[SecretTypeMetadata] = RandomSecretType
-	metadata[SecretLengthMetadata] = plan.Length.String()
+	randomSecretMetadata(metadata, plan.Format.ValueString(), plan.ValueType.ValueString(), plan.Length)
 
-	err := s.vaultApi.UpdateSecretMetadata(secretPath, metadata)
-	if err != nil {
-		resp.Diagnostics.AddError("Error updating secret", fmt.Sprintf("Error while updating metadata for secret %s: %s", secretPath, err.Error()))
+	removed := removedMetadataKeys(state.Metadata, plan.Metadata)
+	removed = deletionProtectionMetadata(metadata, removed, plan.DeletionProtection)
+	removed = externalSecretMetadata(metadata, removed, plan.ExternalSecret)
+
+	// Cubbyhole secrets have no metadata
+	if state.MountType.ValueString() != CubbyholeMountType {
+		checkSecretUnchanged(ctx, s.store, secretPath, plan.CasVersion, resp.Private, resp.Private, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// Only planned in place with length_change_behavior = new_version, other changes re-create the secret
+		if !state.Length.Equal(plan.Length) && !s.regenerate(ctx, secretPath, &state, plan, metadata, resp) {
+			return
+		}
+		// Regenerated secrets are already rendered from the new template
+		if state.Length.Equal(plan.Length) && !state.Template.Equal(plan.Template) && !s.render(ctx, secretPath, &state, plan, metadata, resp) {
+			return
+		}
+
+		// Unless already recorded as a rotation
+		if _, rotated := metadata[HistoryMetadata]; !rotated {
+			historyUpdate(ctx, s.store, s.history, secretPath, metadata, HistoryMetadataUpdated, &resp.Diagnostics)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+
+		err := s.store.UpdateSecretMetadata(ctx, secretPath, metadata, removed)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+			return
+		}
+	}
+
+	if !state.HashAlgorithm.Equal(plan.HashAlgorithm) {
+		state.PasswordHash = types.StringNull()
+		if !plan.HashAlgorithm.IsNull() {
+			secret, err := s.readSecret(ctx, state.MountType, secretPath)
+			if err != nil {
+				addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+				return
+			}
+			if secret == nil {
+				resp.Diagnostics.AddError("Error updating secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+				return
+			}
+			state.PasswordHash, err = randomSecretHash(plan.HashAlgorithm, state.Format.ValueString(), secret.Data)
+			if err != nil {
+				resp.Diagnostics.AddError("Error updating secret", fmt.Sprintf("Couldn't hash secret %s: %s", secretPath, err.Error()))
+				return
+			}
+		}
+		state.HashAlgorithm = plan.HashAlgorithm
+	}
+
+	applyPolicyTemplate(ctx, s.vaultApi, secretPath, state.PolicyTemplate, plan.PolicyTemplate, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if plan.Version.IsUnknown() {
+		state.Version = refreshVersion(ctx, s.store, secretPath, plan.UseLatestVersion, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if plan.EscrowCiphertext.IsUnknown() {
+		read := func(ctx context.Context, secretPath string) (*vault.Secret, error) {
+			return s.readSecret(ctx, state.MountType, secretPath)
+		}
+		plan.EscrowCiphertext = refreshEscrow(ctx, read, secretPath, plan.EscrowPublicKey, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	state.Metadata = plan.Metadata
 	state.ForceDestroy = plan.ForceDestroy
+	state.OnDeletedVersion = plan.OnDeletedVersion
+	state.DeleteAllVersions = plan.DeleteAllVersions
+	state.DeleteMetadata = plan.DeleteMetadata
+	state.UseLatestVersion = plan.UseLatestVersion
+	state.CasVersion = plan.CasVersion
+	state.LengthChange = plan.LengthChange
+	state.LargeSecret = plan.LargeSecret
+	state.Template = plan.Template
+	state.DeletionProtection = plan.DeletionProtection
+	state.DestroyAfter = plan.DestroyAfter
+	state.RestoreDeleted = plan.RestoreDeleted
+	state.ExternalSecret = plan.ExternalSecret
+	state.PolicyTemplate = plan.PolicyTemplate
+	state.EscrowPublicKey = plan.EscrowPublicKey
+	state.EscrowCiphertext = plan.EscrowCiphertext
+	state.ExtraHeaders = plan.ExtraHeaders
+	state.Timeouts = plan.Timeouts
 
 	// Set state
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 }
 
+// regenerate writes a new version of the secret generated with the planned length, see length_change_behavior. The
+// generation parameters of the new version and the rotation's history event are added to metadata.
+func (s *RandomSecret) regenerate(ctx context.Context, secretPath string, state *randomSecretModel, plan randomSecretModel, metadata map[string]string, resp *resource.UpdateResponse) bool {
+	secret, err := s.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error regenerating secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return false
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error regenerating secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return false
+	}
+
+	key, err := generateRandomValue(plan.ValueType.ValueString(), int(plan.Length.ValueInt64()))
+	if err != nil {
+		resp.Diagnostics.AddError("Error regenerating secret", fmt.Sprintf("Could generate random bytes, unexpected error: %s", err.Error()))
+		return false
+	}
+	defer secrets.Wipe(key)
+
+	// Large secrets are encoded in the request body only
+	var data map[string]interface{}
+	if plan.LargeSecret.ValueBool() {
+		data = largeSecretData(key)
+	} else {
+		data = randomSecretData(state.Format.ValueString(), state.ValueType.ValueString(), state.Username.ValueString(), key)
+	}
+	if err = renderSecretTemplate(plan.Template, data); err != nil {
+		resp.Diagnostics.AddError("Error regenerating secret", fmt.Sprintf("Couldn't render template: %s", err.Error()))
+		return false
+	}
+	passwordHash, err := randomSecretHash(plan.HashAlgorithm, state.Format.ValueString(), data)
+	if err != nil {
+		resp.Diagnostics.AddError("Error regenerating secret", fmt.Sprintf("Couldn't hash secret: %s", err.Error()))
+		return false
+	}
+
+	err = s.vaultApi.UpdateSecretData(ctx, secretPath, data, secret.Version)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error regenerating secret", fmt.Sprintf("Error while writing secret %s", secretPath), err)
+		return false
+	}
+	historyUpdate(ctx, s.store, s.history, secretPath, metadata, HistoryRotated, &resp.Diagnostics)
+
+	generation := generationParams{
+		Generator:       secrets.RandomSecretGenerator,
+		RNG:             secrets.RNG(),
+		ProviderVersion: s.providerVersion,
+	}
+	generation.addMetadata(metadata)
+	addOwnershipMetadata(metadata, s.ownership)
+	resp.Diagnostics.Append(setGenerationPrivateState(ctx, resp.Private, generation)...)
+
+	secret, err = s.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error regenerating secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return false
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error regenerating secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return false
+	}
+
+	state.Length = plan.Length
+	state.KeyFingerprint = types.StringValue(secrets.Fingerprint(key))
+	state.PasswordHash = passwordHash
+	state.HashAlgorithm = plan.HashAlgorithm
+	state.VersionsKept = types.Int64Value(int64(secret.VersionsKept))
+	state.Version = secretVersion(secret, plan.UseLatestVersion)
+	return !resp.Diagnostics.HasError()
+}
+
+// render writes a new version of the secret rendered from the planned template, the generated values being kept. The
+// rotation's history event is added to metadata.
+func (s *RandomSecret) render(ctx context.Context, secretPath string, state *randomSecretModel, plan randomSecretModel, metadata map[string]string, resp *resource.UpdateResponse) bool {
+	secret, err := s.vaultApi.ReadSecret(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error rendering secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return false
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error rendering secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return false
+	}
+
+	if err = renderSecretTemplate(plan.Template, secret.Data); err != nil {
+		resp.Diagnostics.AddError("Error rendering secret", fmt.Sprintf("Couldn't render template: %s", err.Error()))
+		return false
+	}
+
+	err = s.vaultApi.UpdateSecretData(ctx, secretPath, secret.Data, secret.Version)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error rendering secret", fmt.Sprintf("Error while writing secret %s", secretPath), err)
+		return false
+	}
+	historyUpdate(ctx, s.store, s.history, secretPath, metadata, HistoryRotated, &resp.Diagnostics)
+
+	secret, err = s.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error rendering secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return false
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error rendering secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return false
+	}
+
+	state.VersionsKept = types.Int64Value(int64(secret.VersionsKept))
+	state.Version = secretVersion(secret, plan.UseLatestVersion)
+	return !resp.Diagnostics.HasError()
+}
+
 func (s *RandomSecret) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, s.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var state randomSecretModel
 
 	diags := req.State.Get(ctx, &state)
@@ -277,6 +1183,32 @@ func (s *RandomSecret) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s = s.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if deleteFailedCreate(ctx, s.store, state.Path.ValueString(), req.Private, &resp.Diagnostics) {
+		return
+	}
+
+	// Cubbyhole secrets have no metadata
+	if state.MountType.ValueString() != CubbyholeMountType {
+		checkStrictMode(ctx, s.store, s.strict, state.Path.ValueString(), &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	if !state.ForceDestroy.ValueBool() {
 		resp.Diagnostics.AddError("Error deleting secret", "Can't delete resource for Vault secret '"+state.Path.ValueString()+"': 'force_destroy' must be set to 'true'")
 		return
@@ -284,10 +1216,34 @@ func (s *RandomSecret) Delete(ctx context.Context, req resource.DeleteRequest, r
 
 	secretPath := state.Path.ValueString()
 
-	err := s.vaultApi.DeleteSecret(secretPath)
-	if err != nil {
-		resp.Diagnostics.AddError("Error deleting secret", fmt.Sprintf("Error while deleting secret %s: %s", secretPath, err.Error()))
+	if state.MountType.ValueString() == CubbyholeMountType {
+		err := s.vaultApi.DeleteCubbyholeSecret(ctx, secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error deleting secret", fmt.Sprintf("Error while deleting secret %s", secretPath), err)
+		}
+		return
+	}
+
+	checkDeletionProtection(ctx, s.store, secretPath, state.DeletionProtection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case !state.DeleteAllVersions.ValueBool():
+		deleteManagedVersions(ctx, s.vaultApi, secretPath, state.DeleteMetadata, &resp.Diagnostics)
+	case !state.DestroyAfter.IsNull():
+		scheduleSecretDeletion(ctx, s.vaultApi, secretPath, state.DestroyAfter, &resp.Diagnostics)
+	default:
+		err := s.store.DeleteSecret(ctx, secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error deleting secret", fmt.Sprintf("Error while deleting secret %s", secretPath), err)
+		}
+	}
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	// Revoked last, readers keep their access to a secret whose deletion failed
+	applyPolicyTemplate(ctx, s.vaultApi, secretPath, state.PolicyTemplate, nil, &resp.Diagnostics)
 }
@@ -19,8 +19,13 @@ func TestAccRandomSecret(t *testing.T) {
 				Config: testAccExampleResourceConfig("my_team", false),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr(resourceName, "path", "/secret/foo/bar"),
+					resource.TestCheckResourceAttr(resourceName, "id", "secret/foo/bar"),
 					resource.TestCheckResourceAttr(resourceName, "length", "32"),
 					resource.TestCheckResourceAttr(resourceName, "force_destroy", "false"),
+					resource.TestCheckResourceAttr(resourceName, "deletion_protection", "false"),
+					resource.TestCheckResourceAttr(resourceName, "versions_kept", "1"),
+					resource.TestCheckResourceAttr(resourceName, "data_path", "secret/data/foo/bar"),
+					resource.TestCheckResourceAttr(resourceName, "metadata_path", "secret/metadata/foo/bar"),
 					resource.TestCheckResourceAttr(resourceName, "metadata.owner", "my_team"),
 					resource.TestCheckResourceAttr(resourceName, "metadata.foo", "bar"),
 				),
@@ -41,10 +46,6 @@ func TestAccRandomSecret(t *testing.T) {
 				ImportState:       true,
 				ImportStateVerify: true,
 				ImportStateId:     "/secret/foo/bar",
-
-				// random_secret use the 'path' attribute as identifier while Terraform insist on default on 'id'
-				ImportStateVerifyIgnore:              []string{"id"},
-				ImportStateVerifyIdentifierAttribute: "path",
 			},
 			// ForceDestroy testing (also needed at the end so the resource can be automatically deleted)
 			{
@@ -73,3 +74,71 @@ resource "vaultprov_random_secret" "test" {
 }
 `, team, forceDestroy)
 }
+
+func TestAccRandomSecretLengthNewVersion(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccLengthNewVersionResourceConfig(32),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "length", "32"),
+					resource.TestCheckResourceAttr(resourceName, "versions_kept", "1"),
+				),
+			},
+			// The secret is updated in place, its previous version is kept
+			{
+				Config: testAccLengthNewVersionResourceConfig(48),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", "secret/foo/length"),
+					resource.TestCheckResourceAttr(resourceName, "length", "48"),
+					resource.TestCheckResourceAttr(resourceName, "versions_kept", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccLengthNewVersionResourceConfig(length int) string {
+	return fmt.Sprintf(`
+resource "vaultprov_random_secret" "test" {
+  path                   = "/secret/foo/length"
+  length                 = %d
+  length_change_behavior = "new_version"
+  force_destroy          = true
+}
+`, length)
+}
+
+func TestAccRandomSecretTemplate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTemplateResourceConfig("postgres://app:{{ secret }}@db/app"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "versions_kept", "1"),
+				),
+			},
+			// The secret is rendered again from the new template, the generated value is kept
+			{
+				Config: testAccTemplateResourceConfig("postgres://app:{{ secret }}@db:5432/app"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "versions_kept", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccTemplateResourceConfig(dsn string) string {
+	return fmt.Sprintf(`
+resource "vaultprov_random_secret" "test" {
+  path          = "secret/foo/template"
+  template      = jsonencode({ dsn = %q })
+  force_destroy = true
+}
+`, dsn)
+}
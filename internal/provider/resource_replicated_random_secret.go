@@ -0,0 +1,683 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &ReplicatedRandomSecret{}
+var _ resource.ResourceWithModifyPlan = &ReplicatedRandomSecret{}
+var _ resource.ResourceWithUpgradeState = &ReplicatedRandomSecret{}
+
+// ReplicatedRandomSecret generates one random secret and writes identical copies of it, at the same path, to several
+// Vault clusters or namespaces declared as endpoints of the provider. Copies found missing or drifted are re-synced from
+// a copy still holding the generated value.
+type ReplicatedRandomSecret struct {
+	endpoints       map[string]*vault.VaultApi
+	providerVersion string
+	maxSecretLength int64
+	strict          bool
+	readOnly        bool
+	ownership       map[string]string
+	history         bool
+	ignoredMetadata []string
+}
+
+type replicatedRandomSecretModel struct {
+	ID             types.String    `tfsdk:"id"`
+	Path           secretPathValue `tfsdk:"path"`
+	Targets        types.Set       `tfsdk:"targets"`
+	Length         types.Int64     `tfsdk:"length"`
+	ValueType      types.String    `tfsdk:"type"`
+	Metadata       types.Map       `tfsdk:"metadata"`
+	ForceDestroy   types.Bool      `tfsdk:"force_destroy"`
+	TargetStatus   types.Map       `tfsdk:"target_status"`
+	KeyFingerprint types.String    `tfsdk:"key_fingerprint"`
+	ExtraHeaders   types.Map       `tfsdk:"extra_headers"`
+	Timeouts       timeouts.Value  `tfsdk:"timeouts"`
+}
+
+func NewReplicatedRandomSecret() resource.Resource {
+	return &ReplicatedRandomSecret{}
+}
+
+func (r *ReplicatedRandomSecret) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "replicated_random_secret")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.endpoints = data.endpoints
+	r.providerVersion = data.version
+	r.strict = data.strict
+	r.readOnly = data.readOnly
+	r.ownership = data.ownership
+	r.history = data.history
+	r.ignoredMetadata = data.ignoredMetadata
+	r.maxSecretLength = data.maxSecretLength
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests, to every endpoint, carry the resource's
+// extra_headers.
+func (r *ReplicatedRandomSecret) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *ReplicatedRandomSecret {
+	clone := *r
+	clone.endpoints = make(map[string]*vault.VaultApi, len(r.endpoints))
+	for name, api := range r.endpoints {
+		clone.endpoints[name] = vaultApiWithHeaders(api, extraHeaders, diags)
+	}
+	return &clone
+}
+
+// UpgradeState migrates states stored with a prior schema version, see replicatedRandomSecretSchemaVersion.
+func (r *ReplicatedRandomSecret) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{}
+}
+
+func (r *ReplicatedRandomSecret) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_replicated_random_secret"
+}
+
+func (r *ReplicatedRandomSecret) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: replicatedRandomSecretSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret, as the `path` of the secret resources (e.g. `secret/foo/bar`). The copies are written at this path on every target, whose KV v2 mount must exist.",
+			},
+			"targets": schema.SetAttribute{
+				ElementType: types.StringType,
+				Required:    true,
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+				},
+				MarkdownDescription: "Names of the provider's `endpoints` the secret is written to. Adding a target writes a copy of the secret there. Removing one deletes its copy with `force_destroy`, and leaves it intact with a warning otherwise.",
+			},
+			"length": schema.Int64Attribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					planmodifiers.Int64DefaultValue(types.Int64Value(DefaultRandomSecretLength)),
+					int64planmodifier.RequiresReplace(),
+				},
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				MarkdownDescription: "The length (in bytes, or characters with the `alphanumeric` type) of the secret. Default is 32. Not allowed with the `uuid` type. Changing it generates a new secret.",
+			},
+			"type": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					planmodifiers.StringDefaultValue(types.StringValue(BytesValueType)),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(BytesValueType, HexValueType, UUIDValueType, AlphanumericValueType),
+				},
+				MarkdownDescription: "Type of the secret value, as the `type` of `vaultprov_random_secret`: `bytes` (default, base64 encoded), `hex`, `alphanumeric` or `uuid`. The value is stored under the `secret` key. Changing it generates a new secret.",
+			},
+			"metadata": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					validators.CustomMetadata(MaxUserMetadata),
+				},
+				MarkdownDescription: "A map of key/value strings that will be stored along every copy as custom metadata. Vault limits keys to 128 bytes and values to 512 bytes. At most 48 entries are allowed, the remaining custom metadata being reserved for the provider.",
+			},
+			"force_destroy": schema.BoolAttribute{
+				Optional:            true,
+				Required:            false,
+				MarkdownDescription: "If set to `true`, removing the resource will delete every copy and all their versions in Vault. If set to `false` or not defined, removing the resource will fail.",
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+			},
+			"target_status": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Status of the copy on each target, as of the last refresh: `" + TargetInSync + "`, `" + TargetDrifted + "` (the copy holds another value, e.g. written by hand) or `" + TargetMissing + "` (deleted outside Terraform). Copies not in sync show up as a diff, and are re-synced from a copy in sync on apply.",
+			},
+			"key_fingerprint": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Hex encoded SHA-256 of the random key, identifying the generated value on every target.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "A random secret generated once and written, as identical copies at the same path, to several Vault clusters or namespaces declared in the provider's `endpoints`, e.g. one per region. Each copy is marked with a custom metadata `secret_type` with the value `" + ReplicatedRandomSecretType + "`. Copies deleted or modified outside Terraform are reported in `target_status` and re-synced on apply.",
+	}
+}
+
+func (r *ReplicatedRandomSecret) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource, the deletion is summarized for reviewers
+	if req.Plan.Raw.IsNull() {
+		var state replicatedRandomSecretModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() {
+			replicas := make([]string, 0, len(state.Targets.Elements()))
+			for _, target := range targetNames(state.Targets) {
+				replicas = append(replicas, fmt.Sprintf("%s (%s)", state.Path.ValueString(), target))
+			}
+			warnDestroy(&resp.Diagnostics, secretDestroy{
+				paths:             replicas,
+				forceDestroy:      state.ForceDestroy,
+				deleteAllVersions: types.BoolValue(true),
+			})
+		}
+		return
+	}
+
+	var plan replicatedRandomSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var configLength types.Int64
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("length"), &configLength)...)
+	checkSecretValueType(&resp.Diagnostics, plan.ValueType, configLength)
+	checkIgnoredMetadata(&resp.Diagnostics, r.ignoredMetadata, plan.Metadata)
+
+	// Existing secrets are not affected by a lower limit as long as they are not re-created
+	if req.State.Raw.IsNull() {
+		checkSecretLength(&resp.Diagnostics, plan.Length, r.maxSecretLength)
+	}
+
+	if plan.Targets.IsUnknown() || slices.ContainsFunc(plan.Targets.Elements(), attr.Value.IsUnknown) {
+		return
+	}
+	targets := targetNames(plan.Targets)
+	for _, target := range targets {
+		if _, ok := r.endpoints[target]; !ok {
+			resp.Diagnostics.AddAttributeError(path.Root("targets"), "Unknown endpoint", fmt.Sprintf("Target %s isn't one of the provider's endpoints.", target))
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Copies found out of sync by the refresh are re-synced on apply
+	if !req.State.Raw.IsNull() {
+		var state replicatedRandomSecretModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		var resynced []string
+		for target, status := range state.TargetStatus.Elements() {
+			if s := status.(types.String).ValueString(); s != TargetInSync && slices.Contains(targets, target) {
+				resynced = append(resynced, fmt.Sprintf("%s (%s)", target, s))
+			}
+		}
+		if len(resynced) > 0 {
+			slices.Sort(resynced)
+			resp.Diagnostics.AddWarning("Replicated secret out of sync", fmt.Sprintf("The copies of secret %s on targets %s will be re-synced with the value generated by Terraform.", state.Path.ValueString(), strings.Join(resynced, ", ")))
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("target_status"), syncedTargetStatus(targets))...)
+}
+
+func (r *ReplicatedRandomSecret) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan replicatedRandomSecretModel
+
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	checkSecretLength(&response.Diagnostics, plan.Length, r.maxSecretLength)
+	targets := targetNames(plan.Targets)
+	r.checkTargets(targets, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	valueType := plan.ValueType.ValueString()
+	key, err := generateRandomValue(valueType, int(plan.Length.ValueInt64()))
+	if err != nil {
+		response.Diagnostics.AddError("Error creating replicated secret", fmt.Sprintf("Could generate random bytes, unexpected error: %s", err.Error()))
+		return
+	}
+	defer secrets.Wipe(key)
+
+	generation := generationParams{
+		Generator:       secrets.RandomSecretGenerator,
+		RNG:             secrets.RNG(),
+		ProviderVersion: r.providerVersion,
+	}
+	secretPath := plan.Path.ValueString()
+	data := map[string]interface{}{SecretDataKey: encodeRandomValue(RawSecretFormat, valueType, key)}
+
+	for i, target := range targets {
+		metadata := r.replicaMetadata(plan)
+		generation.addMetadata(metadata)
+		addOwnershipMetadata(metadata, r.ownership)
+		addHistoryMetadata(metadata, r.history, HistoryCreated)
+
+		if _, err = r.endpoints[target].CreateSecret(ctx, vault.Secret{Path: secretPath, Data: data, Metadata: metadata}); err != nil {
+			addVaultError(&response.Diagnostics, "Error creating replicated secret", fmt.Sprintf("Couldn't create the copy of secret %s on endpoint %s", secretPath, target), err)
+			// Copies written so far would hold a value unknown to Terraform
+			r.deleteReplicas(ctx, secretPath, targets[:i], &response.Diagnostics)
+			return
+		}
+	}
+
+	diags = setGenerationPrivateState(ctx, response.Private, generation)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.KeyFingerprint = types.StringValue(secrets.Fingerprint(key))
+	plan.TargetStatus = syncedTargetStatus(targets)
+	plan.ID = secretPathID(plan.Path)
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+func (r *ReplicatedRandomSecret) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data replicatedRandomSecretModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	secretPath := data.Path.ValueString()
+	targets := r.knownTargets(secretPath, targetNames(data.Targets), &resp.Diagnostics)
+	// Nothing can be read, the state is kept as is
+	if len(targets) == 0 {
+		return
+	}
+
+	status := make(map[string]attr.Value, len(targets))
+	var synced, found *vault.Secret
+	for _, target := range targets {
+		secret, _, err := readReplica(ctx, r.endpoints[target], secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading the copy of secret %s on endpoint %s", secretPath, target), err)
+			return
+		}
+		checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+
+		s := replicaStatus(secret, data.ValueType.ValueString(), data.KeyFingerprint.ValueString())
+		status[target] = types.StringValue(s)
+		if s == TargetInSync && synced == nil {
+			synced = secret
+		}
+		if secret != nil && found == nil {
+			found = secret
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Deleted everywhere, the secret is generated again
+	if found == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if synced != nil {
+		found = synced
+	}
+
+	additionalMetadata := make(map[string]attr.Value)
+	for k, v := range found.Metadata {
+		if isReplicatedSecretMetadata(k) || isGenerationMetadata(k) || isStampMetadata(k) || isOwnershipMetadata(k) || isHistoryMetadata(k) {
+			continue
+		}
+		additionalMetadata[k] = types.StringValue(v)
+	}
+	data.Metadata = metadataValue(data.Metadata, additionalMetadata, r.ignoredMetadata)
+	data.TargetStatus = types.MapValueMust(types.StringType, status)
+
+	if synced != nil {
+		diags = syncGenerationPrivateState(ctx, resp.Private, synced.Metadata)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.ForceDestroy.IsNull() {
+		data.ForceDestroy = types.BoolValue(false)
+	}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ReplicatedRandomSecret) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan replicatedRandomSecretModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state replicatedRandomSecretModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	targets := targetNames(plan.Targets)
+	var removedTargets []string
+	for _, target := range targetNames(state.Targets) {
+		if !slices.Contains(targets, target) {
+			removedTargets = append(removedTargets, target)
+		}
+	}
+	secretPath := state.Path.ValueString()
+	r.checkTargets(targets, &resp.Diagnostics)
+	removedTargets = r.knownTargets(secretPath, removedTargets, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The value generated by Terraform is only known from the copies still in sync, removed targets included
+	valueType := state.ValueType.ValueString()
+	replicas := make(map[string]*vault.Secret, len(targets))
+	deleted := make(map[string]bool, len(targets))
+	var value interface{}
+	for _, target := range append(slices.Clone(targets), removedTargets...) {
+		secret, isDeleted, err := readReplica(ctx, r.endpoints[target], secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error updating replicated secret", fmt.Sprintf("Error while reading the copy of secret %s on endpoint %s", secretPath, target), err)
+			return
+		}
+		checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+		replicas[target], deleted[target] = secret, isDeleted
+		if value == nil && replicaStatus(secret, valueType, state.KeyFingerprint.ValueString()) == TargetInSync {
+			value = secret.Data[SecretDataKey]
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if value == nil {
+		resp.Diagnostics.AddError("Error updating replicated secret", fmt.Sprintf("No copy of secret %s holds the value generated by Terraform anymore (key_fingerprint %s), the copies can't be re-synced. Replace the resource to generate a new secret.", secretPath, state.KeyFingerprint.ValueString()))
+		return
+	}
+
+	data := map[string]interface{}{SecretDataKey: value}
+	removed := removedMetadataKeys(state.Metadata, plan.Metadata)
+	for _, target := range targets {
+		r.syncReplica(ctx, target, secretPath, plan, replicas[target], deleted[target], data, removed, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if plan.ForceDestroy.ValueBool() {
+		r.deleteReplicas(ctx, secretPath, removedTargets, &resp.Diagnostics)
+	} else if len(removedTargets) > 0 {
+		resp.Diagnostics.AddWarning("Copies left intact", fmt.Sprintf("The copies of secret %s on endpoints %s, removed from the targets, have not been deleted: force_destroy isn't set.", secretPath, strings.Join(removedTargets, ", ")))
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Targets = plan.Targets
+	state.Metadata = plan.Metadata
+	state.ForceDestroy = plan.ForceDestroy
+	state.TargetStatus = syncedTargetStatus(targets)
+	state.ExtraHeaders = plan.ExtraHeaders
+	state.Timeouts = plan.Timeouts
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ReplicatedRandomSecret) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state replicatedRandomSecretModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if !state.ForceDestroy.ValueBool() {
+		resp.Diagnostics.AddError("Error deleting secret", "Can't delete resource for replicated secret '"+state.Path.ValueString()+"': 'force_destroy' must be set to 'true'")
+		return
+	}
+
+	secretPath := state.Path.ValueString()
+	targets := r.knownTargets(secretPath, targetNames(state.Targets), &resp.Diagnostics)
+	for _, target := range targets {
+		checkStrictMode(ctx, r.endpoints[target], r.strict, secretPath, &resp.Diagnostics)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.deleteReplicas(ctx, secretPath, targets, &resp.Diagnostics)
+}
+
+// checkTargets reports an error when a target isn't one of the provider's endpoints, e.g. removed from the provider's
+// configuration since the secret was written.
+func (r *ReplicatedRandomSecret) checkTargets(targets []string, diags *diag.Diagnostics) {
+	for _, target := range targets {
+		if _, ok := r.endpoints[target]; !ok {
+			diags.AddError("Unknown endpoint", fmt.Sprintf("Target %s isn't one of the provider's endpoints.", target))
+		}
+	}
+}
+
+// knownTargets returns the targets that are endpoints of the provider. The others, e.g. removed from the provider's
+// configuration along with the targets, are reported as warnings and their copies of the secret left untouched.
+func (r *ReplicatedRandomSecret) knownTargets(secretPath string, targets []string, diags *diag.Diagnostics) []string {
+	known := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if _, ok := r.endpoints[target]; !ok {
+			diags.AddWarning("Unknown endpoint", fmt.Sprintf("Target %s isn't one of the provider's endpoints anymore, the copy of secret %s it may hold is left untouched.", target, secretPath))
+			continue
+		}
+		known = append(known, target)
+	}
+	return known
+}
+
+// replicaMetadata returns the custom metadata of the copies of the secret: the user's metadata and the ones describing
+// how the secret is generated.
+func (r *ReplicatedRandomSecret) replicaMetadata(plan replicatedRandomSecretModel) map[string]string {
+	metadata := make(map[string]string)
+	for k, v := range plan.Metadata.Elements() {
+		metadata[k] = v.(types.String).ValueString()
+	}
+	metadata[SecretTypeMetadata] = ReplicatedRandomSecretType
+	randomSecretMetadata(metadata, RawSecretFormat, plan.ValueType.ValueString(), plan.Length)
+	return metadata
+}
+
+// syncReplica brings the copy of the secret on target in sync with data, the value generated by Terraform, and writes
+// the planned custom metadata. secret is the copy read on the target, nil when missing or deleted.
+func (r *ReplicatedRandomSecret) syncReplica(ctx context.Context, target, secretPath string, plan replicatedRandomSecretModel, secret *vault.Secret, deleted bool, data map[string]interface{}, removed []string, diags *diag.Diagnostics) {
+	api := r.endpoints[target]
+	metadata := r.replicaMetadata(plan)
+
+	if secret == nil {
+		addOwnershipMetadata(metadata, r.ownership)
+		addHistoryMetadata(metadata, r.history, HistoryCreated)
+		var err error
+		if deleted {
+			_, err = api.ReplaceDeletedSecret(ctx, vault.Secret{Path: secretPath, Data: data, Metadata: metadata})
+		} else {
+			_, err = api.CreateSecret(ctx, vault.Secret{Path: secretPath, Data: data, Metadata: metadata})
+		}
+		if err != nil {
+			addVaultError(diags, "Error updating replicated secret", fmt.Sprintf("Couldn't write the copy of secret %s on endpoint %s", secretPath, target), err)
+		}
+		return
+	}
+
+	if replicaStatus(secret, plan.ValueType.ValueString(), plan.KeyFingerprint.ValueString()) != TargetInSync {
+		if err := api.UpdateSecretData(ctx, secretPath, data, secret.Version); err != nil {
+			addVaultError(diags, "Error updating replicated secret", fmt.Sprintf("Couldn't re-sync the copy of secret %s on endpoint %s", secretPath, target), err)
+			return
+		}
+	}
+
+	historyUpdate(ctx, api, r.history, secretPath, metadata, HistoryMetadataUpdated, diags)
+	if diags.HasError() {
+		return
+	}
+	if err := api.UpdateSecretMetadata(ctx, secretPath, metadata, removed); err != nil {
+		addVaultError(diags, "Error updating replicated secret", fmt.Sprintf("Error while updating metadata for the copy of secret %s on endpoint %s", secretPath, target), err)
+	}
+}
+
+// deleteReplicas deletes the copies of the secret on targets, going on after a failure so that as few copies as
+// possible remain. Copies already deleted are skipped.
+func (r *ReplicatedRandomSecret) deleteReplicas(ctx context.Context, secretPath string, targets []string, diags *diag.Diagnostics) {
+	for _, target := range targets {
+		api := r.endpoints[target]
+		if secret, err := api.ReadSecretMetadata(ctx, secretPath); err == nil && secret == nil {
+			continue
+		}
+		if err := api.DeleteSecret(ctx, secretPath); err != nil {
+			addVaultError(diags, "Error deleting secret", fmt.Sprintf("Error while deleting the copy of secret %s on endpoint %s", secretPath, target), err)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestReplicatedRandomSecret(t *testing.T) {
+	endpoints := map[string]*fakeKV{"a": newFakeKV(t), "b": newFakeKV(t), "c": newFakeKV(t)}
+	r := newTestResource(t, endpoints, "vaultprov_replicated_random_secret")
+
+	// Create
+	r.apply(r.config(map[string]tftypes.Value{
+		"path":          tftypes.NewValue(tftypes.String, "secret/foo"),
+		"targets":       testStringSet("a", "b"),
+		"force_destroy": tftypes.NewValue(tftypes.Bool, true),
+	}))
+	value := endpoints["a"].value("foo")
+	if value == "" || endpoints["b"].value("foo") != value {
+		t.Fatalf("Copies differ: %q on a, %q on b", value, endpoints["b"].value("foo"))
+	}
+	if endpoints["c"].exists("foo") {
+		t.Fatal("Copy written on c, which isn't a target")
+	}
+	if secretType := endpoints["b"].metadata("foo")[SecretTypeMetadata]; secretType != ReplicatedRandomSecretType {
+		t.Fatalf("Wrong secret type on b: %v", secretType)
+	}
+
+	// Update: a is replaced by c, force_destroy deleting the copy on a
+	r.apply(r.config(map[string]tftypes.Value{
+		"path":          tftypes.NewValue(tftypes.String, "secret/foo"),
+		"targets":       testStringSet("b", "c"),
+		"force_destroy": tftypes.NewValue(tftypes.Bool, true),
+	}))
+	if endpoints["a"].exists("foo") {
+		t.Fatal("Copy on a not deleted with force_destroy")
+	}
+	for _, target := range []string{"b", "c"} {
+		if v := endpoints[target].value("foo"); v != value {
+			t.Fatalf("Wrong value on %s: %q. Expected: %q", target, v, value)
+		}
+	}
+
+	r.read()
+	var status map[string]tftypes.Value
+	if err := r.attribute("target_status").As(&status); err != nil {
+		t.Fatal("error:", err)
+	}
+	if len(status) != 2 || !status["b"].Equal(tftypes.NewValue(tftypes.String, TargetInSync)) || !status["c"].Equal(tftypes.NewValue(tftypes.String, TargetInSync)) {
+		t.Fatalf("Wrong target status: %v", status)
+	}
+
+	// Delete
+	r.apply(tftypes.NewValue(r.typ, nil))
+	for target, kv := range endpoints {
+		if kv.exists("foo") {
+			t.Fatalf("Copy on %s not deleted", target)
+		}
+	}
+}
+
+func TestReplicatedRandomSecretPartialFailure(t *testing.T) {
+	endpoints := map[string]*fakeKV{"a": newFakeKV(t), "b": newFakeKV(t)}
+	endpoints["b"].failWrites = true
+	r := newTestResource(t, endpoints, "vaultprov_replicated_random_secret")
+
+	diags := r.tryApply(r.config(map[string]tftypes.Value{
+		"path":    tftypes.NewValue(tftypes.String, "secret/foo"),
+		"targets": testStringSet("a", "b"),
+	}))
+	if !hasErrorDiagnostic(diags) {
+		t.Fatal("Expected an error writing the copy on b")
+	}
+	// The copy written on a would hold a value unknown to Terraform
+	if endpoints["a"].exists("foo") {
+		t.Fatal("Copy on a not deleted after the failure on b")
+	}
+	if !r.state.IsNull() {
+		t.Fatalf("Resource created despite the failure: %v", r.state)
+	}
+}
+
+// testResource drives a resource of the provider through the protocol, as Terraform does, the provider's endpoints
+// being fake KV v2 engines.
+type testResource struct {
+	t        *testing.T
+	server   tfprotov6.ProviderServer
+	typeName string
+	typ      tftypes.Object
+	computed map[string]bool
+	state    tftypes.Value
+	private  []byte
+}
+
+func newTestResource(t *testing.T, endpoints map[string]*fakeKV, typeName string) *testResource {
+	return newConfiguredTestResource(t, typeName, func(providerType tftypes.Object) map[string]tftypes.Value {
+		endpointsType := providerType.AttributeTypes["endpoints"].(tftypes.Map)
+		endpointType := endpointsType.ElementType.(tftypes.Object)
+		endpointValues := make(map[string]tftypes.Value, len(endpoints))
+		var address string
+		for name, kv := range endpoints {
+			endpointValues[name] = testObject(endpointType, map[string]tftypes.Value{
+				"address": tftypes.NewValue(tftypes.String, kv.server.URL),
+				"token":   tftypes.NewValue(tftypes.String, "test"),
+			})
+			address = kv.server.URL
+		}
+		return map[string]tftypes.Value{
+			"address":   tftypes.NewValue(tftypes.String, address),
+			"token":     tftypes.NewValue(tftypes.String, "test"),
+			"endpoints": tftypes.NewValue(endpointsType, endpointValues),
+		}
+	})
+}
+
+// newConfiguredTestResource returns a resource of the provider configured with the attributes returned by
+// providerConfig, the others being null.
+func newConfiguredTestResource(t *testing.T, typeName string, providerConfig func(providerType tftypes.Object) map[string]tftypes.Value) *testResource {
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	ctx := context.Background()
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	checkDiagnostics(t, schemas.Diagnostics)
+
+	providerType := schemas.Provider.ValueType().(tftypes.Object)
+	config := testDynamicValue(t, providerType, testObject(providerType, providerConfig(providerType)))
+	configured, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{TerraformVersion: "1.6.0", Config: &config})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	checkDiagnostics(t, configured.Diagnostics)
+
+	resourceSchema := schemas.ResourceSchemas[typeName]
+	computed := make(map[string]bool)
+	for _, attribute := range resourceSchema.Block.Attributes {
+		computed[attribute.Name] = attribute.Computed
+	}
+	typ := resourceSchema.ValueType().(tftypes.Object)
+	return &testResource{
+		t:        t,
+		server:   server,
+		typeName: typeName,
+		typ:      typ,
+		computed: computed,
+		state:    tftypes.NewValue(typ, nil),
+	}
+}
+
+// config returns the configuration of the resource with the given attributes, the others being null.
+func (r *testResource) config(values map[string]tftypes.Value) tftypes.Value {
+	return testObject(r.typ, values)
+}
+
+// apply plans and applies config, a null config destroying the resource, and fails the test on error.
+func (r *testResource) apply(config tftypes.Value) {
+	checkDiagnostics(r.t, r.tryApply(config))
+}
+
+// tryApply plans and applies config, a null config destroying the resource, and returns the diagnostics.
+func (r *testResource) tryApply(config tftypes.Value) []*tfprotov6.Diagnostic {
+	ctx := context.Background()
+	prior := testDynamicValue(r.t, r.typ, r.state)
+	configValue := testDynamicValue(r.t, r.typ, config)
+
+	plan := r.plan(config)
+	if hasErrorDiagnostic(plan.Diagnostics) {
+		return plan.Diagnostics
+	}
+
+	applied, err := r.server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       r.typeName,
+		PriorState:     &prior,
+		PlannedState:   plan.PlannedState,
+		Config:         &configValue,
+		PlannedPrivate: plan.PlannedPrivate,
+	})
+	if err != nil {
+		r.t.Fatal("error:", err)
+	}
+	if applied.NewState != nil {
+		if r.state, err = applied.NewState.Unmarshal(r.typ); err != nil {
+			r.t.Fatal("error:", err)
+		}
+		r.private = applied.Private
+	}
+	return append(plan.Diagnostics, applied.Diagnostics...)
+}
+
+// plan plans the change of the resource to config, a null config destroying the resource.
+func (r *testResource) plan(config tftypes.Value) *tfprotov6.PlanResourceChangeResponse {
+	prior := testDynamicValue(r.t, r.typ, r.state)
+	configValue := testDynamicValue(r.t, r.typ, config)
+	proposed := testDynamicValue(r.t, r.typ, r.proposedNewState(config))
+
+	plan, err := r.server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         r.typeName,
+		PriorState:       &prior,
+		ProposedNewState: &proposed,
+		Config:           &configValue,
+		PriorPrivate:     r.private,
+	})
+	if err != nil {
+		r.t.Fatal("error:", err)
+	}
+	return plan
+}
+
+// proposedNewState merges config with the prior state as Terraform does: computed attributes left null in config keep
+// their prior value.
+func (r *testResource) proposedNewState(config tftypes.Value) tftypes.Value {
+	if config.IsNull() || r.state.IsNull() {
+		return config
+	}
+	var configAttributes, priorAttributes map[string]tftypes.Value
+	if err := config.As(&configAttributes); err != nil {
+		r.t.Fatal("error:", err)
+	}
+	if err := r.state.As(&priorAttributes); err != nil {
+		r.t.Fatal("error:", err)
+	}
+	for name, value := range configAttributes {
+		if value.IsNull() && r.computed[name] {
+			configAttributes[name] = priorAttributes[name]
+		}
+	}
+	return tftypes.NewValue(r.typ, configAttributes)
+}
+
+// read refreshes the state of the resource and fails the test on error.
+func (r *testResource) read() {
+	current := testDynamicValue(r.t, r.typ, r.state)
+	read, err := r.server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     r.typeName,
+		CurrentState: &current,
+		Private:      r.private,
+	})
+	if err != nil {
+		r.t.Fatal("error:", err)
+	}
+	checkDiagnostics(r.t, read.Diagnostics)
+	if r.state, err = read.NewState.Unmarshal(r.typ); err != nil {
+		r.t.Fatal("error:", err)
+	}
+	r.private = read.Private
+}
+
+// attribute returns the value of an attribute in the state of the resource.
+func (r *testResource) attribute(name string) tftypes.Value {
+	var attributes map[string]tftypes.Value
+	if err := r.state.As(&attributes); err != nil {
+		r.t.Fatal("error:", err)
+	}
+	return attributes[name]
+}
+
+func testObject(typ tftypes.Object, values map[string]tftypes.Value) tftypes.Value {
+	attributes := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+	for name, attributeType := range typ.AttributeTypes {
+		if value, ok := values[name]; ok {
+			attributes[name] = value
+		} else {
+			attributes[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+	return tftypes.NewValue(typ, attributes)
+}
+
+func testStringSet(values ...string) tftypes.Value {
+	elements := make([]tftypes.Value, 0, len(values))
+	for _, value := range values {
+		elements = append(elements, tftypes.NewValue(tftypes.String, value))
+	}
+	return tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, elements)
+}
+
+func testDynamicValue(t *testing.T, typ tftypes.Type, value tftypes.Value) tfprotov6.DynamicValue {
+	dynamicValue, err := tfprotov6.NewDynamicValue(typ, value)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	return dynamicValue
+}
+
+func hasErrorDiagnostic(diags []*tfprotov6.Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDiagnosticDetail(diags []*tfprotov6.Diagnostic, detail string) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError && strings.Contains(d.Detail, detail) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkDiagnostics(t *testing.T, diags []*tfprotov6.Diagnostic) {
+	t.Helper()
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("Unexpected error: %s: %s", d.Summary, d.Detail)
+		}
+	}
+}
+
+// fakeKV is an in-memory KV v2 secrets engine mounted at secret/, standing in for a Vault cluster. It only serves the
+// requests needed to manage secrets with a root token.
+type fakeKV struct {
+	t      *testing.T
+	server *httptest.Server
+	mu     sync.Mutex
+	// secrets are the versions of the data and the custom metadata of each secret, by path in the mount
+	secrets map[string]*fakeKVSecret
+	// failWrites makes data writes fail, as when the cluster is unavailable
+	failWrites bool
+	// failMetadataWrites makes metadata writes fail once the secret has a version, e.g. when writing the metadata of a
+	// secret just created
+	failMetadataWrites bool
+}
+
+type fakeKVSecret struct {
+	versions       []map[string]interface{}
+	customMetadata map[string]interface{}
+	createdTime    string
+}
+
+func newFakeKV(t *testing.T) *fakeKV {
+	kv := &fakeKV{t: t, secrets: make(map[string]*fakeKVSecret)}
+	kv.server = httptest.NewServer(http.HandlerFunc(kv.serveHTTP))
+	t.Cleanup(kv.server.Close)
+	return kv
+}
+
+// newUnixFakeKV returns a fakeKV listening on a unix socket, and the path of the socket.
+func newUnixFakeKV(t *testing.T) (*fakeKV, string) {
+	// Unix socket paths are limited to about a hundred characters, too few for t.TempDir()
+	dir, err := os.MkdirTemp("", "vaultprov")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "vault.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	kv := &fakeKV{t: t, secrets: make(map[string]*fakeKVSecret)}
+	kv.server = httptest.NewUnstartedServer(http.HandlerFunc(kv.serveHTTP))
+	kv.server.Listener = listener
+	kv.server.Start()
+	t.Cleanup(kv.server.Close)
+	return kv, socket
+}
+
+// exists tells if a secret, or only its metadata, is stored at path.
+func (kv *fakeKV) exists(path string) bool {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	_, ok := kv.secrets[path]
+	return ok
+}
+
+// value returns the value of the latest version of the secret at path, empty when there is none.
+func (kv *fakeKV) value(path string) string {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	secret, ok := kv.secrets[path]
+	if !ok || len(secret.versions) == 0 {
+		return ""
+	}
+	value, _ := secret.versions[len(secret.versions)-1][SecretDataKey].(string)
+	return value
+}
+
+// metadata returns the custom metadata of the secret at path.
+func (kv *fakeKV) metadata(path string) map[string]interface{} {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	if secret, ok := kv.secrets[path]; ok {
+		return secret.customMetadata
+	}
+	return nil
+}
+
+func (kv *fakeKV) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	var body map[string]interface{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			kv.t.Errorf("Invalid request body for %s %s: %s", r.Method, r.URL.Path, err)
+		}
+	}
+
+	switch p := r.URL.Path; {
+	case strings.HasPrefix(p, "/v1/sys/internal/ui/mounts/"):
+		kv.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"path": "secret/", "type": "kv", "options": map[string]interface{}{"version": "2"}}})
+	case p == "/v1/sys/capabilities-self":
+		kv.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"capabilities": []string{"root"}}})
+	case strings.HasPrefix(p, "/v1/secret/data/"):
+		kv.serveData(w, r.Method, strings.TrimPrefix(p, "/v1/secret/data/"), body)
+	case strings.HasPrefix(p, "/v1/secret/metadata/"):
+		kv.serveMetadata(w, r.Method, strings.TrimPrefix(p, "/v1/secret/metadata/"), body)
+	default:
+		kv.t.Errorf("Unexpected request %s %s", r.Method, p)
+		kv.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func (kv *fakeKV) serveData(w http.ResponseWriter, method, path string, body map[string]interface{}) {
+	secret := kv.secrets[path]
+	switch method {
+	case http.MethodGet:
+		if secret == nil || len(secret.versions) == 0 {
+			kv.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		kv.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"data":     secret.versions[len(secret.versions)-1],
+			"metadata": map[string]interface{}{"version": len(secret.versions), "created_time": secret.createdTime, "deletion_time": "", "destroyed": false},
+		}})
+	case http.MethodPut, http.MethodPost:
+		if kv.failWrites {
+			kv.reply(w, http.StatusServiceUnavailable, map[string]interface{}{"errors": []string{"Vault is sealed"}})
+			return
+		}
+		if secret == nil {
+			secret = kv.newSecret(path)
+		}
+		if options, ok := body["options"].(map[string]interface{}); ok {
+			if cas, ok := options["cas"].(float64); ok && int(cas) != len(secret.versions) {
+				kv.reply(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"check-and-set parameter did not match the current version"}})
+				return
+			}
+		}
+		data, _ := body["data"].(map[string]interface{})
+		secret.versions = append(secret.versions, data)
+		kv.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"version": len(secret.versions)}})
+	default:
+		kv.t.Errorf("Unexpected request %s on data of %s", method, path)
+		kv.reply(w, http.StatusMethodNotAllowed, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func (kv *fakeKV) serveMetadata(w http.ResponseWriter, method, path string, body map[string]interface{}) {
+	secret := kv.secrets[path]
+	switch method {
+	case http.MethodGet:
+		if secret == nil {
+			kv.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		versions := make(map[string]interface{}, len(secret.versions))
+		for i := range secret.versions {
+			versions[strconv.Itoa(i+1)] = map[string]interface{}{"created_time": secret.createdTime, "deletion_time": "", "destroyed": false}
+		}
+		oldestVersion := 0
+		if len(secret.versions) > 0 {
+			oldestVersion = 1
+		}
+		kv.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"current_version": len(secret.versions),
+			"oldest_version":  oldestVersion,
+			"custom_metadata": secret.customMetadata,
+			"versions":        versions,
+			"created_time":    secret.createdTime,
+			"updated_time":    secret.createdTime,
+			"max_versions":    0,
+		}})
+	case http.MethodPut, http.MethodPost, http.MethodPatch:
+		if kv.failMetadataWrites && secret != nil && len(secret.versions) > 0 {
+			kv.reply(w, http.StatusServiceUnavailable, map[string]interface{}{"errors": []string{"Vault is sealed"}})
+			return
+		}
+		if secret == nil {
+			secret = kv.newSecret(path)
+		}
+		customMetadata, _ := body["custom_metadata"].(map[string]interface{})
+		if method != http.MethodPatch {
+			secret.customMetadata = make(map[string]interface{})
+		}
+		for k, v := range customMetadata {
+			if v == nil {
+				delete(secret.customMetadata, k)
+			} else {
+				secret.customMetadata[k] = v
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		delete(kv.secrets, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		kv.t.Errorf("Unexpected request %s on metadata of %s", method, path)
+		kv.reply(w, http.StatusMethodNotAllowed, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func (kv *fakeKV) newSecret(path string) *fakeKVSecret {
+	secret := &fakeKVSecret{customMetadata: make(map[string]interface{}), createdTime: time.Now().UTC().Format(time.RFC3339Nano)}
+	kv.secrets[path] = secret
+	return secret
+}
+
+func (kv *fakeKV) reply(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		kv.t.Errorf("Can't encode response: %s", err)
+	}
+}
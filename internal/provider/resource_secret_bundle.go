@@ -0,0 +1,814 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"reflect"
+	"regexp"
+)
+
+const (
+	SecretBundleType           = "secret_bundle"
+	SecretBundleFieldsMetadata = "secret_bundle_fields"
+)
+
+var bundleFieldNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &SecretBundle{}
+var _ resource.ResourceWithImportState = &SecretBundle{}
+var _ resource.ResourceWithModifyPlan = &SecretBundle{}
+var _ resource.ResourceWithUpgradeState = &SecretBundle{}
+
+type SecretBundle struct {
+	vaultApi        *vault.VaultApi
+	providerVersion string
+	maxSecretLength int64
+	strict          bool
+	readOnly        bool
+	ownership       map[string]string
+	history         bool
+	ignoredMetadata []string
+	fipsMode        bool
+	kvMounts        []vault.KVMount
+	storage         *storageEstimate
+	createMounts    bool
+}
+
+type secretBundleModel struct {
+	ID                 types.String                      `tfsdk:"id"`
+	Path               secretPathValue                   `tfsdk:"path"`
+	Fields             map[string]secretBundleFieldModel `tfsdk:"fields"`
+	Metadata           types.Map                         `tfsdk:"metadata"`
+	ForceDestroy       types.Bool                        `tfsdk:"force_destroy"`
+	OnDeletedVersion   types.String                      `tfsdk:"on_deleted_version"`
+	DeleteAllVersions  types.Bool                        `tfsdk:"delete_all_versions"`
+	DeleteMetadata     types.Bool                        `tfsdk:"delete_metadata"`
+	DeletionProtection types.Bool                        `tfsdk:"deletion_protection"`
+	DestroyAfter       types.String                      `tfsdk:"destroy_after"`
+	ExternalSecret     *externalSecretModel              `tfsdk:"external_secret"`
+	PolicyTemplate     *policyTemplateModel              `tfsdk:"policy_template"`
+	EscrowPublicKey    types.String                      `tfsdk:"escrow_public_key"`
+	EscrowCiphertext   types.String                      `tfsdk:"escrow_ciphertext"`
+	VersionsKept       types.Int64                       `tfsdk:"versions_kept"`
+	Version            types.Int64                       `tfsdk:"version"`
+	UseLatestVersion   types.Bool                        `tfsdk:"use_latest_version"`
+	CasVersion         types.Bool                        `tfsdk:"cas_version"`
+	DataPath           types.String                      `tfsdk:"data_path"`
+	MetadataPath       types.String                      `tfsdk:"metadata_path"`
+	ExtraHeaders       types.Map                         `tfsdk:"extra_headers"`
+	Timeouts           timeouts.Value                    `tfsdk:"timeouts"`
+}
+
+func NewSecretBundle() resource.Resource {
+	return &SecretBundle{}
+}
+
+func (r *SecretBundle) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "secret_bundle")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.vaultApi = data.vaultApi
+	r.providerVersion = data.version
+	r.strict = data.strict
+	r.readOnly = data.readOnly
+	r.ownership = data.ownership
+	r.history = data.history
+	r.ignoredMetadata = data.ignoredMetadata
+	r.fipsMode = data.fipsMode
+	r.kvMounts = data.kvMounts
+	r.storage = data.storage
+	r.createMounts = data.createMounts
+	r.maxSecretLength = data.maxSecretLength
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (r *SecretBundle) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *SecretBundle {
+	clone := *r
+	clone.vaultApi = vaultApiWithHeaders(r.vaultApi, extraHeaders, diags)
+	return &clone
+}
+
+func (r *SecretBundle) ImportState(ctx context.Context, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
+	importSecret(ctx, r.vaultApi, SecretBundleType, r.history, request, response)
+}
+
+// UpgradeState migrates states stored with a prior schema version, see secretBundleSchemaVersion.
+func (r *SecretBundle) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: rawStateUpgrader(normalizeSecretPathID),
+	}
+}
+
+func (r *SecretBundle) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_secret_bundle"
+}
+
+func (r *SecretBundle) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: secretBundleSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret. For a nested secret the name is the nested path excluding the mount and data prefix. For example, for a secret at `keys/data/foo/bar/baz` the name is `foo/bar/baz`. Serves as the secret id.",
+			},
+			"fields": schema.MapNestedAttribute{
+				Required: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"length": schema.Int64Attribute{
+							Optional: true,
+							Computed: true,
+							PlanModifiers: []planmodifier.Int64{
+								planmodifiers.Int64DefaultValue(types.Int64Value(DefaultRandomSecretLength)),
+							},
+							Validators: []validator.Int64{
+								int64validator.AtLeast(1),
+							},
+							MarkdownDescription: "The length (in bytes) of the field. Default is 32. Changing it rotates the field.",
+						},
+						"previous_field": schema.StringAttribute{
+							Optional: true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(bundleFieldNameRegexp, "must only contain letters, digits, '_' and '-'"),
+							},
+							MarkdownDescription: "Name of the secret data key receiving the previous value of the field when it is rotated, e.g. `previous_key` for a `current_key` field, so that values generated with the previous key can still be verified.",
+						},
+						"rotation_trigger": schema.StringAttribute{
+							Optional:            true,
+							MarkdownDescription: "Arbitrary value, changing it rotates the field, e.g. a date or a counter. After an import, the configured value is adopted without rotating the field.",
+						},
+					},
+				},
+				Validators: []validator.Map{
+					mapvalidator.SizeAtLeast(1),
+					mapvalidator.KeysAre(stringvalidator.RegexMatches(bundleFieldNameRegexp, "must only contain letters, digits, '_' and '-'")),
+				},
+				MarkdownDescription: "Fields of the secret, by name. Each field is a random secret stored base64 encoded under its name in the secret data. The fields layout will be stored as a custom metadata under the key `secret_bundle_fields`.",
+			},
+			"metadata": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					validators.CustomMetadata(MaxUserMetadata),
+				},
+				MarkdownDescription: "A map of key/value strings that will be stored along the secret as custom metadata. Vault limits keys to 128 bytes and values to 512 bytes. At most 48 entries are allowed, the remaining custom metadata being reserved for the provider.",
+			},
+			"force_destroy": schema.BoolAttribute{
+				Optional:            true,
+				Required:            false,
+				MarkdownDescription: "If set to `true`, removing the resource will delete the secret and all versions in Vault. If set to `false` or not defined, removing the resource will fail.",
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+			},
+			"delete_all_versions": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(true)),
+				},
+				MarkdownDescription: "If set to `false`, removing the resource only deletes the versions of the secret written by the provider (listed in the custom metadata `managed_versions`), leaving the versions written by other systems and the secret's metadata intact. Meant for shared paths that predate Terraform management. `destroy_after` is ignored in that case. Default is `true`.",
+			},
+			"delete_metadata": deleteMetadataAttribute(),
+			"deletion_protection": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+				MarkdownDescription: "If set to `true`, the secret can't be deleted, even with `force_destroy` set to `true`. The flag must first be set to `false` and applied. This information will be stored as a custom metadata under the key `deletion_protection`, deletion is also refused when this metadata is set directly in Vault.",
+			},
+			"destroy_after": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					validators.Duration(),
+				},
+				MarkdownDescription: "Grace period (e.g. `72h`) before the secret is deleted when the resource is destroyed. Instead of being deleted right away, the secret's `delete_version_after` is set so that Vault deletes it once the period is over, and the deletion date is stored as a custom metadata under the key `scheduled_destroy_at`. Gives a recovery window after an accidental destroy.",
+			},
+			"external_secret":    externalSecretAttribute(),
+			"policy_template":    policyTemplateAttribute(),
+			"escrow_public_key":  escrowPublicKeyAttribute(),
+			"escrow_ciphertext":  escrowCiphertextAttribute(),
+			"version":            versionAttribute(),
+			"use_latest_version": useLatestVersionAttribute(),
+			"cas_version":        casVersionAttribute(),
+			"on_deleted_version": onDeletedVersionAttribute(),
+			"data_path":          dataPathAttribute(),
+			"metadata_path":      metadataPathAttribute(),
+			"versions_kept": schema.Int64Attribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Number of versions of the secret retained by Vault (bounded by the secret's `max_versions`), i.e. how many rotations can still be rolled back.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "Several related random secrets stored in a single Vault secret, e.g. the current and previous keys of a cookie signing key. Each field can be rotated independently, the previous value being kept in another field. The resulting Vault secret will have a custom metadata `secret_type` with the value `secret_bundle` and a custom metadata `secret_bundle_fields` describing the fields.",
+	}
+}
+
+func (r *SecretBundle) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource, the deletion is summarized for reviewers
+	if req.Plan.Raw.IsNull() {
+		var state secretBundleModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() {
+			warnDestroy(&resp.Diagnostics, secretDestroy{
+				paths:              []string{state.Path.ValueString()},
+				forceDestroy:       state.ForceDestroy,
+				deletionProtection: state.DeletionProtection,
+				deleteAllVersions:  state.DeleteAllVersions,
+				deleteMetadata:     state.DeleteMetadata,
+				destroyAfter:       state.DestroyAfter,
+				versionsKept:       state.VersionsKept,
+			})
+		}
+		return
+	}
+
+	var plan secretBundleModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	checkBundleFields(&resp.Diagnostics, plan.Fields)
+	checkKVMount(&resp.Diagnostics, r.kvMounts, r.createMounts, plan.Path.StringValue)
+	checkIgnoredMetadata(&resp.Diagnostics, r.ignoredMetadata, plan.Metadata)
+	checkDeleteMetadata(&resp.Diagnostics, plan.DeleteMetadata, plan.DeleteAllVersions, plan.DestroyAfter)
+	checkFIPSMode(&resp.Diagnostics, r.fipsMode, "escrow_public_key", plan.EscrowPublicKey, fipsEscrowReason)
+	r.storage.add(&resp.Diagnostics, plan.Path.StringValue, secretBundleSize(plan.Fields))
+
+	var state secretBundleModel
+	if !req.State.Raw.IsNull() {
+		diags = req.State.Get(ctx, &state)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// Rotations write a new version of the secret
+		if bundleFieldsChanged(state.Fields, plan.Fields) {
+			resp.Plan.SetAttribute(ctx, path.Root("versions_kept"), types.Int64Unknown())
+			resp.Plan.SetAttribute(ctx, path.Root("version"), types.Int64Unknown())
+			if !plan.EscrowPublicKey.IsNull() {
+				resp.Plan.SetAttribute(ctx, path.Root("escrow_ciphertext"), types.StringUnknown())
+			}
+		}
+		planVersion(ctx, state.UseLatestVersion, plan.UseLatestVersion, resp)
+		planDeletedVersion(ctx, req.Private, plan.OnDeletedVersion, resp)
+		planEscrow(ctx, state.EscrowPublicKey, plan.EscrowPublicKey, resp)
+	}
+
+	// Existing fields are not affected by a lower limit as long as they are not rotated
+	for _, name := range sortedFieldNames(plan.Fields) {
+		if s, ok := state.Fields[name]; ok && s.Length.Equal(plan.Fields[name].Length) {
+			continue
+		}
+		checkSecretLength(&resp.Diagnostics, plan.Fields[name].Length, r.maxSecretLength)
+	}
+}
+
+func (r *SecretBundle) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan *secretBundleModel
+
+	// Retrieve values from plan
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	ensureKVMount(ctx, r.vaultApi, r.createMounts, plan.Path.ValueString(), &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	for _, name := range sortedFieldNames(plan.Fields) {
+		checkSecretLength(&response.Diagnostics, plan.Fields[name].Length, r.maxSecretLength)
+	}
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	data, _, err := rotateBundle(nil, nil, plan.Fields)
+	if err != nil {
+		response.Diagnostics.AddError("Error creating secret bundle", fmt.Sprintf("Couldn't generate random bytes, unexpected error: %s", err.Error()))
+		return
+	}
+
+	// Prepare metadata
+	customMetadata := make(map[string]string)
+	if !plan.Metadata.IsNull() {
+		for k, v := range plan.Metadata.Elements() {
+			customMetadata[k] = v.(types.String).ValueString()
+		}
+	}
+	customMetadata[SecretTypeMetadata] = SecretBundleType
+	customMetadata[SecretBundleFieldsMetadata] = bundleLayout(plan.Fields)
+
+	generation := generationParams{
+		Generator:       secrets.SecretBundleGenerator,
+		RNG:             secrets.RNG(),
+		ProviderVersion: r.providerVersion,
+	}
+	generation.addMetadata(customMetadata)
+	addOwnershipMetadata(customMetadata, r.ownership)
+	addHistoryMetadata(customMetadata, r.history, HistoryCreated)
+	if plan.DeletionProtection.ValueBool() {
+		customMetadata[DeletionProtectionMetadata] = "true"
+	}
+	externalSecretMetadata(customMetadata, nil, plan.ExternalSecret)
+
+	secret := vault.Secret{
+		Path:     plan.Path.ValueString(),
+		Data:     data,
+		Metadata: customMetadata,
+	}
+
+	plan.DataPath, plan.MetadataPath = secretAPIPaths(ctx, r.vaultApi, secret.Path, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.EscrowCiphertext = escrowSecret(plan.EscrowPublicKey, data, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	secret.CreateID = newCreateID(&response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	defer keepFailedCreate(ctx, r.vaultApi, plan.Path, secret.CreateID, response)
+
+	version, err := createSecret(ctx, r.vaultApi, secret, plan.OnDeletedVersion)
+	if err != nil {
+		addVaultError(&response.Diagnostics, "Error creating secret bundle", "Couldn't create Vault secret", err)
+		return
+	}
+
+	plan.VersionsKept = types.Int64Value(1)
+	plan.Version = types.Int64Value(int64(version))
+
+	diags = setGenerationPrivateState(ctx, response.Private, generation)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	applyPolicyTemplate(ctx, r.vaultApi, plan.Path.ValueString(), nil, plan.PolicyTemplate, &response.Diagnostics)
+	plan.ID = secretPathID(plan.Path)
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+func (r *SecretBundle) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state
+	var data secretBundleModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The partial state of a failed creation is kept as is, until the resource is replaced
+	if failedCreateID(ctx, req.Private, &resp.Diagnostics) != "" || resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	secretPath := data.Path.ValueString()
+
+	secret, err := r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		secret, err = readDeletedVersion(ctx, r.vaultApi, data.OnDeletedVersion, resp.Private, err, &resp.Diagnostics)
+	} else {
+		resp.Diagnostics.Append(clearDeletedVersion(ctx, resp.Private)...)
+	}
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return
+	}
+
+	if secret == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(setUpdatedTimePrivateState(ctx, resp.Private, secret)...)
+
+	customMetadata := secret.Metadata
+
+	data.VersionsKept = types.Int64Value(int64(secret.VersionsKept))
+	data.Version = secretVersion(secret, data.UseLatestVersion)
+	data.DataPath = types.StringValue(secret.DataPath)
+	data.MetadataPath = types.StringValue(secret.MetadataPath)
+	data.DeletionProtection = types.BoolValue(false)
+
+	additionalMetadata := make(map[string]attr.Value)
+	for k, v := range customMetadata {
+		if isGenerationMetadata(k) || isExternalSecretMetadata(k) || isStampMetadata(k) || isOwnershipMetadata(k) || isHistoryMetadata(k) {
+			continue
+		}
+		switch k {
+		case DeletionProtectionMetadata:
+			data.DeletionProtection = types.BoolValue(v == "true")
+			continue
+		case SecretTypeMetadata:
+			continue
+		case SecretBundleFieldsMetadata:
+			fields, err := parseBundleLayout(v)
+			if err != nil {
+				resp.Diagnostics.AddError("Error reading secret bundle fields: "+v, fmt.Sprintf("Error while reading secret %s: %s", secretPath, err.Error()))
+				return
+			}
+			// Rotation triggers only live in state
+			for name, f := range fields {
+				if s, ok := data.Fields[name]; ok {
+					f.RotationTrigger = s.RotationTrigger
+					fields[name] = f
+				}
+			}
+			data.Fields = fields
+			continue
+		}
+		additionalMetadata[k] = types.StringValue(v)
+	}
+	data.Metadata = metadataValue(data.Metadata, additionalMetadata, r.ignoredMetadata)
+	data.ExternalSecret = externalSecretValue(data.ExternalSecret, customMetadata)
+
+	diags = syncGenerationPrivateState(ctx, resp.Private, customMetadata)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only path is set in state when importing an existing resource
+	data.ID = secretPathID(data.Path)
+
+	// ForceDestroy may be null in state when importing an existing resource
+	if data.ForceDestroy.IsNull() {
+		data.ForceDestroy = types.BoolValue(false)
+	}
+	if data.OnDeletedVersion.IsNull() {
+		data.OnDeletedVersion = types.StringValue(OnDeletedVersionError)
+	}
+	if data.DeleteAllVersions.IsNull() {
+		data.DeleteAllVersions = types.BoolValue(true)
+	}
+	if data.DeleteMetadata.IsNull() {
+		data.DeleteMetadata = types.BoolValue(false)
+	}
+	if data.UseLatestVersion.IsNull() {
+		data.UseLatestVersion = types.BoolValue(false)
+	}
+	if data.CasVersion.IsNull() {
+		data.CasVersion = types.BoolValue(false)
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *SecretBundle) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan secretBundleModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get current state
+	var state secretBundleModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	// Rolled back first, the checks below fail on a deleted latest version
+	restoreDeletedVersion(ctx, r.vaultApi, state.Path.ValueString(), plan.OnDeletedVersion, req.Private, resp.Private, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	checkStrictMode(ctx, r.vaultApi, r.strict, state.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := state.Path.ValueString()
+
+	metadata := make(map[string]string)
+	for k, v := range plan.Metadata.Elements() {
+		metadata[k] = v.(types.String).ValueString()
+	}
+
+	metadata[SecretTypeMetadata] = SecretBundleType
+	metadata[SecretBundleFieldsMetadata] = bundleLayout(plan.Fields)
+
+	// Checked before the rotation, which writes the secret
+	checkSecretUnchanged(ctx, r.vaultApi, secretPath, plan.CasVersion, resp.Private, resp.Private, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if bundleFieldsChanged(state.Fields, plan.Fields) && !r.rotate(ctx, secretPath, state.Fields, plan.Fields, metadata, resp) {
+		return
+	}
+
+	removed := removedMetadataKeys(state.Metadata, plan.Metadata)
+	removed = deletionProtectionMetadata(metadata, removed, plan.DeletionProtection)
+	removed = externalSecretMetadata(metadata, removed, plan.ExternalSecret)
+
+	// Unless already recorded as a rotation
+	if _, rotated := metadata[HistoryMetadata]; !rotated {
+		historyUpdate(ctx, r.vaultApi, r.history, secretPath, metadata, HistoryMetadataUpdated, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	err := r.vaultApi.UpdateSecretMetadata(ctx, secretPath, metadata, removed)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while updating metadata for secret %s", secretPath), err)
+		return
+	}
+
+	applyPolicyTemplate(ctx, r.vaultApi, secretPath, state.PolicyTemplate, plan.PolicyTemplate, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.VersionsKept.IsUnknown() || plan.Version.IsUnknown() {
+		secret, err := r.vaultApi.ReadSecretMetadata(ctx, secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+			return
+		}
+		if secret == nil {
+			resp.Diagnostics.AddError("Error updating secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+			return
+		}
+		state.VersionsKept = types.Int64Value(int64(secret.VersionsKept))
+		state.Version = secretVersion(secret, plan.UseLatestVersion)
+	}
+
+	if plan.EscrowCiphertext.IsUnknown() {
+		plan.EscrowCiphertext = refreshEscrow(ctx, r.vaultApi.ReadSecret, secretPath, plan.EscrowPublicKey, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	state.Fields = plan.Fields
+	state.Metadata = plan.Metadata
+	state.ForceDestroy = plan.ForceDestroy
+	state.OnDeletedVersion = plan.OnDeletedVersion
+	state.DeleteAllVersions = plan.DeleteAllVersions
+	state.DeleteMetadata = plan.DeleteMetadata
+	state.UseLatestVersion = plan.UseLatestVersion
+	state.CasVersion = plan.CasVersion
+	state.DeletionProtection = plan.DeletionProtection
+	state.DestroyAfter = plan.DestroyAfter
+	state.ExternalSecret = plan.ExternalSecret
+	state.PolicyTemplate = plan.PolicyTemplate
+	state.EscrowPublicKey = plan.EscrowPublicKey
+	state.EscrowCiphertext = plan.EscrowCiphertext
+	state.ExtraHeaders = plan.ExtraHeaders
+	state.Timeouts = plan.Timeouts
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// rotate writes a new version of the secret with the planned fields, generating the rotated ones. The generation
+// parameters of the new version and the rotation's history event are added to metadata.
+func (r *SecretBundle) rotate(ctx context.Context, secretPath string, state, plan map[string]secretBundleFieldModel, metadata map[string]string, resp *resource.UpdateResponse) bool {
+	secret, err := r.vaultApi.ReadSecret(ctx, secretPath)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error rotating secret bundle", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return false
+	}
+	if secret == nil {
+		resp.Diagnostics.AddError("Error rotating secret bundle", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return false
+	}
+
+	data, rotated, err := rotateBundle(secret.Data, state, plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Error rotating secret bundle", fmt.Sprintf("Couldn't generate random bytes, unexpected error: %s", err.Error()))
+		return false
+	}
+	if reflect.DeepEqual(data, secret.Data) {
+		return true
+	}
+
+	err = r.vaultApi.UpdateSecretData(ctx, secretPath, data, secret.Version)
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error rotating secret bundle", fmt.Sprintf("Error while writing secret %s", secretPath), err)
+		return false
+	}
+	historyUpdate(ctx, r.vaultApi, r.history, secretPath, metadata, HistoryRotated, &resp.Diagnostics)
+
+	if len(rotated) > 0 {
+		generation := generationParams{
+			Generator:       secrets.SecretBundleGenerator,
+			RNG:             secrets.RNG(),
+			ProviderVersion: r.providerVersion,
+		}
+		generation.addMetadata(metadata)
+		addOwnershipMetadata(metadata, r.ownership)
+		resp.Diagnostics.Append(setGenerationPrivateState(ctx, resp.Private, generation)...)
+	}
+	return !resp.Diagnostics.HasError()
+}
+
+func (r *SecretBundle) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state secretBundleModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if deleteFailedCreate(ctx, r.vaultApi, state.Path.ValueString(), req.Private, &resp.Diagnostics) {
+		return
+	}
+
+	checkStrictMode(ctx, r.vaultApi, r.strict, state.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !state.ForceDestroy.ValueBool() {
+		resp.Diagnostics.AddError("Error deleting secret", "Can't delete resource for Vault secret '"+state.Path.ValueString()+"': 'force_destroy' must be set to 'true'")
+		return
+	}
+
+	secretPath := state.Path.ValueString()
+
+	checkDeletionProtection(ctx, r.vaultApi, secretPath, state.DeletionProtection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case !state.DeleteAllVersions.ValueBool():
+		deleteManagedVersions(ctx, r.vaultApi, secretPath, state.DeleteMetadata, &resp.Diagnostics)
+	case !state.DestroyAfter.IsNull():
+		scheduleSecretDeletion(ctx, r.vaultApi, secretPath, state.DestroyAfter, &resp.Diagnostics)
+	default:
+		err := r.vaultApi.DeleteSecret(ctx, secretPath)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error deleting secret", fmt.Sprintf("Error while deleting secret %s", secretPath), err)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Revoked last, readers keep their access to a secret whose deletion failed
+	applyPolicyTemplate(ctx, r.vaultApi, secretPath, state.PolicyTemplate, nil, &resp.Diagnostics)
+}
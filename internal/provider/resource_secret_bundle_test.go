@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const secretBundleResourceName = "vaultprov_secret_bundle.test"
+
+func TestAccSecretBundle(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccSecretBundleResourceConfig("1", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(secretBundleResourceName, "path", "/secret/bundle/foo"),
+					resource.TestCheckResourceAttr(secretBundleResourceName, "id", "secret/bundle/foo"),
+					resource.TestCheckResourceAttr(secretBundleResourceName, "fields.current_key.length", "32"),
+					resource.TestCheckResourceAttr(secretBundleResourceName, "fields.salt.length", "16"),
+					resource.TestCheckResourceAttr(secretBundleResourceName, "versions_kept", "1"),
+				),
+			},
+			// Rotation testing
+			{
+				Config: testAccSecretBundleResourceConfig("2", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(secretBundleResourceName, "fields.current_key.rotation_trigger", "2"),
+					resource.TestCheckResourceAttr(secretBundleResourceName, "versions_kept", "2"),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:            secretBundleResourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"fields.current_key.rotation_trigger"},
+				ImportStateId:           "/secret/bundle/foo",
+			},
+			// ForceDestroy testing (also needed at the end so the resource can be automatically deleted)
+			{
+				Config: testAccSecretBundleResourceConfig("2", true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(secretBundleResourceName, "force_destroy", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSecretBundleResourceConfig(trigger string, forceDestroy bool) string {
+	return fmt.Sprintf(`
+resource "vaultprov_secret_bundle" "test" {
+  path   = "/secret/bundle/foo"
+  fields = {
+    current_key = {
+      previous_field   = "previous_key"
+      rotation_trigger = "%s"
+    }
+    salt = {
+      length = 16
+    }
+  }
+  force_destroy = %t
+}
+`, trigger, forceDestroy)
+}
@@ -0,0 +1,325 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &SecretVersionsPurge{}
+var _ resource.ResourceWithModifyPlan = &SecretVersionsPurge{}
+var _ resource.ResourceWithUpgradeState = &SecretVersionsPurge{}
+
+// SecretVersionsPurge permanently destroys old versions of a secret, e.g. compromised versions of a key, through the
+// KV v2 destroy endpoint. It holds nothing in Vault: destroyed versions can't be restored when the resource is deleted.
+type SecretVersionsPurge struct {
+	vaultApi     *vault.VaultApi
+	strict       bool
+	readOnly     bool
+	kvMounts     []vault.KVMount
+	createMounts bool
+}
+
+type secretVersionsPurgeModel struct {
+	ID                types.String    `tfsdk:"id"`
+	Path              secretPathValue `tfsdk:"path"`
+	KeepLatest        types.Int64     `tfsdk:"keep_latest"`
+	Versions          types.Set       `tfsdk:"versions"`
+	Triggers          types.Map       `tfsdk:"triggers"`
+	DestroyedVersions types.List      `tfsdk:"destroyed_versions"`
+	ExtraHeaders      types.Map       `tfsdk:"extra_headers"`
+	Timeouts          timeouts.Value  `tfsdk:"timeouts"`
+}
+
+func NewSecretVersionsPurge() resource.Resource {
+	return &SecretVersionsPurge{}
+}
+
+func (r *SecretVersionsPurge) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "secret_versions_purge")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.vaultApi = data.vaultApi
+	r.strict = data.strict
+	r.readOnly = data.readOnly
+	r.kvMounts = data.kvMounts
+	r.createMounts = data.createMounts
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (r *SecretVersionsPurge) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *SecretVersionsPurge {
+	clone := *r
+	clone.vaultApi = vaultApiWithHeaders(r.vaultApi, extraHeaders, diags)
+	return &clone
+}
+
+// UpgradeState migrates states stored with a prior schema version, see secretVersionsPurgeSchemaVersion.
+func (r *SecretVersionsPurge) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: rawStateUpgrader(normalizeSecretPathID),
+	}
+}
+
+func (r *SecretVersionsPurge) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_secret_versions_purge"
+}
+
+func (r *SecretVersionsPurge) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: secretVersionsPurgeSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Full name of the Vault secret, as the `path` of the secret resources (e.g. `secret/foo/bar`, without the `data/` prefix).",
+			},
+			"keep_latest": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.AtLeastOneOf(path.MatchRoot("versions")),
+				},
+				MarkdownDescription: "Number of versions to keep: every version older than the latest `keep_latest` versions not destroyed yet is destroyed.",
+			},
+			"versions": schema.SetAttribute{
+				ElementType:         types.Int64Type,
+				Optional:            true,
+				MarkdownDescription: "Versions of the secret to destroy. Versions already destroyed or unknown to Vault are skipped. The current version of the secret can't be destroyed.",
+			},
+			"triggers": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Arbitrary values, changing them destroys the selected versions again, e.g. to apply `keep_latest` to the versions written since the last purge. Any other change of the resource's arguments does the same.",
+			},
+			"destroyed_versions": schema.ListAttribute{
+				ElementType:         types.Int64Type,
+				Computed:            true,
+				MarkdownDescription: "Versions destroyed by the last purge, in ascending order.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "Permanently destroys old versions of a secret written in a KV v2 mount, e.g. versions of a key known to be compromised. Destroyed versions can't be undeleted, and are not restored when the resource is deleted. The current version of the secret is never destroyed.",
+	}
+}
+
+func (r *SecretVersionsPurge) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan secretVersionsPurgeModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	checkKVMount(&resp.Diagnostics, r.kvMounts, r.createMounts, plan.Path.StringValue)
+}
+
+func (r *SecretVersionsPurge) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan secretVersionsPurgeModel
+
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	plan.DestroyedVersions = r.purge(ctx, plan, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = secretPathID(plan.Path)
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+func (r *SecretVersionsPurge) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data secretVersionsPurgeModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// The purge is only done again when the secret has been deleted and written from scratch since, e.g. when its
+	// resource has been replaced
+	secret, err := r.vaultApi.ReadSecretMetadata(ctx, data.Path.ValueString())
+	var deletedErr *vault.SecretDeletedError
+	if errors.As(err, &deletedErr) {
+		return
+	}
+	if err != nil {
+		addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading metadata for secret %s", data.Path.ValueString()), err)
+		return
+	}
+	if secret == nil {
+		resp.State.RemoveResource(ctx)
+	}
+}
+
+func (r *SecretVersionsPurge) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan secretVersionsPurgeModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	plan.DestroyedVersions = r.purge(ctx, plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete only removes the resource from the state: destroyed versions can't be restored.
+func (r *SecretVersionsPurge) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+}
+
+// purge destroys the versions of the secret selected by plan and returns them.
+func (r *SecretVersionsPurge) purge(ctx context.Context, plan secretVersionsPurgeModel, diags *diag.Diagnostics) types.List {
+	secretPath := plan.Path.ValueString()
+
+	var versions []int
+	diags.Append(plan.Versions.ElementsAs(ctx, &versions, false)...)
+	if diags.HasError() {
+		return types.ListNull(types.Int64Type)
+	}
+
+	checkStrictMode(ctx, r.vaultApi, r.strict, secretPath, diags)
+	if diags.HasError() {
+		return types.ListNull(types.Int64Type)
+	}
+
+	destroyed, err := r.vaultApi.DestroySecretVersions(ctx, secretPath, int(plan.KeepLatest.ValueInt64()), versions)
+	if err != nil {
+		addVaultError(diags, "Error destroying secret versions", fmt.Sprintf("Error while destroying versions of secret %s", secretPath), err)
+		return types.ListNull(types.Int64Type)
+	}
+
+	elements := make([]attr.Value, len(destroyed))
+	for i, version := range destroyed {
+		elements[i] = types.Int64Value(int64(version))
+	}
+	list, d := types.ListValue(types.Int64Type, elements)
+	diags.Append(d...)
+	return list
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const secretVersionsPurgeResourceName = "vaultprov_secret_versions_purge.test"
+
+func TestAccSecretVersionsPurge(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing: a single version, nothing to destroy
+			{
+				Config: testAccSecretVersionsPurgeResourceConfig("1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(secretVersionsPurgeResourceName, "id", "secret/purge/foo"),
+					resource.TestCheckResourceAttr(secretVersionsPurgeResourceName, "destroyed_versions.#", "0"),
+				),
+			},
+			// Update testing
+			{
+				Config: testAccSecretVersionsPurgeResourceConfig("2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(secretVersionsPurgeResourceName, "destroyed_versions.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSecretVersionsPurgeResourceConfig(trigger string) string {
+	return fmt.Sprintf(`
+resource "vaultprov_random_secret" "test" {
+  path = "/secret/purge/foo"
+}
+
+resource "vaultprov_secret_versions_purge" "test" {
+  path        = vaultprov_random_secret.test.path
+  keep_latest = 1
+  triggers = {
+    rotation = "%s"
+  }
+}
+`, trigger)
+}
@@ -0,0 +1,579 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/validators"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/vault/shamir"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &SplitSecret{}
+var _ resource.ResourceWithModifyPlan = &SplitSecret{}
+var _ resource.ResourceWithUpgradeState = &SplitSecret{}
+
+// SplitSecret generates a random secret and stores it as Shamir shares, each in its own Vault secret, so that no single
+// Vault path holds the full secret. The secret itself is never stored.
+type SplitSecret struct {
+	vaultApi        *vault.VaultApi
+	providerVersion string
+	maxSecretLength int64
+	strict          bool
+	readOnly        bool
+	ownership       map[string]string
+	history         bool
+	ignoredMetadata []string
+	kvMounts        []vault.KVMount
+	storage         *storageEstimate
+	createMounts    bool
+}
+
+type splitSecretModel struct {
+	ID             types.String    `tfsdk:"id"`
+	Path           secretPathValue `tfsdk:"path"`
+	Shares         types.Int64     `tfsdk:"shares"`
+	Threshold      types.Int64     `tfsdk:"threshold"`
+	Length         types.Int64     `tfsdk:"length"`
+	Metadata       types.Map       `tfsdk:"metadata"`
+	ForceDestroy   types.Bool      `tfsdk:"force_destroy"`
+	SharePaths     types.List      `tfsdk:"share_paths"`
+	KeyFingerprint types.String    `tfsdk:"key_fingerprint"`
+	MissingShares  types.List      `tfsdk:"missing_shares"`
+	ExtraHeaders   types.Map       `tfsdk:"extra_headers"`
+	Timeouts       timeouts.Value  `tfsdk:"timeouts"`
+}
+
+func NewSplitSecret() resource.Resource {
+	return &SplitSecret{}
+}
+
+func (r *SplitSecret) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	checkVaultBackend(&resp.Diagnostics, data, "split_secret")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.vaultApi = data.vaultApi
+	r.providerVersion = data.version
+	r.strict = data.strict
+	r.readOnly = data.readOnly
+	r.ownership = data.ownership
+	r.history = data.history
+	r.ignoredMetadata = data.ignoredMetadata
+	r.kvMounts = data.kvMounts
+	r.storage = data.storage
+	r.createMounts = data.createMounts
+	r.maxSecretLength = data.maxSecretLength
+}
+
+// withExtraHeaders returns a copy of the resource whose Vault requests carry the resource's extra_headers.
+func (r *SplitSecret) withExtraHeaders(extraHeaders types.Map, diags *diag.Diagnostics) *SplitSecret {
+	clone := *r
+	clone.vaultApi = vaultApiWithHeaders(r.vaultApi, extraHeaders, diags)
+	return &clone
+}
+
+// UpgradeState migrates states stored with a prior schema version, see splitSecretSchemaVersion.
+func (r *SplitSecret) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{}
+}
+
+func (r *SplitSecret) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_split_secret"
+}
+
+func (r *SplitSecret) Schema(ctx context.Context, request resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Version: splitSecretSchemaVersion,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Identifier of the resource: `path` without leading or trailing slashes.",
+			},
+			"path": schema.StringAttribute{
+				Required:   true,
+				CustomType: secretPathType{},
+				PlanModifiers: []planmodifier.String{
+					requiresReplaceIfPathChanged(),
+				},
+				Validators: []validator.String{
+					validators.SecretPath(),
+				},
+				MarkdownDescription: "Path under which the shares are stored, as the `path` of the secret resources (e.g. `secret/foo/root-key`): share `i` is stored in the secret `<path>/share-<i>`. Nothing is stored at the path itself.",
+			},
+			"shares": schema.Int64Attribute{
+				Required: true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+				Validators: []validator.Int64{
+					int64validator.Between(2, secrets.MaxShares),
+				},
+				MarkdownDescription: "Number of shares the secret is split into, between 2 and 255. Changing it generates a new secret.",
+			},
+			"threshold": schema.Int64Attribute{
+				Required: true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+				Validators: []validator.Int64{
+					int64validator.AtLeast(2),
+				},
+				MarkdownDescription: "Number of shares needed to rebuild the secret, between 2 and `shares`. Changing it generates a new secret.",
+			},
+			"length": schema.Int64Attribute{
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					planmodifiers.Int64DefaultValue(types.Int64Value(DefaultRandomSecretLength)),
+					int64planmodifier.RequiresReplace(),
+				},
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+				MarkdownDescription: "The length (in bytes) of the secret. Default is 32. Changing it generates a new secret.",
+			},
+			"metadata": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					validators.CustomMetadata(MaxUserMetadata),
+				},
+				MarkdownDescription: "A map of key/value strings that will be stored along every share as custom metadata. Vault limits keys to 128 bytes and values to 512 bytes. At most 48 entries are allowed, the remaining custom metadata being reserved for the provider.",
+			},
+			"force_destroy": schema.BoolAttribute{
+				Optional:            true,
+				Required:            false,
+				MarkdownDescription: "If set to `true`, removing the resource will delete the shares and all their versions in Vault. If set to `false` or not defined, removing the resource will fail.",
+				PlanModifiers: []planmodifier.Bool{
+					planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+				},
+			},
+			"share_paths": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Paths of the secrets holding the shares, in order. Each secret holds its share, base64 encoded, under the `share` key. A share is the bytes of the share followed by its x coordinate, as produced by Vault's `shamir` package.",
+			},
+			"key_fingerprint": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				MarkdownDescription: "Hex encoded SHA-256 of the secret, to check that the shares combined by the holders give back the generated secret.",
+			},
+			"missing_shares": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.List{
+					planmodifiers.ListRequiresReplaceIfNotEmpty(),
+				},
+				MarkdownDescription: "Paths of the share secrets deleted outside Terraform, found when refreshing. Shares can't be generated again without the secret: the resource is replaced, generating a new secret, unless the missing shares are restored before the apply.",
+			},
+			"extra_headers": extraHeadersAttribute(),
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		MarkdownDescription: "A random secret split with Shamir's secret sharing into `shares` shares, any `threshold` of which rebuild it, for root-of-trust material no single Vault path should hold. Each share is stored in its own secret, with a custom metadata `secret_type` with the value `split_secret`. The secret itself is never stored, in Vault nor in the Terraform state.",
+	}
+}
+
+func (r *SplitSecret) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying the resource, the deletion is summarized for reviewers
+	if req.Plan.Raw.IsNull() {
+		var state splitSecretModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() {
+			warnDestroy(&resp.Diagnostics, secretDestroy{
+				paths:             splitSharePaths(state.Path.ValueString(), int(state.Shares.ValueInt64())),
+				forceDestroy:      state.ForceDestroy,
+				deleteAllVersions: types.BoolValue(true),
+			})
+		}
+		return
+	}
+
+	var plan splitSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !plan.Shares.IsUnknown() && !plan.Threshold.IsUnknown() && plan.Threshold.ValueInt64() > plan.Shares.ValueInt64() {
+		resp.Diagnostics.AddAttributeError(path.Root("threshold"), "Invalid threshold", fmt.Sprintf("Attribute threshold (%d) can't be greater than shares (%d): the secret could never be rebuilt.", plan.Threshold.ValueInt64(), plan.Shares.ValueInt64()))
+	}
+	checkKVMount(&resp.Diagnostics, r.kvMounts, r.createMounts, plan.Path.StringValue)
+	checkIgnoredMetadata(&resp.Diagnostics, r.ignoredMetadata, plan.Metadata)
+	// Each share is the bytes of the secret followed by its x coordinate
+	r.storage.add(&resp.Diagnostics, plan.Path.StringValue, plan.Shares.ValueInt64()*base64Length(plan.Length.ValueInt64()+1))
+
+	// Existing secrets are not affected by a lower limit as long as they are not re-created
+	if req.State.Raw.IsNull() {
+		checkSecretLength(&resp.Diagnostics, plan.Length, r.maxSecretLength)
+	}
+}
+
+func (r *SplitSecret) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	checkReadOnly(&response.Diagnostics, r.readOnly, "create")
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	var plan splitSecretModel
+
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, DefaultOperationTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	sharePaths := splitSharePaths(plan.Path.ValueString(), int(plan.Shares.ValueInt64()))
+
+	ensureKVMount(ctx, r.vaultApi, r.createMounts, sharePaths[0], &response.Diagnostics)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	checkSecretLength(&response.Diagnostics, plan.Length, r.maxSecretLength)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	key, err := secrets.GenerateRandomSecret(int(plan.Length.ValueInt64()))
+	if err != nil {
+		response.Diagnostics.AddError("Error creating split secret", fmt.Sprintf("Could generate random bytes, unexpected error: %s", err.Error()))
+		return
+	}
+	defer secrets.Wipe(key)
+
+	shares, err := shamir.Split(key, len(sharePaths), int(plan.Threshold.ValueInt64()))
+	if err != nil {
+		response.Diagnostics.AddError("Error creating split secret", fmt.Sprintf("Couldn't split secret: %s", err.Error()))
+		return
+	}
+	defer func() {
+		for _, share := range shares {
+			secrets.Wipe(share)
+		}
+	}()
+
+	generation := generationParams{
+		Generator:       secrets.SplitSecretGenerator,
+		RNG:             secrets.RNG(),
+		ProviderVersion: r.providerVersion,
+	}
+
+	for i, sharePath := range sharePaths {
+		customMetadata := make(map[string]string)
+		for k, v := range plan.Metadata.Elements() {
+			customMetadata[k] = v.(types.String).ValueString()
+		}
+		splitShareMetadata(customMetadata, i+1, len(sharePaths), int(plan.Threshold.ValueInt64()), int(plan.Length.ValueInt64()))
+		generation.addMetadata(customMetadata)
+		addOwnershipMetadata(customMetadata, r.ownership)
+		addHistoryMetadata(customMetadata, r.history, HistoryCreated)
+
+		secret := vault.Secret{
+			Path:     sharePath,
+			Data:     map[string]interface{}{SplitSecretShareKey: secrets.EncodeShare(shares[i])},
+			Metadata: customMetadata,
+		}
+		if _, err = r.vaultApi.CreateSecret(ctx, secret); err != nil {
+			addVaultError(&response.Diagnostics, "Error creating split secret", fmt.Sprintf("Couldn't create share secret %s", sharePath), err)
+			// Shares are useless without the others
+			r.deleteShares(ctx, sharePaths[:i], &response.Diagnostics)
+			return
+		}
+	}
+
+	diags = setGenerationPrivateState(ctx, response.Private, generation)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.SharePaths, diags = types.ListValueFrom(ctx, types.StringType, sharePaths)
+	response.Diagnostics.Append(diags...)
+	plan.KeyFingerprint = types.StringValue(secrets.Fingerprint(key))
+	plan.MissingShares, diags = types.ListValueFrom(ctx, types.StringType, []string{})
+	response.Diagnostics.Append(diags...)
+	plan.ID = secretPathID(plan.Path)
+
+	diags = response.State.Set(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+}
+
+func (r *SplitSecret) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data splitSecretModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(data.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	sharePaths := splitSharePaths(data.Path.ValueString(), int(data.Shares.ValueInt64()))
+
+	var first *vault.Secret
+	var missing []string
+	for _, sharePath := range sharePaths {
+		secret, err := r.vaultApi.ReadSecretMetadata(ctx, sharePath)
+		var deletedErr *vault.SecretDeletedError
+		if errors.As(err, &deletedErr) {
+			secret, err = nil, nil
+		}
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error reading secret", fmt.Sprintf("Error while reading share secret %s", sharePath), err)
+			return
+		}
+		if secret == nil {
+			missing = append(missing, sharePath)
+			continue
+		}
+		checkManagedSecret(secret, r.strict, &resp.Diagnostics)
+		if first == nil {
+			first = secret
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if first == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	// Replacing the resource generates a new secret instead of the root-of-trust material the remaining shares
+	// protect, so it is planned explicitly rather than done behind the user's back
+	if len(missing) > 0 {
+		resp.Diagnostics.AddWarning("Missing shares", fmt.Sprintf("Share secrets %s have been deleted outside Terraform: %d shares left, %d needed to rebuild the secret. The resource will be replaced, generating a new secret. Restore the missing shares before applying to keep the current secret.", strings.Join(missing, ", "), len(sharePaths)-len(missing), data.Threshold.ValueInt64()))
+	}
+	if missing == nil {
+		missing = []string{}
+	}
+	data.MissingShares, diags = types.ListValueFrom(ctx, types.StringType, missing)
+	resp.Diagnostics.Append(diags...)
+
+	additionalMetadata := make(map[string]attr.Value)
+	for k, v := range first.Metadata {
+		if isGenerationMetadata(k) || isSplitShareMetadata(k) || isStampMetadata(k) || isOwnershipMetadata(k) || isHistoryMetadata(k) {
+			continue
+		}
+		additionalMetadata[k] = types.StringValue(v)
+	}
+	data.Metadata = metadataValue(data.Metadata, additionalMetadata, r.ignoredMetadata)
+
+	diags = syncGenerationPrivateState(ctx, resp.Private, first.Metadata)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.ForceDestroy.IsNull() {
+		data.ForceDestroy = types.BoolValue(false)
+	}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *SplitSecret) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "update")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan splitSecretModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state splitSecretModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(plan.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	sharePaths := splitSharePaths(state.Path.ValueString(), int(state.Shares.ValueInt64()))
+	removed := removedMetadataKeys(state.Metadata, plan.Metadata)
+
+	for i, sharePath := range sharePaths {
+		checkStrictMode(ctx, r.vaultApi, r.strict, sharePath, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		metadata := make(map[string]string)
+		for k, v := range plan.Metadata.Elements() {
+			metadata[k] = v.(types.String).ValueString()
+		}
+		splitShareMetadata(metadata, i+1, len(sharePaths), int(state.Threshold.ValueInt64()), int(state.Length.ValueInt64()))
+
+		historyUpdate(ctx, r.vaultApi, r.history, sharePath, metadata, HistoryMetadataUpdated, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		err := r.vaultApi.UpdateSecretMetadata(ctx, sharePath, metadata, removed)
+		if err != nil {
+			addVaultError(&resp.Diagnostics, "Error updating secret", fmt.Sprintf("Error while updating metadata for share secret %s", sharePath), err)
+			return
+		}
+	}
+
+	state.Metadata = plan.Metadata
+	state.ForceDestroy = plan.ForceDestroy
+	state.ExtraHeaders = plan.ExtraHeaders
+	state.Timeouts = plan.Timeouts
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *SplitSecret) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	checkReadOnly(&resp.Diagnostics, r.readOnly, "delete")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state splitSecretModel
+
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, DefaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r = r.withExtraHeaders(state.ExtraHeaders, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if !state.ForceDestroy.ValueBool() {
+		resp.Diagnostics.AddError("Error deleting secret", "Can't delete resource for split secret '"+state.Path.ValueString()+"': 'force_destroy' must be set to 'true'")
+		return
+	}
+
+	sharePaths := splitSharePaths(state.Path.ValueString(), int(state.Shares.ValueInt64()))
+	for _, sharePath := range sharePaths {
+		checkStrictMode(ctx, r.vaultApi, r.strict, sharePath, &resp.Diagnostics)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.deleteShares(ctx, sharePaths, &resp.Diagnostics)
+}
+
+// deleteShares deletes the secrets holding shares, going on after a failure so that as few shares as possible remain.
+// Shares already deleted are skipped.
+func (r *SplitSecret) deleteShares(ctx context.Context, sharePaths []string, diags *diag.Diagnostics) {
+	for _, sharePath := range sharePaths {
+		if secret, err := r.vaultApi.ReadSecretMetadata(ctx, sharePath); err == nil && secret == nil {
+			continue
+		}
+		if err := r.vaultApi.DeleteSecret(ctx, sharePath); err != nil {
+			addVaultError(diags, "Error deleting secret", fmt.Sprintf("Error while deleting share secret %s", sharePath), err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const splitSecretResourceName = "vaultprov_split_secret.test"
+
+func TestAccSplitSecret(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccSplitSecretResourceConfig("my_team"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(splitSecretResourceName, "id", "secret/split/root"),
+					resource.TestCheckResourceAttr(splitSecretResourceName, "length", "32"),
+					resource.TestCheckResourceAttr(splitSecretResourceName, "share_paths.#", "3"),
+					resource.TestCheckResourceAttr(splitSecretResourceName, "share_paths.2", "secret/split/root/share-3"),
+					resource.TestCheckResourceAttrSet(splitSecretResourceName, "key_fingerprint"),
+					resource.TestCheckResourceAttr(splitSecretResourceName, "missing_shares.#", "0"),
+				),
+			},
+			// Update testing: metadata are updated on every share
+			{
+				Config: testAccSplitSecretResourceConfig("other_team"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(splitSecretResourceName, "metadata.owner", "other_team"),
+				),
+			},
+		},
+	})
+}
+
+func testAccSplitSecretResourceConfig(owner string) string {
+	return fmt.Sprintf(`
+resource "vaultprov_split_secret" "test" {
+  path          = "/secret/split/root"
+  shares        = 3
+  threshold     = 2
+  force_destroy = true
+  metadata = {
+    owner = %q
+  }
+}
+`, owner)
+}
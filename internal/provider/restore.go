@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// restoreDeletedSecret restores the deleted secret at secretPath when there's one whose metadata match expected. It
+// returns nil when there's nothing to restore.
+func restoreDeletedSecret(ctx context.Context, vaultApi *vault.VaultApi, secretPath string, expected map[string]string, diags *diag.Diagnostics) *vault.Secret {
+	restored, err := vaultApi.RestoreSecret(ctx, secretPath, expected)
+	if err != nil {
+		addVaultError(diags, "Error restoring secret", fmt.Sprintf("Couldn't restore deleted secret %s", secretPath), err)
+		return nil
+	}
+	return restored
+}
+
+// adoptRestoredSecret aligns the custom metadata of a restored secret with the plan, keeping the generation parameters
+// of the original secret (also copied in private state) and its history, see lifecycle_history.
+func adoptRestoredSecret(ctx context.Context, store vault.SecretStore, restored *vault.Secret, planMetadata types.Map, deletionProtection types.Bool, managed map[string]string, history bool, private privateState, diags *diag.Diagnostics) {
+	metadata := make(map[string]string)
+	for k, v := range planMetadata.Elements() {
+		metadata[k] = v.(types.String).ValueString()
+	}
+	for k, v := range managed {
+		metadata[k] = v
+	}
+
+	removed := make([]string, 0)
+	for k := range restored.Metadata {
+		if _, ok := metadata[k]; !ok && !isGenerationMetadata(k) && !isStampMetadata(k) && !isOwnershipMetadata(k) && !isHistoryMetadata(k) {
+			removed = append(removed, k)
+		}
+	}
+	removed = deletionProtectionMetadata(metadata, removed, deletionProtection)
+	if history {
+		metadata[HistoryMetadata] = appendHistory(restored.Metadata[HistoryMetadata], HistoryRestored, time.Now())
+	}
+
+	err := store.UpdateSecretMetadata(ctx, restored.Path, metadata, removed)
+	if err != nil {
+		addVaultError(diags, "Error restoring secret", fmt.Sprintf("Error while updating metadata for restored secret %s", restored.Path), err)
+		return
+	}
+
+	diags.Append(syncGenerationPrivateState(ctx, private, restored.Metadata)...)
+	diags.AddWarning("Deleted secret restored", fmt.Sprintf("The latest version of deleted secret %s has been restored instead of generating a new secret.", restored.Path))
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ basetypes.StringTypable = secretPathType{}
+var _ basetypes.StringValuableWithSemanticEquals = secretPathValue{}
+
+// secretPathType is the type of the path of secrets. Paths differing only by leading or trailing slashes are
+// semantically equal, as they refer to the same secret, so that reading a secret doesn't report the way its path is
+// written as a change. Terraform doesn't look at semantic equality when planning: see requiresReplaceIfPathChanged.
+type secretPathType struct {
+	basetypes.StringType
+}
+
+func (t secretPathType) Equal(o attr.Type) bool {
+	other, ok := o.(secretPathType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t secretPathType) String() string {
+	return "secretPathType"
+}
+
+func (t secretPathType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return secretPathValue{StringValue: in}, nil
+}
+
+func (t secretPathType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	stringValuable, diags := t.ValueFromString(ctx, stringValue)
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting StringValue to StringValuable: %v", diags)
+	}
+	return stringValuable, nil
+}
+
+func (t secretPathType) ValueType(ctx context.Context) attr.Value {
+	return secretPathValue{}
+}
+
+// secretPathValue is the value of a secretPathType attribute.
+type secretPathValue struct {
+	basetypes.StringValue
+}
+
+func (v secretPathValue) Equal(o attr.Value) bool {
+	other, ok := o.(secretPathValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v secretPathValue) Type(ctx context.Context) attr.Type {
+	return secretPathType{}
+}
+
+func (v secretPathValue) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(secretPathValue)
+	if !ok {
+		diags.AddError("Semantic Equality Check Error", fmt.Sprintf("Expected value type %T but got value type %T. Please report this issue to the provider developers.", v, newValuable))
+		return false, diags
+	}
+
+	return vault.NormalizePath(v.ValueString()) == vault.NormalizePath(newValue.ValueString()), diags
+}
+
+// secretPathString returns a secret path as configured.
+func secretPathString(value string) secretPathValue {
+	return secretPathValue{StringValue: types.StringValue(value)}
+}
+
+// secretPathID returns the identifier of the resource managing the secret at p: its normalized path, so that it doesn't
+// depend on how the path is written in the configuration.
+func secretPathID(p secretPathValue) types.String {
+	if p.IsNull() || p.IsUnknown() {
+		return p.StringValue
+	}
+	return types.StringValue(vault.NormalizePath(p.ValueString()))
+}
+
+// sameSecretPath tells if two paths refer to the same secret, i.e. only differ by leading or trailing slashes.
+func sameSecretPath(a, b string) bool {
+	return vault.NormalizePath(a) == vault.NormalizePath(b)
+}
+
+// requiresReplaceIfPathChanged replaces the resource when its path refers to another secret. Changing `/secret/foo` to
+// `secret/foo` in a configuration is planned as an in-place update instead, only recording the new spelling in state:
+// Terraform rejects planned values differing from the configuration, so the change can't be suppressed entirely.
+func requiresReplaceIfPathChanged() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = !sameSecretPath(req.StateValue.ValueString(), req.PlanValue.ValueString())
+		},
+		"Changing the path to another secret replaces the resource. Leading and trailing slashes are ignored.",
+		"Changing the path to another secret replaces the resource. Leading and trailing slashes are ignored.",
+	)
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestSecretPathSemanticEquals(t *testing.T) {
+	tests := []struct {
+		prior, planned string
+		equal          bool
+	}{
+		{"secret/foo", "secret/foo", true},
+		{"/secret/foo", "secret/foo", true},
+		{"secret/foo/", "/secret/foo", true},
+		{"secret/foo", "secret/bar", false},
+	}
+
+	for _, tt := range tests {
+		equal, diags := secretPathString(tt.prior).StringSemanticEquals(context.Background(), secretPathString(tt.planned))
+		if diags.HasError() {
+			t.Fatalf("Unexpected error: %v", diags)
+		}
+		if equal != tt.equal {
+			t.Fatalf("Wrong semantic equality of %q and %q: %t. Expected: %t", tt.prior, tt.planned, equal, tt.equal)
+		}
+	}
+
+	if id := secretPathID(secretPathString("/secret/foo/")); id.ValueString() != "secret/foo" {
+		t.Fatalf("Wrong id: %s. Expected: secret/foo", id.ValueString())
+	}
+}
+
+func TestSecretPathChangePlan(t *testing.T) {
+	kv := newFakeKV(t)
+	r := newTestResource(t, map[string]*fakeKV{"default": kv}, "vaultprov_random_secret")
+
+	r.apply(r.config(map[string]tftypes.Value{
+		"path":          tftypes.NewValue(tftypes.String, "/secret/foo"),
+		"force_destroy": tftypes.NewValue(tftypes.Bool, true),
+	}))
+	value := kv.value("foo")
+
+	// Another spelling of the same path is updated in place
+	respelled := r.config(map[string]tftypes.Value{
+		"path":          tftypes.NewValue(tftypes.String, "secret/foo"),
+		"force_destroy": tftypes.NewValue(tftypes.Bool, true),
+	})
+	plan := r.plan(respelled)
+	checkDiagnostics(t, plan.Diagnostics)
+	if len(plan.RequiresReplace) != 0 {
+		t.Fatalf("Resource replaced for another spelling of its path: %v", plan.RequiresReplace)
+	}
+	r.apply(respelled)
+	if v := kv.value("foo"); v != value {
+		t.Fatalf("Secret regenerated: %q. Expected: %q", v, value)
+	}
+	if id := r.attribute("id"); !id.Equal(tftypes.NewValue(tftypes.String, "secret/foo")) {
+		t.Fatalf("Wrong id: %v", id)
+	}
+
+	// Another path is another secret
+	plan = r.plan(r.config(map[string]tftypes.Value{
+		"path":          tftypes.NewValue(tftypes.String, "secret/bar"),
+		"force_destroy": tftypes.NewValue(tftypes.Bool, true),
+	}))
+	checkDiagnostics(t, plan.Diagnostics)
+	if len(plan.RequiresReplace) != 1 {
+		t.Fatalf("Resource not replaced for another path: %v", plan.RequiresReplace)
+	}
+}
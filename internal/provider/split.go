@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+)
+
+const (
+	SplitSecretType = "split_secret"
+	// SplitSecretShareMetadata holds the index of a share among the shares of the secret, e.g. `2/5`
+	SplitSecretShareMetadata     = "split_secret_share"
+	SplitSecretThresholdMetadata = "split_secret_threshold"
+	// SplitSecretShareKey is the key of the secret data holding the base64 encoded share
+	SplitSecretShareKey = "share"
+)
+
+// splitSharePaths returns the paths of the secrets holding the shares of a split secret, siblings under its path:
+// `<path>/share-1` to `<path>/share-<shares>`.
+func splitSharePaths(secretPath string, shares int) []string {
+	paths := make([]string, shares)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("%s/share-%d", vault.NormalizePath(secretPath), i+1)
+	}
+	return paths
+}
+
+// splitShareMetadata sets the custom metadata describing a share of a split secret, index starting at 1.
+func splitShareMetadata(metadata map[string]string, index, shares, threshold, length int) {
+	metadata[SecretTypeMetadata] = SplitSecretType
+	metadata[SplitSecretShareMetadata] = fmt.Sprintf("%d/%d", index, shares)
+	metadata[SplitSecretThresholdMetadata] = strconv.Itoa(threshold)
+	metadata[SecretLengthMetadata] = strconv.Itoa(length)
+}
+
+func isSplitShareMetadata(key string) bool {
+	switch key {
+	case SecretTypeMetadata, SplitSecretShareMetadata, SplitSecretThresholdMetadata, SecretLengthMetadata:
+		return true
+	}
+	return false
+}
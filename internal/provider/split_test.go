@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSharePaths(t *testing.T) {
+	paths := splitSharePaths("/secret/root/", 3)
+	expected := []string{"secret/root/share-1", "secret/root/share-2", "secret/root/share-3"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Wrong share paths: %v. Expected: %v", paths, expected)
+	}
+}
+
+func TestSplitShareMetadata(t *testing.T) {
+	metadata := map[string]string{"owner": "my_team"}
+	splitShareMetadata(metadata, 2, 5, 3, 32)
+
+	expected := map[string]string{
+		"owner":                      "my_team",
+		SecretTypeMetadata:           SplitSecretType,
+		SplitSecretShareMetadata:     "2/5",
+		SplitSecretThresholdMetadata: "3",
+		SecretLengthMetadata:         "32",
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatalf("Wrong share metadata: %v. Expected: %v", metadata, expected)
+	}
+	for k := range expected {
+		if isSplitShareMetadata(k) == (k == "owner") {
+			t.Fatalf("Wrong classification of metadata %s", k)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Estimated sizes of the data of PGP keys, private and public ASCII armored keys included
+var pgpKeySizes = map[string]int64{
+	secrets.PGPAlgorithmEd25519: 1500,
+	secrets.PGPAlgorithmRSA:     10000,
+}
+
+// storageEstimate sums the estimated size of the secrets planned under each mount, see storage_warning_threshold.
+// Resources are planned concurrently and each one adds its own secret: the warning is reported once per mount, by the
+// resource whose secret crosses the threshold.
+type storageEstimate struct {
+	threshold int64
+	// mounts are the KV mounts detected by the provider, secrets are grouped by the first segment of their path
+	// without them
+	mounts []vault.KVMount
+
+	mutex  sync.Mutex
+	sizes  map[string]int64
+	counts map[string]int
+	warned map[string]bool
+}
+
+// newStorageEstimate returns nil, i.e. nothing is estimated, when threshold is 0.
+func newStorageEstimate(threshold int64, mounts []vault.KVMount) *storageEstimate {
+	if threshold <= 0 {
+		return nil
+	}
+	return &storageEstimate{
+		threshold: threshold,
+		mounts:    mounts,
+		sizes:     make(map[string]int64),
+		counts:    make(map[string]int),
+		warned:    make(map[string]bool),
+	}
+}
+
+// mount returns the mount of secretPath, with a trailing slash.
+func (e *storageEstimate) mount(secretPath string) string {
+	if mount := vault.FindKVMount(e.mounts, secretPath); mount != nil {
+		return mount.Path
+	}
+	return strings.SplitN(vault.NormalizePath(secretPath), "/", 2)[0] + "/"
+}
+
+// add adds size bytes to the secrets planned under the mount of secretPath, and reports a warning when the total
+// exceeds the threshold. Nothing is added while the path is unknown.
+func (e *storageEstimate) add(diags *diag.Diagnostics, secretPath types.String, size int64) {
+	if e == nil || secretPath.IsNull() || secretPath.IsUnknown() {
+		return
+	}
+
+	mount := e.mount(secretPath.ValueString())
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.sizes[mount] += size
+	e.counts[mount]++
+	if e.sizes[mount] <= e.threshold || e.warned[mount] {
+		return
+	}
+	e.warned[mount] = true
+	diags.AddAttributeWarning(
+		path.Root("path"),
+		"Large secrets planned under mount",
+		fmt.Sprintf("The secrets planned under mount %s so far (%d secrets, this one included) hold about %d bytes, above the provider's storage_warning_threshold of %d bytes. Only the latest version of each secret is counted: Vault also stores the previous versions, up to the max_versions of the mount. Check the capacity of Vault's storage, e.g. Raft, before applying.", mount, e.counts[mount], e.sizes[mount], e.threshold),
+	)
+}
+
+// base64Length is the length of n bytes encoded in padded base64.
+func base64Length(n int64) int64 {
+	return (n + 2) / 3 * 4
+}
+
+// randomSecretSize estimates the size of the data of a random secret.
+func randomSecretSize(plan randomSecretModel) int64 {
+	length := plan.Length.ValueInt64()
+	var size int64
+	switch plan.ValueType.ValueString() {
+	case HexValueType:
+		size = 2 * length
+	case AlphanumericValueType:
+		size = length
+	case UUIDValueType:
+		size = 36
+	default:
+		size = base64Length(length)
+	}
+	return size + int64(len(plan.Username.ValueString())+len(plan.Template.ValueString()))
+}
+
+// apiTokenSize estimates the size of the data of an API token.
+func apiTokenSize(plan apiTokenModel) int64 {
+	size := int64(len(plan.Prefix.ValueString())) + plan.Length.ValueInt64()
+	if plan.Checksum.ValueBool() {
+		size += secrets.TokenChecksumLength
+	}
+	return size
+}
+
+// secretBundleSize estimates the size of the data of a secret bundle, previous values of rotated fields included.
+func secretBundleSize(fields map[string]secretBundleFieldModel) int64 {
+	var size int64
+	for _, field := range fields {
+		fieldSize := base64Length(field.Length.ValueInt64())
+		if !field.PreviousField.IsNull() {
+			fieldSize *= 2
+		}
+		size += fieldSize
+	}
+	return size
+}
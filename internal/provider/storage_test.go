@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestStorageEstimate(t *testing.T) {
+	if newStorageEstimate(0, nil) != nil {
+		t.Fatal("Unexpected estimate without threshold")
+	}
+
+	estimate := newStorageEstimate(100, []vault.KVMount{{Path: "kv/team/", Version: 2}})
+	tests := []struct {
+		path     types.String
+		size     int64
+		warnings int
+	}{
+		{types.StringValue("secret/foo"), 60, 0},
+		{types.StringValue("kv/team/foo"), 60, 0},
+		{types.StringUnknown(), 60, 0},
+		// Crosses the threshold of secret/
+		{types.StringValue("/secret/bar/baz"), 60, 1},
+		// Reported once per mount
+		{types.StringValue("secret/qux"), 60, 0},
+		{types.StringValue("kv/team/bar"), 60, 1},
+	}
+	for _, test := range tests {
+		var diags diag.Diagnostics
+		estimate.add(&diags, test.path, test.size)
+		if diags.WarningsCount() != test.warnings {
+			t.Fatalf("Wrong warnings for %s: %v. Expected: %d", test.path, diags, test.warnings)
+		}
+	}
+	if estimate.sizes["secret/"] != 180 || estimate.counts["secret/"] != 3 {
+		t.Fatalf("Wrong estimate of secret/: %d bytes, %d secrets. Expected: 180 bytes, 3 secrets", estimate.sizes["secret/"], estimate.counts["secret/"])
+	}
+}
+
+func TestRandomSecretSize(t *testing.T) {
+	tests := []struct {
+		valueType string
+		length    int64
+		expected  int64
+	}{
+		{BytesValueType, 32, 44},
+		{HexValueType, 32, 64},
+		{AlphanumericValueType, 32, 32},
+		{UUIDValueType, 0, 36},
+	}
+	for _, test := range tests {
+		plan := randomSecretModel{ValueType: types.StringValue(test.valueType), Length: types.Int64Value(test.length)}
+		if size := randomSecretSize(plan); size != test.expected {
+			t.Fatalf("Wrong size of a %s secret of length %d: %d. Expected: %d", test.valueType, test.length, size, test.expected)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// checkManagedSecret reports an error, in strict mode, when secret has no secret_type custom metadata: it hasn't been
+// written by the provider and must not be modified by it, e.g. after importing the wrong path.
+func checkManagedSecret(secret *vault.Secret, strict bool, diags *diag.Diagnostics) {
+	if !strict || secret == nil {
+		return
+	}
+	if _, ok := secret.Metadata[SecretTypeMetadata]; !ok {
+		diags.AddError("Secret not managed by the provider", fmt.Sprintf("Secret %s has no `%s` custom metadata. The provider is in strict mode and refuses to manage secrets it hasn't written.", secret.Path, SecretTypeMetadata))
+	}
+}
+
+// checkStrictMode reads the metadata of the secret at secretPath, in strict mode, to check that the provider may modify
+// it. See checkManagedSecret.
+func checkStrictMode(ctx context.Context, store vault.SecretStore, strict bool, secretPath string, diags *diag.Diagnostics) {
+	if !strict {
+		return
+	}
+
+	secret, err := store.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error reading secret", fmt.Sprintf("Error while reading metadata of secret %s", secretPath), err)
+		return
+	}
+	checkManagedSecret(secret, strict, diags)
+}
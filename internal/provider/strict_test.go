@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestCheckManagedSecret(t *testing.T) {
+	handWritten := &vault.Secret{Path: "secret/foo", Metadata: map[string]string{"owner": "team_a"}}
+
+	var diags diag.Diagnostics
+	checkManagedSecret(handWritten, false, &diags)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error out of strict mode: %v", diags)
+	}
+
+	checkManagedSecret(&vault.Secret{Path: "secret/foo", Metadata: map[string]string{SecretTypeMetadata: RandomSecretType}}, true, &diags)
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+
+	checkManagedSecret(handWritten, true, &diags)
+	if diags.ErrorsCount() != 1 {
+		t.Fatalf("Expected an error for a secret without %s, got: %v", SecretTypeMetadata, diags)
+	}
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// Schema versions of the resources, stored by Terraform along with their state. Adding optional or computed attributes
+// doesn't require a new version. When an attribute is renamed, removed or changes type, bump the version of the
+// resource and register an upgrader from the previous version in its UpgradeState, so that existing states are
+// migrated instead of failing to decode.
+//
+// Version 1 of the secret resources normalizes the id, see normalizeSecretPathID.
+const (
+	randomSecretSchemaVersion           = 1
+	pgpKeySchemaVersion                 = 1
+	apiTokenSchemaVersion               = 1
+	secretBundleSchemaVersion           = 1
+	policyBindingSchemaVersion          = 0
+	secretVersionsPurgeSchemaVersion    = 1
+	splitSecretSchemaVersion            = 0
+	existingSecretSchemaVersion         = 0
+	replicatedRandomSecretSchemaVersion = 0
+)
+
+// rawStateUpgrader returns a state upgrader applying upgrade to the JSON state stored by a prior schema version. It
+// avoids keeping a copy of every prior schema for changes easily expressed on the raw state, such as renames.
+func rawStateUpgrader(upgrade func(state map[string]interface{}) error) resource.StateUpgrader {
+	return resource.StateUpgrader{
+		StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+			if req.RawState == nil || req.RawState.JSON == nil {
+				resp.Diagnostics.AddError("Error upgrading state", "Missing prior state, only JSON states can be upgraded.")
+				return
+			}
+
+			var state map[string]interface{}
+			if err := json.Unmarshal(req.RawState.JSON, &state); err != nil {
+				resp.Diagnostics.AddError("Error upgrading state", fmt.Sprintf("Couldn't decode prior state: %s", err.Error()))
+				return
+			}
+			if err := upgrade(state); err != nil {
+				resp.Diagnostics.AddError("Error upgrading state", err.Error())
+				return
+			}
+
+			upgraded, err := json.Marshal(state)
+			if err != nil {
+				resp.Diagnostics.AddError("Error upgrading state", fmt.Sprintf("Couldn't encode upgraded state: %s", err.Error()))
+				return
+			}
+			resp.DynamicValue = &tfprotov6.DynamicValue{JSON: upgraded}
+		},
+	}
+}
+
+// renameAttribute moves a top level attribute of a raw state, to be used with rawStateUpgrader.
+func renameAttribute(state map[string]interface{}, from, to string) error {
+	if _, ok := state[to]; ok {
+		return fmt.Errorf("can't rename attribute %s to %s: attribute %s already exists", from, to, to)
+	}
+	if value, ok := state[from]; ok {
+		state[to] = value
+		delete(state, from)
+	}
+	return nil
+}
+
+// normalizeSecretPathID removes leading and trailing slashes from the id of a raw state, to be used with
+// rawStateUpgrader: up to schema version 0, the id of secret resources was their path as written in the configuration.
+func normalizeSecretPathID(state map[string]interface{}) error {
+	id, ok := state["id"].(string)
+	if !ok {
+		return nil
+	}
+	state["id"] = vault.NormalizePath(id)
+	return nil
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+func TestRawStateUpgrader(t *testing.T) {
+	upgrader := rawStateUpgrader(func(state map[string]interface{}) error {
+		return renameAttribute(state, "name", "path")
+	})
+
+	req := resource.UpgradeStateRequest{
+		RawState: &tfprotov6.RawState{JSON: []byte(`{"id":"secret/foo","name":"secret/foo","length":32}`)},
+	}
+	resp := &resource.UpgradeStateResponse{}
+	upgrader.StateUpgrader(context.Background(), req, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Unexpected error: %v", resp.Diagnostics)
+	}
+
+	var state map[string]interface{}
+	if err := json.Unmarshal(resp.DynamicValue.JSON, &state); err != nil {
+		t.Fatal("error:", err)
+	}
+	if _, ok := state["name"]; ok || state["path"] != "secret/foo" || state["length"] != float64(32) {
+		t.Fatalf("Wrong upgraded state: %v", state)
+	}
+
+	resp = &resource.UpgradeStateResponse{}
+	upgrader.StateUpgrader(context.Background(), resource.UpgradeStateRequest{
+		RawState: &tfprotov6.RawState{JSON: []byte(`{"name":"secret/foo","path":"secret/bar"}`)},
+	}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatalf("Expected an error when renaming to an existing attribute")
+	}
+}
+
+func TestNormalizeSecretPathID(t *testing.T) {
+	state := map[string]interface{}{"id": "/secret/foo/", "path": "/secret/foo/"}
+	if err := normalizeSecretPathID(state); err != nil {
+		t.Fatal("error:", err)
+	}
+	if state["id"] != "secret/foo" || state["path"] != "/secret/foo/" {
+		t.Fatalf("Wrong upgraded state: %v", state)
+	}
+
+	state = map[string]interface{}{"id": nil}
+	if err := normalizeSecretPathID(state); err != nil || state["id"] != nil {
+		t.Fatalf("Wrong upgraded state without id: %v (%v)", state, err)
+	}
+}
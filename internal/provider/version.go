@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/planmodifiers"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func versionAttribute() schema.Int64Attribute {
+	return schema.Int64Attribute{
+		Computed: true,
+		PlanModifiers: []planmodifier.Int64{
+			int64planmodifier.UseStateForUnknown(),
+		},
+		MarkdownDescription: "Version of the Vault secret produced by the last apply, i.e. the latest version written by the provider, for consumers pinning `?version=N`. The current version of the secret when `use_latest_version` is set, or when the provider didn't record the versions it wrote (imported secrets).",
+	}
+}
+
+func useLatestVersionAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		Optional: true,
+		Computed: true,
+		PlanModifiers: []planmodifier.Bool{
+			planmodifiers.BoolDefaultValue(types.BoolValue(false)),
+		},
+		MarkdownDescription: "If set to `true`, `version` reports the current version of the Vault secret, including versions written outside Terraform. Default is `false`.",
+	}
+}
+
+// secretVersion returns the value of the `version` attribute: the latest version of the secret written by the
+// provider, or its current version when useLatest is set or when no written version was recorded.
+func secretVersion(secret *vault.Secret, useLatest types.Bool) types.Int64 {
+	if !useLatest.ValueBool() {
+		if version := vault.LatestManagedVersion(secret.Metadata); version > 0 {
+			return types.Int64Value(int64(version))
+		}
+	}
+	return types.Int64Value(int64(secret.Version))
+}
+
+// planVersion marks `version` unknown when use_latest_version changes, so that it is read again on update.
+func planVersion(ctx context.Context, state, plan types.Bool, resp *resource.ModifyPlanResponse) {
+	if !state.Equal(plan) {
+		resp.Plan.SetAttribute(ctx, path.Root("version"), types.Int64Unknown())
+	}
+}
+
+// refreshVersion reads the `version` attribute again from Vault.
+func refreshVersion(ctx context.Context, store vault.SecretStore, secretPath string, useLatest types.Bool, diags *diag.Diagnostics) types.Int64 {
+	secret, err := store.ReadSecretMetadata(ctx, secretPath)
+	if err != nil {
+		addVaultError(diags, "Error updating secret", fmt.Sprintf("Error while reading secret %s", secretPath), err)
+		return types.Int64Unknown()
+	}
+	if secret == nil {
+		diags.AddError("Error updating secret", fmt.Sprintf("Secret %s doesn't exist anymore", secretPath))
+		return types.Int64Unknown()
+	}
+	return secretVersion(secret, useLatest)
+}
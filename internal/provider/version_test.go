@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestSecretVersion(t *testing.T) {
+	secret := &vault.Secret{
+		Metadata: map[string]string{vault.ManagedVersionsMetadata: "1,3"},
+		Version:  4,
+	}
+
+	if version := secretVersion(secret, types.BoolValue(false)); version.ValueInt64() != 3 {
+		t.Fatalf("Wrong version: %d. Expected the latest managed version: 3", version.ValueInt64())
+	}
+	if version := secretVersion(secret, types.BoolValue(true)); version.ValueInt64() != 4 {
+		t.Fatalf("Wrong version: %d. Expected the current version: 4", version.ValueInt64())
+	}
+
+	imported := &vault.Secret{Metadata: map[string]string{}, Version: 2}
+	if version := secretVersion(imported, types.BoolValue(false)); version.ValueInt64() != 2 {
+		t.Fatalf("Wrong version of imported secret: %d. Expected the current version: 2", version.ValueInt64())
+	}
+}
@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+	"golang.org/x/crypto/ssh"
+)
+
+// ParseEscrowRecipient parses the public key secrets are escrowed to: an age recipient (`age1...`), an SSH public key
+// (`ssh-rsa ...` or `ssh-ed25519 ...`) or a PEM encoded RSA public key.
+func ParseEscrowRecipient(publicKey string) (age.Recipient, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	switch {
+	case strings.HasPrefix(publicKey, "age1"):
+		return age.ParseX25519Recipient(publicKey)
+	case strings.HasPrefix(publicKey, "ssh-"):
+		return agessh.ParseRecipient(publicKey)
+	case strings.HasPrefix(publicKey, "-----BEGIN"):
+		return parsePEMRecipient(publicKey)
+	}
+	return nil, fmt.Errorf("unsupported public key, expected an age recipient, an SSH public key or a PEM encoded RSA public key")
+}
+
+func parsePEMRecipient(publicKey string) (age.Recipient, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM block")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T, only RSA keys are supported", key)
+	}
+	sshKey, err := ssh.NewPublicKey(rsaKey)
+	if err != nil {
+		return nil, err
+	}
+	return agessh.NewRSARecipient(sshKey)
+}
+
+// Escrow encrypts secret to publicKey, see ParseEscrowRecipient, and returns an ASCII armored age file. It can be
+// decrypted offline with the matching private key, e.g. `age --decrypt -i key.txt`.
+func Escrow(publicKey string, secret []byte) (string, error) {
+	recipient, err := ParseEscrowRecipient(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	armored := armor.NewWriter(&out)
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(w, bytes.NewReader(secret)); err != nil {
+		return "", err
+	}
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	if err = armored.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+)
+
+func TestEscrow(t *testing.T) {
+	x25519, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaIdentity, err := agessh.NewRSAIdentity(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey string
+		identity  age.Identity
+	}{
+		{"age", x25519.Recipient().String(), x25519},
+		{"pkix", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})), rsaIdentity},
+		{"pkcs1", string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})), rsaIdentity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escrowed, err := Escrow(tt.publicKey, []byte("my secret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(escrowed, armor.Header) {
+				t.Fatalf("Escrowed secret isn't armored: %s", escrowed)
+			}
+
+			r, err := age.Decrypt(armor.NewReader(strings.NewReader(escrowed)), tt.identity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			secret, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(secret) != "my secret" {
+				t.Fatalf("Wrong decrypted secret: %s", secret)
+			}
+		})
+	}
+}
+
+func TestParseEscrowRecipientInvalid(t *testing.T) {
+	for _, publicKey := range []string{"", "not a key", "age1invalid", "ssh-rsa invalid", "-----BEGIN PUBLIC KEY-----\n-----END PUBLIC KEY-----"} {
+		if _, err := ParseEscrowRecipient(publicKey); err == nil {
+			t.Errorf("Expected an error for %q", publicKey)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !boringcrypto
+
+package secrets
+
+// FIPSCrypto tells if the cryptography of the provider is provided by a FIPS 140 validated module, i.e. if it has been
+// built with GOEXPERIMENT=boringcrypto.
+func FIPSCrypto() bool {
+	return false
+}
@@ -0,0 +1,11 @@
+//go:build boringcrypto
+
+package secrets
+
+import "crypto/boring"
+
+// FIPSCrypto tells if the cryptography of the provider is provided by a FIPS 140 validated module, i.e. if it has been
+// built with GOEXPERIMENT=boringcrypto.
+func FIPSCrypto() bool {
+	return boring.Enabled()
+}
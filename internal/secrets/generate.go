@@ -1,9 +1,87 @@
 package secrets
 
-import "crypto/rand"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"sync"
+)
+
+// Generation parameters of the secrets generated by this package. Generator versions must be bumped whenever the way a
+// secret is generated changes, so that secrets generated by a faulty version can be identified later on.
+const (
+	RNGSource             = "crypto/rand"
+	SeededRNGSource       = "seeded"
+	RandomSecretGenerator = "random_secret/v1"
+	PGPKeyGenerator       = "pgp_key/v1"
+	APITokenGenerator     = "api_token/v1"
+	SecretBundleGenerator = "secret_bundle/v1"
+	SplitSecretGenerator  = "split_secret/v1"
+)
+
+var (
+	randReader io.Reader = rand.Reader
+	randSource           = RNGSource
+)
+
+// SetReader replaces the source of randomness used by every generator of this package. It is meant for tests only:
+// secrets generated with anything else than crypto/rand must never be used for real.
+func SetReader(reader io.Reader, source string) {
+	randReader = reader
+	randSource = source
+}
+
+// RNG returns the name of the source of randomness currently in use.
+func RNG() string {
+	return randSource
+}
+
+// NewSeededReader returns a deterministic, NOT cryptographically secure, reader. Generating the same secrets in the
+// same order from the same seed always gives the same values.
+func NewSeededReader(seed int64) io.Reader {
+	return &lockedReader{reader: mathrand.New(mathrand.NewSource(seed))}
+}
+
+// lockedReader makes a reader safe for concurrent use, as resources are created in parallel by Terraform.
+type lockedReader struct {
+	mu     sync.Mutex
+	reader io.Reader
+}
+
+func (r *lockedReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reader.Read(p)
+}
 
 func GenerateRandomSecret(length int) ([]byte, error) {
 	key := make([]byte, length)
-	_, err := rand.Read(key)
+	_, err := io.ReadFull(randReader, key)
 	return key, err
 }
+
+// GenerateUUID generates the 16 bytes of a random (version 4) UUID, see FormatUUID.
+func GenerateUUID() ([]byte, error) {
+	uuid, err := GenerateRandomSecret(16)
+	if err != nil {
+		return nil, err
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return uuid, nil
+}
+
+// FormatUUID returns the canonical textual representation of a UUID, e.g. `f47ac10b-58cc-4372-a567-0e02b2c3d479`.
+func FormatUUID(uuid []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+// Fingerprint returns the hex encoded SHA-256 of the given key material. It allows detecting changes of a secret and
+// referencing it without exposing it.
+func Fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
@@ -3,6 +3,7 @@ package secrets
 import (
 	"math/rand"
 	"reflect"
+	"regexp"
 	"testing"
 )
 
@@ -36,3 +37,46 @@ func TestRandomSecretUniqueness(t *testing.T) {
 		t.Fatalf("Both secret are equal")
 	}
 }
+
+func TestSeededReader(t *testing.T) {
+	defer SetReader(randReader, randSource)
+
+	generate := func() ([]byte, string) {
+		SetReader(NewSeededReader(42), SeededRNGSource)
+
+		secret, err := GenerateRandomSecret(64)
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+		token, err := GenerateAPIToken("sk_test_", 32, true)
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+		return secret, token
+	}
+
+	s1, t1 := generate()
+	s2, t2 := generate()
+
+	if !reflect.DeepEqual(s1, s2) {
+		t.Fatalf("Secrets generated from the same seed differ")
+	}
+	if t1 != t2 {
+		t.Fatalf("Tokens generated from the same seed differ: %s, %s", t1, t2)
+	}
+	if RNG() != SeededRNGSource {
+		t.Fatalf("Wrong RNG source: %s. Expected: %s", RNG(), SeededRNGSource)
+	}
+}
+
+func TestGenerateUUID(t *testing.T) {
+	uuid, err := GenerateUUID()
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	formatted := FormatUUID(uuid)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(formatted) {
+		t.Fatalf("Wrong UUID: %s", formatted)
+	}
+}
@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// Argon2id parameters, as recommended by RFC 9106 for memory constrained environments.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// HashPassword returns a salted hash of password, in the usual modular crypt format of the algorithm: `$2a$...` for
+// bcrypt, `$argon2id$v=19$...` (PHC string format) for argon2id.
+func HashPassword(algorithm string, password string) (string, error) {
+	secret := []byte(password)
+	defer Wipe(secret)
+
+	switch algorithm {
+	case HashBcrypt:
+		hash, err := bcrypt.GenerateFromPassword(secret, bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	case HashArgon2id:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := io.ReadFull(randReader, salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey(secret, salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm %s", algorithm)
+	}
+}
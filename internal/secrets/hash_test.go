@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordBcrypt(t *testing.T) {
+	hash, err := HashPassword(HashBcrypt, "s3cr3t")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(hash), []byte("s3cr3t")); err != nil {
+		t.Fatalf("Hash %s doesn't match password: %s", hash, err)
+	}
+}
+
+func TestHashPasswordArgon2id(t *testing.T) {
+	hash, err := HashPassword(HashArgon2id, "s3cr3t")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" || parts[3] != fmt.Sprintf("m=%d,t=%d,p=%d", argon2Memory, argon2Time, argon2Threads) {
+		t.Fatalf("Wrong hash format: %s", hash)
+	}
+
+	salt, _ := base64.RawStdEncoding.DecodeString(parts[4])
+	key := argon2.IDKey([]byte("s3cr3t"), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	if base64.RawStdEncoding.EncodeToString(key) != parts[5] {
+		t.Fatalf("Hash %s doesn't match password", hash)
+	}
+}
+
+func TestHashPasswordUnsupported(t *testing.T) {
+	if _, err := HashPassword("md5", "s3cr3t"); err == nil {
+		t.Fatalf("Expected an error for an unsupported algorithm")
+	}
+}
@@ -0,0 +1,173 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+const (
+	PGPAlgorithmEd25519 = "ed25519"
+	PGPAlgorithmRSA     = "rsa"
+	PGPRSABits          = 4096
+)
+
+type PGPKey struct {
+	PrivateKey  string
+	PublicKey   string
+	Fingerprint string
+	Name        string
+	Email       string
+	Algorithm   string
+
+	// KeyFingerprint is the SHA-256 of the binary public key
+	KeyFingerprint string
+}
+
+// GeneratePGPKey generates an OpenPGP key (primary signing key and encryption subkey) for the given identity. RSA keys
+// are 4096 bits long. Keys are returned ASCII armored.
+func GeneratePGPKey(name, email, algorithm string) (*PGPKey, error) {
+	config := &packet.Config{Rand: randReader}
+	switch algorithm {
+	case PGPAlgorithmEd25519:
+		config.Algorithm = packet.PubKeyAlgoEdDSA
+	case PGPAlgorithmRSA:
+		config.Algorithm = packet.PubKeyAlgoRSA
+		config.RSABits = PGPRSABits
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s", algorithm)
+	}
+
+	entity, err := openpgp.NewEntity(name, "", email, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key: %w", err)
+	}
+
+	var private bytes.Buffer
+	w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = entity.SerializePrivate(w, config); err != nil {
+		return nil, fmt.Errorf("unable to serialize private key: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	public, raw, err := armorPublicKey(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey := private.String()
+	secret := private.Bytes()
+	Wipe(secret[:cap(secret)])
+
+	return &PGPKey{
+		PrivateKey:     privateKey,
+		PublicKey:      public,
+		Fingerprint:    pgpFingerprint(entity),
+		Name:           name,
+		Email:          email,
+		Algorithm:      algorithm,
+		KeyFingerprint: Fingerprint(raw),
+	}, nil
+}
+
+// PGPPublicKey extracts the ASCII armored public key of an ASCII armored private key, as generated by GeneratePGPKey.
+func PGPPublicKey(armoredPrivateKey string) (string, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredPrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("unable to read private key: %w", err)
+	}
+	if len(entities) != 1 {
+		return "", fmt.Errorf("expected exactly one private key, got %d", len(entities))
+	}
+
+	public, _, err := armorPublicKey(entities[0])
+	return public, err
+}
+
+// armorPublicKey returns the ASCII armored public key of entity along with the binary one.
+func armorPublicKey(entity *openpgp.Entity) (string, []byte, error) {
+	var raw bytes.Buffer
+	if err := entity.Serialize(&raw); err != nil {
+		return "", nil, fmt.Errorf("unable to serialize public key: %w", err)
+	}
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err = w.Write(raw.Bytes()); err != nil {
+		return "", nil, err
+	}
+	if err = w.Close(); err != nil {
+		return "", nil, err
+	}
+	return public.String(), raw.Bytes(), nil
+}
+
+// ParsePGPPublicKey reads an ASCII armored OpenPGP public key and returns its fingerprint, algorithm and primary
+// identity. It fails for keys GeneratePGPKey doesn't generate: other algorithms or RSA keys of another size.
+func ParsePGPPublicKey(armored string) (*PGPKey, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key: %w", err)
+	}
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("expected exactly one public key, got %d", len(entities))
+	}
+
+	var raw bytes.Buffer
+	if err = entities[0].Serialize(&raw); err != nil {
+		return nil, fmt.Errorf("unable to serialize public key: %w", err)
+	}
+
+	algorithm, err := pgpAlgorithm(entities[0])
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PGPKey{
+		PublicKey:      armored,
+		Algorithm:      algorithm,
+		Fingerprint:    pgpFingerprint(entities[0]),
+		KeyFingerprint: Fingerprint(raw.Bytes()),
+	}
+	if identity := entities[0].PrimaryIdentity(); identity != nil {
+		key.Name = identity.UserId.Name
+		key.Email = identity.UserId.Email
+	}
+
+	return key, nil
+}
+
+// pgpAlgorithm returns the algorithm of the primary key of entity, checking its size.
+func pgpAlgorithm(entity *openpgp.Entity) (string, error) {
+	switch entity.PrimaryKey.PubKeyAlgo {
+	case packet.PubKeyAlgoEdDSA:
+		return PGPAlgorithmEd25519, nil
+	case packet.PubKeyAlgoRSA:
+		bits, err := entity.PrimaryKey.BitLength()
+		if err != nil {
+			return "", fmt.Errorf("unable to read RSA key size: %w", err)
+		}
+		if bits != PGPRSABits {
+			return "", fmt.Errorf("unsupported RSA key size %d, expected %d", bits, PGPRSABits)
+		}
+		return PGPAlgorithmRSA, nil
+	default:
+		return "", fmt.Errorf("unsupported public key algorithm %d", entity.PrimaryKey.PubKeyAlgo)
+	}
+}
+
+func pgpFingerprint(entity *openpgp.Entity) string {
+	return strings.ToUpper(fmt.Sprintf("%x", entity.PrimaryKey.Fingerprint))
+}
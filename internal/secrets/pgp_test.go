@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+func TestGeneratePGPKey(t *testing.T) {
+	for _, algorithm := range []string{PGPAlgorithmEd25519, PGPAlgorithmRSA} {
+		key, err := GeneratePGPKey("Release Bot", "release@example.com", algorithm)
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+
+		parsed, err := ParsePGPPublicKey(key.PublicKey)
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+
+		if parsed.Fingerprint != key.Fingerprint {
+			t.Fatalf("Wrong fingerprint for %s key: %s. Expected: %s", algorithm, parsed.Fingerprint, key.Fingerprint)
+		}
+		if parsed.KeyFingerprint != key.KeyFingerprint {
+			t.Fatalf("Wrong key fingerprint for %s key: %s. Expected: %s", algorithm, parsed.KeyFingerprint, key.KeyFingerprint)
+		}
+		if parsed.Name != "Release Bot" || parsed.Email != "release@example.com" {
+			t.Fatalf("Wrong identity for %s key: %s <%s>", algorithm, parsed.Name, parsed.Email)
+		}
+		if parsed.Algorithm != algorithm {
+			t.Fatalf("Wrong algorithm: %s. Expected: %s", parsed.Algorithm, algorithm)
+		}
+	}
+}
+
+func TestPGPPublicKey(t *testing.T) {
+	key, err := GeneratePGPKey("Release Bot", "release@example.com", PGPAlgorithmEd25519)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	publicKey, err := PGPPublicKey(key.PrivateKey)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if publicKey != key.PublicKey {
+		t.Fatalf("Wrong public key: %s. Expected: %s", publicKey, key.PublicKey)
+	}
+
+	if _, err = PGPPublicKey("not a key"); err == nil {
+		t.Fatalf("Expected an error for an invalid private key")
+	}
+}
+
+func TestGeneratePGPKeyUnsupportedAlgorithm(t *testing.T) {
+	if _, err := GeneratePGPKey("Release Bot", "", "dsa"); err == nil {
+		t.Fatalf("Expected an error for unsupported algorithm")
+	}
+}
+
+func TestParsePGPPublicKeyUnsupportedSize(t *testing.T) {
+	entity, err := openpgp.NewEntity("Release Bot", "", "", &packet.Config{Algorithm: packet.PubKeyAlgoRSA, RSABits: 2048})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if err = entity.Serialize(w); err != nil {
+		t.Fatal("error:", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("error:", err)
+	}
+
+	if _, err = ParsePGPPublicKey(public.String()); err == nil {
+		t.Fatalf("Expected an error for a 2048 bits RSA key")
+	}
+}
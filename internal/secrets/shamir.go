@@ -0,0 +1,15 @@
+package secrets
+
+import (
+	"encoding/base64"
+)
+
+// MaxShares is the maximum number of shares Vault's shamir package splits a secret into: shares are the points of
+// polynomials over GF(2^8) at distinct non-zero x coordinates.
+const MaxShares = 255
+
+// EncodeShare encodes a share returned by shamir.Split as stored in Vault. A share is made of one byte per byte of the
+// secret followed by its x coordinate, so that decoded shares can be combined by Vault's tooling (shamir.Combine).
+func EncodeShare(share []byte) string {
+	return base64.StdEncoding.EncodeToString(share)
+}
@@ -0,0 +1,34 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/shamir"
+)
+
+func TestEncodeShare(t *testing.T) {
+	secret := []byte("root of trust material")
+
+	shares, err := shamir.Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	var decoded [][]byte
+	for _, i := range []int{4, 2, 0} {
+		share, err := base64.StdEncoding.DecodeString(EncodeShare(shares[i]))
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+		decoded = append(decoded, share)
+	}
+	combined, err := shamir.Combine(decoded)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if !bytes.Equal(combined, secret) {
+		t.Fatalf("Wrong secret combined from encoded shares: %q", combined)
+	}
+}
@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// templatePlaceholder matches the placeholders of templates, e.g. `{{ secret }}`, spaces being optional.
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// TemplatePlaceholders returns the sorted names of the placeholders found in the strings (keys included) of a JSON
+// template, or an error when the template isn't a valid JSON document.
+func TemplatePlaceholders(template string) ([]string, error) {
+	document, err := decodeTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	walkTemplate(document, func(s string) string {
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			found[match[1]] = true
+		}
+		return s
+	})
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RenderTemplate replaces the placeholders of a JSON template by values and returns the resulting document, in compact
+// form with sorted keys. Placeholders are replaced within the decoded strings, so that the document stays valid
+// whatever the characters of the values. It fails on placeholders without value.
+func RenderTemplate(template string, values map[string]string) (string, error) {
+	document, err := decodeTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	rendered := walkTemplate(document, func(s string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+			name := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("no value for placeholders: %s", strings.Join(missing, ", "))
+	}
+
+	// Values such as connection strings are kept readable, without escaping &, < and >
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err = encoder.Encode(rendered); err != nil {
+		return "", fmt.Errorf("couldn't encode rendered template: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func decodeTemplate(template string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(template))
+	// Numbers are kept as written
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid JSON template: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid JSON template: unexpected data after the document")
+	}
+	return document, nil
+}
+
+// walkTemplate returns a copy of a decoded JSON document whose strings, keys included, are mapped by f.
+func walkTemplate(document interface{}, f func(string) string) interface{} {
+	switch v := document.(type) {
+	case string:
+		return f(v)
+	case []interface{}:
+		walked := make([]interface{}, len(v))
+		for i, e := range v {
+			walked[i] = walkTemplate(e, f)
+		}
+		return walked
+	case map[string]interface{}:
+		walked := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			walked[f(k)] = walkTemplate(e, f)
+		}
+		return walked
+	default:
+		return v
+	}
+}
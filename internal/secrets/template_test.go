@@ -0,0 +1,42 @@
+package secrets
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplatePlaceholders(t *testing.T) {
+	names, err := TemplatePlaceholders(`{"url": "postgres://{{ username }}:{{password}}@db:5432/app", "{{ username }}": [1, "{{ password }}"]}`)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if !reflect.DeepEqual(names, []string{"password", "username"}) {
+		t.Fatalf("Wrong placeholders: %v. Expected: [password username]", names)
+	}
+
+	for _, invalid := range []string{`{"url": `, `{} {}`, ``} {
+		if _, err = TemplatePlaceholders(invalid); err == nil {
+			t.Fatalf("Expected an error for template %q", invalid)
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	template := `{
+  "port": 5432,
+  "dsn": "postgres://app:{{ secret }}@db/app?sslmode=require",
+  "hosts": ["{{secret}}"]
+}`
+	rendered, err := RenderTemplate(template, map[string]string{"secret": `a"b\c&`})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	expected := `{"dsn":"postgres://app:a\"b\\c&@db/app?sslmode=require","hosts":["a\"b\\c&"],"port":5432}`
+	if rendered != expected {
+		t.Fatalf("Wrong rendered template: %s. Expected: %s", rendered, expected)
+	}
+
+	if _, err = RenderTemplate(`{"dsn": "{{ password }}"}`, map[string]string{"secret": "foo"}); err == nil {
+		t.Fatalf("Expected an error for a placeholder without value")
+	}
+}
@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/crc32"
+	"math/big"
+	"strings"
+)
+
+const (
+	base62Alphabet      = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	TokenChecksumLength = 6
+)
+
+// GenerateAPIToken generates a URL-safe token made of the given prefix followed by length random base62 characters.
+// If checksum is set, a base62 encoded CRC32 of the whole token is appended (as done by GitHub tokens), allowing
+// clients and secret scanners to detect mistyped or fake tokens without a lookup.
+func GenerateAPIToken(prefix string, length int, checksum bool) (string, error) {
+	random, err := GenerateAlphanumeric(length)
+	if err != nil {
+		return "", err
+	}
+	defer Wipe(random)
+
+	token := prefix + string(random)
+	if checksum {
+		token += TokenChecksum(token)
+	}
+
+	return token, nil
+}
+
+// GenerateAlphanumeric generates length random base62 characters.
+func GenerateAlphanumeric(length int) ([]byte, error) {
+	random := make([]byte, length)
+	max := big.NewInt(int64(len(base62Alphabet)))
+	for i := range random {
+		n, err := rand.Int(randReader, max)
+		if err != nil {
+			Wipe(random)
+			return nil, err
+		}
+		random[i] = base62Alphabet[n.Int64()]
+	}
+	return random, nil
+}
+
+// TokenChecksum returns the base62 encoded CRC32 of token, left padded to TokenChecksumLength characters.
+func TokenChecksum(token string) string {
+	n := uint64(crc32.ChecksumIEEE([]byte(token)))
+
+	var sb strings.Builder
+	for n > 0 {
+		sb.WriteByte(base62Alphabet[n%62])
+		n /= 62
+	}
+	encoded := []byte(sb.String())
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+
+	return strings.Repeat("0", TokenChecksumLength-len(encoded)) + string(encoded)
+}
+
+// TokenLookupHash returns the hex encoded SHA-256 of token, to be stored server side in place of the token itself.
+func TokenLookupHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPITokenFormat(t *testing.T) {
+	token, err := GenerateAPIToken("sk_live_", 30, true)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	if !strings.HasPrefix(token, "sk_live_") {
+		t.Fatalf("Token %s doesn't start with prefix", token)
+	}
+
+	if len(token) != len("sk_live_")+30+TokenChecksumLength {
+		t.Fatalf("Wrong token's length: %d. Expected: %d", len(token), len("sk_live_")+30+TokenChecksumLength)
+	}
+
+	body, checksum := token[:len(token)-TokenChecksumLength], token[len(token)-TokenChecksumLength:]
+	if TokenChecksum(body) != checksum {
+		t.Fatalf("Wrong checksum: %s. Expected: %s", checksum, TokenChecksum(body))
+	}
+}
+
+func TestAPITokenWithoutChecksum(t *testing.T) {
+	token, err := GenerateAPIToken("", 40, false)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	if len(token) != 40 {
+		t.Fatalf("Wrong token's length: %d. Expected: %d", len(token), 40)
+	}
+}
+
+func TestTokenLookupHash(t *testing.T) {
+	// echo -n "foo" | sha256sum
+	expected := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	if h := TokenLookupHash("foo"); h != expected {
+		t.Fatalf("Wrong lookup hash: %s. Expected: %s", h, expected)
+	}
+}
@@ -0,0 +1,13 @@
+package secrets
+
+import "runtime"
+
+// Wipe overwrites b with zeros so that key material doesn't linger in memory once it has been written to Vault. Go
+// strings can't be wiped: keep secrets as byte slices as long as possible and only encode them when needed.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	// Prevent the compiler from optimizing the loop away as a dead store.
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,23 @@
+package secrets
+
+import "testing"
+
+func TestWipe(t *testing.T) {
+	key, err := GenerateRandomSecret(64)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	Wipe(key)
+
+	if len(key) != 64 {
+		t.Fatalf("Wrong wiped key length: %d. Expected: 64", len(key))
+	}
+	for i, b := range key {
+		if b != 0 {
+			t.Fatalf("Byte %d not wiped: %d", i, b)
+		}
+	}
+
+	Wipe(nil)
+}
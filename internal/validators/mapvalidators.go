@@ -0,0 +1,80 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Limits of the custom_metadata of KV v2 secrets, enforced by Vault.
+const (
+	MaxCustomMetadataKeys        = 64
+	MaxCustomMetadataKeyLength   = 128
+	MaxCustomMetadataValueLength = 512
+)
+
+// CustomMetadata checks that a map of strings fits in the custom_metadata of a KV v2 secret, with at most maxKeys
+// entries, so that the request isn't rejected by Vault at apply time.
+func CustomMetadata(maxKeys int) validator.Map {
+	return &customMetadataValidator{maxKeys: maxKeys}
+}
+
+type customMetadataValidator struct {
+	maxKeys int
+}
+
+func (v *customMetadataValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("map must have at most %d entries, with non-empty keys of at most %d bytes and values of at most %d bytes", v.maxKeys, MaxCustomMetadataKeyLength, MaxCustomMetadataValueLength)
+}
+
+func (v *customMetadataValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v *customMetadataValidator) ValidateMap(ctx context.Context, req validator.MapRequest, resp *validator.MapResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	elements := req.ConfigValue.Elements()
+	if len(elements) > v.maxKeys {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Too many custom metadata",
+			fmt.Sprintf("Attribute %s has %d entries, at most %d are allowed: Vault accepts %d custom metadata per secret, the others are used by the provider.", req.Path, len(elements), v.maxKeys, MaxCustomMetadataKeys),
+		)
+	}
+
+	keys := make([]string, 0, len(elements))
+	for k := range elements {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if k == "" {
+			resp.Diagnostics.AddAttributeError(req.Path, "Invalid custom metadata key", fmt.Sprintf("Attribute %s has an empty key, Vault refuses empty custom metadata keys.", req.Path))
+		} else if len(k) > MaxCustomMetadataKeyLength {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtMapKey(k),
+				"Invalid custom metadata key",
+				fmt.Sprintf("Key %q of attribute %s is %d bytes long, Vault accepts at most %d bytes.", k, req.Path, len(k), MaxCustomMetadataKeyLength),
+			)
+		}
+
+		value, ok := elements[k].(types.String)
+		if !ok || value.IsUnknown() || value.IsNull() {
+			continue
+		}
+		if len(value.ValueString()) > MaxCustomMetadataValueLength {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtMapKey(k),
+				"Invalid custom metadata value",
+				fmt.Sprintf("Value of key %q of attribute %s is %d bytes long, Vault accepts at most %d bytes.", k, req.Path, len(value.ValueString()), MaxCustomMetadataValueLength),
+			)
+		}
+	}
+}
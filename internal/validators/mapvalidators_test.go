@@ -0,0 +1,51 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCustomMetadata(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i < 5; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+
+	tests := []struct {
+		name      string
+		metadata  map[string]string
+		wantError bool
+	}{
+		{"valid", map[string]string{"owner": "my_team", "empty": ""}, false},
+		{"too many keys", tooMany, true},
+		{"empty key", map[string]string{"": "value"}, true},
+		{"key too long", map[string]string{strings.Repeat("k", MaxCustomMetadataKeyLength+1): "value"}, true},
+		{"value too long", map[string]string{"owner": strings.Repeat("v", MaxCustomMetadataValueLength+1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elements := make(map[string]attr.Value)
+			for k, v := range tt.metadata {
+				elements[k] = types.StringValue(v)
+			}
+			req := validator.MapRequest{
+				Path:        path.Root("metadata"),
+				ConfigValue: types.MapValueMust(types.StringType, elements),
+			}
+			resp := &validator.MapResponse{}
+
+			CustomMetadata(4).ValidateMap(context.Background(), req, resp)
+			if resp.Diagnostics.HasError() != tt.wantError {
+				t.Fatalf("Wrong validation result: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
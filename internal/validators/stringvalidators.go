@@ -0,0 +1,104 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	"github.com/blablacar/terraform-provider-vaultprov/internal/vault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// Duration checks that a string is a valid positive Go duration, e.g. `72h` or `30m`.
+func Duration() validator.String {
+	return &durationValidator{}
+}
+
+type durationValidator struct{}
+
+func (v *durationValidator) Description(ctx context.Context) string {
+	return "value must be a positive duration, e.g. `72h`"
+}
+
+func (v *durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v *durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	d, err := time.ParseDuration(req.ConfigValue.ValueString())
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("duration must be positive")
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid duration",
+			fmt.Sprintf("Attribute %s %s, got %q: %s", req.Path, v.Description(ctx), req.ConfigValue.ValueString(), err.Error()),
+		)
+	}
+}
+
+// EscrowPublicKey checks that a string is a public key secrets can be escrowed to, see secrets.ParseEscrowRecipient.
+func EscrowPublicKey() validator.String {
+	return &escrowPublicKeyValidator{}
+}
+
+type escrowPublicKeyValidator struct{}
+
+func (v *escrowPublicKeyValidator) Description(ctx context.Context) string {
+	return "value must be an age recipient, an SSH public key or a PEM encoded RSA public key"
+}
+
+func (v *escrowPublicKeyValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v *escrowPublicKeyValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := secrets.ParseEscrowRecipient(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid escrow public key",
+			fmt.Sprintf("Attribute %s %s: %s", req.Path, v.Description(ctx), err.Error()),
+		)
+	}
+}
+
+// SecretPath checks that a secret path isn't empty once its leading and trailing slashes are removed. Empty paths
+// usually come from a conditional expression or an upstream module output that didn't resolve as expected, and would
+// otherwise fail later with an obscure Vault API error. Unknown values are only checked once known.
+func SecretPath() validator.String {
+	return &secretPathValidator{}
+}
+
+type secretPathValidator struct{}
+
+func (v *secretPathValidator) Description(ctx context.Context) string {
+	return "value must be a non-empty secret path, e.g. `secret/foo/bar`"
+}
+
+func (v *secretPathValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v *secretPathValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if vault.NormalizePath(req.ConfigValue.ValueString()) == "" {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Empty path",
+			fmt.Sprintf("Attribute %s is empty (got %q); did the upstream module output or conditional expression setting it resolve? Attribute %s %s.", req.Path, req.ConfigValue.ValueString(), req.Path, v.Description(ctx)),
+		)
+	}
+}
@@ -0,0 +1,84 @@
+package validators
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDuration(t *testing.T) {
+	tests := map[string]bool{
+		"72h":     false,
+		"1h30m":   false,
+		"-1h":     true,
+		"0s":      true,
+		"3 days":  true,
+		"forever": true,
+	}
+
+	for value, wantError := range tests {
+		req := validator.StringRequest{
+			Path:        path.Root("destroy_after"),
+			ConfigValue: types.StringValue(value),
+		}
+		resp := &validator.StringResponse{}
+
+		Duration().ValidateString(context.Background(), req, resp)
+		if resp.Diagnostics.HasError() != wantError {
+			t.Fatalf("Wrong validation result for %q: %v", value, resp.Diagnostics)
+		}
+	}
+}
+
+func TestEscrowPublicKey(t *testing.T) {
+	tests := map[string]bool{
+		"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p": false,
+		"age1invalid": true,
+		"not a key":   true,
+	}
+
+	for value, wantError := range tests {
+		req := validator.StringRequest{
+			Path:        path.Root("escrow_public_key"),
+			ConfigValue: types.StringValue(value),
+		}
+		resp := &validator.StringResponse{}
+
+		EscrowPublicKey().ValidateString(context.Background(), req, resp)
+		if resp.Diagnostics.HasError() != wantError {
+			t.Fatalf("Wrong validation result for %q: %v", value, resp.Diagnostics)
+		}
+	}
+}
+
+func TestSecretPath(t *testing.T) {
+	tests := map[string]bool{
+		"secret/foo":  false,
+		"/secret/foo": false,
+		"":            true,
+		"/":           true,
+		" // ":        true,
+	}
+
+	for value, wantError := range tests {
+		req := validator.StringRequest{
+			Path:        path.Root("path"),
+			ConfigValue: types.StringValue(value),
+		}
+		resp := &validator.StringResponse{}
+
+		SecretPath().ValidateString(context.Background(), req, resp)
+		if resp.Diagnostics.HasError() != wantError {
+			t.Fatalf("Wrong validation result for %q: %v", value, resp.Diagnostics)
+		}
+	}
+
+	resp := &validator.StringResponse{}
+	SecretPath().ValidateString(context.Background(), validator.StringRequest{Path: path.Root("path"), ConfigValue: types.StringUnknown()}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Unexpected error for an unknown path: %v", resp.Diagnostics)
+	}
+}
@@ -0,0 +1,58 @@
+package vault
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+// LimitConcurrentRequests limits the number of requests the clients created from conf send concurrently to Vault, so
+// that large applies don't overload small Vault clusters. Requests over the limit wait for a slot, or for their
+// context to be done. A request holds its slot until its response body is closed.
+func LimitConcurrentRequests(conf *vaultinternals.Config, max int) {
+	base := conf.HttpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	conf.HttpClient.Transport = &limitedTransport{
+		base:     base,
+		requests: make(chan struct{}, max),
+	}
+}
+
+type limitedTransport struct {
+	base     http.RoundTripper
+	requests chan struct{}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.requests <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		<-t.requests
+		return nil, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { <-t.requests }}
+	return resp, nil
+}
+
+// releasingBody releases the slot of its request once closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
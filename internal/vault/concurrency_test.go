@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestLimitConcurrentRequests(t *testing.T) {
+	var current, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	LimitConcurrentRequests(conf, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := conf.HttpClient.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Fatalf("Too many concurrent requests: %d. Expected at most 2", peak)
+	}
+}
+
+func TestLimitConcurrentRequestsContext(t *testing.T) {
+	conf := vaultinternals.DefaultConfig()
+	LimitConcurrentRequests(conf, 1)
+	transport := conf.HttpClient.Transport.(*limitedTransport)
+	transport.requests <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:8200", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = transport.RoundTrip(req); err != context.Canceled {
+		t.Fatalf("Expected the request to be cancelled while waiting, got %v", err)
+	}
+}
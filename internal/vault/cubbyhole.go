@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CubbyholeMount is the path of the cubbyhole secrets engine. Cubbyhole secrets are scoped to the token used by the
+// provider and vanish with it. They have neither custom metadata nor versions.
+const CubbyholeMount = "cubbyhole"
+
+// CubbyholeAPIPath checks that secretPath is in the cubbyhole and returns its API path.
+func CubbyholeAPIPath(secretPath string) (string, error) {
+	apiPath := sanitizePath(secretPath)
+	if !strings.HasPrefix(apiPath, CubbyholeMount+"/") {
+		return "", fmt.Errorf("path %s is not in the %s mount", secretPath, CubbyholeMount)
+	}
+	return apiPath, nil
+}
+
+// CreateCubbyholeSecret writes a new secret in the cubbyhole of the provider's token. It fails if a secret already
+// exists at the same path.
+func (c *VaultApi) CreateCubbyholeSecret(ctx context.Context, secretPath string, data map[string]interface{}) error {
+	if err := c.checkWritable("create secret"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	apiPath, err := CubbyholeAPIPath(secretPath)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	s, err := c.client.Logical().ReadWithContext(ctx, apiPath)
+	if err != nil {
+		return newError(ctx, "read secret's data", apiPath, err)
+	}
+	if s != nil {
+		return fmt.Errorf("secret %s already exists", secretPath)
+	}
+
+	if err = checkCapabilities(ctx, c.client, apiPath, "create"); err != nil {
+		return err
+	}
+
+	_, err = c.client.Logical().WriteWithContext(ctx, apiPath, data)
+	if err != nil {
+		return newError(ctx, "write secret's data", apiPath, err)
+	}
+	return nil
+}
+
+// ReadCubbyholeSecret reads a secret from the cubbyhole of the provider's token. It returns nil if the secret doesn't
+// exist, e.g. because the token has changed.
+func (c *VaultApi) ReadCubbyholeSecret(ctx context.Context, secretPath string) (*Secret, error) {
+	ctx = withRequestID(ctx)
+	apiPath, err := CubbyholeAPIPath(secretPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	s, err := c.client.Logical().ReadWithContext(ctx, apiPath)
+	if err != nil {
+		return nil, newError(ctx, "read secret's data", apiPath, err)
+	}
+	if s == nil {
+		return nil, nil
+	}
+
+	return &Secret{
+		Path:     secretPath,
+		Data:     s.Data,
+		Metadata: map[string]string{},
+		DataPath: apiPath,
+	}, nil
+}
+
+// DeleteCubbyholeSecret deletes a secret from the cubbyhole of the provider's token. Cubbyhole secrets aren't
+// versioned: the secret is gone for good.
+func (c *VaultApi) DeleteCubbyholeSecret(ctx context.Context, secretPath string) error {
+	if err := c.checkWritable("delete secret"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	apiPath, err := CubbyholeAPIPath(secretPath)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	if err = checkCapabilities(ctx, c.client, apiPath, "delete"); err != nil {
+		return err
+	}
+
+	_, err = c.client.Logical().DeleteWithContext(ctx, apiPath)
+	if err != nil {
+		return newError(ctx, "delete secret", apiPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package vault
+
+import "testing"
+
+func TestCubbyholeAPIPath(t *testing.T) {
+	apiPath, err := CubbyholeAPIPath("/cubbyhole/ci/bootstrap/")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if apiPath != "cubbyhole/ci/bootstrap" {
+		t.Fatalf("Wrong cubbyhole path: %s", apiPath)
+	}
+
+	if _, err = CubbyholeAPIPath("secret/ci/bootstrap"); err == nil {
+		t.Fatalf("Expected an error for a path outside the cubbyhole")
+	}
+}
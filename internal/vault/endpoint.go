@@ -0,0 +1,23 @@
+package vault
+
+// WithEndpoint returns a VaultApi sending its requests to another Vault cluster or namespace, with the settings of the
+// provider's client (transport, retries, headers...). The namespace and token of the provider's client are kept when
+// namespace or token are empty.
+func (c *VaultApi) WithEndpoint(address, namespace, token string) (*VaultApi, error) {
+	client, err := c.client.CloneWithHeaders()
+	if err != nil {
+		return nil, err
+	}
+	if err = client.SetAddress(address); err != nil {
+		return nil, err
+	}
+	if namespace != "" {
+		client.SetNamespace(namespace)
+	}
+	if token == "" {
+		token = c.client.Token()
+	}
+	client.SetToken(token)
+
+	return &VaultApi{client: client, readOnly: c.readOnly}, nil
+}
@@ -0,0 +1,46 @@
+package vault
+
+import (
+	"testing"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestWithEndpoint(t *testing.T) {
+	client, err := vaultinternals.NewClient(vaultinternals.DefaultConfig())
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	client.SetToken("s.token")
+	client.AddHeader("X-Terraform-Run-ID", "run-1")
+	c := NewVaultApi(client)
+
+	eu, err := c.WithEndpoint("https://vault.eu.example.com:8200", "team-a", "")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if eu.client.Address() != "https://vault.eu.example.com:8200" {
+		t.Fatalf("Wrong address: %s. Expected: https://vault.eu.example.com:8200", eu.client.Address())
+	}
+	if eu.client.Namespace() != "team-a" || eu.client.Token() != "s.token" {
+		t.Fatalf("Wrong namespace or token: %q, %q. Expected: team-a, s.token", eu.client.Namespace(), eu.client.Token())
+	}
+	if eu.client.Headers().Get("X-Terraform-Run-ID") != "run-1" {
+		t.Fatalf("Headers not cloned: %v", eu.client.Headers())
+	}
+
+	us, err := c.WithEndpoint("https://vault.us.example.com:8200", "", "s.other")
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if us.client.Token() != "s.other" || us.client.Namespace() != "" {
+		t.Fatalf("Wrong token or namespace: %q, %q. Expected: s.other, no namespace", us.client.Token(), us.client.Namespace())
+	}
+	if client.Address() == us.client.Address() || client.Token() != "s.token" {
+		t.Fatalf("Provider's client changed: %s, %q", client.Address(), client.Token())
+	}
+
+	if _, err = c.WithEndpoint("://invalid", "", ""); err == nil {
+		t.Fatal("Expected an error with an invalid address")
+	}
+}
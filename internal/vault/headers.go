@@ -0,0 +1,24 @@
+package vault
+
+// WithHeaders returns a VaultApi sending the given headers on every request, on top of (or replacing) the headers of
+// the provider's client. The client is cloned, so that the headers don't apply to the requests of other resources. It
+// returns c itself when there are no headers.
+func (c *VaultApi) WithHeaders(headers map[string]string) (*VaultApi, error) {
+	if len(headers) == 0 {
+		return c, nil
+	}
+
+	client, err := c.client.CloneWithHeaders()
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(c.client.Token())
+
+	h := client.Headers()
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	client.SetHeaders(h)
+
+	return &VaultApi{client: client, readOnly: c.readOnly}, nil
+}
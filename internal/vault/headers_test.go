@@ -0,0 +1,36 @@
+package vault
+
+import (
+	"testing"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestWithHeaders(t *testing.T) {
+	client, err := vaultinternals.NewClient(vaultinternals.DefaultConfig())
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	client.SetToken("s.token")
+	client.AddHeader("X-Terraform-Run-ID", "run-1")
+	c := NewVaultApi(client)
+
+	if same, _ := c.WithHeaders(nil); same != c {
+		t.Fatal("Expected the same VaultApi without headers")
+	}
+
+	cloned, err := c.WithHeaders(map[string]string{"X-Team": "billing", "X-Terraform-Run-ID": "run-2"})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if cloned.client.Token() != "s.token" {
+		t.Fatalf("Token not cloned: %q", cloned.client.Token())
+	}
+	headers := cloned.client.Headers()
+	if headers.Get("X-Team") != "billing" || headers.Get("X-Terraform-Run-ID") != "run-2" {
+		t.Fatalf("Wrong headers: %v", headers)
+	}
+	if client.Headers().Get("X-Team") != "" || client.Headers().Get("X-Terraform-Run-ID") != "run-1" {
+		t.Fatalf("Provider's client headers changed: %v", client.Headers())
+	}
+}
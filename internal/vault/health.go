@@ -0,0 +1,54 @@
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"strings"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+// CheckHealth checks that Vault is reachable and able to serve requests: initialized, unsealed and not a DR
+// secondary. Standby nodes are accepted, they forward requests to the active node.
+func (c *VaultApi) CheckHealth(ctx context.Context) error {
+	health, err := c.client.Sys().HealthWithContext(ctx)
+	if err != nil {
+		return unreachableError(c.client.Address(), err)
+	}
+	return healthError(c.client.Address(), health)
+}
+
+// healthError returns an error when the health reported by Vault prevents it from serving requests.
+func healthError(address string, health *vaultinternals.HealthResponse) error {
+	switch {
+	case !health.Initialized:
+		return &HealthError{Address: address, Reason: "Vault is not initialized", hint: "Initialize the Vault cluster, or check that the address targets the right one."}
+	case health.Sealed:
+		return &HealthError{Address: address, Reason: "Vault is sealed", hint: "Unseal the Vault cluster before applying."}
+	case health.ReplicationDRMode == "secondary":
+		return &HealthError{Address: address, Reason: "Vault is a DR secondary", hint: "DR secondaries don't serve requests, use the address of the primary cluster."}
+	}
+	return nil
+}
+
+// unreachableError describes why Vault couldn't be reached at all.
+func unreachableError(address string, err error) error {
+	e := &HealthError{Address: address, Reason: "Vault is unreachable", Err: err}
+
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	switch {
+	case errors.As(err, &certErr), errors.As(err, &authorityErr), errors.As(err, &hostnameErr):
+		e.hint = "The TLS certificate of Vault couldn't be verified. Check VAULT_CACERT (or VAULT_CAPATH) and that the address matches the certificate's hostname."
+	case strings.Contains(err.Error(), "server gave HTTP response to HTTPS client"):
+		e.hint = "Vault doesn't serve TLS on this address, use an http:// address."
+	case strings.Contains(err.Error(), "malformed HTTP response"):
+		e.hint = "Vault serves TLS on this address, use an https:// address."
+	default:
+		e.hint = "Check the provider's `address` (or VAULT_ADDR) and the network path to Vault."
+	}
+	return e
+}
@@ -0,0 +1,50 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestHealthError(t *testing.T) {
+	tests := []struct {
+		name   string
+		health vaultinternals.HealthResponse
+		reason string
+	}{
+		{"healthy", vaultinternals.HealthResponse{Initialized: true}, ""},
+		{"standby", vaultinternals.HealthResponse{Initialized: true, Standby: true}, ""},
+		{"not initialized", vaultinternals.HealthResponse{Sealed: true}, "Vault is not initialized"},
+		{"sealed", vaultinternals.HealthResponse{Initialized: true, Sealed: true}, "Vault is sealed"},
+		{"dr secondary", vaultinternals.HealthResponse{Initialized: true, ReplicationDRMode: "secondary"}, "Vault is a DR secondary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := healthError("https://vault.internal:8200", &tt.health)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				return
+			}
+
+			var healthErr *HealthError
+			if !errors.As(err, &healthErr) || healthErr.Reason != tt.reason || healthErr.Hint() == "" {
+				t.Fatalf("Wrong error: %v. Expected: %s", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestUnreachableErrorHint(t *testing.T) {
+	err := unreachableError("https://vault.internal:8200", fmt.Errorf("Get \"https://vault.internal:8200/v1/sys/health\": http: server gave HTTP response to HTTPS client"))
+
+	var healthErr *HealthError
+	if !errors.As(err, &healthErr) || !strings.Contains(healthErr.Hint(), "http://") {
+		t.Fatalf("Wrong hint for a plain HTTP server: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"sort"
+
+	"github.com/blablacar/terraform-provider-vaultprov/internal/secrets"
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+// Base64Value is a secret data value written base64 encoded (standard encoding with padding), for large secrets such as
+// seed files of a few MiB. The value is encoded straight into the request body, sized up front: the secret is held in
+// memory once as bytes and once encoded, instead of also as an encoded string and as a JSON document built by the
+// Vault client. The body is wiped once sent.
+type Base64Value []byte
+
+// writeSecretData writes data as a new version of the secret at dataPath, with the check-and-set version cas. Data
+// holding a Base64Value is encoded by secretDataBody.
+func (c *VaultApi) writeSecretData(ctx context.Context, dataPath string, data map[string]interface{}, cas int) (*vaultinternals.Secret, error) {
+	body, err := secretDataBody(data, cas)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return c.client.Logical().WriteWithContext(ctx, dataPath, map[string]interface{}{
+			SecretDataField: data,
+			"options": map[string]interface{}{
+				"cas": cas,
+			},
+		})
+	}
+	defer secrets.Wipe(body)
+
+	return c.client.Logical().WriteBytesWithContext(ctx, dataPath, body)
+}
+
+// secretDataBody returns the JSON body of a KV v2 data write of data with the check-and-set version cas, Base64Value
+// fields being encoded in place. It returns nil when data holds no Base64Value, the Vault client encoding the body.
+func secretDataBody(data map[string]interface{}, cas int) ([]byte, error) {
+	size := 0
+	keys := make([]string, 0, len(data))
+	for k, v := range data {
+		keys = append(keys, k)
+		if value, ok := v.(Base64Value); ok {
+			size += base64.StdEncoding.EncodedLen(len(value))
+		}
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	sort.Strings(keys)
+
+	options, err := json.Marshal(map[string]interface{}{"cas": cas})
+	if err != nil {
+		return nil, err
+	}
+
+	// Other fields are small, the body only grows when they don't fit in the margin
+	body := make([]byte, 0, size+len(options)+256)
+	body = append(body, `{"`+SecretDataField+`":{`...)
+	for i, k := range keys {
+		if i > 0 {
+			body = append(body, ',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		body = append(append(body, key...), ':')
+
+		value, ok := data[k].(Base64Value)
+		if !ok {
+			encoded, err := json.Marshal(data[k])
+			if err != nil {
+				return nil, err
+			}
+			body = append(body, encoded...)
+			continue
+		}
+		start := len(body) + 1
+		end := start + base64.StdEncoding.EncodedLen(len(value))
+		body = slices.Grow(body, end+1-len(body))[:end+1]
+		body[start-1] = '"'
+		base64.StdEncoding.Encode(body[start:end], value)
+		body[end] = '"'
+	}
+	body = append(body, `},"options":`...)
+	body = append(body, options...)
+	return append(body, '}'), nil
+}
@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSecretDataBody(t *testing.T) {
+	if body, err := secretDataBody(map[string]interface{}{"secret": "small"}, 0); err != nil || body != nil {
+		t.Fatalf("Expected no body without large values, got %q (%v)", body, err)
+	}
+
+	value := bytes.Repeat([]byte{0xfb, 0xff, 0x01}, 100000)
+	body, err := secretDataBody(map[string]interface{}{
+		"secret":   Base64Value(value),
+		"username": "app\"1",
+	}, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err = json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("Invalid JSON body: %v", err)
+	}
+	expected := map[string]interface{}{
+		SecretDataField: map[string]interface{}{
+			"secret":   base64.StdEncoding.EncodeToString(value),
+			"username": "app\"1",
+		},
+		"options": map[string]interface{}{"cas": float64(3)},
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Fatalf("Wrong body: %.200s. Expected: %.200v", body, expected)
+	}
+}
@@ -0,0 +1,109 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+// RequestOperations are the kinds of requests sent to Vault, see requestOperation
+var RequestOperations = []string{"read", "list", "write", "patch", "delete"}
+
+// RequestMetric describes a request sent to Vault, along with the running counters of the clients it was sent by.
+type RequestMetric struct {
+	// Operation is the kind of request: read, list, write, patch or delete
+	Operation string
+	// Path is the API path of the request, without the /v1/ prefix
+	Path string
+	// Status is the HTTP status of the response, 0 when no response was received
+	Status   int
+	Duration time.Duration
+	Failed   bool
+
+	// Running counters, since the provider was configured
+	Requests          int
+	Failures          int
+	OperationRequests int
+	TotalDuration     time.Duration
+}
+
+// InstrumentRequests reports every request the clients created from conf send to Vault, with its latency and running
+// counters, so that the load put on Vault by Terraform can be monitored. A request fails when no response is received
+// or on an error status, except 404: Vault answers it for secrets that don't exist, which the provider expects.
+func InstrumentRequests(conf *vaultinternals.Config, report func(ctx context.Context, metric RequestMetric)) {
+	base := conf.HttpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	conf.HttpClient.Transport = &instrumentedTransport{
+		base:       base,
+		report:     report,
+		operations: make(map[string]int),
+	}
+}
+
+type instrumentedTransport struct {
+	base   http.RoundTripper
+	report func(ctx context.Context, metric RequestMetric)
+
+	mu            sync.Mutex
+	requests      int
+	failures      int
+	operations    map[string]int
+	totalDuration time.Duration
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	metric := RequestMetric{
+		Operation: requestOperation(req),
+		Path:      strings.TrimPrefix(req.URL.Path, "/v1/"),
+		Duration:  time.Since(start),
+	}
+	if resp != nil {
+		metric.Status = resp.StatusCode
+	}
+	metric.Failed = err != nil || (metric.Status >= 400 && metric.Status != http.StatusNotFound)
+
+	t.mu.Lock()
+	t.requests++
+	if metric.Failed {
+		t.failures++
+	}
+	t.operations[metric.Operation]++
+	t.totalDuration += metric.Duration
+	metric.Requests = t.requests
+	metric.Failures = t.failures
+	metric.OperationRequests = t.operations[metric.Operation]
+	metric.TotalDuration = t.totalDuration
+	t.mu.Unlock()
+
+	t.report(req.Context(), metric)
+	return resp, err
+}
+
+// requestOperation returns the kind of a Vault request from its method. Lists are sent either with the LIST method or
+// as a GET with the list parameter.
+func requestOperation(req *http.Request) string {
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("list") == "true" {
+			return "list"
+		}
+		return "read"
+	case "LIST":
+		return "list"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "write"
+	}
+}
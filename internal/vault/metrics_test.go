@@ -0,0 +1,60 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestInstrumentRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/metadata/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/v1/secret/data/denied":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			_, _ = w.Write([]byte(`{"data":{}}`))
+		}
+	}))
+	defer server.Close()
+
+	var metrics []RequestMetric
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	InstrumentRequests(conf, func(ctx context.Context, metric RequestMetric) {
+		metrics = append(metrics, metric)
+	})
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	ctx := context.Background()
+	_, _ = client.Logical().ReadWithContext(ctx, "secret/metadata/missing")
+	_, _ = client.Logical().ListWithContext(ctx, "secret/metadata/foo")
+	_, _ = client.Logical().WriteWithContext(ctx, "secret/data/denied", map[string]interface{}{"foo": "bar"})
+	_, _ = client.Logical().ReadWithContext(ctx, "secret/metadata/foo")
+
+	expected := []RequestMetric{
+		{Operation: "read", Path: "secret/metadata/missing", Status: http.StatusNotFound, Requests: 1, OperationRequests: 1},
+		{Operation: "list", Path: "secret/metadata/foo", Status: http.StatusOK, Requests: 2, OperationRequests: 1},
+		{Operation: "write", Path: "secret/data/denied", Status: http.StatusForbidden, Failed: true, Requests: 3, Failures: 1, OperationRequests: 1},
+		{Operation: "read", Path: "secret/metadata/foo", Status: http.StatusOK, Requests: 4, Failures: 1, OperationRequests: 2},
+	}
+	if len(metrics) != len(expected) {
+		t.Fatalf("Wrong number of reported requests: %d. Expected: %d", len(metrics), len(expected))
+	}
+	for i, metric := range metrics {
+		if metric.Duration <= 0 || metric.TotalDuration < metric.Duration {
+			t.Fatalf("Wrong durations for request %d: %s (total: %s)", i, metric.Duration, metric.TotalDuration)
+		}
+		metric.Duration, metric.TotalDuration = 0, 0
+		if metric != expected[i] {
+			t.Fatalf("Wrong metric for request %d: %+v. Expected: %+v", i, metric, expected[i])
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+// KVMount is a KV secrets engine mount visible to the provider's token.
+type KVMount struct {
+	// Path of the mount, with a trailing slash (e.g. `secret/`)
+	Path string
+	// Version of the KV secrets engine, 1 or 2
+	Version int
+}
+
+// ListKVMounts lists the KV mounts visible to the provider's token, sorted by path. Mounts are read from
+// sys/internal/ui/mounts, which only requires a valid token: a mount is listed as soon as the token's policies grant
+// something under it. The returned slice is never nil.
+func (c *VaultApi) ListKVMounts(ctx context.Context) ([]KVMount, error) {
+	ctx = withRequestID(ctx)
+	secret, err := c.client.Logical().ReadWithContext(ctx, "sys/internal/ui/mounts")
+	if err != nil {
+		return nil, newError(ctx, "list mounts", "sys/internal/ui/mounts", err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("nil response when listing mounts")
+	}
+
+	engines, _ := secret.Data["secret"].(map[string]interface{})
+	return parseKVMounts(engines), nil
+}
+
+// parseKVMounts returns the KV mounts among the secrets engines listed by sys/internal/ui/mounts, sorted by path.
+func parseKVMounts(engines map[string]interface{}) []KVMount {
+	mounts := make([]KVMount, 0, len(engines))
+	for mountPath, raw := range engines {
+		engine, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Vault reports KV v1 mounts created with the legacy `generic` type as such
+		if engineType, _ := engine["type"].(string); engineType != "kv" && engineType != "generic" {
+			continue
+		}
+
+		mount := KVMount{Path: mountPath, Version: 1}
+		if !strings.HasSuffix(mount.Path, "/") {
+			mount.Path += "/"
+		}
+		if options, ok := engine["options"].(map[string]interface{}); ok && options["version"] == "2" {
+			mount.Version = 2
+		}
+		mounts = append(mounts, mount)
+	}
+
+	sort.Slice(mounts, func(i, j int) bool {
+		return mounts[i].Path < mounts[j].Path
+	})
+	return mounts
+}
+
+// EnsureKVMount enables a KV v2 secrets engine at the first segment of secretPath when the path isn't under any mount.
+// Vault answers the mount preflight with 403 both when there's no mount and when the token can't access the path: the
+// mount is then enabled, unless Vault reports it already exists. It returns the path of the mount enabled, with a
+// trailing slash, "" when none was.
+func (c *VaultApi) EnsureKVMount(ctx context.Context, secretPath string) (string, error) {
+	ctx = withRequestID(ctx)
+	partialPath := sanitizePath(secretPath)
+	if err := checkRelativeSegments(partialPath); err != nil {
+		return "", err
+	}
+
+	// Other preflight errors are reported by the operations on the secret
+	_, _, err := kvPreflightVersionRequest(ctx, c.client, partialPath)
+	var respErr *vaultinternals.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusForbidden {
+		return "", nil
+	}
+
+	mountPath := strings.SplitN(partialPath, "/", 2)[0]
+	if err = c.checkWritable("enable KV v2 secrets engine"); err != nil {
+		return "", err
+	}
+	err = c.client.Sys().MountWithContext(ctx, mountPath, &vaultinternals.MountInput{
+		Type:        "kv",
+		Description: "Enabled by terraform-provider-vaultprov",
+		Options:     map[string]string{"version": "2"},
+	})
+	if isPathInUseError(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", newError(ctx, "enable KV v2 secrets engine", "sys/mounts/"+mountPath, err)
+	}
+	return mountPath + "/", nil
+}
+
+// FindKVMount returns the mount secretPath is under, nil if there's none.
+func FindKVMount(mounts []KVMount, secretPath string) *KVMount {
+	p := sanitizePath(secretPath) + "/"
+	for i := range mounts {
+		if strings.HasPrefix(p, mounts[i].Path) {
+			return &mounts[i]
+		}
+	}
+	return nil
+}
+
+func (m KVMount) String() string {
+	return fmt.Sprintf("%s (kv-v%d)", m.Path, m.Version)
+}
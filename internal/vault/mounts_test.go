@@ -0,0 +1,118 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestParseKVMounts(t *testing.T) {
+	// Secrets engines as listed by sys/internal/ui/mounts
+	var engines map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"secret/": {"type": "kv", "options": {"version": "2"}},
+		"legacy": {"type": "generic", "options": null},
+		"kv1/": {"type": "kv", "options": {"version": "1"}},
+		"cubbyhole/": {"type": "cubbyhole"},
+		"transit/": {"type": "transit"}
+	}`), &engines)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	mounts := parseKVMounts(engines)
+	expected := []KVMount{{"kv1/", 1}, {"legacy/", 1}, {"secret/", 2}}
+	if !reflect.DeepEqual(mounts, expected) {
+		t.Fatalf("Wrong mounts: %v. Expected: %v", mounts, expected)
+	}
+
+	if mounts = parseKVMounts(nil); mounts == nil || len(mounts) != 0 {
+		t.Fatalf("Wrong mounts without secrets engines: %#v", mounts)
+	}
+}
+
+func TestFindKVMount(t *testing.T) {
+	mounts := []KVMount{{"kv1/", 1}, {"secret/", 2}, {"secret-team/", 2}}
+
+	tests := []struct {
+		path  string
+		mount string
+	}{
+		{"secret/foo/bar", "secret/"},
+		{"/secret-team/foo/", "secret-team/"},
+		{"kv1/foo", "kv1/"},
+		{"secret", "secret/"},
+		{"secrets/foo", ""},
+		{"transit/keys/foo", ""},
+	}
+	for _, tt := range tests {
+		mount := FindKVMount(mounts, tt.path)
+		if tt.mount == "" {
+			if mount != nil {
+				t.Errorf("Wrong mount for %s: %v. Expected none", tt.path, mount)
+			}
+			continue
+		}
+		if mount == nil || mount.Path != tt.mount {
+			t.Errorf("Wrong mount for %s: %v. Expected: %s", tt.path, mount, tt.mount)
+		}
+	}
+}
+
+func TestEnsureKVMount(t *testing.T) {
+	tests := []struct {
+		name      string
+		preflight int
+		mount     int
+		expected  string
+	}{
+		{"existing mount", http.StatusOK, 0, ""},
+		{"missing mount", http.StatusForbidden, http.StatusNoContent, "secret/"},
+		{"denied preflight", http.StatusForbidden, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mount map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/sys/internal/ui/mounts/secret/foo":
+					w.WriteHeader(tt.preflight)
+					_, _ = w.Write([]byte(`{"data":{"path":"secret/","options":{"version":"2"}}}`))
+				case "/v1/sys/mounts/secret":
+					_ = json.NewDecoder(r.Body).Decode(&mount)
+					w.WriteHeader(tt.mount)
+					if tt.mount == http.StatusBadRequest {
+						_, _ = w.Write([]byte(`{"errors":["path is already in use at secret/"]}`))
+					}
+				default:
+					t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			conf := vaultinternals.DefaultConfig()
+			conf.Address = server.URL
+			client, err := vaultinternals.NewClient(conf)
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+
+			created, err := NewVaultApi(client).EnsureKVMount(context.Background(), "/secret/foo")
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+			if created != tt.expected {
+				t.Fatalf("Wrong mount created: %q. Expected: %q", created, tt.expected)
+			}
+			if tt.mount != 0 && (mount["type"] != "kv" || !reflect.DeepEqual(mount["options"], map[string]interface{}{"version": "2"})) {
+				t.Fatalf("Wrong mount request: %v", mount)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of identities policies can be attached to
+const (
+	IdentityGroup  = "group"
+	IdentityEntity = "entity"
+)
+
+var errIdentityNotFound = errors.New("identity not found")
+
+// SecretReadPolicy returns an ACL policy document granting read access to the data of the secret, i.e. on its KV v2 data
+// path.
+func (c *VaultApi) SecretReadPolicy(ctx context.Context, secretPath string) (string, error) {
+	ctx = withRequestID(ctx)
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+	return readPolicyDocument(paths.data()), nil
+}
+
+func readPolicyDocument(dataPath string) string {
+	return fmt.Sprintf("path %q {\n  capabilities = [\"read\"]\n}\n", dataPath)
+}
+
+// GetPolicy returns the document of an ACL policy, or an empty string if it doesn't exist.
+func (c *VaultApi) GetPolicy(ctx context.Context, name string) (string, error) {
+	ctx = withRequestID(ctx)
+	document, err := c.client.Sys().GetPolicyWithContext(ctx, name)
+	if err != nil {
+		return "", newError(ctx, "read policy", "sys/policies/acl/"+name, err)
+	}
+	return document, nil
+}
+
+// WritePolicy creates or updates an ACL policy.
+func (c *VaultApi) WritePolicy(ctx context.Context, name, document string) error {
+	if err := c.checkWritable("write policy"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	if err := c.client.Sys().PutPolicyWithContext(ctx, name, document); err != nil {
+		return newError(ctx, "write policy", "sys/policies/acl/"+name, err)
+	}
+	return nil
+}
+
+// DeletePolicy deletes an ACL policy. Deleting a policy that doesn't exist is not an error.
+func (c *VaultApi) DeletePolicy(ctx context.Context, name string) error {
+	if err := c.checkWritable("delete policy"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	if err := c.client.Sys().DeletePolicyWithContext(ctx, name); err != nil {
+		return newError(ctx, "delete policy", "sys/policies/acl/"+name, err)
+	}
+	return nil
+}
+
+// AttachPolicy adds a policy to the policies of an identity group or entity, keeping its other policies.
+func (c *VaultApi) AttachPolicy(ctx context.Context, kind, identity, policy string) error {
+	if err := c.checkWritable("attach policy"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	return c.updateIdentityPolicies(ctx, kind, identity, func(policies []string) []string {
+		for _, p := range policies {
+			if p == policy {
+				return policies
+			}
+		}
+		return append(policies, policy)
+	})
+}
+
+// DetachPolicy removes a policy from the policies of an identity group or entity. Nothing is done when the identity
+// doesn't exist anymore.
+func (c *VaultApi) DetachPolicy(ctx context.Context, kind, identity, policy string) error {
+	if err := c.checkWritable("detach policy"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	err := c.updateIdentityPolicies(ctx, kind, identity, func(policies []string) []string {
+		kept := make([]string, 0, len(policies))
+		for _, p := range policies {
+			if p != policy {
+				kept = append(kept, p)
+			}
+		}
+		return kept
+	})
+	if errors.Is(err, errIdentityNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (c *VaultApi) updateIdentityPolicies(ctx context.Context, kind, identity string, update func([]string) []string) error {
+	if kind != IdentityGroup && kind != IdentityEntity {
+		return fmt.Errorf("unsupported identity kind %s", kind)
+	}
+	identityPath := fmt.Sprintf("identity/%s/name/%s", kind, strings.Trim(identity, "/"))
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, identityPath)
+	if err != nil {
+		return newError(ctx, "read identity "+kind, identityPath, err)
+	}
+	if secret == nil {
+		return fmt.Errorf("%w: %s %s", errIdentityNotFound, kind, identity)
+	}
+
+	policies := make([]string, 0)
+	if raw, ok := secret.Data["policies"].([]interface{}); ok {
+		for _, p := range raw {
+			policies = append(policies, fmt.Sprint(p))
+		}
+	}
+	updated := update(policies)
+	if len(updated) == len(policies) {
+		return nil
+	}
+
+	_, err = c.client.Logical().WriteWithContext(ctx, identityPath, map[string]interface{}{
+		"policies": updated,
+	})
+	if err != nil {
+		return newError(ctx, "update policies of identity "+kind, identityPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package vault
+
+import "testing"
+
+func TestReadPolicyDocument(t *testing.T) {
+	expected := "path \"secret/data/foo/bar\" {\n  capabilities = [\"read\"]\n}\n"
+	if document := readPolicyDocument("secret/data/foo/bar"); document != expected {
+		t.Fatalf("Wrong policy document: %q. Expected: %q", document, expected)
+	}
+}
@@ -0,0 +1,96 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+// RequestIDHeader is the header with the ID of a request, set by Vault or by the proxies in front of it
+const RequestIDHeader = "X-Vault-Request-Id"
+
+// maxErrorBodySize caps the error responses read to find their request ID
+const maxErrorBodySize = 1 << 20
+
+type requestIDKey struct{}
+
+// requestIDHolder receives the ID of the last failed request sent with the context holding it, see withRequestID. The
+// errors of the Vault client don't keep the response, the ID is passed to newError through the context instead.
+type requestIDHolder struct {
+	id atomic.Value
+}
+
+// withRequestID returns a context recording the ID of the failed requests sent with it, read back by newError. The
+// exported methods of VaultApi call it first, their requests being sent one after the other.
+func withRequestID(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(requestIDKey{}).(*requestIDHolder); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, &requestIDHolder{})
+}
+
+// failedRequestID returns the ID of the last request sent with ctx if it failed and its ID was recorded.
+func failedRequestID(ctx context.Context) string {
+	holder, ok := ctx.Value(requestIDKey{}).(*requestIDHolder)
+	if !ok {
+		return ""
+	}
+	id, _ := holder.id.Load().(string)
+	return id
+}
+
+// RecordRequestIDs records the ID of the failed requests the clients created from conf send to Vault, from the
+// X-Vault-Request-Id header or from the request_id field of the response, so that errors can be reported with the ID
+// to look for in Vault's audit logs. Installed after RetryRequests, only the ID of the last attempt is recorded.
+func RecordRequestIDs(conf *vaultinternals.Config) {
+	base := conf.HttpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	conf.HttpClient.Transport = &requestIDTransport{base: base}
+}
+
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	holder, ok := req.Context().Value(requestIDKey{}).(*requestIDHolder)
+	if !ok {
+		return resp, err
+	}
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		holder.id.Store("")
+		return resp, err
+	}
+
+	requestID := resp.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		// The original body is still closed by the client, e.g. to release the connection
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+		var errResp struct {
+			RequestID string `json:"request_id"`
+		}
+		if json.Unmarshal(body, &errResp) == nil {
+			requestID = errResp.RequestID
+		}
+	}
+
+	holder.id.Store(requestID)
+	return resp, nil
+}
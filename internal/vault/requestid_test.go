@@ -0,0 +1,64 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestRecordRequestIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/sys/policies/acl/header":
+			w.Header().Set(RequestIDHeader, "1f6c2a0e-header")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		case "/v1/sys/policies/acl/body":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid policy"],"request_id":"8d2b4f7c-body"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"errors":["internal error"]}`))
+		}
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	conf.MaxRetries = 0
+	RecordRequestIDs(conf)
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	c := NewVaultApi(client)
+
+	for name, expected := range map[string]string{
+		"header": "request ID 1f6c2a0e-header",
+		"body":   "request ID 8d2b4f7c-body",
+	} {
+		_, err := c.GetPolicy(context.Background(), name)
+
+		var vaultErr *Error
+		if !errors.As(err, &vaultErr) {
+			t.Fatalf("Expected a *vault.Error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Error %q doesn't contain %q", err.Error(), expected)
+		}
+		if len(vaultErr.Errors) != 1 {
+			t.Fatalf("Wrong Vault errors: %v", vaultErr.Errors)
+		}
+	}
+
+	_, err = c.GetPolicy(context.Background(), "none")
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) || vaultErr.RequestID != "" || strings.Contains(err.Error(), "request ID") {
+		t.Fatalf("Expected an error without request ID, got %v", err)
+	}
+}
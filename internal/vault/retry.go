@@ -0,0 +1,227 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+const (
+	// DefaultRetryMinWait is the wait before the first retry of a request, doubled at each retry
+	DefaultRetryMinWait = 500 * time.Millisecond
+	// DefaultRetryMaxWait caps the wait between two attempts, Retry-After included
+	DefaultRetryMaxWait = 30 * time.Second
+)
+
+// RetryPolicy sets how requests failing with a transient error are retried.
+type RetryPolicy struct {
+	// MaxRetries is the budget of retries of a request per operation (read, list, write, patch or delete).
+	// Operations not listed get DefaultRetries.
+	MaxRetries     map[string]int
+	DefaultRetries int
+	MinWait        time.Duration
+	MaxWait        time.Duration
+}
+
+// RequestRetry describes a request about to be retried.
+type RequestRetry struct {
+	// Operation is the kind of request: read, list, write, patch or delete
+	Operation string
+	// Path is the API path of the request, without the /v1/ prefix
+	Path string
+	// Attempt is the number of the failed attempt, starting at 1
+	Attempt int
+	// Status is the HTTP status of the failed attempt, 0 when no response was received
+	Status int
+	Err    error
+	Wait   time.Duration
+}
+
+// RetryRequests retries the requests the clients created from conf send to Vault when they fail with a transient error:
+// 412 (performance standby not caught up yet), 429 (rate limited), 502, 503 and 504 responses, and connection errors.
+// Waits grow exponentially with jitter, and follow the Retry-After header when Vault sends one. A request is not
+// retried when it would wait longer than MaxWait, or beyond the deadline of its context: its last response is returned.
+//
+// It replaces the retries of the Vault client (VAULT_MAX_RETRIES setting DefaultRetries instead). Installed after
+// LimitConcurrentRequests, a request waiting to be retried doesn't hold a slot.
+func RetryRequests(conf *vaultinternals.Config, policy RetryPolicy, report func(ctx context.Context, retry RequestRetry)) {
+	base := conf.HttpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if policy.MinWait <= 0 {
+		policy.MinWait = DefaultRetryMinWait
+	}
+	if policy.MaxWait <= 0 {
+		policy.MaxWait = DefaultRetryMaxWait
+	}
+	conf.HttpClient.Transport = &retryingTransport{
+		base:   base,
+		policy: policy,
+		report: report,
+	}
+
+	conf.MaxRetries = 0
+	conf.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		return false, nil
+	}
+}
+
+type retryingTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	report func(ctx context.Context, retry RequestRetry)
+}
+
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := requestOperation(req)
+	budget, ok := t.policy.MaxRetries[operation]
+	if !ok {
+		budget = t.policy.DefaultRetries
+	}
+
+	// The body is replayed on every attempt: from GetBody when the request has one (bodies buffered by the Vault
+	// client), so that large secrets aren't copied once more, read once otherwise
+	getBody := req.GetBody
+	if getBody == nil && req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		getBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if getBody != nil && req.Body != nil && req.Body != http.NoBody {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt > budget || !isTransientFailure(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait, ok := t.retryWait(ctx, attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		retry := RequestRetry{
+			Operation: operation,
+			Path:      strings.TrimPrefix(req.URL.Path, "/v1/"),
+			Attempt:   attempt,
+			Err:       err,
+			Wait:      wait,
+		}
+		if resp != nil {
+			retry.Status = resp.StatusCode
+			// Releases the connection, and the concurrency slot of the request
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		if t.report != nil {
+			t.report(ctx, retry)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryWait returns the wait before the next attempt, and false when the request must not be retried: the wait
+// requested by Vault is longer than MaxWait, or would end after the deadline of ctx.
+func (t *retryingTransport) retryWait(ctx context.Context, attempt int, resp *http.Response) (time.Duration, bool) {
+	var wait time.Duration
+	if retryAfter, ok := parseRetryAfter(resp, time.Now()); ok {
+		if retryAfter > t.policy.MaxWait {
+			return 0, false
+		}
+		wait = retryAfter
+	} else {
+		wait = backoff(t.policy.MinWait, t.policy.MaxWait, attempt)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+		return 0, false
+	}
+	return wait, true
+}
+
+// backoff returns an exponential wait with jitter for the given attempt, starting at 1: a random duration between
+// half and all of minWait * 2^(attempt-1), capped at maxWait.
+func backoff(minWait, maxWait time.Duration, attempt int) time.Duration {
+	wait := maxWait
+	if attempt <= 32 {
+		if exp := minWait << (attempt - 1); exp > 0 && exp < maxWait {
+			wait = exp
+		}
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1))
+}
+
+// parseRetryAfter returns the wait requested by the Retry-After header of resp, either a number of seconds or a date.
+func parseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// isTransientFailure tells whether a request failed in a way worth retrying. Certificate errors and cancelled
+// requests are not.
+func isTransientFailure(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		var certErr *tls.CertificateVerificationError
+		var authorityErr x509.UnknownAuthorityError
+		var hostnameErr x509.HostnameError
+		return !errors.As(err, &certErr) && !errors.As(err, &authorityErr) && !errors.As(err, &hostnameErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPreconditionFailed, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
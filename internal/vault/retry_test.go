@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestRetryRequests(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"data":{}}` {
+			t.Errorf("Wrong body on attempt %d: %s", atomic.LoadInt32(&attempts)+1, body)
+		}
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.WriteHeader(http.StatusPreconditionFailed)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	var retries []RequestRetry
+	RetryRequests(conf, RetryPolicy{DefaultRetries: 2, MinWait: time.Millisecond}, func(ctx context.Context, retry RequestRetry) {
+		retries = append(retries, retry)
+	})
+
+	resp, err := conf.HttpClient.Post(server.URL+"/v1/secret/data/foo", "application/json", strings.NewReader(`{"data":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || attempts != 3 {
+		t.Fatalf("Wrong response: %d after %d attempts. Expected: 200 after 3 attempts", resp.StatusCode, attempts)
+	}
+	if len(retries) != 2 || retries[0].Status != http.StatusPreconditionFailed || retries[1].Wait != 0 || retries[1].Operation != "write" || retries[1].Path != "secret/data/foo" {
+		t.Fatalf("Wrong retries: %+v", retries)
+	}
+}
+
+func TestRetryRequestsBudget(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	RetryRequests(conf, RetryPolicy{MaxRetries: map[string]int{"read": 3}, DefaultRetries: 1, MinWait: time.Millisecond}, nil)
+
+	for _, test := range []struct {
+		method   string
+		attempts int32
+	}{
+		{http.MethodGet, 4},
+		{http.MethodDelete, 2},
+	} {
+		atomic.StoreInt32(&attempts, 0)
+		req, _ := http.NewRequest(test.method, server.URL, nil)
+		resp, err := conf.HttpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable || attempts != test.attempts {
+			t.Fatalf("Wrong response for %s: %d after %d attempts. Expected: 503 after %d attempts", test.method, resp.StatusCode, attempts, test.attempts)
+		}
+	}
+
+	// Not retried when Vault asks to wait longer than MaxWait
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+	atomic.StoreInt32(&attempts, 0)
+	resp, err := conf.HttpClient.Get(limited.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if attempts != 1 {
+		t.Fatalf("Wrong attempts: %d. Expected: 1", attempts)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"Fri, 01 Mar 2024 10:00:30 GMT", 30 * time.Second, true},
+		{"Fri, 01 Mar 2024 09:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, test := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", test.value)
+		wait, ok := parseRetryAfter(resp, now)
+		if wait != test.expected || ok != test.ok {
+			t.Fatalf("Wrong wait for %q: %v, %t. Expected: %v, %t", test.value, wait, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 64; attempt++ {
+		wait := backoff(time.Second, 30*time.Second, attempt)
+		expected := 30 * time.Second
+		if attempt <= 5 {
+			expected = time.Second << (attempt - 1)
+		}
+		if wait < expected/2 || wait > expected {
+			t.Fatalf("Wrong wait for attempt %d: %v. Expected between %v and %v", attempt, wait, expected/2, expected)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package vault
+
+import "context"
+
+// SecretStore is the storage layer behind the resources: secrets are generated by the provider and written directly
+// into the store, they never go through the Terraform state. VaultApi stores secrets in a KV v2 mount, other backends
+// (e.g. GCP Secret Manager) implement the same operations.
+type SecretStore interface {
+	// CreateSecret writes a new secret and returns the version written, 0 if unknown. It fails with a
+	// SecretExistsError if a secret already exists at the same path.
+	CreateSecret(ctx context.Context, secret Secret) (int, error)
+	// ReadSecret reads a secret, data included. It returns nil if the secret doesn't exist.
+	ReadSecret(ctx context.Context, secretPath string) (*Secret, error)
+	// ReadSecretMetadata reads a secret without its data. It returns nil if the secret doesn't exist.
+	ReadSecretMetadata(ctx context.Context, secretPath string) (*Secret, error)
+	// UpdateSecretMetadata merges metadata into the secret's current metadata and drops the removed keys.
+	UpdateSecretMetadata(ctx context.Context, secretPath string, metadata map[string]string, removed []string) error
+	// DeleteSecret deletes a secret and its versions.
+	DeleteSecret(ctx context.Context, secretPath string) error
+	// SecretAPIPaths returns the paths of the secret's data and metadata in the store's API.
+	SecretAPIPaths(ctx context.Context, secretPath string) (string, string, error)
+}
+
+var _ SecretStore = &VaultApi{}
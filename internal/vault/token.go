@@ -0,0 +1,44 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TokenTTL returns the remaining TTL of the provider's token, 0 when it never expires (e.g. root tokens), and whether
+// it can be renewed.
+func (c *VaultApi) TokenTTL(ctx context.Context) (time.Duration, bool, error) {
+	ctx = withRequestID(ctx)
+	secret, err := c.client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return 0, false, newError(ctx, "look up the provider's token", "auth/token/lookup-self", err)
+	}
+	if secret == nil {
+		return 0, false, fmt.Errorf("nil response when looking up the provider's token")
+	}
+
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read the TTL of the provider's token: %w", err)
+	}
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read whether the provider's token is renewable: %w", err)
+	}
+	return ttl, renewable, nil
+}
+
+// RenewToken renews the provider's token for increment and returns its new TTL. Vault may grant less than increment
+// when the token's max TTL is reached.
+func (c *VaultApi) RenewToken(ctx context.Context, increment time.Duration) (time.Duration, error) {
+	ctx = withRequestID(ctx)
+	secret, err := c.client.Auth().Token().RenewSelfWithContext(ctx, int(increment.Seconds()))
+	if err != nil {
+		return 0, newError(ctx, "renew the provider's token", "auth/token/renew-self", err)
+	}
+	if secret == nil || secret.Auth == nil {
+		return 0, fmt.Errorf("nil response when renewing the provider's token")
+	}
+	return time.Duration(secret.Auth.LeaseDuration) * time.Second, nil
+}
@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestTokenTTL(t *testing.T) {
+	var increment map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			_, _ = w.Write([]byte(`{"data":{"ttl":300,"renewable":true}}`))
+		case "/v1/auth/token/renew-self":
+			_ = json.NewDecoder(r.Body).Decode(&increment)
+			_, _ = w.Write([]byte(`{"auth":{"lease_duration":1800,"renewable":true}}`))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	c := NewVaultApi(client)
+
+	ttl, renewable, err := c.TokenTTL(context.Background())
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if ttl != 5*time.Minute || !renewable {
+		t.Fatalf("Wrong token TTL: %s (renewable: %v). Expected: 5m0s (renewable: true)", ttl, renewable)
+	}
+
+	ttl, err = c.RenewToken(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if ttl != 30*time.Minute {
+		t.Fatalf("Wrong renewed token TTL: %s. Expected: 30m0s", ttl)
+	}
+	if increment["increment"] != float64(3600) {
+		t.Fatalf("Wrong renewal increment: %v. Expected: 3600", increment)
+	}
+}
@@ -1,90 +1,243 @@
 package vault
 
 import (
+	"context"
 	"fmt"
+	"github.com/hashicorp/go-uuid"
 	vaultinternals "github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/config"
 	"github.com/mitchellh/mapstructure"
+	"log"
+	"path"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	SecretDataField       = "data"
 	SecretCustomDataField = "custom_metadata"
+
+	// ScheduledDestroyMetadata holds the date (RFC 3339) after which a secret scheduled for deletion is deleted
+	ScheduledDestroyMetadata = "scheduled_destroy_at"
+
+	// ManagedVersionsMetadata lists, comma separated, the versions of a secret written through this package, so that
+	// they can be deleted without touching versions written by other systems
+	ManagedVersionsMetadata = "managed_versions"
+
+	// CreateIDMetadata holds the unique id of the creation of a secret, see Secret.CreateID, to recognize its own
+	// writes when retried
+	CreateIDMetadata = "provider_create_id"
 )
 
 type Secret struct {
 	Path     string
 	Data     map[string]interface{}
 	Metadata map[string]string
+
+	// CreateID is the unique id stamped on the secret when creating it, see CreateIDMetadata. A random one is generated
+	// when empty. Only used when creating a secret in Vault
+	CreateID string
+
+	// VersionsKept is the number of versions of the secret retained by Vault. Only set when reading a secret
+	VersionsKept int
+	// CreatedTime is the creation date of the secret. Only set when reading a secret
+	CreatedTime time.Time
+	// UpdatedTime is the date of the last write to the secret, data or metadata. Only set when reading a secret from
+	// Vault
+	UpdatedTime time.Time
+	// Version is the current version of the secret. Only set when reading a secret
+	Version int
+	// MaxVersions and DeleteVersionAfter are the retention settings of the secret, 0 when the mount's settings apply.
+	// Only set when reading a secret's metadata from Vault
+	MaxVersions        int
+	DeleteVersionAfter time.Duration
+	// DataPath and MetadataPath are the API paths of the secret, e.g. to be used in ACL policies. Only set when
+	// reading a secret
+	DataPath     string
+	MetadataPath string
 }
 
 type VaultApi struct {
 	client *vaultinternals.Client
+	// readOnly makes every method writing to Vault fail with a *ReadOnlyError, see ReadOnly
+	readOnly bool
 }
 
 func NewVaultApi(client *vaultinternals.Client) *VaultApi {
 	return &VaultApi{client: client}
 }
 
-func (c *VaultApi) CreateSecret(secret Secret) error {
-	// Get data path for target Vault secret
-	dataPath, err := secretDataPath(secret.Path, c.client)
+// ReadOnly returns a VaultApi sharing c's client whose methods writing to Vault (secrets, metadata, policies, mounts)
+// fail with a *ReadOnlyError before sending anything. Token renewals are still allowed.
+func (c *VaultApi) ReadOnly() *VaultApi {
+	return &VaultApi{client: c.client, readOnly: true}
+}
+
+// checkWritable returns a *ReadOnlyError for operation when c is read-only.
+func (c *VaultApi) checkWritable(operation string) error {
+	if c.readOnly {
+		return &ReadOnlyError{Operation: operation}
+	}
+	return nil
+}
+
+// CreateSecret writes a new secret and returns the version written, 0 if Vault didn't report it. It fails if a secret
+// already exists at the same path.
+//
+// The secret is stamped with its CreateIDMetadata before its data is written. When the data write is retried after a
+// transient failure (e.g. Vault wrote it but the response was lost), the check-and-set conflict is recognized as our
+// own write thanks to the stamp, instead of being reported as an existing secret. Secrets found before writing anything
+// are always reported as existing, whatever their stamp.
+func (c *VaultApi) CreateSecret(ctx context.Context, secret Secret) (int, error) {
+	if err := c.checkWritable("create secret"); err != nil {
+		return 0, err
+	}
+	ctx = withRequestID(ctx)
+	// Resolve data & metadata paths for target Vault secret
+	paths, err := resolveSecretPaths(ctx, secret.Path, c.client)
 	if err != nil {
-		return fmt.Errorf("invalid path for data: %w", err)
+		return 0, fmt.Errorf("invalid path: %w", err)
+	}
+	dataPath := paths.data()
+	metadataPath := paths.metadata()
+
+	createID := secret.CreateID
+	if createID == "" {
+		if createID, err = uuid.GenerateUUID(); err != nil {
+			return 0, fmt.Errorf("unable to generate create id: %w", err)
+		}
 	}
 
 	// Check if secret already exists in Vault
-	s, err := c.client.Logical().Read(dataPath)
+	s, err := c.client.Logical().ReadWithContext(ctx, dataPath)
 	if err != nil {
-		return fmt.Errorf("unable to read secret's data: %w", err)
+		return 0, newError(ctx, "read secret's data", dataPath, err)
 	}
 
 	if s != nil {
-		return fmt.Errorf("secret %s already exists", secret.Path)
+		return 0, c.secretExistsError(ctx, secret.Path, metadataPath)
 	}
 
-	// Get metadata path for secret in Vault
-	metadataPath, err := secretMetadataPath(secret.Path, c.client)
+	// Check token's capabilities before writing anything
+	if err = checkCapabilities(ctx, c.client, dataPath, "create"); err != nil {
+		return 0, err
+	}
+	if err = checkCapabilities(ctx, c.client, metadataPath, "create", "update"); err != nil {
+		return 0, err
+	}
+
+	customMetadata := mergeMetadata(secret.Metadata, map[string]string{CreateIDMetadata: createID}, nil)
+
+	// Stamp the secret before writing its data, unless metadata are left by a secret with versions, e.g. deleted: they
+	// must not be overwritten before the check-and-set below ensures the path is free. Metadata without versions, such
+	// as those stamped by a previous try whose data write failed, hold no secret.
+	existing, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
 	if err != nil {
-		return fmt.Errorf("invalid path for metadata: %w", err)
+		return 0, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	stamp := existing == nil
+	if existing != nil {
+		metadata, err := decodeSecretMetadata(existing.Data)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read secret's metadata: %w", err)
+		}
+		stamp = metadata.CurrentVersion == 0
+	}
+	if stamp {
+		_, err = c.client.Logical().WriteWithContext(ctx, metadataPath, map[string]interface{}{
+			SecretCustomDataField: customMetadata,
+		})
+		if err != nil {
+			return 0, newError(ctx, "write secret's metadata", metadataPath, err)
+		}
+	}
+
+	// Write secret's data in Vault. Check-and-set ensures the secret hasn't been created concurrently since the above
+	// check
+	var version int
+	written, err := c.writeSecretData(ctx, dataPath, secret.Data, 0)
+	if isCheckAndSetError(err) {
+		created, err := c.isCreatedBy(ctx, metadataPath, createID)
+		if err != nil {
+			return 0, err
+		}
+		if !created {
+			return 0, c.secretExistsError(ctx, secret.Path, metadataPath)
+		}
+		version = 1
+	} else if err != nil {
+		return 0, newError(ctx, "write secret's data", dataPath, err)
+	} else if version, err = writtenVersion(written); err != nil {
+		log.Println("unable to read version written to", dataPath, ":", err)
 	}
 
-	// Write secret's data in Vault
-	secretData := map[string]interface{}{
-		SecretDataField: secret.Data,
+	// Write secret's metadata in Vault, along with the version just written
+	if version > 0 {
+		customMetadata[ManagedVersionsMetadata] = addManagedVersion(secret.Metadata[ManagedVersionsMetadata], version, 0)
+	}
+	fullMetadata := map[string]interface{}{
+		SecretCustomDataField: customMetadata,
 	}
 
-	_, err = c.client.Logical().Write(dataPath, secretData)
+	_, err = c.client.Logical().WriteWithContext(ctx, metadataPath, fullMetadata)
 	if err != nil {
-		return fmt.Errorf("unable to write secret's data: %w", err)
+		return 0, newError(ctx, "write secret's metadata", metadataPath, err)
 	}
 
-	// Write secret's metadata in Vault
-	fullMetadata := map[string]interface{}{
-		SecretCustomDataField: secret.Metadata,
+	return version, nil
+}
+
+// isCreatedBy tells if the secret has been written by a CreateSecret call stamped with createID: it has a single, live
+// version.
+func (c *VaultApi) isCreatedBy(ctx context.Context, metadataPath, createID string) (bool, error) {
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return false, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return false, nil
 	}
 
-	_, err = c.client.Logical().Write(metadataPath, fullMetadata)
+	metadata, err := decodeSecretMetadata(secret.Data)
 	if err != nil {
-		return fmt.Errorf("unable to write secret's metadata: %w", err)
+		return false, fmt.Errorf("unable to read secret's metadata: %w", err)
 	}
+	return metadata.CustomMetadata[CreateIDMetadata] == createID && metadata.CurrentVersion == 1 && !metadata.isCurrentVersionDeleted(), nil
+}
 
-	return nil
+// secretExistsError describes the secret found at secretPath. Its metadata are read on a best effort basis.
+func (c *VaultApi) secretExistsError(ctx context.Context, secretPath, metadataPath string) error {
+	existsErr := &SecretExistsError{Path: secretPath}
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil || secret == nil {
+		return existsErr
+	}
+	if metadata, err := decodeSecretMetadata(secret.Data); err == nil {
+		existsErr.CreatedTime = metadata.CreatedTime
+		existsErr.Metadata = metadata.CustomMetadata
+		existsErr.Deleted = metadata.isCurrentVersionDeleted()
+	}
+
+	return existsErr
 }
 
-func (c *VaultApi) ReadSecret(secretPath string) (*Secret, error) {
+func (c *VaultApi) ReadSecret(ctx context.Context, secretPath string) (*Secret, error) {
+	ctx = withRequestID(ctx)
 
-	// Get data path for secret in Vault
-	dataPath, err := secretDataPath(secretPath, c.client)
+	// Resolve data & metadata paths for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
 	if err != nil {
-		return nil, fmt.Errorf("invalid path for data: %w", err)
+		return nil, fmt.Errorf("invalid path: %w", err)
 	}
+	dataPath := paths.data()
 
 	// Check if secret exists or is deleted
-	secret, err := c.client.Logical().Read(dataPath)
+	secret, err := c.client.Logical().ReadWithContext(ctx, dataPath)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read secret's data: %w", err)
+		return nil, newError(ctx, "read secret's data", dataPath, err)
 	}
 	if secret == nil {
 		return nil, nil
@@ -95,89 +248,587 @@ func (c *VaultApi) ReadSecret(secretPath string) (*Secret, error) {
 		return nil, fmt.Errorf("unable to check secret's deletion status: %w", err)
 	}
 
+	metadataPath := paths.metadata()
+
+	// Fetch secret's metadata from Vault
+	secretMetadata, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return nil, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+
+	metadata, err := decodeSecretMetadata(secretMetadata.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+
 	if isDeleted {
-		return nil, fmt.Errorf("secret is marked deleted")
+		return nil, metadata.deletedError(secretPath)
 	}
 
-	// Get metadata path for secret in Vault
-	metadataPath, err := secretMetadataPath(secretPath, c.client)
+	if metadata.CustomMetadata == nil {
+		return nil, fmt.Errorf("missing custom metadata")
+	}
+
+	data := secret.Data[SecretDataField].(map[string]interface{})
+
+	vaultSecret := &Secret{
+		Path:         secretPath,
+		Data:         data,
+		Metadata:     metadata.CustomMetadata,
+		VersionsKept: metadata.versionsKept(),
+		CreatedTime:  metadata.CreatedTime,
+		UpdatedTime:  metadata.UpdatedTime,
+		Version:      metadata.CurrentVersion,
+		DataPath:     paths.data(),
+		MetadataPath: metadataPath,
+	}
+
+	return vaultSecret, nil
+}
+
+// RestoreSecret undeletes the latest version of a secret when it has been deleted (but not destroyed), and returns the
+// restored secret. It returns nil when there's nothing to restore: the secret doesn't exist or isn't deleted. The
+// secret is only restored if its custom metadata hold the expected values. A deletion scheduled with
+// ScheduleSecretDeletion is cancelled.
+func (c *VaultApi) RestoreSecret(ctx context.Context, secretPath string, expected map[string]string) (*Secret, error) {
+	if err := c.checkWritable("restore secret"); err != nil {
+		return nil, err
+	}
+	ctx = withRequestID(ctx)
+	// Resolve API paths for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
 	if err != nil {
-		return nil, fmt.Errorf("invalid path for metadata: %w", err)
+		return nil, fmt.Errorf("invalid path: %w", err)
 	}
+	metadataPath := paths.metadata()
 
-	// Fetch secret's metadata from Vault
-	secretMetadata, err := c.client.Logical().Read(metadataPath)
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return nil, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return nil, nil
+	}
+
+	metadata, err := decodeSecretMetadata(secret.Data)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read secret's metadata: %w", err)
 	}
+	if !metadata.isCurrentVersionDeleted() {
+		return nil, nil
+	}
+
+	version := metadata.Versions[strconv.Itoa(metadata.CurrentVersion)]
+	if version.Destroyed {
+		return nil, fmt.Errorf("latest version %d of secret %s is destroyed and can't be restored", metadata.CurrentVersion, secretPath)
+	}
+
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if metadata.CustomMetadata[k] != expected[k] {
+			return nil, fmt.Errorf("deleted secret %s doesn't match the configuration: metadata %s is %q, expected %q", secretPath, k, metadata.CustomMetadata[k], expected[k])
+		}
+	}
+
+	undeletePath := paths.undelete()
+
+	// Check token's capabilities before undeleting anything
+	if err = checkCapabilities(ctx, c.client, undeletePath, "update"); err != nil {
+		return nil, err
+	}
+
+	_, err = c.client.Logical().WriteWithContext(ctx, undeletePath, map[string]interface{}{
+		"versions": []int{metadata.CurrentVersion},
+	})
+	if err != nil {
+		return nil, newError(ctx, "undelete secret's latest version", undeletePath, err)
+	}
+
+	// Cancel the scheduled deletion, or Vault would delete the secret again right away
+	if _, ok := metadata.CustomMetadata[ScheduledDestroyMetadata]; ok {
+		fullMetadata := map[string]interface{}{
+			SecretCustomDataField:  mergeMetadata(metadata.CustomMetadata, nil, []string{ScheduledDestroyMetadata}),
+			"delete_version_after": "0s",
+		}
+		_, err = c.client.Logical().WriteWithContext(ctx, metadataPath, fullMetadata)
+		if err != nil {
+			return nil, newError(ctx, "cancel secret's scheduled deletion", metadataPath, err)
+		}
+	}
+
+	return c.ReadSecret(ctx, secretPath)
+}
+
+// ReadLiveVersion reads the latest live version of a secret whose current version is deleted, without writing
+// anything: the version RollbackSecret restores. Version is the deleted current version.
+func (c *VaultApi) ReadLiveVersion(ctx context.Context, secretPath string) (*Secret, error) {
+	ctx = withRequestID(ctx)
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
 
-	if secretMetadata.Data[SecretCustomDataField] == nil {
+	metadata, data, err := c.readLiveVersion(ctx, secretPath, paths)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.CustomMetadata == nil {
 		return nil, fmt.Errorf("missing custom metadata")
+	}
+
+	return &Secret{
+		Path:         secretPath,
+		Data:         data,
+		Metadata:     metadata.CustomMetadata,
+		VersionsKept: metadata.versionsKept(),
+		CreatedTime:  metadata.CreatedTime,
+		UpdatedTime:  metadata.UpdatedTime,
+		Version:      metadata.CurrentVersion,
+		DataPath:     paths.data(),
+		MetadataPath: paths.metadata(),
+	}, nil
+}
 
+// RollbackSecret writes the data of the latest live version of a secret whose current version is deleted as a new
+// version, like `vault kv rollback`, and returns the secret read back. Deleted versions are left as they are.
+func (c *VaultApi) RollbackSecret(ctx context.Context, secretPath string) (*Secret, error) {
+	if err := c.checkWritable("roll back secret"); err != nil {
+		return nil, err
 	}
-	customMetadata := make(map[string]string)
-	for k, v := range secretMetadata.Data[SecretCustomDataField].(map[string]interface{}) {
-		customMetadata[k] = v.(string)
+	ctx = withRequestID(ctx)
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
 	}
 
-	data := secret.Data[SecretDataField].(map[string]interface{})
+	metadata, data, err := c.readLiveVersion(ctx, secretPath, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.UpdateSecretData(ctx, secretPath, data, metadata.CurrentVersion); err != nil {
+		return nil, err
+	}
+	return c.ReadSecret(ctx, secretPath)
+}
+
+// readLiveVersion returns the metadata of a secret whose current version is deleted, and the data of its latest live
+// version.
+func (c *VaultApi) readLiveVersion(ctx context.Context, secretPath string, paths *kvSecretPaths) (*secretV2Metadata, map[string]interface{}, error) {
+	dataPath := paths.data()
+	metadataPath := paths.metadata()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return nil, nil, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return nil, nil, fmt.Errorf("no secret at %s", secretPath)
+	}
+	metadata, err := decodeSecretMetadata(secret.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+	if !metadata.isCurrentVersionDeleted() {
+		return nil, nil, fmt.Errorf("latest version %d of secret %s isn't deleted", metadata.CurrentVersion, secretPath)
+	}
+	live := metadata.liveVersion()
+	if live == 0 {
+		return nil, nil, fmt.Errorf("no version of secret %s left to roll back to", secretPath)
+	}
+
+	versionData, err := c.client.Logical().ReadWithDataWithContext(ctx, dataPath, map[string][]string{"version": {strconv.Itoa(live)}})
+	if err != nil {
+		return nil, nil, newError(ctx, "read secret's data", dataPath, err)
+	}
+	if versionData == nil || versionData.Data[SecretDataField] == nil {
+		return nil, nil, fmt.Errorf("no data for version %d of secret %s", live, secretPath)
+	}
+	data, ok := versionData.Data[SecretDataField].(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected data for version %d of secret %s", live, secretPath)
+	}
+	return metadata, data, nil
+}
+
+// ReplaceDeletedSecret writes secret as a new version of a secret whose current version is deleted, instead of failing
+// like CreateSecret. The custom metadata are replaced by the secret's, the versions written by the provider are still
+// recorded. It returns the version written.
+func (c *VaultApi) ReplaceDeletedSecret(ctx context.Context, secret Secret) (int, error) {
+	if err := c.checkWritable("replace deleted secret"); err != nil {
+		return 0, err
+	}
+	ctx = withRequestID(ctx)
+	paths, err := resolveSecretPaths(ctx, secret.Path, c.client)
+	if err != nil {
+		return 0, fmt.Errorf("invalid path: %w", err)
+	}
+	dataPath := paths.data()
+	metadataPath := paths.metadata()
+
+	existing, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return 0, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if existing == nil {
+		return c.CreateSecret(ctx, secret)
+	}
+	metadata, err := decodeSecretMetadata(existing.Data)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+	if !metadata.isCurrentVersionDeleted() {
+		return 0, c.secretExistsError(ctx, secret.Path, metadataPath)
+	}
+
+	if err = checkCapabilities(ctx, c.client, dataPath, "update"); err != nil {
+		return 0, err
+	}
+	if err = checkCapabilities(ctx, c.client, metadataPath, "update"); err != nil {
+		return 0, err
+	}
+
+	written, err := c.writeSecretData(ctx, dataPath, secret.Data, metadata.CurrentVersion)
+	if isCheckAndSetError(err) {
+		return 0, fmt.Errorf("secret %s has been modified concurrently (expected version %d), retry", secret.Path, metadata.CurrentVersion)
+	}
+	if err != nil {
+		return 0, newError(ctx, "write secret's data", dataPath, err)
+	}
+	version, err := writtenVersion(written)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read version written to secret %s: %w", secret.Path, err)
+	}
+
+	customMetadata := mergeMetadata(secret.Metadata, map[string]string{
+		ManagedVersionsMetadata: addManagedVersion(metadata.CustomMetadata[ManagedVersionsMetadata], version, metadata.OldestVersion),
+	}, nil)
+	fullMetadata := map[string]interface{}{
+		SecretCustomDataField: customMetadata,
+	}
+	// Cancel a deletion scheduled by ScheduleSecretDeletion, or Vault would delete the new version too
+	if _, ok := metadata.CustomMetadata[ScheduledDestroyMetadata]; ok {
+		fullMetadata["delete_version_after"] = "0s"
+	}
+	_, err = c.client.Logical().WriteWithContext(ctx, metadataPath, fullMetadata)
+	if err != nil {
+		return 0, newError(ctx, "write secret's metadata", metadataPath, err)
+	}
+	return version, nil
+}
+
+// SecretAPIPaths returns the KV v2 data and metadata API paths of a secret, resolving the mount it belongs to.
+func (c *VaultApi) SecretAPIPaths(ctx context.Context, secretPath string) (string, string, error) {
+	ctx = withRequestID(ctx)
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid path: %w", err)
+	}
+	return paths.data(), paths.metadata(), nil
+}
+
+// ReadSecretMetadata reads a secret's custom metadata without fetching its data, so that secret material doesn't go
+// through the provider nor appears in Vault audit logs when it's not needed. It returns nil if the secret doesn't exist.
+func (c *VaultApi) ReadSecretMetadata(ctx context.Context, secretPath string) (*Secret, error) {
+	ctx = withRequestID(ctx)
+	// Resolve metadata path for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	metadataPath := paths.metadata()
+
+	// Fetch secret's metadata from Vault
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return nil, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return nil, nil
+	}
+
+	metadata, err := decodeSecretMetadata(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+
+	if metadata.isCurrentVersionDeleted() {
+		return nil, metadata.deletedError(secretPath)
+	}
+
+	if metadata.CustomMetadata == nil {
+		return nil, fmt.Errorf("missing custom metadata")
+	}
 
 	vaultSecret := &Secret{
-		Path:     secretPath,
-		Data:     data,
-		Metadata: customMetadata,
+		Path:         secretPath,
+		Metadata:     metadata.CustomMetadata,
+		VersionsKept: metadata.versionsKept(),
+		CreatedTime:  metadata.CreatedTime,
+		UpdatedTime:  metadata.UpdatedTime,
+		Version:      metadata.CurrentVersion,
+		DataPath:     paths.data(),
+		MetadataPath: metadataPath,
+		MaxVersions:  metadata.MaxVersions,
+	}
+	if metadata.DeleteVersionAfter != "" {
+		vaultSecret.DeleteVersionAfter, err = time.ParseDuration(metadata.DeleteVersionAfter)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read secret's delete_version_after: %w", err)
+		}
 	}
 
 	return vaultSecret, nil
 }
 
-func (c *VaultApi) UpdateSecretMetadata(secretPath string, metadata map[string]string) error {
-	// Get metadata path for secret in Vault
-	metadataPath, err := secretMetadataPath(secretPath, c.client)
+// ListSecrets returns the metadata of the secrets found under prefix, recursively, sorted by path. Secrets whose latest
+// version is deleted are skipped. Like ReadSecretMetadata, secret data are never read.
+func (c *VaultApi) ListSecrets(ctx context.Context, prefix string) ([]Secret, error) {
+	ctx = withRequestID(ctx)
+	paths, err := resolveSecretPaths(ctx, prefix, c.client)
 	if err != nil {
-		return fmt.Errorf("invalid path for metadata: %w", err)
+		return nil, fmt.Errorf("invalid path: %w", err)
 	}
 
-	// Get secret's metadata from Vault
-	secretMetadata, err := c.client.Logical().Read(metadataPath)
+	keys, err := c.listKeys(ctx, paths.metadata(), "")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	secrets := make([]Secret, 0, len(keys))
+	for _, key := range keys {
+		metadataPath := path.Join(paths.metadata(), key)
+		secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+		if err != nil {
+			return nil, newError(ctx, "read secret's metadata", metadataPath, err)
+		}
+		if secret == nil {
+			continue
+		}
+
+		metadata, err := decodeSecretMetadata(secret.Data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read metadata of secret %s: %w", key, err)
+		}
+		if metadata.isCurrentVersionDeleted() {
+			continue
+		}
+
+		secrets = append(secrets, Secret{
+			Path:         strings.TrimSuffix(prefix, "/") + "/" + key,
+			Metadata:     metadata.CustomMetadata,
+			VersionsKept: metadata.versionsKept(),
+			CreatedTime:  metadata.CreatedTime,
+			UpdatedTime:  metadata.UpdatedTime,
+		})
+	}
+
+	return secrets, nil
+}
+
+// listKeys lists the secrets under the metadata path metadataPath, recursively. Keys are relative to metadataPath,
+// prefixed by dir.
+func (c *VaultApi) listKeys(ctx context.Context, metadataPath, dir string) ([]string, error) {
+	listPath := path.Join(metadataPath, dir)
+	secret, err := c.client.Logical().ListWithContext(ctx, listPath)
+	if err != nil {
+		return nil, newError(ctx, "list secrets", listPath, err)
+	}
+	if secret == nil || secret.Data["keys"] == nil {
+		return nil, nil
+	}
+
+	entries, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected list response for %s", listPath)
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := fmt.Sprint(entry)
+		key := dir + name
+		if !strings.HasSuffix(name, "/") {
+			keys = append(keys, key)
+			continue
+		}
+		nested, err := c.listKeys(ctx, metadataPath, key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, nested...)
+	}
+	return keys, nil
+}
+
+// UpdateSecretData writes a new version of the secret's data. Check-and-set ensures the secret hasn't been written
+// since version was read. The new version is recorded in the secret's custom metadata.
+func (c *VaultApi) UpdateSecretData(ctx context.Context, secretPath string, data map[string]interface{}, version int) error {
+	if err := c.checkWritable("write secret's data"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	dataPath := paths.data()
+	metadataPath := paths.metadata()
+
+	if err = checkCapabilities(ctx, c.client, dataPath, "update"); err != nil {
+		return err
+	}
+	if err = checkCapabilities(ctx, c.client, metadataPath, "update"); err != nil {
+		return err
+	}
+
+	written, err := c.writeSecretData(ctx, dataPath, data, version)
+	if isCheckAndSetError(err) {
+		return fmt.Errorf("secret %s has been modified concurrently (expected version %d), retry", secretPath, version)
+	}
+	if err != nil {
+		return newError(ctx, "write secret's data", dataPath, err)
+	}
+
+	newVersion, err := writtenVersion(written)
+	if err != nil {
+		return fmt.Errorf("unable to read version written to secret %s: %w", secretPath, err)
+	}
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return fmt.Errorf("no metadata for secret")
+	}
+	metadata, err := decodeSecretMetadata(secret.Data)
 	if err != nil {
 		return fmt.Errorf("unable to read secret's metadata: %w", err)
 	}
 
-	if secretMetadata.Data[SecretCustomDataField] == nil {
-		return fmt.Errorf("missing custom metadata")
+	return c.patchCustomMetadata(ctx, metadataPath, map[string]string{
+		ManagedVersionsMetadata: addManagedVersion(metadata.CustomMetadata[ManagedVersionsMetadata], newVersion, metadata.OldestVersion),
+	}, nil)
+}
+
+// UpdateSecretMetadata merges metadata into the secret's current custom metadata and drops the removed keys. Only these
+// keys are written: keys changed in Vault by another system and not managed by the caller are left untouched.
+func (c *VaultApi) UpdateSecretMetadata(ctx context.Context, secretPath string, metadata map[string]string, removed []string) error {
+	if err := c.checkWritable("write secret's metadata"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	// Get metadata path for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
 	}
 
-	// Update secret's metadata from plan (only metadata can be changed)
-	updatedMetadata := make(map[string]string)
+	return c.patchCustomMetadata(ctx, paths.metadata(), metadata, removed)
+}
 
+// UpdateSecretRetention sets the retention settings of a secret: the number of versions kept and the duration after
+// which versions are deleted, 0 falling back to the settings of the mount. The secret's data and custom metadata are
+// left untouched.
+func (c *VaultApi) UpdateSecretRetention(ctx context.Context, secretPath string, maxVersions int, deleteVersionAfter time.Duration) error {
+	if err := c.checkWritable("write secret's retention settings"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
+	// Get metadata path for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	metadataPath := paths.metadata()
+
+	// Check token's capabilities before updating anything
+	if err = checkCapabilities(ctx, c.client, metadataPath, "update"); err != nil {
+		return err
+	}
+
+	// Fields left out of the request, custom_metadata included, keep their value
+	_, err = c.client.Logical().WriteWithContext(ctx, metadataPath, map[string]interface{}{
+		"max_versions":         maxVersions,
+		"delete_version_after": deleteVersionAfter.String(),
+	})
+	if err != nil {
+		return newError(ctx, "write secret's retention settings", metadataPath, err)
+	}
+	return nil
+}
+
+// patchCustomMetadata sets the given custom metadata of a secret and removes the removed keys with a JSON merge patch
+// (Vault 1.9+), so that other keys aren't rewritten and changes made concurrently by other systems are preserved. Keys
+// both set and removed are set. When Vault doesn't support patches, the whole custom metadata is read, merged and
+// written back instead. A token lacking the `patch` capability gets the permission error.
+func (c *VaultApi) patchCustomMetadata(ctx context.Context, metadataPath string, metadata map[string]string, removed []string) error {
+	patch := make(map[string]interface{}, len(metadata)+len(removed))
+	for _, k := range removed {
+		// A null value removes the key
+		patch[k] = nil
+	}
 	for k, v := range metadata {
-		updatedMetadata[k] = v
+		patch[k] = v
+	}
+	if len(patch) == 0 {
+		return nil
 	}
 
-	fullMetadata := map[string]interface{}{
-		SecretCustomDataField: updatedMetadata,
+	_, err := c.client.Logical().JSONMergePatch(ctx, metadataPath, map[string]interface{}{
+		SecretCustomDataField: patch,
+	})
+	if err == nil {
+		return nil
 	}
+	if !isPatchUnsupportedError(err) {
+		return newError(ctx, "patch secret's metadata", metadataPath, err)
+	}
+	log.Println("unable to patch", metadataPath, ", writing the whole custom metadata:", err)
 
-	_, err = c.client.Logical().Write(metadataPath, fullMetadata)
+	// Get secret's metadata from Vault
+	secretMetadata, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secretMetadata == nil {
+		return fmt.Errorf("no metadata for secret")
+	}
+	current, err := decodeSecretMetadata(secretMetadata.Data)
+	if err != nil {
+		return fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+
+	fullMetadata := map[string]interface{}{
+		SecretCustomDataField: mergeMetadata(current.CustomMetadata, metadata, removed),
+	}
+	_, err = c.client.Logical().WriteWithContext(ctx, metadataPath, fullMetadata)
 	if err != nil {
-		return fmt.Errorf("unable to write secret's metadata: %w", err)
+		return newError(ctx, "write secret's metadata", metadataPath, err)
 	}
 	return nil
 }
 
-func (c *VaultApi) DeleteSecret(secretPath string) error {
+func (c *VaultApi) DeleteSecret(ctx context.Context, secretPath string) error {
+	if err := c.checkWritable("delete secret"); err != nil {
+		return err
+	}
+	ctx = withRequestID(ctx)
 	// Get metadata path for secret in Vault
-	metadataPath, err := secretMetadataPath(secretPath, c.client)
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
 	if err != nil {
-		return fmt.Errorf("invalid path for metadata: %w", err)
+		return fmt.Errorf("invalid path: %w", err)
 	}
+	metadataPath := paths.metadata()
 
 	// Retrieve secret's metadata from Vault
-	secret, err := c.client.Logical().Read(metadataPath)
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
 	if err != nil {
-		return fmt.Errorf("unable to read secret's metadata: %w", err)
-
+		return newError(ctx, "read secret's metadata", metadataPath, err)
 	}
 	if secret == nil {
 		return fmt.Errorf("no metadata for secret")
@@ -202,21 +853,229 @@ func (c *VaultApi) DeleteSecret(secretPath string) error {
 		versionsToDelete = append(versionsToDelete, version)
 	}
 
-	// Get delete path for secret in Vault
-	deletePath, err := secretMetadataPath(secretPath, c.client)
-	if err != nil {
-		return fmt.Errorf("invalid path for deletion: %w", err)
+	// Deleting the metadata removes every version of the secret
+	deletePath := metadataPath
+
+	// Check token's capabilities before deleting anything
+	if err = checkCapabilities(ctx, c.client, deletePath, "delete"); err != nil {
+		return err
 	}
 
 	// Delete all active secret's versions in Vault (just flag, nothing will be lost)
-	_, err = c.client.Logical().Delete(deletePath)
+	_, err = c.client.Logical().DeleteWithContext(ctx, deletePath)
 	if err != nil {
-		return fmt.Errorf("unable to mark secret's versions as deleted: %w", err)
+		return newError(ctx, "mark secret's versions as deleted", deletePath, err)
 	}
 
 	return nil
 }
 
+// DeleteManagedVersions deletes (soft delete, they can be undeleted) the versions of a secret recorded in its
+// ManagedVersionsMetadata custom metadata, leaving the other versions and the metadata intact. It returns the deleted
+// versions, none when the secret doesn't hold any active version written through this package.
+func (c *VaultApi) DeleteManagedVersions(ctx context.Context, secretPath string) ([]int, error) {
+	if err := c.checkWritable("delete secret's versions"); err != nil {
+		return nil, err
+	}
+	ctx = withRequestID(ctx)
+	// Resolve API paths for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	metadataPath := paths.metadata()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return nil, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no metadata for secret")
+	}
+
+	metadata, err := decodeSecretMetadata(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+
+	versions := metadata.activeManagedVersions()
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	deletePath := paths.delete()
+
+	// Check token's capabilities before deleting anything
+	if err = checkCapabilities(ctx, c.client, deletePath, "update"); err != nil {
+		return nil, err
+	}
+
+	_, err = c.client.Logical().WriteWithContext(ctx, deletePath, map[string]interface{}{
+		"versions": versions,
+	})
+	if err != nil {
+		return nil, newError(ctx, "mark secret's versions as deleted", deletePath, err)
+	}
+
+	return versions, nil
+}
+
+// DeleteTombstonedSecret deletes the metadata of a secret, and with them every version, when none of its versions can be
+// read anymore: only the tombstone of the secret is left in the KV tree. It returns false when the secret is kept,
+// because it doesn't exist or still has a live version.
+func (c *VaultApi) DeleteTombstonedSecret(ctx context.Context, secretPath string) (bool, error) {
+	if err := c.checkWritable("delete secret's metadata"); err != nil {
+		return false, err
+	}
+	ctx = withRequestID(ctx)
+	// Resolve API paths for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return false, fmt.Errorf("invalid path: %w", err)
+	}
+	metadataPath := paths.metadata()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return false, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return false, nil
+	}
+
+	metadata, err := decodeSecretMetadata(secret.Data)
+	if err != nil {
+		return false, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+	if metadata.liveVersion() != 0 {
+		return false, nil
+	}
+
+	// Check token's capabilities before deleting anything
+	if err = checkCapabilities(ctx, c.client, metadataPath, "delete"); err != nil {
+		return false, err
+	}
+
+	_, err = c.client.Logical().DeleteWithContext(ctx, metadataPath)
+	if err != nil {
+		return false, newError(ctx, "delete secret's metadata", metadataPath, err)
+	}
+	return true, nil
+}
+
+// DestroySecretVersions permanently destroys versions of a secret: the given versions, and when keep is positive,
+// every version older than the latest keep versions not destroyed yet. Destroyed versions can't be undeleted. The current
+// version is never destroyed. It returns the versions destroyed, none when there was nothing left to destroy.
+func (c *VaultApi) DestroySecretVersions(ctx context.Context, secretPath string, keep int, versions []int) ([]int, error) {
+	if err := c.checkWritable("destroy secret's versions"); err != nil {
+		return nil, err
+	}
+	ctx = withRequestID(ctx)
+	// Resolve API paths for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	metadataPath := paths.metadata()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return nil, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no metadata for secret")
+	}
+
+	metadata, err := decodeSecretMetadata(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+
+	destroyed, err := metadata.versionsToDestroy(keep, versions)
+	if err != nil {
+		return nil, err
+	}
+	if len(destroyed) == 0 {
+		return destroyed, nil
+	}
+
+	destroyPath := paths.destroy()
+
+	// Check token's capabilities before destroying anything
+	if err = checkCapabilities(ctx, c.client, destroyPath, "update"); err != nil {
+		return nil, err
+	}
+
+	_, err = c.client.Logical().WriteWithContext(ctx, destroyPath, map[string]interface{}{
+		"versions": destroyed,
+	})
+	if err != nil {
+		return nil, newError(ctx, "destroy secret's versions", destroyPath, err)
+	}
+
+	return destroyed, nil
+}
+
+// ScheduleSecretDeletion makes Vault delete the current version of a secret once the given grace period is over,
+// instead of deleting it right away. Vault's delete_version_after is relative to the creation of each version, so it is
+// computed from the current version's creation time. The scheduled date is also written in the secret's custom
+// metadata, and returned.
+func (c *VaultApi) ScheduleSecretDeletion(ctx context.Context, secretPath string, after time.Duration) (time.Time, error) {
+	if err := c.checkWritable("schedule secret's deletion"); err != nil {
+		return time.Time{}, err
+	}
+	ctx = withRequestID(ctx)
+	// Get metadata path for secret in Vault
+	paths, err := resolveSecretPaths(ctx, secretPath, c.client)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid path: %w", err)
+	}
+	metadataPath := paths.metadata()
+
+	// Retrieve secret's metadata from Vault
+	secret, err := c.client.Logical().ReadWithContext(ctx, metadataPath)
+	if err != nil {
+		return time.Time{}, newError(ctx, "read secret's metadata", metadataPath, err)
+	}
+	if secret == nil {
+		return time.Time{}, fmt.Errorf("no metadata for secret")
+	}
+
+	metadata, err := decodeSecretMetadata(secret.Data)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to read secret's metadata: %w", err)
+	}
+
+	version, ok := metadata.Versions[strconv.Itoa(metadata.CurrentVersion)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unable to find current version %d of secret", metadata.CurrentVersion)
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(after)
+
+	// Check token's capabilities before updating anything
+	if err = checkCapabilities(ctx, c.client, metadataPath, "update"); err != nil {
+		return time.Time{}, err
+	}
+
+	customMetadata := mergeMetadata(metadata.CustomMetadata, map[string]string{
+		ScheduledDestroyMetadata: scheduledAt.UTC().Format(time.RFC3339),
+	}, nil)
+
+	fullMetadata := map[string]interface{}{
+		SecretCustomDataField:  customMetadata,
+		"delete_version_after": (now.Sub(version.CreatedTime) + after).Round(time.Second).String(),
+	}
+
+	_, err = c.client.Logical().WriteWithContext(ctx, metadataPath, fullMetadata)
+	if err != nil {
+		return time.Time{}, newError(ctx, "schedule secret's deletion", metadataPath, err)
+	}
+
+	return scheduledAt, nil
+}
+
 func TokenFromHelper() (string, error) {
 	helper, err := config.DefaultTokenHelper()
 	if err != nil {
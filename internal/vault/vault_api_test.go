@@ -0,0 +1,397 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	vaultinternals "github.com/hashicorp/vault/api"
+)
+
+func TestPatchCustomMetadata(t *testing.T) {
+	for _, patchSupported := range []bool{true, false} {
+		var patched, written map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPatch:
+				if !patchSupported {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					_, _ = w.Write([]byte(`{"errors":["1 error occurred:\n\t* unsupported operation\n\n"]}`))
+					return
+				}
+				if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+					t.Errorf("Wrong content type: %s", r.Header.Get("Content-Type"))
+				}
+				_ = json.NewDecoder(r.Body).Decode(&patched)
+			case http.MethodGet:
+				_, _ = w.Write([]byte(`{"data":{"current_version":1,"custom_metadata":{"owner":"team_a","obsolete":"true","external":"foo"}}}`))
+				return
+			default:
+				_ = json.NewDecoder(r.Body).Decode(&written)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		conf := vaultinternals.DefaultConfig()
+		conf.Address = server.URL
+		client, err := vaultinternals.NewClient(conf)
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+
+		c := NewVaultApi(client)
+		err = c.patchCustomMetadata(context.Background(), "secret/metadata/foo", map[string]string{"owner": "team_b"}, []string{"obsolete"})
+		server.Close()
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+
+		if patchSupported {
+			expected := map[string]interface{}{SecretCustomDataField: map[string]interface{}{"owner": "team_b", "obsolete": nil}}
+			if !reflect.DeepEqual(patched, expected) || written != nil {
+				t.Fatalf("Wrong patch: %v. Expected: %v", patched, expected)
+			}
+			continue
+		}
+
+		expected := map[string]interface{}{SecretCustomDataField: map[string]interface{}{"owner": "team_b", "external": "foo"}}
+		if !reflect.DeepEqual(written, expected) {
+			t.Fatalf("Wrong metadata written without patch support: %v. Expected: %v", written, expected)
+		}
+	}
+}
+
+func TestDeleteTombstonedSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions string
+		deleted  bool
+	}{
+		{"tombstone", `{"1":{"deletion_time":"2024-01-01T00:00:00Z"},"2":{"destroyed":true}}`, true},
+		{"live version", `{"1":{"deletion_time":"2024-01-01T00:00:00Z"},"2":{"deletion_time":""}}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var metadataDeleted bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/v1/sys/internal/ui/mounts/secret/foo":
+					_, _ = w.Write([]byte(`{"data":{"path":"secret/","options":{"version":"2"}}}`))
+				case r.URL.Path == "/v1/sys/capabilities-self":
+					_, _ = w.Write([]byte(`{"data":{"capabilities":["root"]}}`))
+				case r.Method == http.MethodGet:
+					_, _ = w.Write([]byte(`{"data":{"current_version":2,"versions":` + tt.versions + `}}`))
+				case r.Method == http.MethodDelete && r.URL.Path == "/v1/secret/metadata/foo":
+					metadataDeleted = true
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			conf := vaultinternals.DefaultConfig()
+			conf.Address = server.URL
+			client, err := vaultinternals.NewClient(conf)
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+
+			deleted, err := NewVaultApi(client).DeleteTombstonedSecret(context.Background(), "secret/foo")
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+			if deleted != tt.deleted || metadataDeleted != tt.deleted {
+				t.Fatalf("Wrong deletion: %v (metadata deleted: %v). Expected: %v", deleted, metadataDeleted, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestDestroySecretVersions(t *testing.T) {
+	var destroyed map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/sys/internal/ui/mounts/secret/foo":
+			_, _ = w.Write([]byte(`{"data":{"path":"secret/","options":{"version":"2"}}}`))
+		case r.URL.Path == "/v1/sys/capabilities-self":
+			_, _ = w.Write([]byte(`{"data":{"capabilities":["root"]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/metadata/foo":
+			_, _ = w.Write([]byte(`{"data":{"current_version":3,"versions":{"1":{"destroyed":true},"2":{},"3":{}}}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/destroy/foo":
+			_ = json.NewDecoder(r.Body).Decode(&destroyed)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	versions, err := NewVaultApi(client).DestroySecretVersions(context.Background(), "secret/foo", 1, nil)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if !reflect.DeepEqual(versions, []int{2}) {
+		t.Fatalf("Wrong destroyed versions: %v. Expected: [2]", versions)
+	}
+	expected := map[string]interface{}{"versions": []interface{}{float64(2)}}
+	if !reflect.DeepEqual(destroyed, expected) {
+		t.Fatalf("Wrong destroy request: %v. Expected: %v", destroyed, expected)
+	}
+}
+
+func TestPatchCustomMetadataForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["1 error occurred:\n\t* permission denied\n\n"]}`))
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	c := NewVaultApi(client)
+	err = c.patchCustomMetadata(context.Background(), "secret/metadata/foo", map[string]string{"owner": "team_b"}, nil)
+
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) || vaultErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected a permission error, got %v", err)
+	}
+}
+
+func TestUpdateSecretRetentionForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/sys/internal/ui/mounts/secret/foo":
+			_, _ = w.Write([]byte(`{"data":{"path":"secret/","options":{"version":"2"}}}`))
+		case r.URL.Path == "/v1/sys/capabilities-self":
+			// Token allowed to read the secret only
+			_, _ = w.Write([]byte(`{"data":{"capabilities":["read","list"]}}`))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+
+	c := NewVaultApi(client)
+	err = c.UpdateSecretRetention(context.Background(), "secret/foo", 5, time.Hour)
+
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) || vaultErr.StatusCode != http.StatusForbidden || vaultErr.Path != "secret/metadata/foo" {
+		t.Fatalf("Expected a permission error on the metadata, got %v", err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/sys/internal/ui/mounts/secret/foo":
+			_, _ = w.Write([]byte(`{"data":{"path":"secret/","options":{"version":"2"}}}`))
+		case "/v1/secret/data/foo":
+			_, _ = w.Write([]byte(`{"data":{"data":{"key":"value"},"metadata":{"version":1}}}`))
+		case "/v1/secret/metadata/foo":
+			_, _ = w.Write([]byte(`{"data":{"current_version":1,"custom_metadata":{"owner":"team_a"},"versions":{"1":{}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	conf := vaultinternals.DefaultConfig()
+	conf.Address = server.URL
+	client, err := vaultinternals.NewClient(conf)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	c := NewVaultApi(client).ReadOnly()
+
+	writes := map[string]func() error{
+		"create": func() error {
+			_, err := c.CreateSecret(context.Background(), Secret{Path: "secret/foo", Data: map[string]interface{}{"key": "value"}})
+			return err
+		},
+		"update": func() error {
+			return c.UpdateSecretMetadata(context.Background(), "secret/foo", map[string]string{"owner": "team_b"}, nil)
+		},
+		"delete": func() error { return c.DeleteSecret(context.Background(), "secret/foo") },
+		"policy": func() error { return c.WritePolicy(context.Background(), "foo", `path "secret/*" {}`) },
+	}
+	for name, write := range writes {
+		var readOnlyErr *ReadOnlyError
+		if err := write(); !errors.As(err, &readOnlyErr) {
+			t.Fatalf("%s: expected a *vault.ReadOnlyError, got %v", name, err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Fatalf("Unexpected requests in read-only mode: %v", requests)
+	}
+
+	withHeaders, err := c.WithHeaders(map[string]string{"X-Team": "b"})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	var readOnlyErr *ReadOnlyError
+	if err := withHeaders.UpdateSecretData(context.Background(), "secret/foo", nil, 1); !errors.As(err, &readOnlyErr) {
+		t.Fatalf("Expected the read-only mode to be kept by WithHeaders, got %v", err)
+	}
+	if _, err := c.ReadSecret(context.Background(), "secret/foo"); err != nil {
+		t.Fatal("error:", err)
+	}
+}
+
+// kvSecret is a KV v2 secret served by newKVServer.
+type kvSecret struct {
+	metadata map[string]interface{}
+	versions []map[string]interface{}
+	// loseDataWrite makes the next data write fail with an internal error once applied, as when the response is lost
+	loseDataWrite bool
+}
+
+// newKVServer serves the secret at secret/foo, on a KV v2 mount.
+func newKVServer(t *testing.T, s *kvSecret) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/sys/internal/ui/mounts/secret/foo":
+			_, _ = w.Write([]byte(`{"data":{"path":"secret/","options":{"version":"2"}}}`))
+		case r.URL.Path == "/v1/sys/capabilities-self":
+			_, _ = w.Write([]byte(`{"data":{"capabilities":["root"]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/foo":
+			if len(s.versions) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"data":     s.versions[len(s.versions)-1],
+				"metadata": map[string]interface{}{"version": len(s.versions), "deletion_time": "", "destroyed": false},
+			}})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/metadata/foo":
+			if s.metadata == nil {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+				return
+			}
+			versions := map[string]interface{}{}
+			for i := range s.versions {
+				versions[strconv.Itoa(i+1)] = map[string]interface{}{"deletion_time": "", "destroyed": false}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"current_version": len(s.versions),
+				"custom_metadata": s.metadata,
+				"versions":        versions,
+			}})
+		case r.URL.Path == "/v1/secret/metadata/foo":
+			var body map[string]map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			s.metadata = body[SecretCustomDataField]
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/v1/secret/data/foo":
+			var body struct {
+				Data    map[string]interface{} `json:"data"`
+				Options struct {
+					CAS int `json:"cas"`
+				} `json:"options"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Options.CAS != len(s.versions) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+				return
+			}
+			s.versions = append(s.versions, body.Data)
+			if s.loseDataWrite {
+				s.loseDataWrite = false
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"errors":["internal error"]}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": len(s.versions)}})
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestCreateSecretStamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+	}{
+		{"new secret", nil},
+		{"metadata without versions", map[string]interface{}{"owner": "team_b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The response to the data write is lost, the Vault client retrying it
+			s := &kvSecret{metadata: tt.metadata, loseDataWrite: true}
+			server := newKVServer(t, s)
+			defer server.Close()
+
+			conf := vaultinternals.DefaultConfig()
+			conf.Address = server.URL
+			conf.MaxRetries = 1
+			conf.MinRetryWait = time.Millisecond
+			conf.MaxRetryWait = time.Millisecond
+			client, err := vaultinternals.NewClient(conf)
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+			c := NewVaultApi(client)
+
+			secret := Secret{Path: "secret/foo", Data: map[string]interface{}{"secret": "first"}, Metadata: map[string]string{"owner": "team_a"}, CreateID: "create-1"}
+			version, err := c.CreateSecret(context.Background(), secret)
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+			expected := map[string]interface{}{"owner": "team_a", CreateIDMetadata: "create-1", ManagedVersionsMetadata: "1"}
+			if version != 1 || len(s.versions) != 1 || !reflect.DeepEqual(s.metadata, expected) {
+				t.Fatalf("Wrong secret written: version %d (%v), metadata %v. Expected: %v", version, s.versions, s.metadata, expected)
+			}
+
+			// Secrets found before writing anything are never taken over, whatever their stamp
+			for _, createID := range []string{"create-2", "create-1"} {
+				other := Secret{Path: "secret/foo", Data: map[string]interface{}{"secret": "other"}, Metadata: map[string]string{"owner": "team_a"}, CreateID: createID}
+				_, err = c.CreateSecret(context.Background(), other)
+				var existsErr *SecretExistsError
+				if !errors.As(err, &existsErr) {
+					t.Fatalf("Expected a SecretExistsError for %s, got: %v", createID, err)
+				}
+			}
+			if len(s.versions) != 1 || s.versions[0]["secret"] != "first" {
+				t.Fatalf("Secret overwritten: %v", s.versions)
+			}
+		})
+	}
+}
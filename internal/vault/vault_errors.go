@@ -0,0 +1,225 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Error is returned by VaultApi whenever a call to Vault fails. It keeps the HTTP status code, the errors reported by
+// Vault and the API path of the request so that callers can give users actionable feedback. RequestID is the ID of the
+// request, to look for in Vault's audit logs, when recorded (see RecordRequestIDs).
+type Error struct {
+	Operation  string
+	Method     string
+	Path       string
+	StatusCode int
+	RequestID  string
+	Errors     []string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("unable to %s", e.Operation)
+	if e.Path != "" {
+		msg += fmt.Sprintf(" at %s", e.Path)
+	}
+	switch {
+	case e.StatusCode != 0 && e.RequestID != "":
+		msg += fmt.Sprintf(" (HTTP %d, request ID %s)", e.StatusCode, e.RequestID)
+	case e.StatusCode != 0:
+		msg += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+
+	if len(e.Errors) > 0 {
+		return msg + ": " + strings.Join(e.Errors, ", ")
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Hint returns a suggestion about what to check depending on the HTTP status code returned by Vault, or an empty
+// string when there is nothing specific to suggest.
+func (e *Error) Hint() string {
+	switch e.StatusCode {
+	case http.StatusForbidden:
+		return "Check that the Vault policies attached to the provider's token grant the required capabilities on this path."
+	case http.StatusNotFound:
+		return "Check that the path is under an existing KV v2 mount and that the mount name is correct."
+	case http.StatusTooManyRequests:
+		return "Vault is rate limiting requests. Retry later or lower Terraform's parallelism."
+	case http.StatusServiceUnavailable:
+		return "Vault is unavailable (sealed or in maintenance). Check the health of the Vault cluster."
+	}
+	return ""
+}
+
+// newError wraps err into an *Error, extracting the status code, the Vault errors and the request ID recorded in ctx
+// (see withRequestID) when err is an *api.ResponseError.
+func newError(ctx context.Context, operation, apiPath string, err error) error {
+	e := &Error{
+		Operation: operation,
+		Path:      apiPath,
+		Err:       err,
+	}
+
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		e.Method = respErr.HTTPMethod
+		e.StatusCode = respErr.StatusCode
+		e.Errors = respErr.Errors
+		e.RequestID = failedRequestID(ctx)
+	}
+
+	return e
+}
+
+// SecretExistsError is returned by CreateSecret when a secret already exists at the requested path. It describes the
+// existing secret so that users can decide whether to import it or to pick another path.
+type SecretExistsError struct {
+	Path        string
+	CreatedTime time.Time
+	Metadata    map[string]string
+	// Deleted is set when the latest version of the existing secret is deleted
+	Deleted bool
+}
+
+func (e *SecretExistsError) Error() string {
+	return fmt.Sprintf("secret %s already exists", e.Path)
+}
+
+// Hint describes the existing secret and how to adopt it.
+func (e *SecretExistsError) Hint() string {
+	var hint strings.Builder
+
+	hint.WriteString("A secret already exists at this path")
+	if !e.CreatedTime.IsZero() {
+		hint.WriteString(fmt.Sprintf(", created at %s", e.CreatedTime.Format(time.RFC3339)))
+	}
+	if len(e.Metadata) > 0 {
+		keys := make([]string, 0, len(e.Metadata))
+		for k := range e.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", k, e.Metadata[k]))
+		}
+		hint.WriteString(fmt.Sprintf(", with custom metadata %s", strings.Join(pairs, ", ")))
+	}
+	hint.WriteString(".\n")
+	hint.WriteString(fmt.Sprintf("If it's the secret this resource should manage, import it with `terraform import <resource address> %s`. Otherwise, choose another path.", e.Path))
+
+	return hint.String()
+}
+
+// SecretDeletedError is returned when reading a secret whose latest version has been deleted or destroyed, e.g. with
+// `vault kv delete` outside Terraform. LiveVersion is the latest version that can still be read, 0 if there's none.
+type SecretDeletedError struct {
+	Path        string
+	Version     int
+	LiveVersion int
+}
+
+func (e *SecretDeletedError) Error() string {
+	return fmt.Sprintf("secret is marked deleted: latest version %d of %s is deleted", e.Version, e.Path)
+}
+
+// Hint describes how to recover the secret.
+func (e *SecretDeletedError) Hint() string {
+	if e.LiveVersion == 0 {
+		return fmt.Sprintf("No version of the secret can be read anymore. Undelete version %d in Vault (`vault kv undelete -versions=%d`) if it's not destroyed, or set `on_deleted_version` to `recreate` to generate a new secret.", e.Version, e.Version)
+	}
+	return fmt.Sprintf("Version %d can still be read. Undelete version %d in Vault (`vault kv undelete -versions=%d`), or set `on_deleted_version` to `restore` to roll back to version %d, or to `recreate` to generate a new secret.", e.LiveVersion, e.Version, e.Version, e.LiveVersion)
+}
+
+// isPatchUnsupportedError tells if err is returned by Vault for a PATCH request it doesn't support: Vault versions
+// before 1.9 don't support the method.
+func isPatchUnsupportedError(err error) bool {
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	return respErr.StatusCode == http.StatusMethodNotAllowed
+}
+
+// isCheckAndSetError tells if err is the error returned by Vault when a check-and-set write fails.
+func isCheckAndSetError(err error) bool {
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, e := range respErr.Errors {
+		if strings.Contains(e, "check-and-set") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnlyError is returned by the methods of a read-only VaultApi writing to Vault, see VaultApi.ReadOnly.
+type ReadOnlyError struct {
+	Operation string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("unable to %s: the provider is in read-only mode", e.Operation)
+}
+
+// Hint explains how to apply the change.
+func (e *ReadOnlyError) Hint() string {
+	return "Only reads are sent to Vault with `read_only = true`. Apply the configuration with a provider not in read-only mode."
+}
+
+// HealthError is returned by CheckHealth when Vault can't serve requests.
+type HealthError struct {
+	Address string
+	Reason  string
+	Err     error
+	hint    string
+}
+
+func (e *HealthError) Error() string {
+	msg := fmt.Sprintf("%s at %s", e.Reason, e.Address)
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *HealthError) Unwrap() error {
+	return e.Err
+}
+
+// Hint suggests what to check depending on the reason Vault can't serve requests.
+func (e *HealthError) Hint() string {
+	return e.hint
+}
+
+// isPathInUseError tells if err is the error returned by Vault when enabling a secrets engine at a path already mounted.
+func isPathInUseError(err error) bool {
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, e := range respErr.Errors {
+		if strings.Contains(e, "already in use") {
+			return true
+		}
+	}
+	return false
+}
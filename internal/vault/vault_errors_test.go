@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestNewErrorFromResponseError(t *testing.T) {
+	respErr := &api.ResponseError{
+		HTTPMethod: http.MethodGet,
+		StatusCode: http.StatusForbidden,
+		Errors:     []string{"permission denied"},
+	}
+
+	err := newError(context.Background(), "read secret's data", "secret/data/foo", fmt.Errorf("wrapped: %w", respErr))
+
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) {
+		t.Fatalf("Expected a *vault.Error, got %T", err)
+	}
+	if vaultErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("Wrong status code: %d. Expected: %d", vaultErr.StatusCode, http.StatusForbidden)
+	}
+	if vaultErr.Hint() == "" {
+		t.Fatalf("Expected a hint for status code %d", vaultErr.StatusCode)
+	}
+
+	expected := "unable to read secret's data at secret/data/foo (HTTP 403): permission denied"
+	if err.Error() != expected {
+		t.Fatalf("Wrong error message: %q. Expected: %q", err.Error(), expected)
+	}
+}
+
+func TestNewErrorFromOtherError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := newError(context.Background(), "read secret's data", "secret/data/foo", cause)
+
+	if !errors.Is(err, cause) {
+		t.Fatalf("Expected error to wrap %v", cause)
+	}
+
+	var vaultErr *Error
+	if !errors.As(err, &vaultErr) || vaultErr.Hint() != "" {
+		t.Fatalf("Expected a *vault.Error without hint, got %v", err)
+	}
+}
+
+func TestSecretExistsErrorHint(t *testing.T) {
+	err := &SecretExistsError{
+		Path:        "/secret/foo/bar",
+		CreatedTime: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		Metadata: map[string]string{
+			"secret_type": "random_secret",
+			"owner":       "my_team",
+		},
+	}
+
+	if err.Error() != "secret /secret/foo/bar already exists" {
+		t.Fatalf("Wrong error message: %q", err.Error())
+	}
+
+	hint := err.Hint()
+	for _, expected := range []string{
+		"created at 2024-01-02T10:00:00Z",
+		"owner=my_team, secret_type=random_secret",
+		"terraform import <resource address> /secret/foo/bar",
+	} {
+		if !strings.Contains(hint, expected) {
+			t.Fatalf("Hint %q doesn't contain %q", hint, expected)
+		}
+	}
+}
+
+func TestSecretDeletedError(t *testing.T) {
+	metadata := &secretV2Metadata{
+		CurrentVersion: 4,
+		Versions: map[string]secretV2Version{
+			"1": {},
+			"2": {},
+			"3": {Destroyed: true},
+			"4": {DeletionTime: "2024-01-03T10:00:00.123456789Z"},
+		},
+	}
+
+	err := metadata.deletedError("secret/foo")
+	if err.Version != 4 || err.LiveVersion != 2 {
+		t.Fatalf("Wrong deleted versions: %d, live %d. Expected: 4, live 2", err.Version, err.LiveVersion)
+	}
+	if hint := err.Hint(); !strings.Contains(hint, "roll back to version 2") {
+		t.Fatalf("Wrong hint: %s", hint)
+	}
+
+	metadata.Versions["1"] = secretV2Version{Destroyed: true}
+	metadata.Versions["2"] = secretV2Version{DeletionTime: "2024-01-02T10:00:00.123456789Z"}
+	if err = metadata.deletedError("secret/foo"); err.LiveVersion != 0 {
+		t.Fatalf("Wrong live version: %d. Expected: 0", err.LiveVersion)
+	}
+}
+
+func TestIsCheckAndSetError(t *testing.T) {
+	casErr := &api.ResponseError{
+		StatusCode: http.StatusBadRequest,
+		Errors:     []string{"check-and-set parameter did not match the current version"},
+	}
+	if !isCheckAndSetError(fmt.Errorf("wrapped: %w", casErr)) {
+		t.Fatalf("Expected a check-and-set error")
+	}
+
+	otherErr := &api.ResponseError{
+		StatusCode: http.StatusBadRequest,
+		Errors:     []string{"invalid request"},
+	}
+	if isCheckAndSetError(otherErr) || isCheckAndSetError(nil) {
+		t.Fatalf("Expected not to be a check-and-set error")
+	}
+}
@@ -1,13 +1,19 @@
 package vault
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/mapstructure"
 	"log"
+	"net/http"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 type secretV2Metadata struct {
@@ -28,31 +34,81 @@ type secretV2Version struct {
 	Destroyed    bool      `json:"destroyed"`
 }
 
-func prefixSecretPath(secretPath, prefix string, c *api.Client) (string, error) {
+// kvSecretPaths holds what's needed to build every KV v2 API path of a secret. It is resolved once per operation so
+// that a single preflight request is sent to Vault.
+type kvSecretPaths struct {
+	secretPath string
+	mountPath  string
+}
+
+func resolveSecretPaths(ctx context.Context, secretPath string, c *api.Client) (*kvSecretPaths, error) {
 	partialPath := sanitizePath(secretPath)
-	mountPath, v2, err := isKVv2(partialPath, c)
+	if err := checkRelativeSegments(partialPath); err != nil {
+		return nil, err
+	}
+	mountPath, v2, err := isKVv2(ctx, partialPath, c)
 	if err != nil {
 		log.Println("error checking", secretPath, "mount type:", err)
-		return "", err
+		return nil, newError(ctx, "check mount type", "sys/internal/ui/mounts/"+partialPath, err)
 	}
 	if !v2 {
 		log.Println("path not using KV v2 mount, metadata not supported:", secretPath)
-		return "", fmt.Errorf("unsupported mount")
+		return nil, fmt.Errorf("unsupported mount")
 	}
 
-	return addPrefixToKVPath(partialPath, mountPath, prefix), nil
+	return &kvSecretPaths{secretPath: partialPath, mountPath: mountPath}, nil
+}
+
+func (p *kvSecretPaths) prefixed(apiPrefix string) string {
+	return addPrefixToKVPath(p.secretPath, p.mountPath, apiPrefix)
+}
+
+func (p *kvSecretPaths) data() string {
+	return p.prefixed("data")
+}
+
+func (p *kvSecretPaths) metadata() string {
+	return p.prefixed("metadata")
 }
 
-func secretMetadataPath(secretPath string, c *api.Client) (string, error) {
-	return prefixSecretPath(secretPath, "metadata", c)
+func (p *kvSecretPaths) undelete() string {
+	return p.prefixed("undelete")
 }
 
-func secretDataPath(secretPath string, c *api.Client) (string, error) {
-	return prefixSecretPath(secretPath, "data", c)
+func (p *kvSecretPaths) delete() string {
+	return p.prefixed("delete")
 }
 
-func secretDeletePath(secretPath string, c *api.Client) (string, error) {
-	return prefixSecretPath(secretPath, "delete", c)
+func (p *kvSecretPaths) destroy() string {
+	return p.prefixed("destroy")
+}
+
+// checkCapabilities ensures the current token holds at least one of the given capabilities on the given API path.
+// The check is best effort: if capabilities can't be looked up, it is skipped and Vault will report the actual error.
+func checkCapabilities(ctx context.Context, c *api.Client, apiPath string, capabilities ...string) error {
+	granted, err := c.Sys().CapabilitiesSelfWithContext(ctx, apiPath)
+	if err != nil {
+		log.Println("unable to look up token capabilities on", apiPath, ":", err)
+		return nil
+	}
+
+	for _, g := range granted {
+		if g == "root" {
+			return nil
+		}
+		for _, required := range capabilities {
+			if g == required {
+				return nil
+			}
+		}
+	}
+
+	return &Error{
+		Operation:  "use the provider's token",
+		Path:       apiPath,
+		StatusCode: http.StatusForbidden,
+		Errors:     []string{fmt.Sprintf("token lacks %s (granted: %s)", strings.Join(capabilities, "/"), strings.Join(granted, ", "))},
+	}
 }
 
 func isSecretDeleted(secret *api.Secret) (bool, error) {
@@ -65,8 +121,22 @@ func isSecretDeleted(secret *api.Secret) (bool, error) {
 		return false, fmt.Errorf("missing secret metadata")
 	}
 
-	deletionDate := metadata.(map[string]interface{})["deletion_time"]
-	return deletionDate == nil, nil
+	// Deleted versions have a deletion time, destroyed ones are flagged as such
+	versionMetadata := metadata.(map[string]interface{})
+	deletionTime, _ := versionMetadata["deletion_time"].(string)
+	destroyed, _ := versionMetadata["destroyed"].(bool)
+	return deletionTime != "" || destroyed, nil
+}
+
+// checkRelativeSegments rejects paths with "." or ".." segments: once joined with the API prefix, they would point
+// outside of the secret (e.g. `secret/data/../foo` is `secret/foo`).
+func checkRelativeSegments(p string) error {
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("invalid secret path %s: %q segments aren't allowed", p, segment)
+		}
+	}
+	return nil
 }
 
 func addPrefixToKVPath(p, mountPath, apiPrefix string) string {
@@ -94,8 +164,8 @@ func addPrefixToKVPath(p, mountPath, apiPrefix string) string {
 	return path.Join(mountPath, apiPrefix, tp)
 }
 
-func isKVv2(path string, client *api.Client) (string, bool, error) {
-	mountPath, version, err := kvPreflightVersionRequest(client, path)
+func isKVv2(ctx context.Context, path string, client *api.Client) (string, bool, error) {
+	mountPath, version, err := kvPreflightVersionRequest(ctx, client, path)
 	if err != nil {
 		return "", false, err
 	}
@@ -103,7 +173,7 @@ func isKVv2(path string, client *api.Client) (string, bool, error) {
 	return mountPath, version == 2, nil
 }
 
-func kvPreflightVersionRequest(client *api.Client, path string) (string, int, error) {
+func kvPreflightVersionRequest(ctx context.Context, client *api.Client, path string) (string, int, error) {
 	// We don't want to use a wrapping call here so save any custom value and
 	// restore after
 	currentWrappingLookupFunc := client.CurrentWrappingLookupFunc()
@@ -116,8 +186,7 @@ func kvPreflightVersionRequest(client *api.Client, path string) (string, int, er
 	client.SetOutputPolicy(false)
 	defer client.SetOutputPolicy(currentOutputPolicy)
 
-	r := client.NewRequest("GET", "/v1/sys/internal/ui/mounts/"+path)
-	resp, err := client.RawRequest(r)
+	resp, err := client.Logical().ReadRawWithContext(ctx, "sys/internal/ui/mounts/"+path)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -173,33 +242,193 @@ re-run this command with a token with read access to %s`, path)
 	return mountPath, 1, nil
 }
 
-// sanitizePath removes any leading or trailing things from a "path".
+// sanitizePath removes any leading or trailing slashes and spaces from a "path". They are trimmed together: a space
+// left after a slash (e.g. `/ secret/foo / `) would end up in the path of the secret.
 func sanitizePath(s string) string {
-	return ensureNoTrailingSlash(ensureNoLeadingSlash(s))
+	return strings.TrimFunc(s, func(r rune) bool {
+		return r == '/' || unicode.IsSpace(r)
+	})
+}
+
+// NormalizePath returns the canonical form of a secret path, without leading or trailing slashes: `/secret/foo` and
+// `secret/foo` refer to the same secret.
+func NormalizePath(secretPath string) string {
+	return sanitizePath(secretPath)
+}
+
+// mergeMetadata returns a copy of current where removed keys are deleted and keys of updated are set.
+func mergeMetadata(current, updated map[string]string, removed []string) map[string]string {
+	merged := make(map[string]string, len(current)+len(updated))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for _, k := range removed {
+		delete(merged, k)
+	}
+	for k, v := range updated {
+		merged[k] = v
+	}
+	return merged
+}
+
+// decodeSecretMetadata decodes the response of a KV v2 metadata endpoint.
+func decodeSecretMetadata(data map[string]interface{}) (*secretV2Metadata, error) {
+	var metadata secretV2Metadata
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeHookFunc(time.RFC3339Nano),
+		WeaklyTypedInput: true,
+		TagName:          "json",
+		Result:           &metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = decoder.Decode(data); err != nil {
+		return nil, err
+	}
+	// Vault 1.9+ returns a null custom_metadata for secrets without custom metadata, e.g. written by hand. Only older
+	// versions, which don't support custom metadata, leave it out.
+	if _, ok := data[SecretCustomDataField]; ok && metadata.CustomMetadata == nil {
+		metadata.CustomMetadata = make(map[string]string)
+	}
+	return &metadata, nil
+}
+
+// isCurrentVersionDeleted tells if the current version of a secret has been deleted or destroyed.
+func (m *secretV2Metadata) isCurrentVersionDeleted() bool {
+	version, ok := m.Versions[strconv.Itoa(m.CurrentVersion)]
+	if !ok {
+		return true
+	}
+	return version.DeletionTime != "" || version.Destroyed
+}
+
+// deletedError describes the secret whose current version is deleted.
+func (m *secretV2Metadata) deletedError(secretPath string) *SecretDeletedError {
+	return &SecretDeletedError{Path: secretPath, Version: m.CurrentVersion, LiveVersion: m.liveVersion()}
+}
+
+// liveVersion returns the latest version that is neither deleted nor destroyed, 0 if there's none.
+func (m *secretV2Metadata) liveVersion() int {
+	live := 0
+	for k, v := range m.Versions {
+		version, err := strconv.Atoi(k)
+		if err != nil || v.DeletionTime != "" || v.Destroyed {
+			continue
+		}
+		if version > live {
+			live = version
+		}
+	}
+	return live
+}
+
+// versionsToDestroy returns, in ascending order, the versions of the secret to destroy: the given versions, and when
+// keep is positive, every version older than the latest keep versions not destroyed yet. Versions already destroyed or
+// unknown are skipped. The current version is never destroyed: listing it is an error.
+func (m *secretV2Metadata) versionsToDestroy(keep int, versions []int) ([]int, error) {
+	kept := make([]int, 0, len(m.Versions))
+	for k, v := range m.Versions {
+		version, err := strconv.Atoi(k)
+		if err != nil || v.Destroyed {
+			continue
+		}
+		kept = append(kept, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(kept)))
+
+	selected := make(map[int]bool)
+	if keep > 0 && len(kept) > keep {
+		for _, version := range kept[keep:] {
+			selected[version] = true
+		}
+	}
+	for _, version := range versions {
+		if version == m.CurrentVersion {
+			return nil, fmt.Errorf("version %d is the current version of the secret and can't be destroyed", version)
+		}
+		if v, ok := m.Versions[strconv.Itoa(version)]; ok && !v.Destroyed {
+			selected[version] = true
+		}
+	}
+	delete(selected, m.CurrentVersion)
+
+	destroyed := make([]int, 0, len(selected))
+	for version := range selected {
+		destroyed = append(destroyed, version)
+	}
+	sort.Ints(destroyed)
+	return destroyed, nil
+}
+
+// versionsKept returns the number of versions of the secret still retained by Vault, i.e. not destroyed, bounded by
+// the secret's max_versions when set.
+func (m *secretV2Metadata) versionsKept() int {
+	kept := 0
+	for _, v := range m.Versions {
+		if !v.Destroyed {
+			kept++
+		}
+	}
+	if m.MaxVersions > 0 && kept > m.MaxVersions {
+		kept = m.MaxVersions
+	}
+	return kept
 }
 
-// ensureNoLeadingSlash ensures the given string does not have a leading slash.
-func ensureNoLeadingSlash(s string) string {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return ""
+// activeManagedVersions returns the versions recorded in the ManagedVersionsMetadata custom metadata that are neither
+// deleted nor destroyed, in ascending order.
+func (m *secretV2Metadata) activeManagedVersions() []int {
+	active := make([]int, 0)
+	for _, version := range managedVersions(m.CustomMetadata[ManagedVersionsMetadata]) {
+		v, ok := m.Versions[strconv.Itoa(version)]
+		if ok && v.DeletionTime == "" && !v.Destroyed {
+			active = append(active, version)
+		}
 	}
+	return active
+}
 
-	for len(s) > 0 && s[0] == '/' {
-		s = s[1:]
+// managedVersions parses the value of the ManagedVersionsMetadata custom metadata. Invalid entries are ignored.
+func managedVersions(stamps string) []int {
+	versions := make([]int, 0)
+	for _, stamp := range strings.Split(stamps, ",") {
+		version, err := strconv.Atoi(strings.TrimSpace(stamp))
+		if err != nil || version <= 0 {
+			continue
+		}
+		versions = append(versions, version)
 	}
-	return s
+	sort.Ints(versions)
+	return versions
 }
 
-// ensureNoTrailingSlash ensures the given string does not have a trailing slash.
-func ensureNoTrailingSlash(s string) string {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return ""
+// LatestManagedVersion returns the latest version recorded in the ManagedVersionsMetadata custom metadata, 0 if there
+// is none.
+func LatestManagedVersion(metadata map[string]string) int {
+	versions := managedVersions(metadata[ManagedVersionsMetadata])
+	if len(versions) == 0 {
+		return 0
 	}
+	return versions[len(versions)-1]
+}
+
+// addManagedVersion adds version to the value of the ManagedVersionsMetadata custom metadata. Versions older than
+// oldest are no longer retained by Vault and are dropped, keeping the value within Vault's size limit.
+func addManagedVersion(stamps string, version int, oldest int) string {
+	kept := make([]string, 0)
+	for _, v := range managedVersions(stamps) {
+		if v >= oldest && v != version {
+			kept = append(kept, strconv.Itoa(v))
+		}
+	}
+	return strings.Join(append(kept, strconv.Itoa(version)), ",")
+}
 
-	for len(s) > 0 && s[len(s)-1] == '/' {
-		s = s[:len(s)-1]
+// writtenVersion returns the version created by a write to the data endpoint of a KV v2 secret.
+func writtenVersion(written *api.Secret) (int, error) {
+	if written == nil || written.Data["version"] == nil {
+		return 0, fmt.Errorf("no version in response")
 	}
-	return s
+	return strconv.Atoi(fmt.Sprint(written.Data["version"]))
 }
@@ -0,0 +1,364 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	current := map[string]string{
+		"secret_type": "random_secret",
+		"owner":       "my_team",
+		"obsolete":    "true",
+		"external":    "set by another system",
+	}
+	updated := map[string]string{
+		"secret_type": "random_secret",
+		"owner":       "some_other_team",
+	}
+
+	merged := mergeMetadata(current, updated, []string{"obsolete"})
+
+	expected := map[string]string{
+		"secret_type": "random_secret",
+		"owner":       "some_other_team",
+		"external":    "set by another system",
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Fatalf("Wrong merged metadata: %v. Expected: %v", merged, expected)
+	}
+	if current["owner"] != "my_team" {
+		t.Fatalf("Current metadata must not be modified")
+	}
+}
+
+func TestDecodeSecretMetadata(t *testing.T) {
+	data := map[string]interface{}{
+		"cas_required":    false,
+		"created_time":    "2024-01-02T10:00:00.123456789Z",
+		"current_version": json.Number("2"),
+		"custom_metadata": map[string]interface{}{
+			"secret_type": "random_secret",
+		},
+		"max_versions": json.Number("0"),
+		"versions": map[string]interface{}{
+			"1": map[string]interface{}{
+				"created_time":  "2024-01-02T10:00:00.123456789Z",
+				"deletion_time": "2024-01-03T10:00:00.123456789Z",
+				"destroyed":     false,
+			},
+			"2": map[string]interface{}{
+				"created_time":  "2024-01-03T10:00:00.123456789Z",
+				"deletion_time": "",
+				"destroyed":     false,
+			},
+		},
+	}
+
+	metadata, err := decodeSecretMetadata(data)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if metadata.CurrentVersion != 2 {
+		t.Fatalf("Wrong current version: %d. Expected: 2", metadata.CurrentVersion)
+	}
+	if metadata.CustomMetadata["secret_type"] != "random_secret" {
+		t.Fatalf("Wrong custom metadata: %v", metadata.CustomMetadata)
+	}
+	if metadata.isCurrentVersionDeleted() {
+		t.Fatalf("Current version must not be deleted")
+	}
+
+	if metadata.versionsKept() != 2 {
+		t.Fatalf("Wrong number of versions kept: %d. Expected: 2", metadata.versionsKept())
+	}
+
+	metadata.MaxVersions = 1
+	if metadata.versionsKept() != 1 {
+		t.Fatalf("Wrong number of versions kept: %d. Expected: 1", metadata.versionsKept())
+	}
+
+	metadata.CurrentVersion = 1
+	if !metadata.isCurrentVersionDeleted() {
+		t.Fatalf("Version 1 must be deleted")
+	}
+
+	// Secrets without custom metadata, e.g. written by hand
+	data[SecretCustomDataField] = nil
+	metadata, err = decodeSecretMetadata(data)
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if metadata.CustomMetadata == nil || len(metadata.CustomMetadata) != 0 {
+		t.Fatalf("Wrong custom metadata: %v. Expected an empty map", metadata.CustomMetadata)
+	}
+}
+
+func TestIsSecretDeleted(t *testing.T) {
+	tests := map[string]struct {
+		metadata map[string]interface{}
+		deleted  bool
+	}{
+		"active":    {map[string]interface{}{"deletion_time": "", "destroyed": false}, false},
+		"deleted":   {map[string]interface{}{"deletion_time": "2024-01-03T10:00:00.123456789Z", "destroyed": false}, true},
+		"destroyed": {map[string]interface{}{"deletion_time": "", "destroyed": true}, true},
+	}
+
+	for name, tt := range tests {
+		deleted, err := isSecretDeleted(&api.Secret{Data: map[string]interface{}{"metadata": tt.metadata}})
+		if err != nil {
+			t.Fatal("error:", err)
+		}
+		if deleted != tt.deleted {
+			t.Fatalf("Wrong deletion status for %s secret: %t", name, deleted)
+		}
+	}
+}
+
+func TestAddManagedVersion(t *testing.T) {
+	tests := []struct {
+		stamps   string
+		version  int
+		oldest   int
+		expected string
+	}{
+		{"", 1, 0, "1"},
+		{"1", 2, 1, "1,2"},
+		{"1,2,4", 5, 2, "2,4,5"},
+		{"foo,3", 4, 0, "3,4"},
+		{"3", 3, 0, "3"},
+	}
+
+	for _, tt := range tests {
+		if stamps := addManagedVersion(tt.stamps, tt.version, tt.oldest); stamps != tt.expected {
+			t.Fatalf("Wrong managed versions after adding %d to %q: %q. Expected: %q", tt.version, tt.stamps, stamps, tt.expected)
+		}
+	}
+}
+
+func TestLatestManagedVersion(t *testing.T) {
+	if version := LatestManagedVersion(map[string]string{ManagedVersionsMetadata: "3,12,4"}); version != 12 {
+		t.Fatalf("Wrong latest managed version: %d. Expected: 12", version)
+	}
+	if version := LatestManagedVersion(map[string]string{}); version != 0 {
+		t.Fatalf("Wrong latest managed version without managed versions: %d. Expected: 0", version)
+	}
+}
+
+func TestActiveManagedVersions(t *testing.T) {
+	metadata := &secretV2Metadata{
+		CustomMetadata: map[string]string{ManagedVersionsMetadata: "1,3,4,6"},
+		Versions: map[string]secretV2Version{
+			"2": {},
+			"3": {DeletionTime: "2024-01-03T10:00:00.123456789Z"},
+			"4": {},
+			"5": {},
+			"6": {Destroyed: true},
+		},
+	}
+
+	if versions := metadata.activeManagedVersions(); !reflect.DeepEqual(versions, []int{4}) {
+		t.Fatalf("Wrong active managed versions: %v. Expected: [4]", versions)
+	}
+}
+
+func TestVersionsToDestroy(t *testing.T) {
+	metadata := &secretV2Metadata{
+		CurrentVersion: 5,
+		Versions: map[string]secretV2Version{
+			"1": {Destroyed: true},
+			"2": {},
+			"3": {DeletionTime: "2024-01-03T10:00:00.123456789Z"},
+			"4": {},
+			"5": {},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		keep     int
+		versions []int
+		expected []int
+	}{
+		{"keep latest", 2, nil, []int{2, 3}},
+		{"keep more than existing", 10, nil, []int{}},
+		{"selected versions", 0, []int{1, 3, 9}, []int{3}},
+		{"both", 3, []int{4}, []int{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destroyed, err := metadata.versionsToDestroy(tt.keep, tt.versions)
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+			if !reflect.DeepEqual(destroyed, tt.expected) {
+				t.Fatalf("Wrong versions to destroy: %v. Expected: %v", destroyed, tt.expected)
+			}
+		})
+	}
+
+	if _, err := metadata.versionsToDestroy(0, []int{5}); err == nil {
+		t.Fatalf("Expected an error when destroying the current version")
+	}
+}
+
+func TestWrittenVersion(t *testing.T) {
+	version, err := writtenVersion(&api.Secret{Data: map[string]interface{}{"version": json.Number("3")}})
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if version != 3 {
+		t.Fatalf("Wrong written version: %d. Expected: 3", version)
+	}
+
+	if _, err = writtenVersion(nil); err == nil {
+		t.Fatalf("Expected an error without response")
+	}
+}
+
+func TestCheckRelativeSegments(t *testing.T) {
+	for _, valid := range []string{"secret/foo/bar", "secret/.foo/bar..", ""} {
+		if err := checkRelativeSegments(valid); err != nil {
+			t.Fatalf("Unexpected error for %q: %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"secret/../sys/foo", "secret/./foo", ".."} {
+		if err := checkRelativeSegments(invalid); err == nil {
+			t.Fatalf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func FuzzSanitizePath(f *testing.F) {
+	for _, seed := range []string{"/secret/foo/bar", "secret/foo/", " //secret//foo// ", "/ secret/foo / ", "/secret/clé/ünïcode/", "\t/\u00a0/"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		sanitized := sanitizePath(s)
+		if strings.HasPrefix(sanitized, "/") || strings.HasSuffix(sanitized, "/") {
+			t.Fatalf("Leading or trailing slash in %q (from %q)", sanitized, s)
+		}
+		if sanitized != strings.TrimSpace(sanitized) {
+			t.Fatalf("Leading or trailing space in %q (from %q)", sanitized, s)
+		}
+		if again := sanitizePath(sanitized); again != sanitized {
+			t.Fatalf("Sanitizing %q twice gives %q", sanitized, again)
+		}
+		if !strings.Contains(s, sanitized) {
+			t.Fatalf("%q is not a part of %q", sanitized, s)
+		}
+	})
+}
+
+func FuzzAddPrefixToKVPath(f *testing.F) {
+	f.Add("secret/", "", "foo/bar")
+	f.Add("secret/", "", "")
+	f.Add("kv/team/", "ns1/", "foo//bar/")
+	f.Add("secret/", "ns1/ns2/", "clé/ünïcode")
+	f.Add("secret/", "secret/", "foo")
+
+	f.Fuzz(func(t *testing.T, mount, namespace, key string) {
+		mount = sanitizePath(mount)
+		namespace = sanitizePath(namespace)
+		if mount == "" || !validPath(mount) || !validPath(namespace) || !validPath(key) {
+			t.Skip()
+		}
+		secretPath := sanitizePath(mount + "/" + key)
+		// Mounts are reported with their namespace but secret paths are relative to the namespace
+		mountPath := path.Join(namespace, mount) + "/"
+		if namespace != "" {
+			// The end of the namespace can't be told apart from the start of the secret path, e.g. with a namespace
+			// and a mount both named secret: addPrefixToKVPath strips the longest part of the mount path found in the
+			// secret path
+			segments := strings.Split(strings.TrimSuffix(mountPath, "/"), "/")
+			for i := range strings.Split(namespace, "/") {
+				if strings.HasPrefix(secretPath, strings.Join(segments[i:], "/")) {
+					t.Skip()
+				}
+			}
+		}
+
+		for _, apiPrefix := range []string{"data", "metadata"} {
+			expected := path.Join(mount, apiPrefix, key)
+			if p := addPrefixToKVPath(secretPath, mountPath, apiPrefix); p != expected {
+				t.Fatalf("Wrong %s path for %q in mount %q: %q. Expected: %q", apiPrefix, secretPath, mountPath, p, expected)
+			}
+		}
+	})
+}
+
+// validPath checks that p is made of non-empty segments other than "." and "..", as Vault paths are.
+func validPath(p string) bool {
+	if p == "" {
+		return true
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "" || segment == "." || segment == ".." || segment != strings.TrimSpace(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestKVPreflightVersionRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		mount   string
+		version int
+		errCode int
+	}{
+		{"kv v2", http.StatusOK, `{"data":{"path":"secret/","options":{"version":"2"}}}`, "secret/", 2, 0},
+		{"kv v1", http.StatusOK, `{"data":{"path":"legacy/","options":null}}`, "legacy/", 1, 0},
+		{"older vault", http.StatusNotFound, `{"errors":[]}`, "", 1, 0},
+		{"no mount or denied", http.StatusForbidden, `{"errors":["permission denied"]}`, "", 0, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/v1/sys/internal/ui/mounts/secret/foo" {
+					t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			conf := api.DefaultConfig()
+			conf.Address = server.URL
+			conf.MaxRetries = 0
+			client, err := api.NewClient(conf)
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+
+			mount, version, err := kvPreflightVersionRequest(context.Background(), client, "secret/foo")
+			var respErr *api.ResponseError
+			if tt.errCode != 0 {
+				if !errors.As(err, &respErr) || respErr.StatusCode != tt.errCode {
+					t.Fatalf("Wrong error: %v. Expected a %d response error", err, tt.errCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("error:", err)
+			}
+			if mount != tt.mount || version != tt.version {
+				t.Fatalf("Wrong preflight result: %q, %d. Expected: %q, %d", mount, version, tt.mount, tt.version)
+			}
+		})
+	}
+}
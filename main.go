@@ -13,13 +13,16 @@ import (
 
 const providerUrl = "registry.terraform.io/blablacar/vaultprov"
 
+// version is set by goreleaser at build time
+var version = "dev"
+
 func main() {
 	var debug bool
 
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	err := providerserver.Serve(context.Background(), provider.New(), providerserver.ServeOpts{
+	err := providerserver.Serve(context.Background(), provider.New(version), providerserver.ServeOpts{
 		Address:         providerUrl,
 		Debug:           debug,
 		ProtocolVersion: 6,
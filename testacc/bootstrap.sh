@@ -0,0 +1,14 @@
+#!/bin/sh
+# Prepares the dev mode Vault for acceptance tests. Run inside the Vault container, see `make testacc-docker`.
+# The Kubernetes auth method is stubbed by the tests themselves (TestAccKubernetesAuth), so that they also run against
+# a Vault started another way.
+set -e
+
+export VAULT_ADDR=http://127.0.0.1:8200
+export VAULT_TOKEN=ROOT_TOKEN
+
+# Dev mode mounts a KV v2 engine at secret/, make sure it's there whatever the Vault version
+if ! vault secrets list -format=json | grep -q '"secret/"'; then
+  vault secrets enable -path=secret -version=2 kv
+fi
+vault kv enable-versioning secret/ >/dev/null